@@ -5,13 +5,14 @@ import (
 	"context"
 	"crm-system/docs"
 	"crm-system/pkg/api"
+	"crm-system/pkg/authmiddleware"
 	"crm-system/pkg/authmiddleware/appauth"
 	"crm-system/pkg/config"
+	"crm-system/pkg/importer"
 	"crm-system/pkg/logger"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/reminder"
 	"crm-system/pkg/store"
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"os"
 	"os/signal"
@@ -38,24 +39,52 @@ func main() {
 		logger.Fatalf("Can't read config file: %s", err)
 	}
 
-	atKey, err := LoadKey(conf.Keys.AccessKey)
+	atKeys, err := LoadKeyRing(conf.Keys.AccessKeyDir, conf.Keys.AccessKey, conf.Keys.AccessKeyID)
 	if err != nil {
 		logger.Fatalf("main.go--->main()--->LoadAccKey: %s", err)
 	}
 
-	rtKey, err := LoadKey(conf.Keys.RefreshKey)
+	rtKeys, err := LoadKeyRing(conf.Keys.RefreshKeyDir, conf.Keys.RefreshKey, conf.Keys.RefreshKeyID)
 	if err != nil {
 		logger.Fatalf("main.go--->main()--->LoadRefKey: %s", err)
 	}
 
+	mfaKey, err := authmiddleware.MFATokenKey(conf.MFA.TokenSecret)
+	if err != nil {
+		logger.Fatalf("main.go--->main()--->MFATokenKey: %s", err)
+	}
+
+	hasher, err := authmiddleware.NewPasswordHasher(conf.Password)
+	if err != nil {
+		logger.Fatalf("main.go--->main()--->NewPasswordHasher: %s", err)
+	}
+
+	authmiddleware.SetPasswordHasher(hasher)
+
 	storeDB, err := store.NewStore(conf)
 	if err != nil {
 		logger.Fatalf("main.go--->main()--->NewStore: %s", err)
 	}
 
-	middleware := appauth.NewAuthMiddleware(storeDB, atKey, rtKey)
+	middleware := appauth.NewAuthMiddleware(storeDB, atKeys, rtKeys, mfaKey)
 
-	apiServer := api.NewServer(&conf.Server, storeDB, middleware)
+	mail, err := mailer.New(conf.Mailer)
+	if err != nil {
+		logger.Fatalf("main.go--->main()--->mailer.New: %s", err)
+	}
+
+	notifier, err := reminder.NewNotifier(conf.Reminder.Notifier, mail)
+	if err != nil {
+		logger.Fatalf("main.go--->main()--->reminder.NewNotifier: %s", err)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go reminder.NewScheduler(storeDB.Task, storeDB.Auth, notifier, conf.Reminder).Run(workersCtx)
+	go importer.NewWorker(storeDB.Import, conf.Import).Run(workersCtx)
+
+	apiServer := api.NewServer(conf, storeDB, middleware, mail)
 	runErr := make(chan error, 1)
 	quitCh := make(chan os.Signal, 1)
 	signal.Notify(quitCh, syscall.SIGINT, syscall.SIGTERM)
@@ -76,6 +105,8 @@ func main() {
 		logger.Fatalf("Running error: %s", err)
 	case s := <-quitCh:
 		logger.Infof("Received signal: %v. Running graceful shutdown...", s)
+		stopWorkers()
+
 		ctx := context.Background()
 
 		err = apiServer.Shutdown(ctx)
@@ -85,13 +116,11 @@ func main() {
 	}
 }
 
-func LoadKey(path string) (*ecdsa.PrivateKey, error) {
-	txt, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+// LoadKeyRing prefers the key directory and falls back to the single key file.
+func LoadKeyRing(dir, file, activeID string) (*authmiddleware.KeyRing, error) {
+	if dir != "" {
+		return authmiddleware.LoadKeyRing(dir, activeID)
 	}
-	block, _ := pem.Decode(txt)
-	x509Encoded := block.Bytes
 
-	return x509.ParseECPrivateKey(x509Encoded)
+	return authmiddleware.LoadKeyRingFile(file)
 }
@@ -0,0 +1,17 @@
+create table refresh_tokens
+(
+    id          uuid not null
+        primary key,
+    user_id     uuid not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    family_id   uuid not null,
+    expires_at  timestamp with time zone not null,
+    created_at  timestamp with time zone not null default now(),
+    revoked_at  timestamp with time zone,
+    replaced_by uuid
+);
+
+create index idx_refresh_tokens_family_id on refresh_tokens (family_id);
+create index idx_refresh_tokens_user_id on refresh_tokens (user_id);
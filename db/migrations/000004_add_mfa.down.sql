@@ -0,0 +1,10 @@
+drop table mfa_challenges;
+
+drop table mfa_policies;
+
+drop table recovery_codes;
+
+alter table auth_users
+    drop column totp_secret,
+    drop column totp_enabled,
+    drop column totp_step;
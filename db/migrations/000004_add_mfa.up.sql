@@ -0,0 +1,34 @@
+alter table auth_users
+    add column totp_secret  text    not null default '',
+    add column totp_enabled boolean not null default false,
+    add column totp_step    bigint  not null default 0;
+
+create table recovery_codes
+(
+    id         uuid not null
+        primary key,
+    user_id    uuid not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    code_hash  text not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+create index idx_recovery_codes_user_id on recovery_codes (user_id);
+
+create table mfa_policies
+(
+    role     text    not null
+        primary key,
+    required boolean not null default false
+);
+
+create table mfa_challenges
+(
+    token_id   text                     not null
+        primary key,
+    expires_at timestamp with time zone not null,
+    created_at timestamp with time zone not null default now()
+);
@@ -0,0 +1,8 @@
+create table login_attempts
+(
+    key             text    not null
+        primary key,
+    failures        integer not null default 0,
+    last_failure_at timestamp with time zone not null default now(),
+    locked_until    timestamp with time zone
+);
@@ -0,0 +1,6 @@
+drop table password_reset_tokens;
+
+drop index idx_auth_users_email;
+
+alter table auth_users
+    drop column email;
@@ -0,0 +1,20 @@
+alter table auth_users
+    add column email text;
+
+create unique index idx_auth_users_email on auth_users (lower(email));
+
+create table password_reset_tokens
+(
+    id         uuid not null
+        primary key,
+    user_id    uuid not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    token_hash text not null,
+    expires_at timestamp with time zone not null,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+create unique index idx_password_reset_tokens_token_hash on password_reset_tokens (token_hash);
@@ -0,0 +1,9 @@
+alter table mfa_policies
+    drop constraint fk_mfa_policies_role;
+
+alter table auth_users
+    drop constraint fk_auth_users_role;
+
+drop table role_permissions;
+
+drop table roles;
@@ -0,0 +1,49 @@
+create table roles
+(
+    name        text    not null
+        primary key,
+    description text    not null default '',
+    builtin     boolean not null default false
+);
+
+create table role_permissions
+(
+    role       text not null
+        constraint fk_role
+            references roles
+            on update cascade
+            on delete cascade,
+    permission text not null,
+    primary key (role, permission)
+);
+
+insert into roles (name, description, builtin)
+values ('ADMIN', 'Full access', true),
+       ('BASE', 'Own profile only', true);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['users:create', 'users:read', 'users:update', 'users:delete',
+    'roles:read', 'roles:create', 'roles:update', 'roles:delete',
+    'mfa-policies:read', 'mfa-policies:update']) as permission;
+
+-- keep any other role already in use, without permissions
+insert into roles (name)
+select distinct role
+from auth_users
+where role is not null
+union
+select role
+from mfa_policies
+on conflict do nothing;
+
+alter table auth_users
+    add constraint fk_auth_users_role
+        foreign key (role) references roles
+            on update cascade;
+
+alter table mfa_policies
+    add constraint fk_mfa_policies_role
+        foreign key (role) references roles
+            on update cascade
+            on delete cascade;
@@ -0,0 +1,2 @@
+alter table auth_users
+    drop column active;
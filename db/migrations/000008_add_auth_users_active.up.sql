@@ -0,0 +1,2 @@
+alter table auth_users
+    add column active boolean not null default true;
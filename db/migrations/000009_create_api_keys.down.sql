@@ -0,0 +1,10 @@
+delete
+from role_permissions
+where permission in ('api-keys:read', 'api-keys:create', 'api-keys:delete');
+
+drop table api_key_permissions;
+
+drop table api_keys;
+
+alter table auth_users
+    drop column service_account;
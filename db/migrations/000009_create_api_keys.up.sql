@@ -0,0 +1,37 @@
+alter table auth_users
+    add column service_account boolean not null default false;
+
+create table api_keys
+(
+    id           uuid                     not null
+        primary key,
+    user_id      uuid                     not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    name         text                     not null,
+    prefix       text                     not null,
+    key_hash     text                     not null,
+    expires_at   timestamp with time zone,
+    last_used_at timestamp with time zone,
+    created_at   timestamp with time zone not null default now(),
+    revoked_at   timestamp with time zone
+);
+
+create unique index idx_api_keys_key_hash on api_keys (key_hash);
+create index idx_api_keys_user_id on api_keys (user_id);
+
+create table api_key_permissions
+(
+    api_key_id uuid not null
+        constraint fk_api_key
+            references api_keys
+            on delete cascade,
+    permission text not null,
+    primary key (api_key_id, permission)
+);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['api-keys:read', 'api-keys:create', 'api-keys:delete']) as permission
+on conflict do nothing;
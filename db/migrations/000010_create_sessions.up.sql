@@ -0,0 +1,25 @@
+create table sessions
+(
+    id           uuid                     not null
+        primary key,
+    user_id      uuid                     not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    user_agent   text                     not null default '',
+    ip           text                     not null default '',
+    created_at   timestamp with time zone not null default now(),
+    last_seen_at timestamp with time zone not null default now(),
+    expires_at   timestamp with time zone not null,
+    revoked_at   timestamp with time zone
+);
+
+create index idx_sessions_user_id on sessions (user_id);
+
+-- refresh token families issued before sessions existed become sessions,
+-- so they keep working after their next refresh
+insert into sessions (id, user_id, created_at, last_seen_at, expires_at)
+select family_id, user_id, min(created_at), max(created_at), max(expires_at)
+from refresh_tokens
+where revoked_at is null
+group by family_id, user_id;
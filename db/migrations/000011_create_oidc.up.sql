@@ -0,0 +1,23 @@
+create table auth_identities
+(
+    issuer     text                     not null,
+    subject    text                     not null,
+    user_id    uuid                     not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    created_at timestamp with time zone not null default now(),
+    primary key (issuer, subject)
+);
+
+create index idx_auth_identities_user_id on auth_identities (user_id);
+
+create table oidc_states
+(
+    state_hash    text                     not null
+        primary key,
+    nonce         text                     not null,
+    code_verifier text                     not null,
+    expires_at    timestamp with time zone not null,
+    created_at    timestamp with time zone not null default now()
+);
@@ -0,0 +1,17 @@
+create table invitations
+(
+    id          uuid                     not null
+        primary key,
+    email       text                     not null,
+    role        text                     not null,
+    token_hash  text                     not null
+        constraint uq_invitations_token_hash
+            unique,
+    invited_by  uuid                     not null,
+    expires_at  timestamp with time zone not null,
+    created_at  timestamp with time zone not null default now(),
+    accepted_at timestamp with time zone,
+    revoked_at  timestamp with time zone
+);
+
+create index idx_invitations_email on invitations (lower(email));
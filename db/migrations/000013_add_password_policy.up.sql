@@ -0,0 +1,16 @@
+alter table auth_users
+    add column password_changed_at timestamp with time zone not null default now();
+
+create table password_history
+(
+    id            uuid                     not null
+        primary key,
+    user_id       uuid                     not null
+        constraint fk_password_history_user
+            references auth_users
+            on delete cascade,
+    password_hash text                     not null,
+    created_at    timestamp with time zone not null default now()
+);
+
+create index idx_password_history_user_id on password_history (user_id, created_at);
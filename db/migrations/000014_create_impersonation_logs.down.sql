@@ -0,0 +1,5 @@
+delete
+from role_permissions
+where permission = 'users:impersonate';
+
+drop table impersonation_logs;
@@ -0,0 +1,21 @@
+-- no foreign keys, the audit trail outlives deleted users
+create table impersonation_logs
+(
+    id              uuid                     not null
+        primary key,
+    impersonator_id uuid                     not null,
+    user_id         uuid                     not null,
+    token_id        text                     not null,
+    method          text                     not null,
+    path            text                     not null,
+    status          integer                  not null,
+    ip              text                     not null default '',
+    created_at      timestamp with time zone not null default now()
+);
+
+create index idx_impersonation_logs_impersonator_id on impersonation_logs (impersonator_id, created_at);
+create index idx_impersonation_logs_user_id on impersonation_logs (user_id, created_at);
+
+insert into role_permissions (role, permission)
+values ('ADMIN', 'users:impersonate')
+on conflict do nothing;
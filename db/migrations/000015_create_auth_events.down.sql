@@ -0,0 +1,7 @@
+delete
+from role_permissions
+where permission = 'audit:read';
+
+drop table auth_events;
+
+drop function auth_events_append_only();
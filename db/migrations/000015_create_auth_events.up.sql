@@ -0,0 +1,37 @@
+-- no foreign keys, the audit trail outlives deleted users
+create table auth_events
+(
+    id         uuid                     not null
+        primary key,
+    type       text                     not null,
+    success    boolean                  not null,
+    detail     text                     not null default '',
+    actor_id   uuid,
+    target_id  uuid,
+    username   text                     not null default '',
+    ip         text                     not null default '',
+    user_agent text                     not null default '',
+    created_at timestamp with time zone not null default now()
+);
+
+create index idx_auth_events_created_at on auth_events (created_at);
+create index idx_auth_events_actor_id on auth_events (actor_id, created_at);
+create index idx_auth_events_target_id on auth_events (target_id, created_at);
+
+create function auth_events_append_only() returns trigger
+    language plpgsql as
+$$
+begin
+    raise exception 'auth_events is append-only';
+end;
+$$;
+
+create trigger trg_auth_events_append_only
+    before update or delete
+    on auth_events
+    for each row
+execute function auth_events_append_only();
+
+insert into role_permissions (role, permission)
+values ('ADMIN', 'audit:read')
+on conflict do nothing;
@@ -0,0 +1,9 @@
+delete
+from role_permissions
+where permission in ('contacts:read', 'contacts:update', 'contacts:delete');
+
+drop table contact_phones;
+
+drop table contact_emails;
+
+drop table contacts;
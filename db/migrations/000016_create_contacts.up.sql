@@ -0,0 +1,53 @@
+-- company_id links a contact to a company, companies don't exist yet
+create table contacts
+(
+    id              uuid                     not null
+        primary key,
+    first_name      text                     not null default '',
+    last_name       text                     not null default '',
+    job_title       text                     not null default '',
+    company_id      uuid,
+    owner_id        uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    source          text                     not null default '',
+    lifecycle_stage text                     not null default 'lead',
+    created_at      timestamp with time zone not null default now(),
+    updated_at      timestamp with time zone not null default now()
+);
+
+create index idx_contacts_owner_id on contacts (owner_id);
+create index idx_contacts_company_id on contacts (company_id);
+create index idx_contacts_name on contacts (lower(last_name), lower(first_name));
+
+create table contact_emails
+(
+    contact_id uuid    not null
+        constraint fk_contact
+            references contacts
+            on delete cascade,
+    position   integer not null,
+    email      text    not null,
+    label      text    not null default '',
+    primary key (contact_id, position)
+);
+
+create index idx_contact_emails_email on contact_emails (email);
+
+create table contact_phones
+(
+    contact_id uuid    not null
+        constraint fk_contact
+            references contacts
+            on delete cascade,
+    position   integer not null,
+    phone      text    not null,
+    label      text    not null default '',
+    primary key (contact_id, position)
+);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['contacts:read', 'contacts:update', 'contacts:delete']) as permission
+on conflict do nothing;
@@ -0,0 +1,11 @@
+delete
+from role_permissions
+where permission in ('companies:read', 'companies:update', 'companies:delete');
+
+alter table contacts
+    drop constraint fk_company,
+    drop column company_role;
+
+drop table company_addresses;
+
+drop table companies;
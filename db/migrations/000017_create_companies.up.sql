@@ -0,0 +1,56 @@
+create table companies
+(
+    id         uuid                     not null
+        primary key,
+    name       text                     not null,
+    domain     text                     not null default '',
+    industry   text                     not null default '',
+    size       text                     not null default '',
+    parent_id  uuid
+        constraint fk_parent_company
+            references companies
+            on delete set null,
+    owner_id   uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index idx_companies_name on companies (lower(name));
+create index idx_companies_domain on companies (domain);
+create index idx_companies_parent_id on companies (parent_id);
+create index idx_companies_owner_id on companies (owner_id);
+
+create table company_addresses
+(
+    company_id  uuid    not null
+        constraint fk_company
+            references companies
+            on delete cascade,
+    position    integer not null,
+    label       text    not null default '',
+    street      text    not null default '',
+    city        text    not null default '',
+    region      text    not null default '',
+    postal_code text    not null default '',
+    country     text    not null default '',
+    primary key (company_id, position)
+);
+
+-- there were no companies to link to
+update contacts
+set company_id = null
+where company_id is not null;
+
+alter table contacts
+    add column company_role text not null default '',
+    add constraint fk_company
+        foreign key (company_id) references companies
+            on delete set null;
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['companies:read', 'companies:update', 'companies:delete']) as permission
+on conflict do nothing;
@@ -0,0 +1,14 @@
+delete
+from role_permissions
+where permission in ('pipelines:create', 'pipelines:update', 'pipelines:delete',
+                     'deals:read', 'deals:update', 'deals:delete');
+
+drop table deal_stage_changes;
+
+drop table deal_contacts;
+
+drop table deals;
+
+drop table pipeline_stages;
+
+drop table pipelines;
@@ -0,0 +1,106 @@
+create table pipelines
+(
+    id         uuid                     not null
+        primary key,
+    name       text                     not null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create table pipeline_stages
+(
+    id          uuid    not null
+        primary key,
+    pipeline_id uuid    not null
+        constraint fk_pipeline
+            references pipelines
+            on delete cascade,
+    position    integer not null,
+    name        text    not null,
+    probability integer not null default 0
+        constraint chk_probability
+            check (probability between 0 and 100),
+    kind        text    not null default 'open'
+);
+
+create index idx_pipeline_stages_pipeline_id on pipeline_stages (pipeline_id, position);
+
+create table deals
+(
+    id                  uuid                     not null
+        primary key,
+    title               text                     not null,
+    amount              bigint                   not null default 0,
+    currency            text                     not null default 'USD',
+    expected_close_date date,
+    -- a pipeline or stage with deals can't be deleted
+    pipeline_id         uuid                     not null
+        constraint fk_pipeline
+            references pipelines,
+    stage_id            uuid                     not null
+        constraint fk_stage
+            references pipeline_stages,
+    owner_id            uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    company_id          uuid
+        constraint fk_company
+            references companies
+            on delete set null,
+    closed_at           timestamp with time zone,
+    created_at          timestamp with time zone not null default now(),
+    updated_at          timestamp with time zone not null default now()
+);
+
+create index idx_deals_stage_id on deals (stage_id);
+create index idx_deals_pipeline_id on deals (pipeline_id);
+create index idx_deals_owner_id on deals (owner_id);
+create index idx_deals_company_id on deals (company_id);
+
+create table deal_contacts
+(
+    deal_id    uuid    not null
+        constraint fk_deal
+            references deals
+            on delete cascade,
+    position   integer not null,
+    contact_id uuid    not null
+        constraint fk_contact
+            references contacts
+            on delete cascade,
+    primary key (deal_id, position)
+);
+
+create index idx_deal_contacts_contact_id on deal_contacts (contact_id);
+
+create table deal_stage_changes
+(
+    id            uuid                     not null
+        primary key,
+    deal_id       uuid                     not null
+        constraint fk_deal
+            references deals
+            on delete cascade,
+    from_stage_id uuid
+        constraint fk_from_stage
+            references pipeline_stages
+            on delete set null,
+    to_stage_id   uuid
+        constraint fk_to_stage
+            references pipeline_stages
+            on delete set null,
+    changed_by    uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    changed_at    timestamp with time zone not null default now()
+);
+
+create index idx_deal_stage_changes_deal_id on deal_stage_changes (deal_id, changed_at);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['pipelines:create', 'pipelines:update', 'pipelines:delete',
+    'deals:read', 'deals:update', 'deals:delete']) as permission
+on conflict do nothing;
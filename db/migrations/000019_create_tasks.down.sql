@@ -0,0 +1,5 @@
+delete
+from role_permissions
+where permission in ('tasks:read', 'tasks:update', 'tasks:delete');
+
+drop table tasks;
@@ -0,0 +1,37 @@
+create table tasks
+(
+    id           uuid                     not null
+        primary key,
+    title        text                     not null,
+    description  text                     not null default '',
+    due_at       timestamp with time zone,
+    priority     text                     not null default 'normal',
+    status       text                     not null default 'open',
+    assignee_id  uuid
+        constraint fk_assignee
+            references "auth_users"
+            on delete set null,
+    creator_id   uuid
+        constraint fk_creator
+            references "auth_users"
+            on delete set null,
+    -- links any record type, the repositories of the records clear it on delete
+    record_type  text                     not null default '',
+    record_id    uuid,
+    completed_at timestamp with time zone,
+    reminded_at  timestamp with time zone,
+    created_at   timestamp with time zone not null default now(),
+    updated_at   timestamp with time zone not null default now()
+);
+
+create index idx_tasks_assignee_id on tasks (assignee_id, due_at);
+create index idx_tasks_creator_id on tasks (creator_id);
+create index idx_tasks_record on tasks (record_type, record_id);
+-- the open tasks the reminder scheduler still has to claim
+create index idx_tasks_reminder on tasks (due_at)
+    where reminded_at is null and status in ('open', 'in_progress');
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['tasks:read', 'tasks:update', 'tasks:delete']) as permission
+on conflict do nothing;
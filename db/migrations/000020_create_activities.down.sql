@@ -0,0 +1,9 @@
+delete
+from role_permissions
+where permission in ('activities:update', 'activities:delete');
+
+drop table timeline_events;
+
+drop table activity_participants;
+
+drop table activities;
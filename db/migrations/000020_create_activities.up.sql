@@ -0,0 +1,60 @@
+create table activities
+(
+    id          uuid                     not null
+        primary key,
+    type        text                     not null,
+    body        text                     not null default '',
+    occurred_at timestamp with time zone not null,
+    duration    integer                  not null default 0
+        constraint chk_duration
+            check (duration between 0 and 1440),
+    author_id   uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    -- any record type, the repositories of the records delete their activities
+    entity_type text                     not null,
+    entity_id   uuid                     not null,
+    created_at  timestamp with time zone not null default now(),
+    updated_at  timestamp with time zone not null default now()
+);
+
+create index idx_activities_entity on activities (entity_type, entity_id, occurred_at desc);
+
+create table activity_participants
+(
+    activity_id uuid    not null
+        constraint fk_activity
+            references activities
+            on delete cascade,
+    position    integer not null,
+    user_id     uuid    not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    primary key (activity_id, position)
+);
+
+create index idx_activity_participants_user_id on activity_participants (user_id);
+
+create table timeline_events
+(
+    id          uuid                     not null
+        primary key,
+    entity_type text                     not null,
+    entity_id   uuid                     not null,
+    kind        text                     not null,
+    actor_id    uuid
+        constraint fk_auth_user
+            references "auth_users"
+            on delete set null,
+    changes     jsonb                    not null default '[]',
+    occurred_at timestamp with time zone not null default now()
+);
+
+create index idx_timeline_events_entity on timeline_events (entity_type, entity_id, occurred_at desc);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['activities:update', 'activities:delete']) as permission
+on conflict do nothing;
@@ -0,0 +1,10 @@
+delete
+from role_permissions
+where permission in ('custom-fields:create', 'custom-fields:update', 'custom-fields:delete');
+
+drop index idx_users_custom_fields;
+
+alter table users
+    drop column custom_fields;
+
+drop table custom_field_definitions;
@@ -0,0 +1,29 @@
+create table custom_field_definitions
+(
+    id          uuid                     not null
+        primary key,
+    entity_type text                     not null,
+    key         text                     not null,
+    label       text                     not null,
+    type        text                     not null,
+    required    boolean                  not null default false,
+    "unique"    boolean                  not null default false,
+    options     jsonb                    not null default '[]',
+    max_length  integer,
+    min         double precision,
+    max         double precision,
+    created_at  timestamp with time zone not null default now(),
+    updated_at  timestamp with time zone not null default now(),
+    constraint uq_custom_field_key
+        unique (entity_type, key)
+);
+
+alter table users
+    add column custom_fields jsonb not null default '{}';
+
+create index idx_users_custom_fields on users using gin (custom_fields jsonb_path_ops);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['custom-fields:create', 'custom-fields:update', 'custom-fields:delete']) as permission
+on conflict do nothing;
@@ -0,0 +1,7 @@
+delete
+from role_permissions
+where permission in ('tags:create', 'tags:update', 'tags:delete');
+
+drop table entity_tags;
+
+drop table tags;
@@ -0,0 +1,32 @@
+create table tags
+(
+    id          uuid                     not null
+        primary key,
+    name        text                     not null,
+    color       text                     not null default '',
+    description text                     not null default '',
+    created_at  timestamp with time zone not null default now(),
+    updated_at  timestamp with time zone not null default now()
+);
+
+create unique index uq_tags_name on tags (lower(name));
+
+create table entity_tags
+(
+    tag_id      uuid                     not null
+        constraint fk_tag
+            references "tags"
+            on delete cascade,
+    -- any record type, the repositories of the records delete their links
+    entity_type text                     not null,
+    entity_id   uuid                     not null,
+    created_at  timestamp with time zone not null default now(),
+    primary key (tag_id, entity_type, entity_id)
+);
+
+create index idx_entity_tags_entity on entity_tags (entity_type, entity_id);
+
+insert into role_permissions (role, permission)
+select 'ADMIN', permission
+from unnest(array ['tags:create', 'tags:update', 'tags:delete']) as permission
+on conflict do nothing;
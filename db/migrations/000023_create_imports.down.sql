@@ -0,0 +1,7 @@
+delete
+from role_permissions
+where permission = 'imports:create';
+
+drop table import_row_errors;
+
+drop table imports;
@@ -0,0 +1,42 @@
+create table imports
+(
+    id             uuid                     not null
+        primary key,
+    owner_id       uuid                     not null
+        constraint fk_auth_user
+            references "auth_users"
+            on delete cascade,
+    file_name      text                     not null default '',
+    headers        jsonb                    not null default '[]',
+    -- column header -> contact field
+    mapping        jsonb                    not null default '{}',
+    status         text                     not null default 'uploaded',
+    total_rows     integer                  not null default 0,
+    processed_rows integer                  not null default 0,
+    created_rows   integer                  not null default 0,
+    failed_rows    integer                  not null default 0,
+    error          text                     not null default '',
+    data           bytea                    not null,
+    created_at     timestamp with time zone not null default now(),
+    updated_at     timestamp with time zone not null default now(),
+    started_at     timestamp with time zone,
+    finished_at    timestamp with time zone
+);
+
+create index idx_imports_owner_id on imports (owner_id, created_at);
+create index idx_imports_status on imports (status, created_at) where status in ('queued', 'running');
+
+create table import_row_errors
+(
+    import_id uuid    not null
+        constraint fk_import
+            references imports
+            on delete cascade,
+    row_no    integer not null,
+    errors    jsonb   not null default '[]',
+    primary key (import_id, row_no)
+);
+
+insert into role_permissions (role, permission)
+values ('ADMIN', 'imports:create')
+on conflict do nothing;
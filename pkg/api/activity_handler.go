@@ -0,0 +1,342 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type ActivityHandler struct {
+	api *api
+}
+
+func NewActivityHandler(a *api) *ActivityHandler {
+	return &ActivityHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary log an activity against a record
+// @Description the caller is the author, entity_type is user with the ID of the account, contact, company or deal; the profiles of other users require users:read and the records of other owners the read permission of their type, duration is in minutes and occurred_at defaults to now
+// @Produce json
+// @Tags Activities
+// @Security ApiKeyAuth
+// @Param activity  body model.Activity  true "Activity"
+// @Success 200 {object} model.Activity
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/activities [post]
+//
+//nolint:varnamelen
+func (h *ActivityHandler) Create(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	activity := &model.Activity{}
+	err = c.ShouldBindJSON(&activity)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := activity.Validate(time.Now()); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if !h.api.canSeeRecord(principal, activity.EntityType, activity.EntityID) {
+		logger.Errorf("Create.canSee", activity.EntityID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRecord)
+
+		return
+	}
+
+	if !h.checkParticipants(c, activity.ParticipantIDs) {
+		return
+	}
+
+	activity.ID = uuid.Nil
+	activity.AuthorID = &principal.UserID
+
+	err = h.api.postgresStore.Activity.Create(activity)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// Get
+// @Summary get an activity
+// @Description activities are visible to the users who see their record
+// @Produce json
+// @Tags Activities
+// @Security ApiKeyAuth
+// @Param id  path string  true "Activity ID"
+// @Success 200 {object} model.Activity
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/activities/{id} [get]
+//
+//nolint:varnamelen
+func (h *ActivityHandler) Get(c *gin.Context) {
+	activity, _, ok := h.activityParam(c, "")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// Update
+// @Summary replace an activity
+// @Description activities of other authors require activities:update, the record and the author are kept
+// @Produce json
+// @Tags Activities
+// @Security ApiKeyAuth
+// @Param id        path string  true "Activity ID"
+// @Param activity  body model.Activity  true "Activity"
+// @Success 200 {object} model.Activity
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/activities/{id} [put]
+//
+//nolint:varnamelen
+func (h *ActivityHandler) Update(c *gin.Context) {
+	activityDB, _, ok := h.activityParam(c, model.PermActivitiesUpdate)
+	if !ok {
+		return
+	}
+
+	activity := &model.Activity{}
+	err := c.ShouldBindJSON(&activity)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	activity.EntityType = activityDB.EntityType
+	activity.EntityID = activityDB.EntityID
+
+	if fields := activity.Validate(time.Now()); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if !h.checkParticipants(c, activity.ParticipantIDs) {
+		return
+	}
+
+	activity.ID = activityDB.ID
+	activity.AuthorID = activityDB.AuthorID
+	activity.CreatedAt = activityDB.CreatedAt
+
+	err = h.api.postgresStore.Activity.Update(activity)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// Delete
+// @Summary delete an activity
+// @Description activities of other authors require activities:delete
+// @Produce json
+// @Tags Activities
+// @Security ApiKeyAuth
+// @Param id  path string  true "Activity ID"
+// @Success 200 {object} crm.ActivityDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/activities/{id} [delete]
+//
+//nolint:varnamelen
+func (h *ActivityHandler) Delete(c *gin.Context) {
+	activity, _, ok := h.activityParam(c, model.PermActivitiesDelete)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Activity.Delete(activity.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.ActivityDeleteResponse{Status: "activity deleted"})
+}
+
+// Timeline
+// @Summary get the timeline of a record
+// @Description the activities and the system events of the record, newest first, like profile updates; the profiles of other users require users:read and the records of other owners the read permission of their type
+// @Produce json
+// @Tags Activities
+// @Security ApiKeyAuth
+// @Param type      path  string true  "Entity type, user, contact, company or deal"
+// @Param id        path  string true  "Entity ID, the account ID for user"
+// @Param page      query int    false "Page, starts at 1"
+// @Param per_page  query int    false "Entries per page, 20 by default, at most 100"
+// @Success 200 {object} crm.TimelineResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/timeline/{type}/{id} [get]
+//
+//nolint:varnamelen
+func (h *ActivityHandler) Timeline(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Timeline.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	entityType := model.RecordType(c.Param("type"))
+
+	entityID, err := uuid.FromString(c.Param("id"))
+	if err != nil || !entityType.IsKnown() {
+		logger.Errorf("Timeline.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	page := model.Pagination{}
+	err = c.ShouldBindQuery(&page)
+	if err != nil {
+		logger.Errorf("Timeline.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	page.Normalize()
+
+	if !h.api.canSeeRecord(principal, entityType, entityID) {
+		logger.Errorf("Timeline.canSee", entityID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	entries, total, err := h.api.postgresStore.Activity.Timeline(entityType, entityID, page)
+	if err != nil {
+		logger.Errorf("Timeline.Timeline", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.TimelineResponse{
+		Entries: entries,
+		Total:   total,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+	})
+}
+
+// activityParam loads the activity of the id path parameter. Activities of
+// records the caller doesn't see respond as missing, changing the activities of
+// other authors needs the permission; an empty permission only reads.
+//
+//nolint:varnamelen
+func (h *ActivityHandler) activityParam(
+	c *gin.Context,
+	permission model.Permission,
+) (*model.Activity, *authmiddleware.Principal, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("activityParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	activityID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("activityParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, nil, false
+	}
+
+	activity, exists := h.api.postgresStore.Activity.Get(activityID)
+	if !exists || !h.api.canSeeRecord(principal, activity.EntityType, activity.EntityID) {
+		logger.Errorf("activityParam.Get", activityID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, nil, false
+	}
+
+	if permission != "" && !activity.IsAuthoredBy(principal.UserID) && !principal.Can(permission) {
+		logger.Errorf("activityParam.Can", permission)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return nil, nil, false
+	}
+
+	return activity, principal, true
+}
+
+// checkParticipants responds with an error unless every participant is a user.
+//
+//nolint:varnamelen
+func (h *ActivityHandler) checkParticipants(c *gin.Context, participantIDs []uuid.UUID) bool {
+	for _, participantID := range participantIDs {
+		if _, exists := h.api.postgresStore.Auth.Get(participantID); !exists {
+			logger.Errorf("checkParticipants.Get", participantID)
+			c.JSON(http.StatusBadRequest, model.ErrInvalidParticipant)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// timelineEvent appends the event to the timeline of its record, the actor is
+// the principal or the impersonating admin. The request has been handled so
+// errors are only logged.
+//
+//nolint:varnamelen
+func (h *ActivityHandler) timelineEvent(c *gin.Context, event *model.TimelineEvent) {
+	event.OccurredAt = time.Now()
+
+	if principal, err := authmiddleware.GetPrincipal(c); err == nil {
+		actorID := principal.UserID
+		if principal.IsImpersonated() {
+			actorID = principal.ImpersonatorID
+		}
+
+		event.ActorID = &actorID
+	}
+
+	err := h.api.postgresStore.Activity.CreateEvent(event)
+	if err != nil {
+		logger.Errorf("timelineEvent.CreateEvent", err)
+	}
+}
@@ -0,0 +1,664 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	activityID         = uuid.NewV4()
+	activityOccurredAt = time.Date(2026, time.October, 1, 10, 0, 0, 0, time.UTC)
+	testActivity       = model.Activity{
+		ID:             activityID,
+		Type:           model.ActivityCall,
+		Body:           "Discussed the renewal",
+		OccurredAt:     activityOccurredAt,
+		Duration:       15,
+		AuthorID:       &contactOwnerID,
+		EntityType:     model.RecordUser,
+		EntityID:       contactOwnerID,
+		ParticipantIDs: []uuid.UUID{},
+	}
+	otherActivity = model.Activity{
+		ID:             activityID,
+		Type:           model.ActivityNote,
+		Body:           "Prefers email",
+		OccurredAt:     activityOccurredAt,
+		AuthorID:       &contactOtherID,
+		EntityType:     model.RecordUser,
+		EntityID:       contactOtherID,
+		ParticipantIDs: []uuid.UUID{},
+	}
+	profileEvent = model.TimelineEvent{
+		ID:         uuid.NewV4(),
+		EntityType: model.RecordUser,
+		EntityID:   contactOwnerID,
+		Kind:       model.EventProfileUpdated,
+		ActorID:    &contactOwnerID,
+		Changes:    model.FieldChanges{{Field: "phone", From: "", To: "+380681234567"}},
+		OccurredAt: activityOccurredAt.Add(time.Hour),
+	}
+)
+
+var testMapActivityHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "PositiveOwnProfile",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/activities",
+			Data: model.Activity{
+				Type:           "CALL",
+				Body:           " Discussed the renewal ",
+				OccurredAt:     activityOccurredAt,
+				Duration:       15,
+				EntityType:     model.RecordUser,
+				EntityID:       contactOwnerID,
+				ParticipantIDs: []uuid.UUID{contactOtherID, contactOtherID},
+			},
+			ExpectedData: model.Activity{
+				Type:           model.ActivityCall,
+				Body:           "Discussed the renewal",
+				OccurredAt:     activityOccurredAt,
+				Duration:       15,
+				AuthorID:       &contactOwnerID,
+				EntityType:     model.RecordUser,
+				EntityID:       contactOwnerID,
+				ParticipantIDs: []uuid.UUID{contactOtherID},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, AuthRepoGetMock, ActivityRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "PositiveOwnContact",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/activities",
+			Data:   model.Activity{Type: model.ActivityMeeting, OccurredAt: activityOccurredAt, EntityType: model.RecordContact, EntityID: contactID},
+			ExpectedData: model.Activity{
+				Type:           model.ActivityMeeting,
+				OccurredAt:     activityOccurredAt,
+				AuthorID:       &contactOwnerID,
+				EntityType:     model.RecordContact,
+				EntityID:       contactID,
+				ParticipantIDs: []uuid.UUID{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoGetMock, ActivityRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "PositiveOtherDealWithRead",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/activities",
+			Data:   model.Activity{Type: model.ActivityEmail, OccurredAt: activityOccurredAt, EntityType: model.RecordDeal, EntityID: dealID},
+			ExpectedData: model.Activity{
+				Type:           model.ActivityEmail,
+				OccurredAt:     activityOccurredAt,
+				AuthorID:       &contactOwnerID,
+				EntityType:     model.RecordDeal,
+				EntityID:       dealID,
+				ParticipantIDs: []uuid.UUID{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermDealsRead},
+			Mock:         makeList(DealRepoGetMock, ActivityRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/activities",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/activities",
+			Data:         model.Activity{Type: model.ActivityNote, Duration: -5, EntityType: "planet"},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "body", Rule: "required", Message: "a note needs a body"},
+				{Field: "duration", Rule: "range", Message: "duration must be between 0 and 1440 minutes"},
+				{Field: "entity_type", Rule: "oneof", Message: "unknown entity type"},
+				{Field: "entity_id", Rule: "required", Message: "entity_id is required"},
+			}),
+		},
+		{
+			Name:         "NegativeOtherProfileWithoutRead",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/activities",
+			Data:         model.Activity{Type: model.ActivityCall, EntityType: model.RecordUser, EntityID: contactOtherID},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+		},
+		{
+			Name:         "NegativeOtherDealWithoutRead",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/activities",
+			Data:         model.Activity{Type: model.ActivityCall, EntityType: model.RecordDeal, EntityID: dealID},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermContactsRead},
+			Mock:        makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeUnknownParticipant",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/activities",
+			Data: model.Activity{Type: model.ActivityMeeting, EntityType: model.RecordUser, EntityID: contactOwnerID,
+				ParticipantIDs: []uuid.UUID{contactOtherID}},
+			PositiveTest: false, WhatError: model.ErrInvalidParticipant,
+			UserID: contactOwnerID,
+			Mock:   makeList(AuthRepoGetMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeActivityRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/activities",
+			Data:         model.Activity{Type: model.ActivityEmail, EntityType: model.RecordUser, EntityID: contactOwnerID},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(AuthRepoGetMock, ActivityRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Get": {
+		{
+			Name:         "PositiveOtherProfileWithRead",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			ExpectedData: otherActivity,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermUsersRead},
+			Mock:         makeList(ActivityRepoGetMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherActivity,
+					true,
+				},
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherProfile",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(ActivityRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherActivity,
+					true,
+				},
+			},
+		},
+	},
+	"Update": {
+		{
+			Name:   "PositiveAuthorKeepsRecord",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			Data: model.Activity{
+				Type:       model.ActivityMeeting,
+				Body:       "Discussed the renewal",
+				OccurredAt: activityOccurredAt,
+				Duration:   45,
+				EntityID:   contactOtherID,
+			},
+			ExpectedData: model.Activity{
+				ID:             activityID,
+				Type:           model.ActivityMeeting,
+				Body:           "Discussed the renewal",
+				OccurredAt:     activityOccurredAt,
+				Duration:       45,
+				AuthorID:       &contactOwnerID,
+				EntityType:     model.RecordUser,
+				EntityID:       contactOwnerID,
+				ParticipantIDs: []uuid.UUID{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ActivityRepoGetMock, AuthRepoGetMock, ActivityRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testActivity,
+					true,
+				},
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotAuthor",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			Data:         model.Activity{Type: model.ActivityNote, Body: "Prefers calls"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermUsersRead},
+			Mock:        makeList(ActivityRepoGetMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherActivity,
+					true,
+				},
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "PositiveWithPermission",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			ExpectedData: crm.ActivityDeleteResponse{Status: "activity deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermUsersRead, model.PermActivitiesDelete},
+			Mock:         makeList(ActivityRepoGetMock, AuthRepoGetMock, ActivityRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&otherActivity,
+					true,
+				},
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotFound",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/activities/" + activityID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(ActivityRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Timeline": {
+		{
+			Name:   "PositiveOwnProfile",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/timeline/user/" + contactOwnerID.String() + "?per_page=2",
+			ExpectedData: crm.TimelineResponse{
+				Entries: []model.TimelineEntry{
+					{Kind: model.TimelineEntryEvent, OccurredAt: profileEvent.OccurredAt, Event: &profileEvent},
+					{Kind: model.TimelineEntryActivity, OccurredAt: activityOccurredAt, Activity: &testActivity},
+				},
+				Total:   3,
+				Page:    1,
+				PerPage: 2,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, ActivityRepoTimelineMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					model.Pagination{Page: 1, PerPage: 2},
+					[]model.TimelineEntry{
+						{Kind: model.TimelineEntryEvent, OccurredAt: profileEvent.OccurredAt, Event: &profileEvent},
+						{Kind: model.TimelineEntryActivity, OccurredAt: activityOccurredAt, Activity: &testActivity},
+					},
+					int64(3),
+				},
+			},
+		},
+		{
+			Name:   "PositiveOtherContactWithRead",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/timeline/contact/" + contactID.String(),
+			ExpectedData: crm.TimelineResponse{
+				Entries: []model.TimelineEntry{},
+				Total:   0,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermContactsRead},
+			Mock:         makeList(ContactRepoGetMock, ActivityRepoTimelineMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+				{
+					model.RecordContact,
+					model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+					[]model.TimelineEntry{},
+					int64(0),
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherContact",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/timeline/contact/" + contactID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherProfile",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/timeline/user/" + contactOtherID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+		},
+		{
+			Name:         "NegativeUnknownType",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/timeline/planet/" + contactOwnerID.String(),
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+}
+
+func TestActivityHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	activityRepo := mockpostgresstore.NewMockActivityRepository(mockCtrl)
+	mockPostgresStore.Activity = activityRepo
+	repos = append(repos, activityRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	dealRepo := mockpostgresstore.NewMockDealRepository(mockCtrl)
+	mockPostgresStore.Deal = dealRepo
+	repos = append(repos, dealRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapActivityHandler)
+}
+
+func ActivityRepoCreateMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func ActivityRepoGetMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var result *model.Activity
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Activity:
+			// the handler may change the activity it gets
+			activity := *t
+			result = &activity
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().Get(activityID).Return(result, exist).Times(1)
+}
+
+func ActivityRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func ActivityRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().Delete(activityID).Return(err).Times(1)
+}
+
+func ActivityRepoTimelineMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var recordType = model.RecordUser
+	var page interface{} = gomock.Any()
+	var result []model.TimelineEntry
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.RecordType:
+			recordType = t
+		case model.Pagination:
+			page = t
+		case []model.TimelineEntry:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().Timeline(recordType, gomock.Any(), page).Return(result, total, err).Times(1)
+}
+
+func ActivityRepoCreateEventMock(repos []interface{}, data []interface{}) {
+	var activityMock *mockpostgresstore.MockActivityRepository
+	var changes model.FieldChanges
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockActivityRepository:
+			activityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.FieldChanges:
+			changes = t
+		default:
+			continue
+		}
+	}
+
+	activityMock.EXPECT().CreateEvent(gomock.Any()).DoAndReturn(func(event *model.TimelineEvent) error {
+		if event.Kind != model.EventProfileUpdated || event.ActorID == nil || *event.ActorID != event.EntityID {
+			return errors.New("not a profile update of the user")
+		}
+
+		if changes != nil && len(changes) != len(event.Changes) {
+			return errors.New("unexpected changes")
+		}
+
+		for i := range changes {
+			if changes[i] != event.Changes[i] {
+				return errors.New("unexpected changes")
+			}
+		}
+
+		return err
+	}).Times(1)
+}
@@ -0,0 +1,487 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type AdminHandler struct {
+	api *api
+}
+
+func NewAdminHandler(a *api) *AdminHandler {
+	return &AdminHandler{
+		api: a,
+	}
+}
+
+// Unlock
+// @Summary unlock an account locked after failed logins
+// @Description requires users:update, clears the failed attempts of the account, the per-IP counters are kept
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} admin.UnlockResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/unlock [post]
+//
+//nolint:varnamelen
+func (h *AdminHandler) Unlock(c *gin.Context) {
+	userDB, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.Guard().Unlock(userDB.Username)
+	if err != nil {
+		logger.Errorf("Unlock.Unlock", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.UnlockResponse{Status: "user unlocked"})
+}
+
+// ListUsers
+// @Summary list users
+// @Description requires users:read, search matches username, email, name and surname
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param search    query string false "Search"
+// @Param role      query string false "Role"
+// @Param active    query bool   false "Active"
+// @Param cf        query object false "Custom field values, cf[key]=value, multi_select fields match one of their options"
+// @Param tags      query string false "Tag names, comma separated, e.g. vip,partner"
+// @Param tags_match query string false "all tags (default) or any of them"
+// @Param page      query int    false "Page, starts at 1"
+// @Param per_page  query int    false "Users per page, 20 by default, at most 100"
+// @Success 200 {object} admin.UserListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users [get]
+//
+//nolint:varnamelen
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	query := model.UserListQuery{}
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("ListUsers.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	query.Normalize()
+
+	if !query.TagFilter.IsValid() {
+		logger.Errorf("ListUsers.TagFilter", query.TagFilter)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	customFields, ok := h.api.CustomField().filter(c, model.RecordUser)
+	if !ok {
+		return
+	}
+
+	query.CustomFields = customFields
+
+	users, total, err := h.api.postgresStore.Auth.List(query)
+	if err != nil {
+		logger.Errorf("ListUsers.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.UserListResponse{
+		Users:   users,
+		Total:   total,
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// GetUser
+// @Summary get a user
+// @Description requires users:read
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} model.UserAccount
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id} [get]
+//
+//nolint:varnamelen
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("GetUser.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	account, exists := h.api.postgresStore.Auth.GetAccount(userID)
+	if !exists {
+		logger.Errorf("GetUser.GetAccount", userID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// SetUserRole
+// @Summary change the role of a user
+// @Description requires users:update, the user's access tokens stop working and have to be refreshed.
+// @Description the caller must hold every permission of both the current and the new role
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id    path string  true "User ID"
+// @Param role  body model.UserRoleChange  true "Role"
+// @Success 200 {object} model.UserAccount
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/role [put]
+//
+//nolint:varnamelen
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	change := &model.UserRoleChange{}
+	err := c.ShouldBindJSON(&change)
+	if err != nil {
+		logger.Errorf("SetUserRole.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !change.IsValid() {
+		logger.Errorf("SetUserRole.Empty role", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, ok := h.otherUserParam(c)
+	if !ok {
+		return
+	}
+
+	// demoting takes the permissions of the current role as promoting those of the new one
+	if !h.api.Role().checkGrantable(c, change.Role) || !h.api.Role().checkGrantable(c, userDB.Role) {
+		return
+	}
+
+	err = h.api.postgresStore.Auth.SetRole(userDB.ID, change.Role)
+	if err != nil {
+		logger.Errorf("SetUserRole.SetRole", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventRoleChange, userDB).
+		Succeeded(string(userDB.Role)+" -> "+string(change.Role)))
+
+	h.respondAccount(c, userDB.ID)
+}
+
+// DeactivateUser
+// @Summary deactivate a user
+// @Description requires users:update, the user can't log in and every session is revoked
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} model.UserAccount
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/deactivate [post]
+//
+//nolint:varnamelen
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	userDB, ok := h.otherUserParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Auth.SetActive(userDB.ID, false)
+	if err != nil {
+		logger.Errorf("DeactivateUser.SetActive", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.auth.LogoutAll(userDB.ID)
+	if err != nil {
+		logger.Errorf("DeactivateUser.LogoutAll", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.respondAccount(c, userDB.ID)
+}
+
+// ReactivateUser
+// @Summary reactivate a deactivated user
+// @Description requires users:update
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} model.UserAccount
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+//
+//nolint:varnamelen
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	userDB, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Auth.SetActive(userDB.ID, true)
+	if err != nil {
+		logger.Errorf("ReactivateUser.SetActive", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.respondAccount(c, userDB.ID)
+}
+
+// DeleteUser
+// @Summary delete a user with the profile, sessions and 2FA data
+// @Description requires users:delete
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} admin.UserStatusResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id} [delete]
+//
+//nolint:varnamelen
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	userDB, ok := h.otherUserParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Auth.Delete(userDB.ID)
+	if err != nil {
+		logger.Errorf("DeleteUser.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.resetAttempts(userDB.Username)
+
+	c.JSON(http.StatusOK, admin.UserStatusResponse{Status: "user deleted"})
+}
+
+// ListUserSessions
+// @Summary list the active sessions of a user
+// @Description requires users:read
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {array} model.Session
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/sessions [get]
+//
+//nolint:varnamelen
+func (h *AdminHandler) ListUserSessions(c *gin.Context) {
+	userDB, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+
+	h.api.Session().list(c, userDB.ID, uuid.Nil)
+}
+
+// RevokeUserSession
+// @Summary log out a session of a user
+// @Description requires users:update
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id   path string  true "User ID"
+// @Param sid  path string  true "Session ID"
+// @Success 200 {object} auth.SessionRevokeResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/sessions/{sid} [delete]
+//
+//nolint:varnamelen
+func (h *AdminHandler) RevokeUserSession(c *gin.Context) {
+	userDB, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+
+	h.api.Session().revoke(c, userDB.ID, c.Param("sid"))
+}
+
+// CreateServiceAccount
+// @Summary create a service account
+// @Description requires users:create, service accounts can't log in and authenticate with API keys.
+// @Description the caller must hold every permission of the role
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param account  body model.ServiceAccountCreate  true "Service account"
+// @Success 200 {object} model.UserAccount
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/service-accounts [post]
+//
+//nolint:varnamelen
+func (h *AdminHandler) CreateServiceAccount(c *gin.Context) {
+	account := &model.ServiceAccountCreate{}
+	err := c.ShouldBindJSON(&account)
+	if err != nil {
+		logger.Errorf("CreateServiceAccount.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !account.IsValid() {
+		logger.Errorf("CreateServiceAccount.IsValid", account.Username)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !h.api.Role().checkGrantable(c, account.Role) {
+		return
+	}
+
+	userDB, err := h.api.postgresStore.Auth.GetByUsername(account.Username)
+	if err != nil {
+		logger.Errorf("CreateServiceAccount.GetByUsername", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if userDB.ID != uuid.Nil {
+		logger.Errorf("CreateServiceAccount.Username exist", account.Username)
+		c.JSON(http.StatusBadRequest, model.ErrUsenameExist)
+
+		return
+	}
+
+	user := &model.AuthUser{
+		Username:       account.Username,
+		Role:           account.Role,
+		Active:         true,
+		ServiceAccount: true,
+	}
+
+	err = h.api.postgresStore.Auth.Create(user)
+	if err != nil {
+		logger.Errorf("CreateServiceAccount.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventRegistration, user).Succeeded(string(user.Role)))
+
+	h.respondAccount(c, user.ID)
+}
+
+// userParam loads the user of the id path parameter.
+//
+//nolint:varnamelen
+func (h *AdminHandler) userParam(c *gin.Context) (*model.AuthUser, bool) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	return h.getUser(c, userID)
+}
+
+// otherUserParam is userParam that refuses the current user, so an admin
+// can't lock themselves out.
+//
+//nolint:varnamelen
+func (h *AdminHandler) otherUserParam(c *gin.Context) (*model.AuthUser, bool) {
+	currentID, err := authmiddleware.CurrentUserID(c)
+	if err != nil {
+		logger.Errorf("otherUserParam.CurrentUserID", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	if uuid.Equal(userID, currentID) {
+		logger.Errorf("otherUserParam.self", currentID)
+		c.JSON(http.StatusBadRequest, model.ErrSelfAction)
+
+		return nil, false
+	}
+
+	return h.getUser(c, userID)
+}
+
+//nolint:varnamelen
+func (h *AdminHandler) userIDParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("userParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+//nolint:varnamelen
+func (h *AdminHandler) getUser(c *gin.Context, userID uuid.UUID) (*model.AuthUser, bool) {
+	userDB, exists := h.api.postgresStore.Auth.Get(userID)
+	if !exists {
+		logger.Errorf("userParam.Get", userID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, false
+	}
+
+	return userDB, true
+}
+
+//nolint:varnamelen
+func (h *AdminHandler) respondAccount(c *gin.Context, userID uuid.UUID) {
+	account, exists := h.api.postgresStore.Auth.GetAccount(userID)
+	if !exists {
+		logger.Errorf("respondAccount.GetAccount", userID)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
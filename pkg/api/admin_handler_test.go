@@ -0,0 +1,789 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/bruteforce"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	adminID       = uuid.NewV4()
+	targetID      = uuid.NewV4()
+	targetAccount = &model.UserAccount{ID: targetID, Username: "user", Role: model.BaseUserRole, Active: true}
+
+	serviceAccount = &model.UserAccount{
+		ID:             targetID,
+		Username:       "billing",
+		Role:           model.BaseUserRole,
+		Active:         true,
+		ServiceAccount: true,
+	}
+)
+
+var testMapAdminHandler = map[string][]model.TestStructure{
+	"Unlock": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + uuid.NewV4().String() + "/unlock",
+			ExpectedData: admin.UnlockResponse{Status: "user unlocked"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(LoginAttemptLockMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					bruteforce.UsernameKey("locked"),
+				},
+				{
+					&model.AuthUser{
+						Username: "locked",
+					},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + uuid.NewV4().String() + "/unlock",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+		{
+			Name:         "NegativeInvalidID",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/invalid/unlock",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeAuthRepoGetMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + uuid.NewV4().String() + "/unlock",
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"ListUsers": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/users?search=us&page=2&per_page=500",
+			ExpectedData: admin.UserListResponse{
+				Users:   []model.UserAccount{*targetAccount},
+				Total:   101,
+				Page:    2,
+				PerPage: model.MaxPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.UserAccount{*targetAccount},
+					int64(101),
+				},
+			},
+		},
+		{
+			Name:   "PositiveCustomFields",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/users?cf[department]=sales&cf[languages]=EN",
+			ExpectedData: admin.UserListResponse{
+				Users:   []model.UserAccount{*targetAccount},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(CustomFieldRepoListMock, AuthRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.CustomFieldDefinition{departmentField, languagesField},
+				},
+				{
+					model.CustomFieldValues{"department": "Sales", "languages": []string{"en"}},
+					[]model.UserAccount{*targetAccount},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeCustomFields",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users?cf[remote]=maybe&cf[shoe_size]=42",
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "cf[remote]", Rule: "format", Message: "invalid value for the custom field"},
+				{Field: "cf[shoe_size]", Rule: "unknown", Message: "unknown custom field"},
+			}),
+			Mock: makeList(CustomFieldRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.CustomFieldDefinition{remoteField},
+				},
+			},
+		},
+		{
+			Name:   "PositiveTags",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/users?tags=VIP,partner&tags_match=ANY",
+			ExpectedData: admin.UserListResponse{
+				Users:   []model.UserAccount{*targetAccount},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.TagFilter{Tags: "VIP,partner", Match: model.TagsMatchAny},
+					[]model.UserAccount{*targetAccount},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeTagsMatch",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users?tags=vip&tags_match=some",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidQuery",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users?active=maybe",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeAuthRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(AuthRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{},
+		},
+	},
+	"GetUser": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String(),
+			ExpectedData: targetAccount,
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					targetAccount,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoGetAccountMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"SetUserRole": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{Role: "admin"},
+			ExpectedData: targetAccount,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       adminID,
+			Mock: makeList(AuthRepoGetMock, RoleRepoGetMock, RoleRepoGetMock, AuthRepoSetRoleMock,
+				AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Role: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{},
+				{
+					targetAccount,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativePromoteBeyondOwnPermissions",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{Role: model.AdminUserRole},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      adminID,
+			Permissions: []model.Permission{model.PermUsersUpdate},
+			Mock:        makeList(AuthRepoGetMock, RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Role: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeDemoteBeyondOwnPermissions",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      adminID,
+			Permissions: []model.Permission{model.PermUsersUpdate},
+			Mock:        makeList(AuthRepoGetMock, RoleRepoGetMock, RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Role: model.AdminUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeEmptyRole",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeSelf",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + adminID.String() + "/role",
+			Data:         model.UserRoleChange{Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID: adminID,
+		},
+		{
+			Name:         "NegativeRoleRepoGetMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{Role: "UNKNOWN"},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			UserID: adminID,
+			Mock:   makeList(AuthRepoGetMock, RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID},
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoSetRoleMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/role",
+			Data:         model.UserRoleChange{Role: model.AdminUserRole},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: adminID,
+			Mock:   makeList(AuthRepoGetMock, RoleRepoGetMock, RoleRepoGetMock, AuthRepoSetRoleMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Role: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.AdminUserRole},
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"DeactivateUser": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/deactivate",
+			ExpectedData: targetAccount,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       adminID,
+			Mock:         makeList(AuthRepoGetMock, AuthRepoSetActiveMock, MiddlewareLogoutAllMock, AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID},
+					true,
+				},
+				{},
+				{},
+				{
+					targetAccount,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeSelf",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + adminID.String() + "/deactivate",
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID: adminID,
+		},
+		{
+			Name:         "NegativeSelfUppercase",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + strings.ToUpper(adminID.String()) + "/deactivate",
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID: adminID,
+		},
+		{
+			Name:         "NegativeMiddlewareLogoutAllMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/deactivate",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: adminID,
+			Mock:   makeList(AuthRepoGetMock, AuthRepoSetActiveMock, MiddlewareLogoutAllMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID},
+					true,
+				},
+				{},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"ReactivateUser": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/reactivate",
+			ExpectedData: targetAccount,
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetMock, AuthRepoSetActiveMock, AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID},
+					true,
+				},
+				{},
+				{
+					targetAccount,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoSetActiveMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String() + "/reactivate",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(AuthRepoGetMock, AuthRepoSetActiveMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"DeleteUser": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String(),
+			ExpectedData: admin.UserStatusResponse{Status: "user deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       adminID,
+			Mock:         makeList(AuthRepoGetMock, AuthRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Username: "user"},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeSelf",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + adminID.String(),
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID: adminID,
+		},
+		{
+			Name:         "NegativeAuthRepoDeleteMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: adminID,
+			Mock:   makeList(AuthRepoGetMock, AuthRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: targetID, Username: "user"},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + targetID.String(),
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersUpdate},
+		},
+	},
+	"CreateServiceAccount": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: "base"},
+			ExpectedData: serviceAccount,
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock, AuthRepoGetAccountMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{},
+				{
+					serviceAccount,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleBeyondOwnPermissions",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: model.AdminUserRole},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersCreate},
+			Mock:        makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeEmptyUsername",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeRoleRepoGetMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: "UNKNOWN"},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUsernameExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrUsenameExist,
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{ID: targetID, Username: "billing"},
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/service-accounts",
+			Data:         model.ServiceAccountCreate{Username: "billing", Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+	},
+}
+
+func TestAdminHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	//all repos mock what need for tests
+	userAuthRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = userAuthRepo
+	repos = append(repos, userAuthRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	loginAttemptRepo := memorystore.NewLoginAttemptRepository()
+	mockPostgresStore.LoginAttempt = loginAttemptRepo
+	repos = append(repos, loginAttemptRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	customFieldRepo := mockpostgresstore.NewMockCustomFieldRepository(mockCtrl)
+	mockPostgresStore.CustomField = customFieldRepo
+	repos = append(repos, customFieldRepo)
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	runHandlerTests(t, testAPI, repos, testMapAdminHandler)
+}
+
+func AuthRepoListMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var result []model.UserAccount
+	var customFields model.CustomFieldValues
+	tagFilter := model.TagFilter{Match: model.TagsMatchAll}
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.UserAccount:
+			result = t
+		case model.CustomFieldValues:
+			customFields = t
+		case model.TagFilter:
+			tagFilter = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().List(gomock.Any()).DoAndReturn(func(query model.UserListQuery) ([]model.UserAccount, int64, error) {
+		if !reflect.DeepEqual(customFields, query.CustomFields) {
+			return nil, 0, errors.New("unexpected custom field filter")
+		}
+
+		if tagFilter != query.TagFilter {
+			return nil, 0, errors.New("unexpected tag filter")
+		}
+
+		return result, total, err
+	}).Times(1)
+}
+
+func AuthRepoGetAccountMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var result *model.UserAccount
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.UserAccount:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().GetAccount(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func AuthRepoSetRoleMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().SetRole(gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
+
+func AuthRepoSetActiveMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().SetActive(gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
+
+func AuthRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().Delete(gomock.Any()).Return(err).Times(1)
+}
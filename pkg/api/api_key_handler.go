@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type APIKeyHandler struct {
+	api *api
+}
+
+func NewAPIKeyHandler(a *api) *APIKeyHandler {
+	return &APIKeyHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary issue an API key to a service account
+// @Description requires api-keys:create, the key is returned only once and can't grant permissions the caller lacks
+// @Produce json
+// @Tags API keys
+// @Security ApiKeyAuth
+// @Param key  body model.APIKeyCreate  true "API key"
+// @Success 200 {object} admin.APIKeyCreateResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/api-keys [post]
+//
+//nolint:varnamelen
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	request := &model.APIKeyCreate{}
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !request.IsValid(time.Now()) {
+		logger.Errorf("Create.IsValid", request.Name)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	for _, permission := range request.Permissions {
+		if !principal.Can(permission) {
+			logger.Errorf("Create.Can", permission)
+			c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+			return
+		}
+	}
+
+	userDB, exists := h.api.postgresStore.Auth.Get(request.UserID)
+	if !exists {
+		logger.Errorf("Create.Get", request.UserID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	if !userDB.ServiceAccount {
+		logger.Errorf("Create.ServiceAccount", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrNotServiceAccount)
+
+		return
+	}
+
+	key, prefix, hash, err := authmiddleware.GenerateAPIKey()
+	if err != nil {
+		logger.Errorf("Create.GenerateAPIKey", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	apiKey := &model.APIKey{
+		UserID:      userDB.ID,
+		Name:        request.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Permissions: request.Permissions,
+		ExpiresAt:   request.ExpiresAt,
+	}
+
+	err = h.api.postgresStore.APIKey.Create(apiKey)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.APIKeyCreateResponse{Key: key, APIKey: *apiKey})
+}
+
+// List
+// @Summary list API keys
+// @Description requires api-keys:read, the keys themselves are never returned
+// @Produce json
+// @Tags API keys
+// @Security ApiKeyAuth
+// @Param user_id  query string false "Owner ID"
+// @Success 200 {array} model.APIKey
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/api-keys [get]
+//
+//nolint:varnamelen
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID := uuid.Nil
+
+	if raw := c.Query("user_id"); raw != "" {
+		var err error
+
+		userID, err = uuid.FromString(raw)
+		if err != nil {
+			logger.Errorf("List.FromString", err)
+			c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+			return
+		}
+	}
+
+	keys, err := h.api.postgresStore.APIKey.List(userID)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// Revoke
+// @Summary revoke an API key
+// @Description requires api-keys:delete, the key is rejected at once
+// @Produce json
+// @Tags API keys
+// @Security ApiKeyAuth
+// @Param id  path string  true "API key ID"
+// @Success 200 {object} admin.APIKeyRevokeResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/api-keys/{id} [delete]
+//
+//nolint:varnamelen
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	keyID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Revoke.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.APIKey.Get(keyID); !exists {
+		logger.Errorf("Revoke.Get", keyID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	err = h.api.postgresStore.APIKey.Revoke(keyID)
+	if err != nil {
+		logger.Errorf("Revoke.Revoke", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.APIKeyRevokeResponse{Status: "api key revoked"})
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	serviceAccountID = uuid.NewV4()
+	apiKeyID         = uuid.NewV4()
+	testAPIKey       = model.APIKey{
+		ID:          apiKeyID,
+		UserID:      serviceAccountID,
+		Name:        "billing",
+		Prefix:      "crm_0123456789ab",
+		Permissions: []model.Permission{model.PermUsersRead},
+		CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+var testMapAPIKeyHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{model.PermUsersRead, model.PermUsersRead},
+			},
+			ExpectedData: map[string]interface{}{},
+			SkipFields:   []string{"key", "api_key"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetMock, APIKeyRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: serviceAccountID, ServiceAccount: true},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeNoPermissions",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID: serviceAccountID,
+				Name:   "billing",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeUnknownPermission",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{"users:fly"},
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeExpired",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{model.PermUsersRead},
+				ExpiresAt:   &time.Time{},
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeGrantNotHeld",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{model.PermUsersDelete},
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermAPIKeysCreate},
+		},
+		{
+			Name:   "NegativeNotServiceAccount",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{model.PermUsersRead},
+			},
+			PositiveTest: false, WhatError: model.ErrNotServiceAccount,
+			Mock: makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: serviceAccountID},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeAPIKeyRepoCreateMock",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/api-keys",
+			Data: model.APIKeyCreate{
+				UserID:      serviceAccountID,
+				Name:        "billing",
+				Permissions: []model.Permission{model.PermUsersRead},
+			},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(AuthRepoGetMock, APIKeyRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: serviceAccountID, ServiceAccount: true},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys?user_id=" + serviceAccountID.String(),
+			ExpectedData: []model.APIKey{testAPIKey},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(APIKeyRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.APIKey{testAPIKey},
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidUserID",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys?user_id=1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+	},
+	"Revoke": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys/" + apiKeyID.String(),
+			ExpectedData: admin.APIKeyRevokeResponse{Status: "api key revoked"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(APIKeyRepoGetMock, APIKeyRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testAPIKey,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeAPIKeyRepoGetMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys/" + apiKeyID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(APIKeyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAPIKeyRepoRevokeMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys/" + apiKeyID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(APIKeyRepoGetMock, APIKeyRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testAPIKey,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestAPIKeyHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	apiKeyRepo := mockpostgresstore.NewMockAPIKeyRepository(mockCtrl)
+	mockPostgresStore.APIKey = apiKeyRepo
+	repos = append(repos, apiKeyRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapAPIKeyHandler)
+}
+
+func APIKeyRepoCreateMock(repos []interface{}, data []interface{}) {
+	var apiKeyMock *mockpostgresstore.MockAPIKeyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAPIKeyRepository:
+			apiKeyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	apiKeyMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func APIKeyRepoListMock(repos []interface{}, data []interface{}) {
+	var apiKeyMock *mockpostgresstore.MockAPIKeyRepository
+	var result []model.APIKey
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAPIKeyRepository:
+			apiKeyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.APIKey:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	apiKeyMock.EXPECT().List(gomock.Any()).Return(result, err).Times(1)
+}
+
+func APIKeyRepoGetMock(repos []interface{}, data []interface{}) {
+	var apiKeyMock *mockpostgresstore.MockAPIKeyRepository
+	var result *model.APIKey
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAPIKeyRepository:
+			apiKeyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.APIKey:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	apiKeyMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func APIKeyRepoRevokeMock(repos []interface{}, data []interface{}) {
+	var apiKeyMock *mockpostgresstore.MockAPIKeyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAPIKeyRepository:
+			apiKeyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	apiKeyMock.EXPECT().Revoke(gomock.Any()).Return(err).Times(1)
+}
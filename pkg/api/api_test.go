@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runHandlerTests executes the table of handler tests grouped by handler name.
+// Mocks of every case are registered right before its request is served.
+func runHandlerTests(t *testing.T, testAPI *api, repos []interface{}, tests map[string][]model.TestStructure) {
+	t.Helper()
+
+	for apiName, testsHandlers := range tests {
+		t.Run(apiName, func(t *testing.T) {
+			for _, data := range testsHandlers {
+				t.Run(data.Name, func(t *testing.T) {
+					if data.Mock != nil {
+						for i, m := range data.Mock {
+							m(repos, data.MockData[i])
+						}
+					}
+					body, err := json.Marshal(data.Data)
+					require.NoError(t, err)
+					req, err := http.NewRequest(data.Method, data.URL, bytes.NewBuffer(body))
+					require.NoError(t, err)
+					req = req.WithContext(context.WithValue(req.Context(), testCaseKey{}, data))
+
+					rr := httptest.NewRecorder()
+					testAPI.ServeHTTP(rr, req)
+					testAPI.background.Wait()
+
+					if data.PositiveTest {
+						assert.Equal(t, http.StatusOK, rr.Code, "handler return wrong status code")
+						if reflect.TypeOf(data.ExpectedData).String() != "string" { // if func don't have answered or answer is string
+							body, err = json.Marshal(data.ExpectedData)
+							require.NoError(t, err)
+							if data.SkipFields != nil {
+								var bodyActual, bodyExpectet interface{}
+								if data.SkipRoot != "" {
+									expect := data.ExpectedData.(map[string]interface{})
+									body, err = json.Marshal(expect[data.SkipRoot])
+									require.NoError(t, err)
+									var actualMap, expectMap []map[string]interface{}
+									err = json.Unmarshal(body, &expectMap)
+									require.NoError(t, err)
+									keys := make([]string, 0, len(expect))
+									for k := range expect {
+										keys = append(keys, k)
+									}
+									sort.Strings(keys)
+									for _, key := range keys {
+										if key != data.SkipRoot {
+											expectMap = append(expectMap, map[string]interface{}{key: expect[key]})
+										}
+									}
+
+									var actual map[string]interface{}
+									err = json.Unmarshal(rr.Body.Bytes(), &actual)
+									require.NoError(t, err)
+									body, err = json.Marshal(actual[data.SkipRoot])
+									require.NoError(t, err)
+									err = json.Unmarshal(body, &actualMap)
+									require.NoError(t, err)
+									keys = make([]string, 0, len(actual))
+									for k := range actual {
+										keys = append(keys, k)
+									}
+									sort.Strings(keys)
+									for _, key := range keys {
+										if key != data.SkipRoot {
+											actualMap = append(actualMap, map[string]interface{}{key: actual[key]})
+										}
+									}
+
+									for _, skip := range data.SkipFields {
+										require.Equal(t, len(actualMap), len(expectMap))
+										for i := range actualMap {
+											delete(actualMap[i], skip)
+											delete(expectMap[i], skip)
+										}
+									}
+									bodyActual = actualMap
+									bodyExpectet = expectMap
+								} else {
+									var actual, expect map[string]interface{}
+									err = json.Unmarshal(body, &expect)
+									require.NoError(t, err)
+									err = json.Unmarshal(rr.Body.Bytes(), &actual)
+									require.NoError(t, err)
+									for _, skip := range data.SkipFields {
+										delete(actual, skip)
+										delete(expect, skip)
+									}
+									bodyActual = actual
+									bodyExpectet = expect
+								}
+								body, err = json.Marshal(bodyExpectet)
+								require.NoError(t, err)
+								resActual, err := json.Marshal(bodyActual)
+								require.NoError(t, err)
+								rr.Body = bytes.NewBuffer(resActual)
+							}
+							assert.JSONEq(t, string(body), rr.Body.String())
+						} else {
+							assert.Equal(t, data.ExpectedData, rr.Body.String())
+						}
+					} else {
+						body, err = json.Marshal(data.WhatError)
+						require.NoError(t, err)
+						assert.JSONEq(t, string(body), rr.Body.String())
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	for name, test := range map[string]struct {
+		proxies  []string
+		expected string
+	}{
+		"untrusted": {nil, "10.0.0.1"},
+		"trusted":   {[]string{"10.0.0.0/8"}, "203.0.113.7"},
+	} {
+		conf := testConfig()
+		conf.Server.TrustedProxies = test.proxies
+
+		middleware := mockauthmiddleware.NewMockAuthMiddleware(gomock.NewController(t))
+		testAPI := initTestAPIWithConfig(t, middleware, &store.Store{}, conf)
+		testAPI.router.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+		w := httptest.NewRecorder()
+		testAPI.ServeHTTP(w, req)
+
+		assert.Equal(t, test.expected, w.Body.String(), name)
+	}
+}
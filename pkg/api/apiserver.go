@@ -4,15 +4,30 @@ package api
 import (
 	_ "crm-system/docs"
 	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/appauth"
+	"crm-system/pkg/authmiddleware/bruteforce"
+	"crm-system/pkg/authmiddleware/ldapauth"
+	"crm-system/pkg/authmiddleware/oidc"
+	"crm-system/pkg/authmiddleware/passwordpolicy"
 	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
 	"crm-system/pkg/store"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+const oidcTimeout = 10 * time.Second
+
 type Server struct {
 	*http.Server
 }
@@ -20,24 +35,52 @@ type Server struct {
 type api struct {
 	postgresStore *store.Store
 	router        *gin.Engine
-	config        *config.ServerConfig
+	config        *config.Configs
 	auth          authmiddleware.AuthMiddleware
+	mailer        mailer.Mailer
+	// background tracks the work responses don't wait for
+	background sync.WaitGroup
+
+	authHandler          *AuthHandler
+	userHandler          *UserHandler
+	mfaHandler           *MFAHandler
+	adminHandler         *AdminHandler
+	passwordHandler      *PasswordHandler
+	roleHandler          *RoleHandler
+	apiKeyHandler        *APIKeyHandler
+	sessionHandler       *SessionHandler
+	oidcHandler          *OIDCHandler
+	invitationHandler    *InvitationHandler
+	impersonationHandler *ImpersonationHandler
+	auditHandler         *AuditHandler
+	contactHandler       *ContactHandler
+	companyHandler       *CompanyHandler
+	pipelineHandler      *PipelineHandler
+	dealHandler          *DealHandler
+	taskHandler          *TaskHandler
+	activityHandler      *ActivityHandler
+	customFieldHandler   *CustomFieldHandler
+	tagHandler           *TagHandler
+	importHandler        *ImportHandler
 
-	authHandler *AuthHandler
-	userHandler *UserHandler
+	guard          *bruteforce.Guard
+	oidcProvider   *oidc.Provider
+	authenticator  authmiddleware.Authenticator
+	passwordPolicy *passwordpolicy.Policy
 }
 
 func NewServer(
-	config *config.ServerConfig,
+	config *config.Configs,
 	postgresStore *store.Store,
 	auth authmiddleware.AuthMiddleware,
+	mailer mailer.Mailer,
 ) *Server {
-	handler := newAPI(config, postgresStore, auth)
+	handler := newAPI(config, postgresStore, auth, mailer)
 
 	srv := &http.Server{
-		Addr:              config.ServerPort,
+		Addr:              config.Server.ServerPort,
 		Handler:           handler,
-		ReadHeaderTimeout: config.ReadTimeout.Duration,
+		ReadHeaderTimeout: config.Server.ReadTimeout.Duration,
 	}
 
 	return &Server{
@@ -46,14 +89,20 @@ func NewServer(
 }
 
 func newAPI(
-	config *config.ServerConfig,
+	config *config.Configs,
 	postgresStore *store.Store,
 	auth authmiddleware.AuthMiddleware,
+	mailer mailer.Mailer,
 ) *api {
 	api := &api{
-		config:        config,
-		postgresStore: postgresStore,
-		auth:          auth,
+		config:         config,
+		postgresStore:  postgresStore,
+		auth:           auth,
+		mailer:         mailer,
+		guard:          bruteforce.NewGuard(postgresStore.LoginAttempt, config.BruteForce),
+		oidcProvider:   oidc.NewProvider(config.OIDC, &http.Client{Timeout: oidcTimeout}),
+		authenticator:  newAuthenticator(config, postgresStore),
+		passwordPolicy: passwordpolicy.NewPolicy(postgresStore.Auth, config.PasswordPolicy),
 	}
 
 	api.router = configureRouter(api)
@@ -63,13 +112,23 @@ func newAPI(
 	return api
 }
 
+// newAuthenticator checks the local password first and then the directory, when LDAP_URL is set.
+func newAuthenticator(config *config.Configs, postgresStore *store.Store) authmiddleware.Authenticator {
+	authenticators := authmiddleware.Authenticators{appauth.NewLocalAuthenticator(postgresStore)}
+	if config.LDAP.URL != "" {
+		authenticators = append(authenticators, ldapauth.NewAuthenticator(config.LDAP, postgresStore))
+	}
+
+	return authenticators
+}
+
 //nolint:varnamelen
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding,"+
-			"X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			"X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == http.MethodOptions {
@@ -101,3 +160,346 @@ func (a *api) User() *UserHandler {
 
 	return a.userHandler
 }
+
+func (a *api) MFA() *MFAHandler {
+	if a.mfaHandler == nil {
+		a.mfaHandler = NewMFAHandler(a)
+	}
+
+	return a.mfaHandler
+}
+
+func (a *api) Admin() *AdminHandler {
+	if a.adminHandler == nil {
+		a.adminHandler = NewAdminHandler(a)
+	}
+
+	return a.adminHandler
+}
+
+func (a *api) Password() *PasswordHandler {
+	if a.passwordHandler == nil {
+		a.passwordHandler = NewPasswordHandler(a)
+	}
+
+	return a.passwordHandler
+}
+
+func (a *api) Role() *RoleHandler {
+	if a.roleHandler == nil {
+		a.roleHandler = NewRoleHandler(a)
+	}
+
+	return a.roleHandler
+}
+
+func (a *api) APIKey() *APIKeyHandler {
+	if a.apiKeyHandler == nil {
+		a.apiKeyHandler = NewAPIKeyHandler(a)
+	}
+
+	return a.apiKeyHandler
+}
+
+func (a *api) Session() *SessionHandler {
+	if a.sessionHandler == nil {
+		a.sessionHandler = NewSessionHandler(a)
+	}
+
+	return a.sessionHandler
+}
+
+func (a *api) OIDC() *OIDCHandler {
+	if a.oidcHandler == nil {
+		a.oidcHandler = NewOIDCHandler(a)
+	}
+
+	return a.oidcHandler
+}
+
+func (a *api) Invitation() *InvitationHandler {
+	if a.invitationHandler == nil {
+		a.invitationHandler = NewInvitationHandler(a)
+	}
+
+	return a.invitationHandler
+}
+
+func (a *api) Impersonation() *ImpersonationHandler {
+	if a.impersonationHandler == nil {
+		a.impersonationHandler = NewImpersonationHandler(a)
+	}
+
+	return a.impersonationHandler
+}
+
+func (a *api) Audit() *AuditHandler {
+	if a.auditHandler == nil {
+		a.auditHandler = NewAuditHandler(a)
+	}
+
+	return a.auditHandler
+}
+
+func (a *api) Contact() *ContactHandler {
+	if a.contactHandler == nil {
+		a.contactHandler = NewContactHandler(a)
+	}
+
+	return a.contactHandler
+}
+
+func (a *api) Company() *CompanyHandler {
+	if a.companyHandler == nil {
+		a.companyHandler = NewCompanyHandler(a)
+	}
+
+	return a.companyHandler
+}
+
+func (a *api) Pipeline() *PipelineHandler {
+	if a.pipelineHandler == nil {
+		a.pipelineHandler = NewPipelineHandler(a)
+	}
+
+	return a.pipelineHandler
+}
+
+func (a *api) Deal() *DealHandler {
+	if a.dealHandler == nil {
+		a.dealHandler = NewDealHandler(a)
+	}
+
+	return a.dealHandler
+}
+
+func (a *api) Task() *TaskHandler {
+	if a.taskHandler == nil {
+		a.taskHandler = NewTaskHandler(a)
+	}
+
+	return a.taskHandler
+}
+
+func (a *api) Activity() *ActivityHandler {
+	if a.activityHandler == nil {
+		a.activityHandler = NewActivityHandler(a)
+	}
+
+	return a.activityHandler
+}
+
+func (a *api) CustomField() *CustomFieldHandler {
+	if a.customFieldHandler == nil {
+		a.customFieldHandler = NewCustomFieldHandler(a)
+	}
+
+	return a.customFieldHandler
+}
+
+func (a *api) Tag() *TagHandler {
+	if a.tagHandler == nil {
+		a.tagHandler = NewTagHandler(a)
+	}
+
+	return a.tagHandler
+}
+
+func (a *api) Import() *ImportHandler {
+	if a.importHandler == nil {
+		a.importHandler = NewImportHandler(a)
+	}
+
+	return a.importHandler
+}
+
+func (a *api) Guard() *bruteforce.Guard {
+	return a.guard
+}
+
+func (a *api) OIDCProvider() *oidc.Provider {
+	return a.oidcProvider
+}
+
+func (a *api) Authenticator() authmiddleware.Authenticator {
+	return a.authenticator
+}
+
+func (a *api) PasswordPolicy() *passwordpolicy.Policy {
+	return a.passwordPolicy
+}
+
+// checkPassword responds with the broken rules of the password policy, field is
+// the request field of the password. History is checked when the user exists.
+//
+//nolint:varnamelen
+func (a *api) checkPassword(c *gin.Context, field, password string, user *model.AuthUser) bool {
+	violations, err := a.PasswordPolicy().Check(field, password, user)
+	if err != nil {
+		logger.Errorf("checkPassword.Check", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if len(violations) > 0 {
+		logger.Errorf("checkPassword.Check", violations)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(violations))
+
+		return false
+	}
+
+	return true
+}
+
+// checkAttempts responds with 429 and Retry-After when any of the keys is locked.
+//
+//nolint:varnamelen
+func (a *api) checkAttempts(c *gin.Context, keys ...string) bool {
+	wait, err := a.Guard().Check(keys...)
+	if err != nil {
+		logger.Errorf("checkAttempts.Check", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, model.ErrTooManyAttempts)
+
+		return false
+	}
+
+	return true
+}
+
+// failAttempt counts a failed attempt and records the lockouts it causes, the
+// request has failed anyway so errors are only logged.
+//
+//nolint:varnamelen
+func (a *api) failAttempt(c *gin.Context, keys ...string) {
+	lockedOut, err := a.Guard().Fail(keys...)
+	if err != nil {
+		logger.Errorf("failAttempt.Fail", err)
+	}
+
+	for _, key := range lockedOut {
+		event := model.NewAuthEvent(model.AuthEventLockout, nil).Failed(key)
+		if username, ok := bruteforce.Username(key); ok {
+			event.Username = username
+		}
+
+		a.authEvent(c, event)
+	}
+}
+
+func (a *api) resetAttempts(username string) {
+	err := a.Guard().Succeed(bruteforce.UsernameKey(username))
+	if err != nil {
+		logger.Errorf("resetAttempts.Succeed", err)
+	}
+}
+
+// inBackground runs the work after the response, so its time isn't part of it.
+func (a *api) inBackground(work func()) {
+	a.background.Add(1)
+
+	go func() {
+		defer a.background.Done()
+
+		work()
+	}()
+}
+
+func attemptKeys(c *gin.Context, username string) []string {
+	return []string{bruteforce.UsernameKey(username), bruteforce.IPKey(c.ClientIP())}
+}
+
+// authEvent writes the event to the audit log with the client of the request.
+// The actor is the principal, the impersonating admin when there is one, or
+// the target on success; the request has been handled so errors are only logged.
+//
+//nolint:varnamelen
+func (a *api) authEvent(c *gin.Context, event *model.AuthEvent) {
+	client := authmiddleware.ClientFromContext(c)
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+	event.CreatedAt = time.Now()
+
+	if principal, err := authmiddleware.GetPrincipal(c); err == nil {
+		actorID := principal.UserID
+		if principal.IsImpersonated() {
+			actorID = principal.ImpersonatorID
+		}
+
+		event.ActorID = &actorID
+	} else if event.Success {
+		event.ActorID = event.TargetID
+	}
+
+	err := a.postgresStore.AuthEvent.Create(event)
+	if err != nil {
+		logger.Errorf("authEvent.Create", err)
+	}
+}
+
+// checkRecordAccess responds with an error unless the principal may act on a
+// record it doesn't own with permission; records it can't read respond as missing.
+//
+//nolint:varnamelen
+func checkRecordAccess(c *gin.Context, principal *authmiddleware.Principal, owned bool, read, permission model.Permission) bool {
+	if owned {
+		return true
+	}
+
+	if !principal.Can(read) {
+		logger.Errorf("checkRecordAccess.Can", read)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return false
+	}
+
+	if !principal.Can(permission) {
+		logger.Errorf("checkRecordAccess.Can", permission)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return false
+	}
+
+	return true
+}
+
+// canSeeRecord reports whether the record exists and the principal may see it,
+// the rules are the ones of the record's own handler.
+func (a *api) canSeeRecord(principal *authmiddleware.Principal, recordType model.RecordType, recordID uuid.UUID) bool {
+	switch recordType {
+	case model.RecordUser:
+		return a.User().canSee(principal, recordID)
+	case model.RecordContact:
+		return a.Contact().canSee(principal, recordID)
+	case model.RecordCompany:
+		return a.Company().canSee(principal, recordID)
+	case model.RecordDeal:
+		return a.Deal().canSee(principal, recordID)
+	default:
+		return false
+	}
+}
+
+// canChangeRecord reports whether the principal may change the record, the
+// rules are the ones of the record's own handler.
+func (a *api) canChangeRecord(principal *authmiddleware.Principal, recordType model.RecordType, recordID uuid.UUID) bool {
+	switch recordType {
+	case model.RecordUser:
+		return a.User().canChange(principal, recordID)
+	case model.RecordContact:
+		return a.Contact().canChange(principal, recordID)
+	case model.RecordCompany:
+		return a.Company().canChange(principal, recordID)
+	case model.RecordDeal:
+		return a.Deal().canChange(principal, recordID)
+	default:
+		return false
+	}
+}
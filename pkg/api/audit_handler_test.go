@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	auditUserID = uuid.NewV4()
+	auditTime   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	auditEvents = []model.AuthEvent{
+		{
+			ID:        uuid.NewV4(),
+			Type:      model.AuthEventLogin,
+			Success:   false,
+			Detail:    model.ReasonInvalidCredentials,
+			Username:  "=cmd",
+			IP:        "10.0.0.1",
+			UserAgent: "curl/8.0",
+			CreatedAt: auditTime,
+		},
+		{
+			ID:        uuid.NewV4(),
+			Type:      model.AuthEventLogin,
+			Success:   true,
+			Detail:    "password",
+			ActorID:   &auditUserID,
+			TargetID:  &auditUserID,
+			Username:  "jane",
+			IP:        "10.0.0.1",
+			UserAgent: "curl/8.0",
+			CreatedAt: auditTime.Add(time.Minute),
+		},
+		{
+			ID:        uuid.NewV4(),
+			Type:      model.AuthEventRoleChange,
+			Success:   true,
+			Detail:    "BASE -> ADMIN",
+			ActorID:   &sessionUserID,
+			TargetID:  &auditUserID,
+			Username:  "jane",
+			IP:        "10.0.0.2",
+			CreatedAt: auditTime.Add(2 * time.Minute),
+		},
+	}
+)
+
+var testMapAuditHandler = map[string][]model.TestStructure{
+	"ListAuthEvents": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/audit/auth?per_page=2",
+			ExpectedData: admin.AuthEventListResponse{
+				Events:  []model.AuthEvent{auditEvents[2], auditEvents[1]},
+				Total:   3,
+				Page:    1,
+				PerPage: 2,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+		},
+		{
+			Name:   "PositiveFilter",
+			Method: http.MethodGet,
+			URL: "https://localhost:8000/api/v1/admin/audit/auth?type=login&success=true&user_id=" + auditUserID.String() +
+				"&from=2024-01-01T00:00:30Z",
+			ExpectedData: admin.AuthEventListResponse{
+				Events:  []model.AuthEvent{auditEvents[1]},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+		},
+		{
+			Name:         "NegativeInvalidUserID",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/audit/auth?user_id=1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidFrom",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/audit/auth?from=yesterday",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidFormat",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/audit/auth?format=xml",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/audit/auth",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+	},
+}
+
+func initAuditTestAPI(t *testing.T) (*api, *memorystore.AuthEventRepository) {
+	t.Helper()
+
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	for i := range auditEvents {
+		require.NoError(t, authEventRepo.Create(&auditEvents[i]))
+	}
+
+	return initTestAPI(t, mockAuthMiddleware, &store.Store{AuthEvent: authEventRepo}), authEventRepo
+}
+
+func TestAuditHandlers(t *testing.T) {
+	testAPI, authEventRepo := initAuditTestAPI(t)
+
+	runHandlerTests(t, testAPI, []interface{}{authEventRepo}, testMapAuditHandler)
+}
+
+func TestAuditExportCSV(t *testing.T) {
+	testAPI, _ := initAuditTestAPI(t)
+
+	rr := httptest.NewRecorder()
+	testAPI.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/auth?format=csv&type=login", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id,created_at,type,success,detail,actor_id,target_id,username,ip,user_agent", lines[0])
+	assert.Equal(t, auditEvents[1].ID.String()+",2024-01-01T00:01:00Z,login,true,password,"+
+		auditUserID.String()+","+auditUserID.String()+",jane,10.0.0.1,curl/8.0", lines[1])
+	assert.Equal(t, auditEvents[0].ID.String()+",2024-01-01T00:00:00Z,login,false,invalid_credentials,,,'=cmd,10.0.0.1,curl/8.0", lines[2])
+
+	rr = httptest.NewRecorder()
+	testAPI.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/auth?format=csv&type=lockout", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "id,created_at,type,success,detail,actor_id,target_id,username,ip,user_agent\n", rr.Body.String())
+}
+
+func TestLoginRecordsAuthEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mfaPolicyRepo := mockpostgresstore.NewMockMFAPolicyRepository(mockCtrl)
+	authEventRepo := memorystore.NewAuthEventRepository()
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, &store.Store{
+		Auth:         authRepo,
+		MFAPolicy:    mfaPolicyRepo,
+		LoginAttempt: memorystore.NewLoginAttemptRepository(),
+		AuthEvent:    authEventRepo,
+	})
+
+	userDB := &model.AuthUser{
+		ID:       uuid.NewV4(),
+		Username: "jane",
+		Password: hashPassword("secret"),
+		Role:     model.BaseUserRole,
+		Active:   true,
+	}
+
+	authRepo.EXPECT().GetByUsername("jane").Return(userDB, nil).Times(2)
+	mfaPolicyRepo.EXPECT().IsRequired(model.BaseUserRole).Return(false, nil)
+	mockAuthMiddleware.EXPECT().CreateTokens(userDB.ID, model.BaseUserRole, gomock.Any()).
+		Return(&authmiddleware.Tokens{Access: "access", Refresh: "refresh"}, nil)
+
+	for _, password := range []string{"wrong", "secret"} {
+		body, err := json.Marshal(model.AuthUser{Username: "jane", Password: password})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewReader(body))
+		req.Header.Set("User-Agent", "curl/8.0")
+
+		rr := httptest.NewRecorder()
+		testAPI.ServeHTTP(rr, req)
+	}
+
+	events := authEventRepo.Events()
+	require.Len(t, events, 2)
+
+	assert.Equal(t, model.AuthEventLogin, events[0].Type)
+	assert.False(t, events[0].Success)
+	assert.Equal(t, model.ReasonInvalidCredentials, events[0].Detail)
+	assert.Equal(t, "jane", events[0].Username)
+	assert.Nil(t, events[0].ActorID)
+	assert.Equal(t, "curl/8.0", events[0].UserAgent)
+
+	assert.True(t, events[1].Success)
+	assert.Equal(t, "password", events[1].Detail)
+	require.NotNil(t, events[1].TargetID)
+	assert.Equal(t, userDB.ID, *events[1].TargetID)
+	assert.Equal(t, events[1].TargetID, events[1].ActorID)
+}
@@ -5,11 +5,11 @@ import (
 	"crm-system/pkg/logger"
 	"crm-system/pkg/model"
 	"crm-system/pkg/model/ui/auth"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 
 	"net/http"
-	"strings"
 
 	uuid "github.com/satori/go.uuid"
 )
@@ -26,11 +26,16 @@ func NewAuthHandler(a *api) *AuthHandler {
 
 // Login
 // @Summary user login
+// @Description the password is checked against auth_users and then against the LDAP directory when it is configured,
+// @Description when two-factor authentication is enabled or required for the role,
+// @Description auth.MFAChallengeResponse is returned instead of tokens, see /api/v1/login/2fa
 // @Produce json
 // @Tags Auth
 // @Param userInfo  body model.AuthUser  true "User Info"
 // @Success 200 {object} authmiddleware.Tokens
 // @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 403 {object} errors.UIResponseErrorBadRequest "account deactivated or password expired, see /api/v1/password/expired"
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many failed attempts, see Retry-After"
 // @Router /api/v1/login [post]
 //
 //nolint:varnamelen
@@ -51,26 +56,65 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	userDB, err := h.api.postgresStore.Auth.GetByUsername(user.Username)
+	keys := attemptKeys(c, user.Username)
+	if !h.api.checkAttempts(c, keys...) {
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, user).Failed(model.ReasonTooManyAttempts))
+
+		return
+	}
+
+	userDB, err := h.api.Authenticator().Authenticate(user.Username, user.Password)
 	if err != nil {
-		logger.Errorf("Login.Get", err)
-		if err.Error() == model.NotFound {
+		logger.Errorf("Login.Authenticate", err)
+		switch {
+		case errors.Is(err, authmiddleware.ErrUnknownUser):
+			h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, user).Failed(model.ReasonUnknownUser))
+			h.api.failAttempt(c, keys...)
+			c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+		case errors.Is(err, authmiddleware.ErrInvalidCredentials):
+			h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, user).Failed(model.ReasonInvalidCredentials))
+			h.api.failAttempt(c, keys...)
 			c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
-		} else {
+		default:
 			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
 		}
 
 		return
 	}
 
-	if !authmiddleware.IsPasswordMatch(user.Password, userDB.Password) {
-		logger.Errorf("Login.IsPasswordMatch", err)
-		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+	h.api.resetAttempts(userDB.Username)
+
+	if !userDB.Active {
+		logger.Errorf("Login.Active", userDB.ID)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Failed(model.ReasonAccountDisabled))
+		c.JSON(http.StatusForbidden, model.ErrAccountDisabled)
+
+		return
+	}
+
+	if h.api.PasswordPolicy().Expired(userDB) {
+		logger.Errorf("Login.Expired", userDB.ID)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Failed(model.ReasonPasswordExpired))
+		c.JSON(http.StatusForbidden, model.ErrPasswordExpired)
+
+		return
+	}
+
+	challenge, err := h.api.MFA().challenge(userDB)
+	if err != nil {
+		logger.Errorf("Login.challenge", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
 
 		return
 	}
 
-	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role)
+	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role, authmiddleware.ClientFromContext(c))
 	if err != nil {
 		logger.Errorf("Login.CreateTokens", err)
 		c.JSON(http.StatusBadRequest, model.ErrUnhealthy)
@@ -78,18 +122,21 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Succeeded("password"))
+
 	c.JSON(http.StatusOK, tokens)
 }
 
 // Register
 // @Summary user registration
-// @Description available user roles: ADMIN/BASE
+// @Description requires users:create, role is any role from /api/v1/admin/roles, the optional email is used for password reset.
+// @Description the caller must hold every permission of the role
 // @Produce json
 // @Tags Auth
 // @Security ApiKeyAuth
 // @Param userInfo  body model.AuthUser  true "User Info"
 // @Success 200 {object} auth.RegistrationResponse
-// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 400 {object} errors.UIResponseErrorValidation "invalid body or the password breaks the policy"
 // @Router /api/v1/registration [post]
 //
 //nolint:varnamelen
@@ -103,30 +150,29 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	userRole, err := h.getUserRoleFromHeader(c)
-	if err != nil {
-		logger.Errorf("Register.getUserRoleFromHeader", err)
-		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+	if !user.IsValid(true) {
+		logger.Errorf("Register.Empty username or pass", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	if userRole != model.AdminUserRole {
-		logger.Errorf("Register.GetUserRole", err)
-		c.JSON(http.StatusBadRequest, model.ErrInvalidRole)
+	if !h.api.Role().checkGrantable(c, user.Role) {
+		return
+	}
 
+	if !h.api.checkPassword(c, "password", user.Password, user) {
 		return
 	}
 
-	if !user.IsValid(true) {
-		logger.Errorf("Register.Empty username or pass", err)
-		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+	user.Password, err = authmiddleware.CreateHashPassword(user.Password)
+	if err != nil {
+		logger.Errorf("Register.CreateHashPassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
 
 		return
 	}
 
-	user.Password = authmiddleware.CreateHashPassword(user.Password)
-
 	userDB, err := h.api.postgresStore.Auth.GetByUsername(user.Username)
 	if err != nil {
 		logger.Errorf("Register.GetByUsername", err)
@@ -142,6 +188,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if user.Email != nil {
+		if _, exists := h.api.postgresStore.Auth.GetByEmail(*user.Email); exists {
+			logger.Errorf("Register.Email exist", *user.Email)
+			c.JSON(http.StatusBadRequest, model.ErrEmailExist)
+
+			return
+		}
+	}
+
 	err = h.api.postgresStore.Auth.Create(user)
 	if err != nil {
 		logger.Errorf("Register.Create", err)
@@ -150,6 +205,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventRegistration, user).Succeeded(string(user.Role)))
+
 	c.JSON(http.StatusOK, auth.RegistrationResponse{Status: "user created"})
 }
 
@@ -176,21 +233,93 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	newTokens, err := h.api.auth.Refresh(oldTokens)
 	if err != nil {
 		logger.Errorf("Refresh.Refresh", err)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventRefresh, nil).Failed(model.ReasonInvalidToken))
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
+	event := model.NewAuthEvent(model.AuthEventRefresh, nil).Succeeded("")
+	if claims, err := h.api.auth.Validate(newTokens.Access); err == nil {
+		event.TargetID = &claims.BaseClaims.ID
+	}
+
+	h.api.authEvent(c, event)
+
 	c.JSON(http.StatusOK, newTokens)
 }
 
+// Logout
+// @Summary revoke the session of the refresh token
+// @Produce json
+// @Tags Auth
+// @Param token  body authmiddleware.Tokens  true "Tokens"
+// @Success 200 {object} auth.LogoutResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/logout [post]
+//
+//nolint:varnamelen
+func (h *AuthHandler) Logout(c *gin.Context) {
+	tokens := authmiddleware.Tokens{}
+	err := c.ShouldBindJSON(&tokens)
+	if err != nil {
+		logger.Errorf("Logout.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	err = h.api.auth.Logout(tokens.Refresh)
+	if err != nil {
+		logger.Errorf("Logout.Logout", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.LogoutResponse{Status: "logged out"})
+}
+
+// LogoutAll
+// @Summary revoke all sessions of the current user
+// @Produce json
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Success 200 {object} auth.LogoutResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/logout-all [post]
+//
+//nolint:varnamelen
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, err := authmiddleware.CurrentUserID(c)
+	if err != nil {
+		logger.Errorf("LogoutAll.CurrentUserID", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	err = h.api.auth.LogoutAll(userID)
+	if err != nil {
+		logger.Errorf("LogoutAll.LogoutAll", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.LogoutResponse{Status: "logged out"})
+}
+
 // ChangePassword
 // @Summary user change password
 // @Produce json
 // @Tags Auth
+// @Security ApiKeyAuth
 // @Param ChangePassword  body model.ChangePassword  true "Change Password"
 // @Success 200 {object} authmiddleware.Tokens
-// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 400 {object} errors.UIResponseErrorValidation "invalid body or the password breaks the policy"
+// @Failure 403 {object} errors.UIResponseErrorBadRequest "the password is managed by the directory or identity provider"
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many failed attempts, see Retry-After"
 // @Router /api/v1/change-password [patch]
 //
 //nolint:varnamelen
@@ -211,9 +340,9 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.getUserIDFromHeader(c)
+	userID, err := authmiddleware.CurrentUserID(c)
 	if err != nil {
-		logger.Errorf("Register.getUserRoleFromHeader", err)
+		logger.Errorf("ChangePassword.CurrentUserID", err)
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
@@ -227,17 +356,40 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if !h.api.Password().checkLocalPassword(c, userDB) {
+		return
+	}
+
+	keys := attemptKeys(c, userDB.Username)
+	if !h.api.checkAttempts(c, keys...) {
+		return
+	}
+
 	if !authmiddleware.IsPasswordMatch(changePass.OldPassword, userDB.Password) {
 		logger.Errorf("ChangePassword.IsPasswordMatch", nil)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, userDB).Failed(model.ReasonInvalidCredentials))
+		h.api.failAttempt(c, keys...)
 
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
-	changePass.NewPassword = authmiddleware.CreateHashPassword(changePass.NewPassword)
+	h.api.resetAttempts(userDB.Username)
 
-	err = h.api.postgresStore.Auth.ChangePassword(userID, changePass.NewPassword)
+	if !h.api.checkPassword(c, "new_password", changePass.NewPassword, userDB) {
+		return
+	}
+
+	changePass.NewPassword, err = authmiddleware.CreateHashPassword(changePass.NewPassword)
+	if err != nil {
+		logger.Errorf("ChangePassword.CreateHashPassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.postgresStore.Auth.ChangePassword(userID, changePass.NewPassword, h.api.PasswordPolicy().History())
 	if err != nil {
 		logger.Errorf("ChangePassword.ChangePassword", err)
 		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
@@ -245,7 +397,9 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role)
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, userDB).Succeeded(""))
+
+	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role, authmiddleware.ClientFromContext(c))
 	if err != nil {
 		logger.Errorf("ChangePassword.CreateTokens", err)
 		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
@@ -256,18 +410,15 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, tokens)
 }
 
-//nolint:gocritic
-func (h *AuthHandler) getUserIDFromHeader(c *gin.Context) (uuid.UUID, error) {
-	token := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
-	userID, err := h.api.auth.GetUserID(token)
-
-	return userID, err
-}
-
-//nolint:gocritic
-func (h *AuthHandler) getUserRoleFromHeader(c *gin.Context) (model.UserRole, error) {
-	token := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
-	userRole, err := h.api.auth.GetUserRole(token)
-
-	return userRole, err
+// JWKS
+// @Summary public keys to verify access tokens
+// @Produce json
+// @Tags Auth
+// @Success 200 {object} authmiddleware.JWKSet
+// @Router /.well-known/jwks.json [get]
+//
+//nolint:varnamelen
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.api.auth.JWKS())
 }
@@ -1,25 +1,27 @@
 package api
 
 import (
-	"bytes"
 	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/bruteforce"
 	"crm-system/pkg/authmiddleware/mockauthmiddleware"
 	"crm-system/pkg/model"
 	"crm-system/pkg/model/ui/auth"
 	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
 	"crm-system/pkg/store/mockpostgresstore"
-	"encoding/json"
 	"errors"
 	"net/http"
-	"net/http/httptest"
-	"reflect"
-	"sort"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	uuid "github.com/satori/go.uuid"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
+)
+
+var (
+	testEmail    = "user@example.com"
+	invalidEmail = "user"
+	loginUserID  = uuid.NewV4()
 )
 
 var testMapAuthHandler = map[string][]model.TestStructure{
@@ -38,14 +40,56 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(AuthRepoGetByUsernameMock, MiddlewareCreateTokensMock),
+			Mock:         makeList(AuthRepoGetByUsernameMock, MFAPolicyRepoIsRequiredMock, MiddlewareCreateTokensMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
+						Username: "user",
+						Password: hashPassword("password"),
+					},
+				},
+				{
+					false,
+				},
+				{
+					&authmiddleware.Tokens{
+						Access:  "access_token",
+						Refresh: "refresh_token",
+					},
+				},
+			},
+		},
+		{
+			Name:   "PositiveLegacyPasswordRehash",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+			},
+			ExpectedData: &authmiddleware.Tokens{
+				Access:  "access_token",
+				Refresh: "refresh_token",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(AuthRepoGetByUsernameMock, AuthRepoRehashPasswordMock, MFAPolicyRepoIsRequiredMock,
+				MiddlewareCreateTokensMock),
 			MockData: [][]interface{}{
 				{
 					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
 						Username: "user",
-						Password: authmiddleware.CreateHashPassword("password"),
+						Password: authmiddleware.H3hash("password" + authmiddleware.AuthSalt),
 					},
 				},
+				{},
+				{
+					false,
+				},
 				{
 					&authmiddleware.Tokens{
 						Access:  "access_token",
@@ -54,6 +98,94 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				},
 			},
 		},
+		{
+			Name:   "PositiveMFAChallenge",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+			},
+			ExpectedData: auth.MFAChallengeResponse{
+				MFARequired: true,
+				MFAToken:    "mfa-token",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByUsernameMock, MiddlewareCreateMFATokenMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:          loginUserID,
+						Active:      true,
+						Username:    "user",
+						Password:    hashPassword("password"),
+						TOTPEnabled: true,
+					},
+				},
+				{
+					"mfa-token",
+				},
+			},
+		},
+		{
+			Name:   "PositiveMFAEnrollmentRequired",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+			},
+			ExpectedData: auth.MFAChallengeResponse{
+				MFARequired:        true,
+				EnrollmentRequired: true,
+				MFAToken:           "mfa-token",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByUsernameMock, MFAPolicyRepoIsRequiredMock, MiddlewareCreateMFATokenMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
+						Username: "user",
+						Password: hashPassword("password"),
+						Role:     model.AdminUserRole,
+					},
+				},
+				{
+					true,
+				},
+				{
+					"mfa-token",
+				},
+			},
+		},
+		{
+			Name:   "NegativeMFAPolicyRepoIsRequiredMock",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+			},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(AuthRepoGetByUsernameMock, MFAPolicyRepoIsRequiredMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
+						Username: "user",
+						Password: hashPassword("password"),
+					},
+				},
+				{
+					model.ErrUnhealthy,
+				},
+			},
+		},
 		{
 			Name:         "NegativeJsonData",
 			Method:       http.MethodPost,
@@ -116,12 +248,72 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			MockData: [][]interface{}{
 				{
 					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
+						Username: "user",
+						Password: hashPassword("incorrect-password"),
+					},
+				},
+			},
+		},
+		{
+			Name:   "NegativeServiceAccount",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "billing",
+				Password: "password",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:             loginUserID,
+						Username:       "billing",
+						Password:       hashPassword("password"),
+						Active:         true,
+						ServiceAccount: true,
+					},
+				},
+			},
+		},
+		{
+			Name:   "NegativeDeactivated",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+			},
+			PositiveTest: false, WhatError: model.ErrAccountDisabled,
+			Mock: makeList(AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						ID:       loginUserID,
 						Username: "user",
-						Password: authmiddleware.CreateHashPassword("incorrect-password"),
+						Password: hashPassword("password"),
 					},
 				},
 			},
 		},
+		{
+			Name:   "NegativeTooManyAttempts",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "locked",
+				Password: "password",
+			},
+			PositiveTest: false, WhatError: model.ErrTooManyAttempts,
+			Mock: makeList(LoginAttemptLockMock),
+			MockData: [][]interface{}{
+				{
+					bruteforce.UsernameKey("locked"),
+				},
+			},
+		},
 		{
 			Name:   "NegativeMiddlewareCreateTokensMock",
 			Method: http.MethodPost,
@@ -131,14 +323,19 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				Password: "password",
 			},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(AuthRepoGetByUsernameMock, MiddlewareCreateTokensMock),
+			Mock: makeList(AuthRepoGetByUsernameMock, MFAPolicyRepoIsRequiredMock, MiddlewareCreateTokensMock),
 			MockData: [][]interface{}{
 				{
 					&model.AuthUser{
+						ID:       loginUserID,
+						Active:   true,
 						Username: "user",
-						Password: authmiddleware.CreateHashPassword("password"),
+						Password: hashPassword("password"),
 					},
 				},
+				{
+					false,
+				},
 				{
 					model.ErrUnhealthy,
 				},
@@ -160,10 +357,11 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(MiddlewareGetUserRoleMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock),
+			Mock:         makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock),
 			MockData: [][]interface{}{
 				{
-					model.AdminUserRole,
+					&model.Role{Name: model.AdminUserRole},
+					true,
 				},
 				{
 					&model.AuthUser{
@@ -173,6 +371,25 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				{},
 			},
 		},
+		{
+			Name:   "NegativeRoleBeyondOwnPermissions",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/registration",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "password",
+				Role:     model.AdminUserRole,
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersCreate},
+			Mock:        makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+			},
+		},
 		{
 			Name:         "NegativeJsonData",
 			Method:       http.MethodPost,
@@ -181,34 +398,31 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			PositiveTest: false, WhatError: model.ErrInvalidBody,
 		},
 		{
-			Name:   "NegativeGetUserRole",
+			Name:   "NegativeForbidden",
 			Method: http.MethodPost,
 			URL:    "https://localhost:8000/api/v1/registration",
 			Data: model.AuthUser{
-				Username: "",
-				Password: "",
-			},
-			PositiveTest: false, WhatError: model.ErrUnauthorized,
-			Mock: makeList(MiddlewareGetUserRoleMock),
-			MockData: [][]interface{}{
-				{
-					model.ErrUnhealthy,
-				},
+				Username: "user",
+				Password: "password",
+				Role:     model.AdminUserRole,
 			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
 		},
 		{
-			Name:   "NegativeUserRole",
+			Name:   "NegativeRoleRepoGetMock",
 			Method: http.MethodPost,
 			URL:    "https://localhost:8000/api/v1/registration",
 			Data: model.AuthUser{
-				Username: "",
-				Password: "",
+				Username: "user",
+				Password: "password",
+				Role:     "UNKNOWN",
 			},
 			PositiveTest: false, WhatError: model.ErrInvalidRole,
-			Mock: makeList(MiddlewareGetUserRoleMock),
+			Mock: makeList(RoleRepoGetMock),
 			MockData: [][]interface{}{
 				{
-					model.BaseUserRole,
+					false,
 				},
 			},
 		},
@@ -222,12 +436,6 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				Role:     "",
 			},
 			PositiveTest: false, WhatError: model.ErrInvalidBody,
-			Mock: makeList(MiddlewareGetUserRoleMock),
-			MockData: [][]interface{}{
-				{
-					model.AdminUserRole,
-				},
-			},
 		},
 		{
 			Name:   "NegativeAuthRepoGetByUsernameMock",
@@ -239,10 +447,11 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				Role:     model.AdminUserRole,
 			},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserRoleMock, AuthRepoGetByUsernameMock),
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock),
 			MockData: [][]interface{}{
 				{
-					model.AdminUserRole,
+					&model.Role{Name: model.AdminUserRole},
+					true,
 				},
 				{
 					model.ErrUnhealthy,
@@ -259,16 +468,55 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				Role:     model.AdminUserRole,
 			},
 			PositiveTest: false, WhatError: model.ErrUsenameExist,
-			Mock: makeList(MiddlewareGetUserRoleMock, AuthRepoGetByUsernameMock),
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{ID: uuid.NewV4()},
+				},
+			},
+		},
+		{
+			Name:   "NegativeAuthRepoGetByEmailMockEmailExists",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/registration",
+			Data: model.AuthUser{
+				Username: "username",
+				Password: "password",
+				Email:    &testEmail,
+				Role:     model.AdminUserRole,
+			},
+			PositiveTest: false, WhatError: model.ErrEmailExist,
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoGetByEmailMock),
 			MockData: [][]interface{}{
 				{
-					model.AdminUserRole,
+					&model.Role{Name: model.AdminUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
 				},
 				{
 					&model.AuthUser{ID: uuid.NewV4()},
+					true,
 				},
 			},
 		},
+		{
+			Name:   "NegativeInvalidEmail",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/registration",
+			Data: model.AuthUser{
+				Username: "username",
+				Password: "password",
+				Email:    &invalidEmail,
+				Role:     model.AdminUserRole,
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
 		{
 			Name:   "NegativeAuthRepoCreateMock",
 			Method: http.MethodPost,
@@ -279,10 +527,11 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				Role:     model.AdminUserRole,
 			},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserRoleMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock),
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoCreateMock),
 			MockData: [][]interface{}{
 				{
-					model.AdminUserRole,
+					&model.Role{Name: model.AdminUserRole},
+					true,
 				},
 				{
 					&model.AuthUser{
@@ -310,7 +559,7 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(MiddlewareRefreshTokensMock),
+			Mock:         makeList(MiddlewareRefreshTokensMock, MiddlewareValidateMock),
 			MockData: [][]interface{}{
 				{
 					&authmiddleware.Tokens{
@@ -318,6 +567,9 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 						Refresh: "new-token",
 					},
 				},
+				{
+					&authmiddleware.AccessClaims{BaseClaims: authmiddleware.BaseClaims{ID: uuid.NewV4()}},
+				},
 			},
 		},
 		{
@@ -344,6 +596,101 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			},
 		},
 	},
+	"Logout": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/logout",
+			Data: authmiddleware.Tokens{
+				Refresh: "refresh-token",
+			},
+			ExpectedData: auth.LogoutResponse{
+				Status: "logged out",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(MiddlewareLogoutMock),
+			MockData: [][]interface{}{
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/logout",
+			Data:         "{",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeMiddlewareLogoutMock",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/logout",
+			Data: authmiddleware.Tokens{
+				Refresh: "refresh-token",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareLogoutMock),
+			MockData: [][]interface{}{
+				{
+					model.ErrUnauthorized,
+				},
+			},
+		},
+	},
+	"LogoutAll": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/logout-all",
+			ExpectedData: auth.LogoutResponse{
+				Status: "logged out",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(MiddlewareLogoutAllMock),
+			MockData: [][]interface{}{
+				{},
+			},
+		},
+		{
+			Name:         "NegativeMiddlewareLogoutAllMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/logout-all",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(MiddlewareLogoutAllMock),
+			MockData: [][]interface{}{
+				{
+					model.ErrUnhealthy,
+				},
+			},
+		},
+	},
+	"JWKS": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/.well-known/jwks.json",
+			ExpectedData: authmiddleware.JWKSet{
+				Keys: []authmiddleware.JWK{
+					{Kty: "EC", Crv: "P-256", X: "x", Y: "y", Kid: "2023-10-01", Use: "sig", Alg: "ES256"},
+				},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(MiddlewareJWKSMock),
+			MockData: [][]interface{}{
+				{
+					authmiddleware.JWKSet{
+						Keys: []authmiddleware.JWK{
+							{Kty: "EC", Crv: "P-256", X: "x", Y: "y", Kid: "2023-10-01", Use: "sig", Alg: "ES256"},
+						},
+					},
+				},
+			},
+		},
+	},
 	"ChangePassword": {
 		{
 			Name:   "Positive",
@@ -359,17 +706,18 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(MiddlewareGetUserIDMock, AuthRepoGetMock, AuthRepoChangePasswordMock, MiddlewareCreateTokensMock),
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(AuthRepoGetMock, IdentityRepoIsLinkedMock, AuthRepoChangePasswordMock, MiddlewareCreateTokensMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					&model.AuthUser{
-						Password: authmiddleware.CreateHashPassword("old-pass"),
+						Password: hashPassword("old-pass"),
 					},
 					true,
 				},
+				{
+					false,
+				},
 				{},
 				{
 					&authmiddleware.Tokens{
@@ -397,37 +745,42 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 			PositiveTest: false, WhatError: model.ErrInvalidBody,
 		},
 		{
-			Name:   "NegativeMiddlewareGetUserIDMock",
+			Name:   "NegativeUserNotExist",
 			Method: http.MethodPatch,
 			URL:    "https://localhost:8000/api/v1/change-password",
 			Data: model.ChangePassword{
 				OldPassword: "old-pass",
 				NewPassword: "new-pass",
 			},
-			PositiveTest: false, WhatError: model.ErrUnauthorized,
-			Mock: makeList(MiddlewareGetUserIDMock),
+			PositiveTest: false, WhatError: model.ErrRefreshExpired,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock),
 			MockData: [][]interface{}{
 				{
-					model.ErrUnhealthy,
+					false,
 				},
 			},
 		},
 		{
-			Name:   "NegativeUserNotExist",
+			Name:   "NegativeLinkedUser",
 			Method: http.MethodPatch,
 			URL:    "https://localhost:8000/api/v1/change-password",
 			Data: model.ChangePassword{
 				OldPassword: "old-pass",
 				NewPassword: "new-pass",
 			},
-			PositiveTest: false, WhatError: model.ErrRefreshExpired,
-			Mock: makeList(MiddlewareGetUserIDMock, AuthRepoGetMock),
+			PositiveTest: false, WhatError: model.ErrExternalPassword,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, IdentityRepoIsLinkedMock),
 			MockData: [][]interface{}{
 				{
-					uuid.NewV4(),
+					&model.AuthUser{
+						Password: hashPassword("old-pass"),
+					},
+					true,
 				},
 				{
-					false,
+					true,
 				},
 			},
 		},
@@ -440,17 +793,18 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				NewPassword: "new-pass",
 			},
 			PositiveTest: false, WhatError: model.ErrUnauthorized,
-			Mock: makeList(MiddlewareGetUserIDMock, AuthRepoGetMock),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, IdentityRepoIsLinkedMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					&model.AuthUser{
-						Password: authmiddleware.CreateHashPassword("incorrect-pass"),
+						Password: hashPassword("incorrect-pass"),
 					},
 					true,
 				},
+				{
+					false,
+				},
 			},
 		},
 		{
@@ -462,17 +816,18 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				NewPassword: "new-pass",
 			},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserIDMock, AuthRepoGetMock, AuthRepoChangePasswordMock),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, IdentityRepoIsLinkedMock, AuthRepoChangePasswordMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					&model.AuthUser{
-						Password: authmiddleware.CreateHashPassword("old-pass"),
+						Password: hashPassword("old-pass"),
 					},
 					true,
 				},
+				{
+					false,
+				},
 				{model.ErrUnhealthy},
 			},
 		},
@@ -485,17 +840,18 @@ var testMapAuthHandler = map[string][]model.TestStructure{
 				NewPassword: "new-pass",
 			},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserIDMock, AuthRepoGetMock, AuthRepoChangePasswordMock, MiddlewareCreateTokensMock),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, IdentityRepoIsLinkedMock, AuthRepoChangePasswordMock, MiddlewareCreateTokensMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					&model.AuthUser{
-						Password: authmiddleware.CreateHashPassword("old-pass"),
+						Password: hashPassword("old-pass"),
 					},
 					true,
 				},
+				{
+					false,
+				},
 				{},
 				{
 					model.ErrUnhealthy,
@@ -515,116 +871,35 @@ func TestAuthHandlers(t *testing.T) {
 
 	mockPostgresStore := &store.Store{}
 
-	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
-	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Return().AnyTimes()
-
 	//all repos mock what need for tests
 	userAuthRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
 	mockPostgresStore.Auth = userAuthRepo
 	repos = append(repos, userAuthRepo)
 
-	//execute tests
-	for apiName, testsAuthHandlers := range testMapAuthHandler {
-		t.Run(apiName, func(t *testing.T) {
-			for _, data := range testsAuthHandlers {
-				t.Run(data.Name, func(t *testing.T) {
-					if data.Mock != nil {
-						for i, m := range data.Mock {
-							m(repos, data.MockData[i])
-						}
-					}
-					body, err := json.Marshal(data.Data)
-					require.NoError(t, err)
-					req, err := http.NewRequest(data.Method, data.URL, bytes.NewBuffer(body))
-					require.NoError(t, err)
-
-					rr := httptest.NewRecorder()
-					testAPI.ServeHTTP(rr, req)
-
-					if data.PositiveTest {
-						assert.Equal(t, http.StatusOK, rr.Code, "handler return wrong status code")
-						if reflect.TypeOf(data.ExpectedData).String() != "string" { // if func don't have answered or answer is string
-							body, err = json.Marshal(data.ExpectedData)
-							require.NoError(t, err)
-							if data.SkipFields != nil {
-								var bodyActual, bodyExpectet interface{}
-								if data.SkipRoot != "" {
-									expect := data.ExpectedData.(map[string]interface{})
-									body, err = json.Marshal(expect[data.SkipRoot])
-									require.NoError(t, err)
-									var actualMap, expectMap []map[string]interface{}
-									err = json.Unmarshal(body, &expectMap)
-									require.NoError(t, err)
-									keys := make([]string, 0, len(expect))
-									for k := range expect {
-										keys = append(keys, k)
-									}
-									sort.Strings(keys)
-									for _, key := range keys {
-										if key != data.SkipRoot {
-											expectMap = append(expectMap, map[string]interface{}{key: expect[key]})
-										}
-									}
-
-									var actual map[string]interface{}
-									err = json.Unmarshal(rr.Body.Bytes(), &actual)
-									require.NoError(t, err)
-									body, err = json.Marshal(actual[data.SkipRoot])
-									require.NoError(t, err)
-									err = json.Unmarshal(body, &actualMap)
-									require.NoError(t, err)
-									keys = make([]string, 0, len(actual))
-									for k := range actual {
-										keys = append(keys, k)
-									}
-									sort.Strings(keys)
-									for _, key := range keys {
-										if key != data.SkipRoot {
-											actualMap = append(actualMap, map[string]interface{}{key: actual[key]})
-										}
-									}
-
-									for _, skip := range data.SkipFields {
-										require.Equal(t, len(actualMap), len(expectMap))
-										for i := range actualMap {
-											delete(actualMap[i], skip)
-											delete(expectMap[i], skip)
-										}
-									}
-									bodyActual = actualMap
-									bodyExpectet = expectMap
-								} else {
-									var actual, expect map[string]interface{}
-									err = json.Unmarshal(body, &expect)
-									require.NoError(t, err)
-									err = json.Unmarshal(rr.Body.Bytes(), &actual)
-									require.NoError(t, err)
-									for _, skip := range data.SkipFields {
-										delete(actual, skip)
-										delete(expect, skip)
-									}
-									bodyActual = actual
-									bodyExpectet = expect
-								}
-								body, err = json.Marshal(bodyExpectet)
-								require.NoError(t, err)
-								resActual, err := json.Marshal(bodyActual)
-								require.NoError(t, err)
-								rr.Body = bytes.NewBuffer(resActual)
-							}
-							assert.JSONEq(t, string(body), rr.Body.String())
-						} else {
-							assert.Equal(t, data.ExpectedData, rr.Body.String())
-						}
-					} else {
-						body, err = json.Marshal(data.WhatError)
-						require.NoError(t, err)
-						assert.JSONEq(t, string(body), rr.Body.String())
-					}
-				})
-			}
-		})
-	}
+	mfaPolicyRepo := mockpostgresstore.NewMockMFAPolicyRepository(mockCtrl)
+	mockPostgresStore.MFAPolicy = mfaPolicyRepo
+	repos = append(repos, mfaPolicyRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	identityRepo := mockpostgresstore.NewMockIdentityRepository(mockCtrl)
+	mockPostgresStore.Identity = identityRepo
+	repos = append(repos, identityRepo)
+
+	loginAttemptRepo := memorystore.NewLoginAttemptRepository()
+	mockPostgresStore.LoginAttempt = loginAttemptRepo
+	repos = append(repos, loginAttemptRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	runHandlerTests(t, testAPI, repos, testMapAuthHandler)
 }
 
 func MiddlewareCreateTokensMock(repos []interface{}, data []interface{}) {
@@ -650,7 +925,7 @@ func MiddlewareCreateTokensMock(repos []interface{}, data []interface{}) {
 		}
 	}
 
-	middlewareMock.EXPECT().CreateTokens(gomock.Any(), gomock.Any()).Return(result, err).Times(1)
+	middlewareMock.EXPECT().CreateTokens(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, err).Times(1)
 }
 
 func MiddlewareRefreshTokensMock(repos []interface{}, data []interface{}) {
@@ -679,9 +954,9 @@ func MiddlewareRefreshTokensMock(repos []interface{}, data []interface{}) {
 	middlewareMock.EXPECT().Refresh(gomock.Any()).Return(result, err).Times(1)
 }
 
-func MiddlewareGetUserRoleMock(repos []interface{}, data []interface{}) {
+func MiddlewareValidateMock(repos []interface{}, data []interface{}) {
 	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
-	var result model.UserRole
+	var result *authmiddleware.AccessClaims
 	var err error
 
 	for _, r := range repos {
@@ -695,19 +970,41 @@ func MiddlewareGetUserRoleMock(repos []interface{}, data []interface{}) {
 		switch t := i.(type) {
 		case error:
 			err = t
-		case model.UserRole:
+		case *authmiddleware.AccessClaims:
 			result = t
 		default:
 			continue
 		}
 	}
 
-	middlewareMock.EXPECT().GetUserRole(gomock.Any()).Return(result, err).Times(1)
+	middlewareMock.EXPECT().Validate(gomock.Any()).Return(result, err).Times(1)
+}
+
+func MiddlewareLogoutMock(repos []interface{}, data []interface{}) {
+	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockauthmiddleware.MockAuthMiddleware:
+			middlewareMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	middlewareMock.EXPECT().Logout(gomock.Any()).Return(err).Times(1)
 }
 
-func MiddlewareGetUserIDMock(repos []interface{}, data []interface{}) {
+func MiddlewareLogoutAllMock(repos []interface{}, data []interface{}) {
 	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
-	var result uuid.UUID
 	var err error
 
 	for _, r := range repos {
@@ -721,14 +1018,35 @@ func MiddlewareGetUserIDMock(repos []interface{}, data []interface{}) {
 		switch t := i.(type) {
 		case error:
 			err = t
-		case uuid.UUID:
+		default:
+			continue
+		}
+	}
+
+	middlewareMock.EXPECT().LogoutAll(gomock.Any()).Return(err).Times(1)
+}
+
+func MiddlewareJWKSMock(repos []interface{}, data []interface{}) {
+	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
+	var result authmiddleware.JWKSet
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockauthmiddleware.MockAuthMiddleware:
+			middlewareMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case authmiddleware.JWKSet:
 			result = t
 		default:
 			continue
 		}
 	}
 
-	middlewareMock.EXPECT().GetUserID(gomock.Any()).Return(result, err).Times(1)
+	middlewareMock.EXPECT().JWKS().Return(result).Times(1)
 }
 
 func AuthRepoGetByUsernameMock(repos []interface{}, data []interface{}) {
@@ -783,6 +1101,32 @@ func AuthRepoGetMock(repos []interface{}, data []interface{}) {
 	authMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
 }
 
+func AuthRepoGetByEmailMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var result *model.AuthUser
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.AuthUser:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().GetByEmail(gomock.Any()).Return(result, exist).Times(1)
+}
+
 func AuthRepoChangePasswordMock(repos []interface{}, data []interface{}) {
 	var authMock *mockpostgresstore.MockAuthRepository
 	var err error
@@ -803,7 +1147,30 @@ func AuthRepoChangePasswordMock(repos []interface{}, data []interface{}) {
 		}
 	}
 
-	authMock.EXPECT().ChangePassword(gomock.Any(), gomock.Any()).Return(err).Times(1)
+	authMock.EXPECT().ChangePassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
+
+func AuthRepoRehashPasswordMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().RehashPassword(gomock.Any(), gomock.Any()).Return(err).Times(1)
 }
 
 func AuthRepoCreateMock(repos []interface{}, data []interface{}) {
@@ -828,3 +1195,24 @@ func AuthRepoCreateMock(repos []interface{}, data []interface{}) {
 
 	authMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
 }
+
+// LoginAttemptLockMock locks the given keys in the in-memory attempts repository.
+func LoginAttemptLockMock(repos []interface{}, data []interface{}) {
+	var attemptsRepo *memorystore.LoginAttemptRepository
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *memorystore.LoginAttemptRepository:
+			attemptsRepo = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case string:
+			_ = attemptsRepo.Lock(t, time.Now().Add(time.Minute))
+		default:
+			continue
+		}
+	}
+}
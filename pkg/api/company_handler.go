@@ -0,0 +1,411 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type CompanyHandler struct {
+	api *api
+}
+
+func NewCompanyHandler(a *api) *CompanyHandler {
+	return &CompanyHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create a company
+// @Description the company is owned by the caller unless owner_id is set, giving it to another user requires companies:update
+// @Produce json
+// @Tags Companies
+// @Security ApiKeyAuth
+// @Param company  body model.Company  true "Company"
+// @Success 200 {object} model.Company
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/companies [post]
+//
+//nolint:varnamelen
+func (h *CompanyHandler) Create(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	company := &model.Company{}
+	err = c.ShouldBindJSON(&company)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := company.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	company.ID = uuid.Nil
+
+	if company.OwnerID == nil {
+		company.OwnerID = &principal.UserID
+	}
+
+	if !h.api.User().checkOwner(c, principal, company.OwnerID, model.PermCompaniesUpdate) || !h.checkParent(c, company) {
+		return
+	}
+
+	err = h.api.postgresStore.Company.Create(company)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, company)
+}
+
+// List
+// @Summary list companies
+// @Description only the caller's companies without companies:read, search matches the name and the domain
+// @Produce json
+// @Tags Companies
+// @Security ApiKeyAuth
+// @Param search     query string false "Search"
+// @Param owner_id   query string false "Owner ID"
+// @Param parent_id  query string false "Parent company ID"
+// @Param industry   query string false "Industry"
+// @Param size       query string false "Size"
+// @Param tags       query string false "Tag names, comma separated, e.g. vip,partner"
+// @Param tags_match query string false "all tags (default) or any of them"
+// @Param sort       query string false "name, created_at or updated_at, prefixed with - for descending order, -created_at by default"
+// @Param page       query int    false "Page, starts at 1"
+// @Param per_page   query int    false "Companies per page, 20 by default, at most 100"
+// @Success 200 {object} crm.CompanyListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/companies [get]
+//
+//nolint:varnamelen
+func (h *CompanyHandler) List(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("List.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	query := model.CompanyQuery{}
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("List.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !query.IsValid() {
+		logger.Errorf("List.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !principal.Can(model.PermCompaniesRead) {
+		query.OwnerID = principal.UserID.String()
+	}
+
+	companies, total, err := h.api.postgresStore.Company.List(query)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.CompanyListResponse{
+		Companies: companies,
+		Total:     total,
+		Page:      query.Page,
+		PerPage:   query.PerPage,
+	})
+}
+
+// Get
+// @Summary get a company with its contacts and subsidiaries
+// @Description companies of other users require companies:read, contacts and subsidiaries are limited like their lists and to the first 100 by name
+// @Produce json
+// @Tags Companies
+// @Security ApiKeyAuth
+// @Param id  path string  true "Company ID"
+// @Success 200 {object} crm.CompanyDetailResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/companies/{id} [get]
+//
+//nolint:varnamelen
+func (h *CompanyHandler) Get(c *gin.Context) {
+	company, principal, ok := h.companyParam(c, model.PermCompaniesRead)
+	if !ok {
+		return
+	}
+
+	contactQuery := model.ContactQuery{
+		Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+		CompanyID:  company.ID.String(),
+		Sorting:    model.Sorting{Sort: "name"},
+	}
+	if !principal.Can(model.PermContactsRead) {
+		contactQuery.OwnerID = principal.UserID.String()
+	}
+
+	contacts, contactsTotal, err := h.api.postgresStore.Contact.List(contactQuery)
+	if err != nil {
+		logger.Errorf("Get.Contact.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	childQuery := model.CompanyQuery{
+		Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+		ParentID:   company.ID.String(),
+		Sorting:    model.Sorting{Sort: "name"},
+	}
+	if !principal.Can(model.PermCompaniesRead) {
+		childQuery.OwnerID = principal.UserID.String()
+	}
+
+	children, childrenTotal, err := h.api.postgresStore.Company.List(childQuery)
+	if err != nil {
+		logger.Errorf("Get.Company.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.CompanyDetailResponse{
+		Company:       *company,
+		Contacts:      contacts,
+		ContactsTotal: contactsTotal,
+		Children:      children,
+		ChildrenTotal: childrenTotal,
+	})
+}
+
+// Update
+// @Summary replace a company
+// @Description companies of other users and giving a company to another user require companies:update, the owner is kept without owner_id
+// @Produce json
+// @Tags Companies
+// @Security ApiKeyAuth
+// @Param id       path string  true "Company ID"
+// @Param company  body model.Company  true "Company"
+// @Success 200 {object} model.Company
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/companies/{id} [put]
+//
+//nolint:varnamelen
+func (h *CompanyHandler) Update(c *gin.Context) {
+	companyDB, principal, ok := h.companyParam(c, model.PermCompaniesUpdate)
+	if !ok {
+		return
+	}
+
+	company := &model.Company{}
+	err := c.ShouldBindJSON(&company)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := company.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	company.ID = companyDB.ID
+	company.CreatedAt = companyDB.CreatedAt
+
+	if company.OwnerID == nil {
+		company.OwnerID = companyDB.OwnerID
+	}
+
+	ownerChanged := company.OwnerID != nil && (companyDB.OwnerID == nil || *company.OwnerID != *companyDB.OwnerID)
+	if ownerChanged && !h.api.User().checkOwner(c, principal, company.OwnerID, model.PermCompaniesUpdate) {
+		return
+	}
+
+	parentChanged := company.ParentID != nil && (companyDB.ParentID == nil || *company.ParentID != *companyDB.ParentID)
+	if parentChanged && !h.checkParent(c, company) {
+		return
+	}
+
+	err = h.api.postgresStore.Company.Update(company)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, company)
+}
+
+// Delete
+// @Summary delete a company
+// @Description companies of other users require companies:delete, its contacts and subsidiaries are kept without the link
+// @Produce json
+// @Tags Companies
+// @Security ApiKeyAuth
+// @Param id  path string  true "Company ID"
+// @Success 200 {object} crm.CompanyDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/companies/{id} [delete]
+//
+//nolint:varnamelen
+func (h *CompanyHandler) Delete(c *gin.Context) {
+	company, _, ok := h.companyParam(c, model.PermCompaniesDelete)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Company.Delete(company.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.CompanyDeleteResponse{Status: "company deleted"})
+}
+
+// companyParam loads the company of the id path parameter. Companies of other
+// users need the permission, they respond as missing without companies:read.
+//
+//nolint:varnamelen
+func (h *CompanyHandler) companyParam(c *gin.Context, permission model.Permission) (*model.Company, *authmiddleware.Principal, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("companyParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	companyID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("companyParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, nil, false
+	}
+
+	company, exists := h.api.postgresStore.Company.Get(companyID)
+	if !exists {
+		logger.Errorf("companyParam.Get", companyID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, nil, false
+	}
+
+	if !checkRecordAccess(c, principal, company.IsOwnedBy(principal.UserID), model.PermCompaniesRead, permission) {
+		return nil, nil, false
+	}
+
+	return company, principal, true
+}
+
+// checkParent responds with an error unless the parent company exists and
+// isn't the company or one of its subsidiaries, which would make a cycle.
+//
+//nolint:varnamelen
+func (h *CompanyHandler) checkParent(c *gin.Context, company *model.Company) bool {
+	if company.ParentID == nil {
+		return true
+	}
+
+	if _, exists := h.api.postgresStore.Company.Get(*company.ParentID); !exists {
+		logger.Errorf("checkParent.Get", *company.ParentID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidCompany)
+
+		return false
+	}
+
+	if company.ID == uuid.Nil {
+		return true
+	}
+
+	cycle, err := h.api.postgresStore.Company.InSubtree(company.ID, *company.ParentID)
+	if err != nil {
+		logger.Errorf("checkParent.InSubtree", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if cycle {
+		logger.Errorf("checkParent.InSubtree", *company.ParentID)
+		c.JSON(http.StatusBadRequest, model.ErrCompanyHierarchy)
+
+		return false
+	}
+
+	return true
+}
+
+// checkLinked responds with an error when the linked company doesn't exist.
+//
+//nolint:varnamelen
+func (h *CompanyHandler) checkLinked(c *gin.Context, companyID *uuid.UUID) bool {
+	if companyID == nil {
+		return true
+	}
+
+	if _, exists := h.api.postgresStore.Company.Get(*companyID); !exists {
+		logger.Errorf("checkLinked.Get", *companyID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidCompany)
+
+		return false
+	}
+
+	return true
+}
+
+// canSee reports whether the company exists and the principal owns it or holds
+// companies:read.
+func (h *CompanyHandler) canSee(principal *authmiddleware.Principal, companyID uuid.UUID) bool {
+	company, exists := h.api.postgresStore.Company.Get(companyID)
+
+	return exists && (company.IsOwnedBy(principal.UserID) || principal.Can(model.PermCompaniesRead))
+}
+
+// canChange reports whether the principal owns the company or holds companies:read
+// and companies:update, the company is only loaded to check the owner.
+func (h *CompanyHandler) canChange(principal *authmiddleware.Principal, companyID uuid.UUID) bool {
+	if principal.Can(model.PermCompaniesRead) && principal.Can(model.PermCompaniesUpdate) {
+		return true
+	}
+
+	company, exists := h.api.postgresStore.Company.Get(companyID)
+
+	return exists && company.IsOwnedBy(principal.UserID)
+}
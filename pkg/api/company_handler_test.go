@@ -0,0 +1,578 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	companyID       = uuid.NewV4()
+	parentCompanyID = uuid.NewV4()
+	testCompany     = model.Company{
+		ID:        companyID,
+		Name:      "Acme",
+		Domain:    "acme.example.com",
+		Industry:  "Manufacturing",
+		Size:      model.CompanySize51To200,
+		OwnerID:   &contactOwnerID,
+		Addresses: []model.CompanyAddress{{Label: "hq", City: "Kyiv", Country: "Ukraine"}},
+	}
+	otherCompany = model.Company{
+		ID:        companyID,
+		Name:      "Globex",
+		OwnerID:   &contactOtherID,
+		Addresses: []model.CompanyAddress{},
+	}
+	childCompany = model.Company{
+		ID:        uuid.NewV4(),
+		Name:      "Acme Labs",
+		ParentID:  &companyID,
+		OwnerID:   &contactOwnerID,
+		Addresses: []model.CompanyAddress{},
+	}
+)
+
+var testMapCompanyHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/companies",
+			Data: model.Company{
+				Name:      " Acme ",
+				Domain:    "https://www.Acme.example.com/about",
+				Industry:  "Manufacturing",
+				Size:      model.CompanySize51To200,
+				ParentID:  &parentCompanyID,
+				Addresses: []model.CompanyAddress{{Label: "hq", City: " Kyiv ", Country: "Ukraine"}},
+			},
+			ExpectedData: model.Company{
+				Name:      "Acme",
+				Domain:    "acme.example.com",
+				Industry:  "Manufacturing",
+				Size:      model.CompanySize51To200,
+				ParentID:  &parentCompanyID,
+				OwnerID:   &contactOwnerID,
+				Addresses: []model.CompanyAddress{{Label: "hq", City: "Kyiv", Country: "Ukraine"}},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CompanyRepoGetMock, CompanyRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Company{ID: parentCompanyID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/companies",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeValidation",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/companies",
+			Data: model.Company{
+				Domain:    "not a domain",
+				Size:      "huge",
+				Addresses: []model.CompanyAddress{{Label: "hq"}},
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "name", Rule: "required", Message: "name is required"},
+				{Field: "domain", Rule: "domain", Message: "invalid domain"},
+				{Field: "size", Rule: "oneof", Message: "unknown company size"},
+				{Field: "addresses", Rule: "required", Message: "an address needs a street, city or country"},
+			}),
+		},
+		{
+			Name:         "NegativeUnknownParent",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/companies",
+			Data:         model.Company{Name: "Acme", ParentID: &parentCompanyID},
+			PositiveTest: false, WhatError: model.ErrInvalidCompany,
+			UserID: contactOwnerID,
+			Mock:   makeList(CompanyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbiddenOwner",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/companies",
+			Data:         model.Company{Name: "Acme", OwnerID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermCompaniesRead},
+		},
+		{
+			Name:         "NegativeCompanyRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/companies",
+			Data:         model.Company{Name: "Acme"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(CompanyRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:   "PositiveOwnOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/companies?size=51-200&sort=-name",
+			ExpectedData: crm.CompanyListResponse{
+				Companies: []model.Company{testCompany},
+				Total:     1,
+				Page:      1,
+				PerPage:   model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CompanyRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.CompanyQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+						OwnerID:    contactOwnerID.String(),
+						Size:       model.CompanySize51To200,
+						Sorting:    model.Sorting{Sort: "-name"},
+						TagFilter:  model.TagFilter{Match: model.TagsMatchAll},
+					},
+					[]model.Company{testCompany},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidSize",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/companies?size=huge",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeCompanyRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/companies",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(CompanyRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Get": {
+		{
+			Name:   "PositiveOwner",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			ExpectedData: crm.CompanyDetailResponse{
+				Company:       testCompany,
+				Contacts:      []model.Contact{testContact},
+				ContactsTotal: 1,
+				Children:      []model.Company{childCompany},
+				ChildrenTotal: 1,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CompanyRepoGetMock, ContactRepoListMock, CompanyRepoListMock),
+			MockData: [][]interface{}{
+				{
+					&testCompany,
+					true,
+				},
+				{
+					model.ContactQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+						OwnerID:    contactOwnerID.String(),
+						CompanyID:  companyID.String(),
+						Sorting:    model.Sorting{Sort: "name"},
+					},
+					[]model.Contact{testContact},
+					int64(1),
+				},
+				{
+					model.CompanyQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+						OwnerID:    contactOwnerID.String(),
+						ParentID:   companyID.String(),
+						Sorting:    model.Sorting{Sort: "name"},
+					},
+					[]model.Company{childCompany},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermContactsRead},
+			Mock:        makeList(CompanyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherCompany,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeContactRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(CompanyRepoGetMock, ContactRepoListMock),
+			MockData: [][]interface{}{
+				{
+					&otherCompany,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Update": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			Data:   model.Company{Name: "Globex Corporation", ParentID: &parentCompanyID},
+			ExpectedData: model.Company{
+				ID:        companyID,
+				Name:      "Globex Corporation",
+				ParentID:  &parentCompanyID,
+				OwnerID:   &contactOtherID,
+				Addresses: []model.CompanyAddress{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermCompaniesRead, model.PermCompaniesUpdate},
+			Mock:         makeList(CompanyRepoGetMock, CompanyRepoGetMock, CompanyRepoInSubtreeMock, CompanyRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&otherCompany,
+					true,
+				},
+				{
+					&model.Company{ID: parentCompanyID},
+					true,
+				},
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeCycle",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			Data:         model.Company{Name: "Acme", ParentID: &childCompany.ID},
+			PositiveTest: false, WhatError: model.ErrCompanyHierarchy,
+			UserID: contactOwnerID,
+			Mock:   makeList(CompanyRepoGetMock, CompanyRepoGetMock, CompanyRepoInSubtreeMock),
+			MockData: [][]interface{}{
+				{
+					&testCompany,
+					true,
+				},
+				{
+					&childCompany,
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeReadOnly",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			Data:         model.Company{Name: "Globex"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermCompaniesRead},
+			Mock:        makeList(CompanyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherCompany,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeCompanyRepoUpdateMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			Data:         model.Company{Name: "Acme"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(CompanyRepoGetMock, CompanyRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testCompany,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			ExpectedData: crm.CompanyDeleteResponse{Status: "company deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CompanyRepoGetMock, CompanyRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testCompany,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/companies/" + companyID.String(),
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermCompaniesRead, model.PermCompaniesUpdate},
+			Mock:        makeList(CompanyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherCompany,
+					true,
+				},
+			},
+		},
+	},
+}
+
+func TestCompanyHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	companyRepo := mockpostgresstore.NewMockCompanyRepository(mockCtrl)
+	mockPostgresStore.Company = companyRepo
+	repos = append(repos, companyRepo)
+
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapCompanyHandler)
+}
+
+func CompanyRepoCreateMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func CompanyRepoGetMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var id interface{} = gomock.Any()
+	var result *model.Company
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Company:
+			// the handler may change the company it gets
+			company := *t
+			result = &company
+			id = t.ID
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().Get(id).Return(result, exist).Times(1)
+}
+
+func CompanyRepoListMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var query interface{} = gomock.Any()
+	var result []model.Company
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.CompanyQuery:
+			query = t
+		case []model.Company:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().List(query).Return(result, total, err).Times(1)
+}
+
+func CompanyRepoInSubtreeMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var result bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			result = t
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().InSubtree(companyID, gomock.Any()).Return(result, err).Times(1)
+}
+
+func CompanyRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func CompanyRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var companyMock *mockpostgresstore.MockCompanyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCompanyRepository:
+			companyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	companyMock.EXPECT().Delete(companyID).Return(err).Times(1)
+}
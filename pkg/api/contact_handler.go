@@ -0,0 +1,315 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type ContactHandler struct {
+	api *api
+}
+
+func NewContactHandler(a *api) *ContactHandler {
+	return &ContactHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create a contact
+// @Description the contact is owned by the caller unless owner_id is set, giving it to another user requires contacts:update
+// @Produce json
+// @Tags Contacts
+// @Security ApiKeyAuth
+// @Param contact  body model.Contact  true "Contact"
+// @Success 200 {object} model.Contact
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/contacts [post]
+//
+//nolint:varnamelen
+func (h *ContactHandler) Create(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	contact := &model.Contact{}
+	err = c.ShouldBindJSON(&contact)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := contact.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	contact.ID = uuid.Nil
+
+	if contact.OwnerID == nil {
+		contact.OwnerID = &principal.UserID
+	}
+
+	if !h.api.User().checkOwner(c, principal, contact.OwnerID, model.PermContactsUpdate) || !h.api.Company().checkLinked(c, contact.CompanyID) {
+		return
+	}
+
+	err = h.api.postgresStore.Contact.Create(contact)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// List
+// @Summary list contacts
+// @Description only the caller's contacts without contacts:read, search matches names, job title, emails and phones
+// @Produce json
+// @Tags Contacts
+// @Security ApiKeyAuth
+// @Param search           query string false "Search"
+// @Param owner_id         query string false "Owner ID"
+// @Param company_id       query string false "Company ID"
+// @Param lifecycle_stage  query string false "Lifecycle stage"
+// @Param source           query string false "Source"
+// @Param tags             query string false "Tag names, comma separated, e.g. vip,partner"
+// @Param tags_match       query string false "all tags (default) or any of them"
+// @Param sort             query string false "name, created_at or updated_at, prefixed with - for descending order, -created_at by default"
+// @Param page             query int    false "Page, starts at 1"
+// @Param per_page         query int    false "Contacts per page, 20 by default, at most 100"
+// @Success 200 {object} crm.ContactListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/contacts [get]
+//
+//nolint:varnamelen
+func (h *ContactHandler) List(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("List.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	query := model.ContactQuery{}
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("List.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !query.IsValid() {
+		logger.Errorf("List.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !principal.Can(model.PermContactsRead) {
+		query.OwnerID = principal.UserID.String()
+	}
+
+	contacts, total, err := h.api.postgresStore.Contact.List(query)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.ContactListResponse{
+		Contacts: contacts,
+		Total:    total,
+		Page:     query.Page,
+		PerPage:  query.PerPage,
+	})
+}
+
+// Get
+// @Summary get a contact
+// @Description contacts of other users require contacts:read
+// @Produce json
+// @Tags Contacts
+// @Security ApiKeyAuth
+// @Param id  path string  true "Contact ID"
+// @Success 200 {object} model.Contact
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/contacts/{id} [get]
+//
+//nolint:varnamelen
+func (h *ContactHandler) Get(c *gin.Context) {
+	contact, _, ok := h.contactParam(c, model.PermContactsRead)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// Update
+// @Summary replace a contact
+// @Description contacts of other users and giving a contact to another user require contacts:update, the owner is kept without owner_id
+// @Produce json
+// @Tags Contacts
+// @Security ApiKeyAuth
+// @Param id       path string  true "Contact ID"
+// @Param contact  body model.Contact  true "Contact"
+// @Success 200 {object} model.Contact
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/contacts/{id} [put]
+//
+//nolint:varnamelen
+func (h *ContactHandler) Update(c *gin.Context) {
+	contactDB, principal, ok := h.contactParam(c, model.PermContactsUpdate)
+	if !ok {
+		return
+	}
+
+	contact := &model.Contact{}
+	err := c.ShouldBindJSON(&contact)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := contact.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	contact.ID = contactDB.ID
+	contact.CreatedAt = contactDB.CreatedAt
+
+	if contact.OwnerID == nil {
+		contact.OwnerID = contactDB.OwnerID
+	}
+
+	ownerChanged := contact.OwnerID != nil && (contactDB.OwnerID == nil || *contact.OwnerID != *contactDB.OwnerID)
+	if ownerChanged && !h.api.User().checkOwner(c, principal, contact.OwnerID, model.PermContactsUpdate) {
+		return
+	}
+
+	companyChanged := contact.CompanyID != nil && (contactDB.CompanyID == nil || *contact.CompanyID != *contactDB.CompanyID)
+	if companyChanged && !h.api.Company().checkLinked(c, contact.CompanyID) {
+		return
+	}
+
+	err = h.api.postgresStore.Contact.Update(contact)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// Delete
+// @Summary delete a contact
+// @Description contacts of other users require contacts:delete
+// @Produce json
+// @Tags Contacts
+// @Security ApiKeyAuth
+// @Param id  path string  true "Contact ID"
+// @Success 200 {object} crm.ContactDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/contacts/{id} [delete]
+//
+//nolint:varnamelen
+func (h *ContactHandler) Delete(c *gin.Context) {
+	contact, _, ok := h.contactParam(c, model.PermContactsDelete)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Contact.Delete(contact.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.ContactDeleteResponse{Status: "contact deleted"})
+}
+
+// contactParam loads the contact of the id path parameter. Contacts of other
+// users need the permission, they respond as missing without contacts:read.
+//
+//nolint:varnamelen
+func (h *ContactHandler) contactParam(c *gin.Context, permission model.Permission) (*model.Contact, *authmiddleware.Principal, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("contactParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	contactID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("contactParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, nil, false
+	}
+
+	contact, exists := h.api.postgresStore.Contact.Get(contactID)
+	if !exists {
+		logger.Errorf("contactParam.Get", contactID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, nil, false
+	}
+
+	if !checkRecordAccess(c, principal, contact.IsOwnedBy(principal.UserID), model.PermContactsRead, permission) {
+		return nil, nil, false
+	}
+
+	return contact, principal, true
+}
+
+// canSee reports whether the contact exists and the principal owns it or holds
+// contacts:read.
+func (h *ContactHandler) canSee(principal *authmiddleware.Principal, contactID uuid.UUID) bool {
+	contact, exists := h.api.postgresStore.Contact.Get(contactID)
+
+	return exists && (contact.IsOwnedBy(principal.UserID) || principal.Can(model.PermContactsRead))
+}
+
+// canChange reports whether the principal owns the contact or holds contacts:read
+// and contacts:update, the contact is only loaded to check the owner.
+func (h *ContactHandler) canChange(principal *authmiddleware.Principal, contactID uuid.UUID) bool {
+	if principal.Can(model.PermContactsRead) && principal.Can(model.PermContactsUpdate) {
+		return true
+	}
+
+	contact, exists := h.api.postgresStore.Contact.Get(contactID)
+
+	return exists && contact.IsOwnedBy(principal.UserID)
+}
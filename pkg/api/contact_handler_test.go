@@ -0,0 +1,638 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	contactOwnerID = uuid.NewV4()
+	contactOtherID = uuid.NewV4()
+	contactID      = uuid.NewV4()
+	testContact    = model.Contact{
+		ID:             contactID,
+		FirstName:      "Jane",
+		LastName:       "Doe",
+		JobTitle:       "CTO",
+		OwnerID:        &contactOwnerID,
+		Source:         "referral",
+		LifecycleStage: model.LifecycleCustomer,
+		Emails:         []model.ContactEmail{{Email: "jane@example.com", Label: "work"}},
+		Phones:         []model.ContactPhone{{Phone: "+380 44 123 4567", Label: "office"}},
+	}
+	otherContact = model.Contact{
+		ID:             contactID,
+		FirstName:      "John",
+		OwnerID:        &contactOtherID,
+		LifecycleStage: model.LifecycleLead,
+		Emails:         []model.ContactEmail{},
+		Phones:         []model.ContactPhone{},
+	}
+)
+
+var testMapContactHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/contacts",
+			Data: model.Contact{
+				FirstName: " Jane ",
+				LastName:  "Doe",
+				JobTitle:  "CTO",
+				Source:    "referral",
+				Emails:    []model.ContactEmail{{Email: "Jane Doe <Jane@Example.com>", Label: "work"}},
+				Phones:    []model.ContactPhone{{Phone: "+380 44 123 4567", Label: "office"}},
+			},
+			ExpectedData: model.Contact{
+				FirstName:      "Jane",
+				LastName:       "Doe",
+				JobTitle:       "CTO",
+				OwnerID:        &contactOwnerID,
+				Source:         "referral",
+				LifecycleStage: model.LifecycleLead,
+				Emails:         []model.ContactEmail{{Email: "jane@example.com", Label: "work"}},
+				Phones:         []model.ContactPhone{{Phone: "+380 44 123 4567", Label: "office"}},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoCreateMock),
+			MockData: [][]interface{}{
+				{},
+			},
+		},
+		{
+			Name:   "PositiveOtherOwner",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/contacts",
+			Data:   model.Contact{LastName: "Doe", OwnerID: &contactOtherID},
+			ExpectedData: model.Contact{
+				LastName:       "Doe",
+				OwnerID:        &contactOtherID,
+				LifecycleStage: model.LifecycleLead,
+				Emails:         []model.ContactEmail{},
+				Phones:         []model.ContactPhone{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(AuthRepoGetMock, ContactRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeValidation",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/contacts",
+			Data: model.Contact{
+				LifecycleStage: "friend",
+				Emails:         []model.ContactEmail{{Email: "not an email"}},
+				Phones:         []model.ContactPhone{{Phone: "call me"}},
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first or last name is required"},
+				{Field: "lifecycle_stage", Rule: "oneof", Message: "unknown lifecycle stage"},
+				{Field: "emails", Rule: "email", Message: "invalid email address"},
+				{Field: "phones", Rule: "phone", Message: "invalid phone number"},
+			}),
+		},
+		{
+			Name:         "NegativeForbiddenOwner",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			Data:         model.Contact{LastName: "Doe", OwnerID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+		},
+		{
+			Name:         "NegativeUnknownOwner",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			Data:         model.Contact{LastName: "Doe", OwnerID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrInvalidOwner,
+			UserID: contactOwnerID,
+			Mock:   makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownCompany",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			Data:         model.Contact{LastName: "Doe", CompanyID: &companyID},
+			PositiveTest: false, WhatError: model.ErrInvalidCompany,
+			UserID: contactOwnerID,
+			Mock:   makeList(CompanyRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeContactRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			Data:         model.Contact{LastName: "Doe"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(ContactRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:   "PositiveOwnOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/contacts?owner_id=" + contactOtherID.String() + "&sort=name",
+			ExpectedData: crm.ContactListResponse{
+				Contacts: []model.Contact{testContact},
+				Total:    1,
+				Page:     1,
+				PerPage:  model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.ContactQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+						OwnerID:    contactOwnerID.String(),
+						Sorting:    model.Sorting{Sort: "name"},
+						TagFilter:  model.TagFilter{Match: model.TagsMatchAll},
+					},
+					[]model.Contact{testContact},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:   "PositiveFilter",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/contacts?search=jane&lifecycle_stage=Customer&tags=vip,partner&tags_match=ANY&page=2&per_page=10",
+			ExpectedData: crm.ContactListResponse{
+				Contacts: []model.Contact{},
+				Total:    1,
+				Page:     2,
+				PerPage:  10,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ContactRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.ContactQuery{
+						Pagination:     model.Pagination{Page: 2, PerPage: 10},
+						Search:         "jane",
+						LifecycleStage: model.LifecycleCustomer,
+						Sorting:        model.Sorting{Sort: "-created_at"},
+						TagFilter:      model.TagFilter{Tags: "vip,partner", Match: model.TagsMatchAny},
+					},
+					[]model.Contact{},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidSort",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts?sort=password",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidOwnerID",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts?owner_id=1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeContactRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(ContactRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Get": {
+		{
+			Name:         "PositiveOwner",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			ExpectedData: testContact,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "PositiveContactsRead",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			ExpectedData: otherContact,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermContactsRead},
+			Mock:         makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidID",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts/1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeContactRepoGetMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Update": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			Data: model.Contact{
+				ID:             uuid.NewV4(),
+				FirstName:      "Jane",
+				LastName:       "Smith",
+				LifecycleStage: model.LifecycleCustomer,
+			},
+			ExpectedData: model.Contact{
+				ID:             contactID,
+				FirstName:      "Jane",
+				LastName:       "Smith",
+				OwnerID:        &contactOwnerID,
+				LifecycleStage: model.LifecycleCustomer,
+				Emails:         []model.ContactEmail{},
+				Phones:         []model.ContactPhone{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoGetMock, ContactRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeGiveAway",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			Data:         model.Contact{FirstName: "Jane", OwnerID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeReadOnly",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			Data:         model.Contact{FirstName: "John"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermContactsRead},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			Data:         model.Contact{FirstName: "Jane", Emails: []model.ContactEmail{{Email: "a@b.c"}, {Email: "A@b.c"}}},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "emails", Rule: "unique", Message: "duplicate email address"},
+			}),
+			UserID: contactOwnerID,
+			Mock:   makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeContactRepoUpdateMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			Data:         model.Contact{FirstName: "Jane"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(ContactRepoGetMock, ContactRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			ExpectedData: crm.ContactDeleteResponse{Status: "contact deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermContactsRead, model.PermContactsDelete},
+			Mock:         makeList(ContactRepoGetMock, ContactRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermContactsRead},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeContactRepoDeleteMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/contacts/" + contactID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(ContactRepoGetMock, ContactRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestContactHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	companyRepo := mockpostgresstore.NewMockCompanyRepository(mockCtrl)
+	mockPostgresStore.Company = companyRepo
+	repos = append(repos, companyRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapContactHandler)
+}
+
+func ContactRepoCreateMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func ContactRepoGetMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var result *model.Contact
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Contact:
+			// the handler may change the contact it gets
+			contact := *t
+			result = &contact
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func ContactRepoListMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var query interface{} = gomock.Any()
+	var result []model.Contact
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.ContactQuery:
+			query = t
+		case []model.Contact:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().List(query).Return(result, total, err).Times(1)
+}
+
+func ContactRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func ContactRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().Delete(contactID).Return(err).Times(1)
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type CustomFieldHandler struct {
+	api *api
+}
+
+func NewCustomFieldHandler(a *api) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		api: a,
+	}
+}
+
+// List
+// @Summary list custom field definitions
+// @Description every user reads the definitions to show and fill in the custom fields of records
+// @Produce json
+// @Tags Custom fields
+// @Security ApiKeyAuth
+// @Param entity_type  query string false "Entity type, user, every type by default"
+// @Success 200 {array} model.CustomFieldDefinition
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/custom-fields [get]
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) List(c *gin.Context) {
+	entityType := model.RecordType(strings.ToLower(strings.TrimSpace(c.Query("entity_type"))))
+	if entityType != "" && !entityType.IsKnown() {
+		logger.Errorf("List.IsKnown", entityType)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	definitions, err := h.api.postgresStore.CustomField.List(entityType)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, definitions)
+}
+
+// Get
+// @Summary get a custom field definition
+// @Produce json
+// @Tags Custom fields
+// @Security ApiKeyAuth
+// @Param id  path string  true "Custom field ID"
+// @Success 200 {object} model.CustomFieldDefinition
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/custom-fields/{id} [get]
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) Get(c *gin.Context) {
+	definition, ok := h.definitionParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+// Create
+// @Summary define a custom field
+// @Description requires custom-fields:create, the key is unique per entity type, select and multi_select fields need options
+// @Produce json
+// @Tags Custom fields
+// @Security ApiKeyAuth
+// @Param definition  body model.CustomFieldDefinition  true "Custom field"
+// @Success 200 {object} model.CustomFieldDefinition
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/admin/custom-fields [post]
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) Create(c *gin.Context) {
+	definition := &model.CustomFieldDefinition{}
+	err := c.ShouldBindJSON(&definition)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := definition.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.CustomField.GetByKey(definition.EntityType, definition.Key); exists {
+		logger.Errorf("Create.GetByKey", definition.Key)
+		c.JSON(http.StatusBadRequest, model.ErrCustomFieldExist)
+
+		return
+	}
+
+	definition.ID = uuid.Nil
+
+	err = h.api.postgresStore.CustomField.Create(definition)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+// Update
+// @Summary change a custom field definition
+// @Description requires custom-fields:update, the entity type, the key and the type are kept, stored values are checked again when they are written
+// @Produce json
+// @Tags Custom fields
+// @Security ApiKeyAuth
+// @Param id          path string  true "Custom field ID"
+// @Param definition  body model.CustomFieldDefinition  true "Custom field"
+// @Success 200 {object} model.CustomFieldDefinition
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/admin/custom-fields/{id} [put]
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) Update(c *gin.Context) {
+	definitionDB, ok := h.definitionParam(c)
+	if !ok {
+		return
+	}
+
+	definition := &model.CustomFieldDefinition{}
+	err := c.ShouldBindJSON(&definition)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	definition.ID = definitionDB.ID
+	definition.EntityType = definitionDB.EntityType
+	definition.Key = definitionDB.Key
+	definition.Type = definitionDB.Type
+	definition.CreatedAt = definitionDB.CreatedAt
+
+	if fields := definition.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	err = h.api.postgresStore.CustomField.Update(definition)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+// Delete
+// @Summary delete a custom field definition
+// @Description requires custom-fields:delete, the values of the records are deleted with it
+// @Produce json
+// @Tags Custom fields
+// @Security ApiKeyAuth
+// @Param id  path string  true "Custom field ID"
+// @Success 200 {object} admin.CustomFieldDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/custom-fields/{id} [delete]
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) Delete(c *gin.Context) {
+	definition, ok := h.definitionParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.CustomField.Delete(definition.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.CustomFieldDeleteResponse{Status: "custom field deleted"})
+}
+
+// definitionParam loads the definition of the id path parameter.
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) definitionParam(c *gin.Context) (*model.CustomFieldDefinition, bool) {
+	definitionID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("definitionParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, false
+	}
+
+	definition, exists := h.api.postgresStore.CustomField.Get(definitionID)
+	if !exists {
+		logger.Errorf("definitionParam.Get", definitionID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, false
+	}
+
+	return definition, true
+}
+
+// values applies the update to the current custom field values of a
+// record and responds with an error unless the result is valid and no other
+// record has the written values of unique fields.
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) values(
+	c *gin.Context,
+	entityType model.RecordType,
+	entityID uuid.UUID,
+	current, update model.CustomFieldValues,
+) (model.CustomFieldValues, bool) {
+	definitions, err := h.api.postgresStore.CustomField.List(entityType)
+	if err != nil {
+		logger.Errorf("values.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	values, fields := model.ValidateCustomFields(definitions, current, update)
+
+	for _, definition := range definitions {
+		value, ok := values[definition.Key]
+		if _, written := update[definition.Key]; !definition.Unique || !ok || !written {
+			continue
+		}
+
+		taken, err := h.api.postgresStore.CustomField.IsTaken(entityType, definition.Key, value, entityID)
+		if err != nil {
+			logger.Errorf("values.IsTaken", err)
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+			return nil, false
+		}
+
+		if taken {
+			fields = append(fields, model.FieldError{Field: "custom_fields." + definition.Key, Rule: "unique",
+				Message: definition.Label + " is already taken"})
+		}
+	}
+
+	if len(fields) > 0 {
+		logger.Errorf("values.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return nil, false
+	}
+
+	return values, true
+}
+
+// filter parses the cf[key]=value query parameters into the JSON the
+// custom fields of the listed records contain, it is nil without parameters.
+//
+//nolint:varnamelen
+func (h *CustomFieldHandler) filter(c *gin.Context, entityType model.RecordType) (model.CustomFieldValues, bool) {
+	params := c.QueryMap("cf")
+	if len(params) == 0 {
+		return nil, true
+	}
+
+	definitions, err := h.api.postgresStore.CustomField.List(entityType)
+	if err != nil {
+		logger.Errorf("filter.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	byKey := make(map[string]*model.CustomFieldDefinition, len(definitions))
+	for i := range definitions {
+		byKey[definitions[i].Key] = &definitions[i]
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	filter := model.CustomFieldValues{}
+	fields := []model.FieldError{}
+
+	for _, key := range keys {
+		definition, ok := byKey[key]
+		if !ok {
+			fields = append(fields, model.FieldError{Field: "cf[" + key + "]", Rule: "unknown", Message: "unknown custom field"})
+
+			continue
+		}
+
+		value, ok := definition.Filter(params[key])
+		if !ok {
+			fields = append(fields, model.FieldError{Field: "cf[" + key + "]", Rule: "format",
+				Message: "invalid value for the custom field"})
+
+			continue
+		}
+
+		filter[key] = value[key]
+	}
+
+	if len(fields) > 0 {
+		logger.Errorf("filter.Filter", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return nil, false
+	}
+
+	return filter, true
+}
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	customFieldID = uuid.NewV4()
+	badgeField    = model.CustomFieldDefinition{
+		ID:         customFieldID,
+		EntityType: model.RecordUser,
+		Key:        "badge",
+		Label:      "Badge",
+		Type:       model.CustomFieldText,
+		Required:   true,
+		Unique:     true,
+		Options:    model.StringList{},
+	}
+	departmentField = model.CustomFieldDefinition{
+		ID:         uuid.NewV4(),
+		EntityType: model.RecordUser,
+		Key:        "department",
+		Label:      "Department",
+		Type:       model.CustomFieldSelect,
+		Options:    model.StringList{"Sales", "Support"},
+	}
+	languagesField = model.CustomFieldDefinition{
+		ID:         uuid.NewV4(),
+		EntityType: model.RecordUser,
+		Key:        "languages",
+		Label:      "Languages",
+		Type:       model.CustomFieldMultiSelect,
+		Options:    model.StringList{"en", "uk"},
+	}
+	remoteField = model.CustomFieldDefinition{
+		ID:         uuid.NewV4(),
+		EntityType: model.RecordUser,
+		Key:        "remote",
+		Label:      "Remote",
+		Type:       model.CustomFieldBoolean,
+		Options:    model.StringList{},
+	}
+)
+
+var testMapCustomFieldHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:         "PositiveWithoutPermissions",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/custom-fields?entity_type=USER",
+			ExpectedData: []model.CustomFieldDefinition{badgeField, departmentField},
+			PositiveTest: true,
+			WhatError:    nil,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CustomFieldRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.CustomFieldDefinition{badgeField, departmentField},
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownEntityType",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/custom-fields?entity_type=planet",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+	"Get": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/custom-fields/" + customFieldID.String(),
+			ExpectedData: badgeField,
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(CustomFieldRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotFound",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/custom-fields/" + customFieldID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(CustomFieldRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/custom-fields",
+			Data: model.CustomFieldDefinition{
+				EntityType: "User",
+				Key:        "department",
+				Label:      " Department ",
+				Type:       "SELECT",
+				Options:    model.StringList{" Sales", "Support "},
+			},
+			ExpectedData: model.CustomFieldDefinition{
+				EntityType: model.RecordUser,
+				Key:        "department",
+				Label:      "Department",
+				Type:       model.CustomFieldSelect,
+				Options:    model.StringList{"Sales", "Support"},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(CustomFieldRepoGetByKeyMock, CustomFieldRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeValidation",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/custom-fields",
+			Data: model.CustomFieldDefinition{
+				EntityType: model.RecordUser,
+				Key:        "Shoe Size",
+				Label:      "Shoe size",
+				Type:       model.CustomFieldBoolean,
+				Unique:     true,
+				Options:    model.StringList{"yes"},
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "key", Rule: "format",
+					Message: "key must start with a letter and have up to 63 lowercase letters, digits and underscores"},
+				{Field: "unique", Rule: "oneof", Message: "multi_select and boolean fields can't be unique"},
+				{Field: "options", Rule: "oneof", Message: "options are for select fields"},
+			}),
+		},
+		{
+			Name:   "NegativeKeyExist",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/custom-fields",
+			Data: model.CustomFieldDefinition{
+				EntityType: model.RecordUser,
+				Key:        "badge",
+				Label:      "Badge",
+				Type:       model.CustomFieldText,
+			},
+			PositiveTest: false, WhatError: model.ErrCustomFieldExist,
+			Mock: makeList(CustomFieldRepoGetByKeyMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeForbidden",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/custom-fields",
+			Data: model.CustomFieldDefinition{
+				EntityType: model.RecordUser,
+				Key:        "badge",
+				Label:      "Badge",
+				Type:       model.CustomFieldText,
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermCustomFieldsUpdate},
+		},
+	},
+	"Update": {
+		{
+			Name:   "PositiveKeepsKeyAndType",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/custom-fields/" + customFieldID.String(),
+			Data: model.CustomFieldDefinition{
+				Key:       "employee_badge",
+				Label:     "Employee badge",
+				Type:      model.CustomFieldNumber,
+				MaxLength: intPointer(20),
+			},
+			ExpectedData: model.CustomFieldDefinition{
+				ID:         customFieldID,
+				EntityType: model.RecordUser,
+				Key:        "badge",
+				Label:      "Employee badge",
+				Type:       model.CustomFieldText,
+				Options:    model.StringList{},
+				MaxLength:  intPointer(20),
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(CustomFieldRepoGetMock, CustomFieldRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/custom-fields/" + customFieldID.String(),
+			Data:         model.CustomFieldDefinition{Label: "Badge", Min: floatPointer(1)},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "min", Rule: "oneof", Message: "min and max are for number fields"},
+			}),
+			Mock: makeList(CustomFieldRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/custom-fields/" + customFieldID.String(),
+			ExpectedData: admin.CustomFieldDeleteResponse{Status: "custom field deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(CustomFieldRepoGetMock, CustomFieldRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeCustomFieldRepoDeleteMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/custom-fields/" + customFieldID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(CustomFieldRepoGetMock, CustomFieldRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&badgeField,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestCustomFieldHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	customFieldRepo := mockpostgresstore.NewMockCustomFieldRepository(mockCtrl)
+	mockPostgresStore.CustomField = customFieldRepo
+	repos = append(repos, customFieldRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapCustomFieldHandler)
+}
+
+func intPointer(v int) *int {
+	return &v
+}
+
+func floatPointer(v float64) *float64 {
+	return &v
+}
+
+func CustomFieldRepoListMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	result := []model.CustomFieldDefinition{}
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.CustomFieldDefinition:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().List(model.RecordUser).Return(result, err).Times(1)
+}
+
+func CustomFieldRepoGetMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var result *model.CustomFieldDefinition
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.CustomFieldDefinition:
+			// the handler may change the definition it gets
+			definition := *t
+			result = &definition
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().Get(customFieldID).Return(result, exist).Times(1)
+}
+
+func CustomFieldRepoGetByKeyMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var result *model.CustomFieldDefinition
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.CustomFieldDefinition:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().GetByKey(model.RecordUser, gomock.Any()).Return(result, exist).Times(1)
+}
+
+func CustomFieldRepoCreateMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func CustomFieldRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func CustomFieldRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().Delete(customFieldID).Return(err).Times(1)
+}
+
+func CustomFieldRepoIsTakenMock(repos []interface{}, data []interface{}) {
+	var customFieldMock *mockpostgresstore.MockCustomFieldRepository
+	var taken bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockCustomFieldRepository:
+			customFieldMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			taken = t
+		default:
+			continue
+		}
+	}
+
+	customFieldMock.EXPECT().IsTaken(model.RecordUser, "badge", "B-17", gomock.Any()).Return(taken, err).Times(1)
+}
@@ -0,0 +1,480 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type DealHandler struct {
+	api *api
+}
+
+func NewDealHandler(a *api) *DealHandler {
+	return &DealHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create a deal
+// @Description the deal is owned by the caller unless owner_id is set, giving it to another user requires deals:update, it starts in the first stage of the pipeline without stage_id
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param deal  body model.Deal  true "Deal, amount in minor units of the currency"
+// @Success 200 {object} model.Deal
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/deals [post]
+//
+//nolint:varnamelen
+func (h *DealHandler) Create(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	deal := &model.Deal{}
+	err = c.ShouldBindJSON(&deal)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := deal.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	deal.ID = uuid.Nil
+	deal.ClosedAt = nil
+
+	if deal.OwnerID == nil {
+		deal.OwnerID = &principal.UserID
+	}
+
+	if !h.api.User().checkOwner(c, principal, deal.OwnerID, model.PermDealsUpdate) ||
+		!h.api.Company().checkLinked(c, deal.CompanyID) || !h.checkContacts(c, deal.ContactIDs) {
+		return
+	}
+
+	stage, ok := h.stage(c, deal.PipelineID, deal.StageID)
+	if !ok {
+		return
+	}
+
+	deal.MoveTo(stage, time.Now())
+
+	err = h.api.postgresStore.Deal.Create(deal, principal.UserID)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deal)
+}
+
+// List
+// @Summary list deals
+// @Description only the caller's deals without deals:read, search matches the title, status is open, won or lost
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param search       query string false "Search"
+// @Param owner_id     query string false "Owner ID"
+// @Param company_id   query string false "Company ID"
+// @Param contact_id   query string false "Contact ID"
+// @Param pipeline_id  query string false "Pipeline ID"
+// @Param stage_id     query string false "Stage ID"
+// @Param status       query string false "Status"
+// @Param tags         query string false "Tag names, comma separated, e.g. vip,partner"
+// @Param tags_match   query string false "all tags (default) or any of them"
+// @Param sort         query string false "title, amount, expected_close_date, created_at or updated_at, prefixed with - for descending order, -created_at by default"
+// @Param page         query int    false "Page, starts at 1"
+// @Param per_page     query int    false "Deals per page, 20 by default, at most 100"
+// @Success 200 {object} crm.DealListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/deals [get]
+//
+//nolint:varnamelen
+func (h *DealHandler) List(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("List.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	query := model.DealQuery{}
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("List.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !query.IsValid() {
+		logger.Errorf("List.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !principal.Can(model.PermDealsRead) {
+		query.OwnerID = principal.UserID.String()
+	}
+
+	deals, total, err := h.api.postgresStore.Deal.List(query)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.DealListResponse{
+		Deals:   deals,
+		Total:   total,
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// Get
+// @Summary get a deal
+// @Description deals of other users require deals:read
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param id  path string  true "Deal ID"
+// @Success 200 {object} model.Deal
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/deals/{id} [get]
+//
+//nolint:varnamelen
+func (h *DealHandler) Get(c *gin.Context) {
+	deal, _, ok := h.dealParam(c, model.PermDealsRead)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, deal)
+}
+
+// Update
+// @Summary replace a deal
+// @Description deals of other users and giving a deal to another user require deals:update, the owner is kept without owner_id and the stage without stage_id unless the pipeline changes
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param id    path string  true "Deal ID"
+// @Param deal  body model.Deal  true "Deal, amount in minor units of the currency"
+// @Success 200 {object} model.Deal
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/deals/{id} [put]
+//
+//nolint:varnamelen
+func (h *DealHandler) Update(c *gin.Context) {
+	dealDB, principal, ok := h.dealParam(c, model.PermDealsUpdate)
+	if !ok {
+		return
+	}
+
+	deal := &model.Deal{}
+	err := c.ShouldBindJSON(&deal)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := deal.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	stageID := deal.StageID
+	if stageID == uuid.Nil && deal.PipelineID == dealDB.PipelineID {
+		stageID = dealDB.StageID
+	}
+
+	deal.ID = dealDB.ID
+	deal.StageID = dealDB.StageID
+	deal.ClosedAt = dealDB.ClosedAt
+	deal.CreatedAt = dealDB.CreatedAt
+
+	if deal.OwnerID == nil {
+		deal.OwnerID = dealDB.OwnerID
+	}
+
+	ownerChanged := deal.OwnerID != nil && (dealDB.OwnerID == nil || *deal.OwnerID != *dealDB.OwnerID)
+	if ownerChanged && !h.api.User().checkOwner(c, principal, deal.OwnerID, model.PermDealsUpdate) {
+		return
+	}
+
+	companyChanged := deal.CompanyID != nil && (dealDB.CompanyID == nil || *deal.CompanyID != *dealDB.CompanyID)
+	if companyChanged && !h.api.Company().checkLinked(c, deal.CompanyID) {
+		return
+	}
+
+	if !h.checkContacts(c, deal.ContactIDs) {
+		return
+	}
+
+	stage, ok := h.stage(c, deal.PipelineID, stageID)
+	if !ok {
+		return
+	}
+
+	deal.MoveTo(stage, time.Now())
+
+	err = h.api.postgresStore.Deal.Update(deal, principal.UserID)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deal)
+}
+
+// Move
+// @Summary move a deal to another stage of its pipeline
+// @Description deals of other users require deals:update, moving to a won or lost stage closes the deal and back to an open stage reopens it, every move is recorded
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param id    path string  true "Deal ID"
+// @Param move  body model.DealStageMove  true "Stage"
+// @Success 200 {object} model.Deal
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/deals/{id}/stage [patch]
+//
+//nolint:varnamelen
+func (h *DealHandler) Move(c *gin.Context) {
+	deal, principal, ok := h.dealParam(c, model.PermDealsUpdate)
+	if !ok {
+		return
+	}
+
+	move := &model.DealStageMove{}
+	err := c.ShouldBindJSON(&move)
+	if err != nil || move.StageID == uuid.Nil {
+		logger.Errorf("Move.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	stage, ok := h.stage(c, deal.PipelineID, move.StageID)
+	if !ok {
+		return
+	}
+
+	if stage.ID == deal.StageID {
+		c.JSON(http.StatusOK, deal)
+
+		return
+	}
+
+	deal.MoveTo(stage, time.Now())
+
+	err = h.api.postgresStore.Deal.Update(deal, principal.UserID)
+	if err != nil {
+		logger.Errorf("Move.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deal)
+}
+
+// History
+// @Summary list the stage changes of a deal
+// @Description deals of other users require deals:read, the first change is the stage the deal was created in
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param id  path string  true "Deal ID"
+// @Success 200 {object} crm.DealHistoryResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/deals/{id}/history [get]
+//
+//nolint:varnamelen
+func (h *DealHandler) History(c *gin.Context) {
+	deal, _, ok := h.dealParam(c, model.PermDealsRead)
+	if !ok {
+		return
+	}
+
+	changes, err := h.api.postgresStore.Deal.History(deal.ID)
+	if err != nil {
+		logger.Errorf("History.History", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.DealHistoryResponse{Changes: changes})
+}
+
+// Delete
+// @Summary delete a deal
+// @Description deals of other users require deals:delete
+// @Produce json
+// @Tags Deals
+// @Security ApiKeyAuth
+// @Param id  path string  true "Deal ID"
+// @Success 200 {object} crm.DealDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/deals/{id} [delete]
+//
+//nolint:varnamelen
+func (h *DealHandler) Delete(c *gin.Context) {
+	deal, _, ok := h.dealParam(c, model.PermDealsDelete)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Deal.Delete(deal.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.DealDeleteResponse{Status: "deal deleted"})
+}
+
+// dealParam loads the deal of the id path parameter. Deals of other users need
+// the permission, they respond as missing without deals:read.
+//
+//nolint:varnamelen
+func (h *DealHandler) dealParam(c *gin.Context, permission model.Permission) (*model.Deal, *authmiddleware.Principal, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("dealParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	dealID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("dealParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, nil, false
+	}
+
+	deal, exists := h.api.postgresStore.Deal.Get(dealID)
+	if !exists {
+		logger.Errorf("dealParam.Get", dealID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, nil, false
+	}
+
+	if !checkRecordAccess(c, principal, deal.IsOwnedBy(principal.UserID), model.PermDealsRead, permission) {
+		return nil, nil, false
+	}
+
+	return deal, principal, true
+}
+
+// stage returns the stage of the pipeline, its first stage without stageID, and
+// responds with an error when the pipeline or the stage doesn't exist.
+//
+//nolint:varnamelen
+func (h *DealHandler) stage(c *gin.Context, pipelineID, stageID uuid.UUID) (*model.PipelineStage, bool) {
+	pipeline, exists := h.api.postgresStore.Pipeline.Get(pipelineID)
+	if !exists {
+		logger.Errorf("stage.Pipeline.Get", pipelineID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidPipeline)
+
+		return nil, false
+	}
+
+	if stageID == uuid.Nil && len(pipeline.Stages) > 0 {
+		return &pipeline.Stages[0], true
+	}
+
+	stage, exists := pipeline.Stage(stageID)
+	if !exists {
+		logger.Errorf("stage.Stage", stageID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidStage)
+
+		return nil, false
+	}
+
+	return stage, true
+}
+
+// checkContacts responds with an error unless every linked contact exists.
+//
+//nolint:varnamelen
+func (h *DealHandler) checkContacts(c *gin.Context, contactIDs []uuid.UUID) bool {
+	if len(contactIDs) == 0 {
+		return true
+	}
+
+	count, err := h.api.postgresStore.Contact.Count(contactIDs)
+	if err != nil {
+		logger.Errorf("checkContacts.Count", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if count != int64(len(contactIDs)) {
+		logger.Errorf("checkContacts.Count", contactIDs)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidContact)
+
+		return false
+	}
+
+	return true
+}
+
+// canSee reports whether the deal exists and the principal owns it or holds
+// deals:read.
+func (h *DealHandler) canSee(principal *authmiddleware.Principal, dealID uuid.UUID) bool {
+	deal, exists := h.api.postgresStore.Deal.Get(dealID)
+
+	return exists && (deal.IsOwnedBy(principal.UserID) || principal.Can(model.PermDealsRead))
+}
+
+// canChange reports whether the principal owns the deal or holds deals:read
+// and deals:update, the deal is only loaded to check the owner.
+func (h *DealHandler) canChange(principal *authmiddleware.Principal, dealID uuid.UUID) bool {
+	if principal.Can(model.PermDealsRead) && principal.Can(model.PermDealsUpdate) {
+		return true
+	}
+
+	deal, exists := h.api.postgresStore.Deal.Get(dealID)
+
+	return exists && deal.IsOwnedBy(principal.UserID)
+}
@@ -0,0 +1,760 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	dealID          = uuid.NewV4()
+	dealCloseDate   = model.NewDate(2026, time.December, 31)
+	otherPipelineID = uuid.NewV4()
+	otherPipeline   = model.Pipeline{
+		ID:     otherPipelineID,
+		Name:   "Partners",
+		Stages: []model.PipelineStage{{ID: uuid.NewV4(), Name: "Intro", Probability: 10, Kind: model.StageOpen}},
+	}
+	testDeal = model.Deal{
+		ID:         dealID,
+		Title:      "Renewal",
+		Amount:     150000,
+		Currency:   "USD",
+		PipelineID: pipelineID,
+		StageID:    stageLeadID,
+		OwnerID:    &contactOwnerID,
+		ContactIDs: []uuid.UUID{},
+	}
+	otherDeal = model.Deal{
+		ID:         dealID,
+		Title:      "Migration",
+		Currency:   "USD",
+		PipelineID: pipelineID,
+		StageID:    stageLeadID,
+		OwnerID:    &contactOtherID,
+		ContactIDs: []uuid.UUID{},
+	}
+	dealChange = model.DealStageChange{
+		ID:        uuid.NewV4(),
+		DealID:    dealID,
+		ToStageID: &stageLeadID,
+		ChangedBy: &contactOwnerID,
+	}
+)
+
+var testMapDealHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/deals",
+			Data: model.Deal{
+				Title:             " Renewal ",
+				Amount:            150000,
+				Currency:          "eur",
+				ExpectedCloseDate: &dealCloseDate,
+				PipelineID:        pipelineID,
+				CompanyID:         &companyID,
+				ContactIDs:        []uuid.UUID{contactID, contactID},
+			},
+			ExpectedData: model.Deal{
+				Title:             "Renewal",
+				Amount:            150000,
+				Currency:          "EUR",
+				ExpectedCloseDate: &dealCloseDate,
+				PipelineID:        pipelineID,
+				StageID:           stageLeadID,
+				OwnerID:           &contactOwnerID,
+				CompanyID:         &companyID,
+				ContactIDs:        []uuid.UUID{contactID},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(CompanyRepoGetMock, ContactRepoCountMock, PipelineRepoGetMock, DealRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&testCompany,
+					true,
+				},
+				{
+					int64(1),
+				},
+				{
+					&testPipeline,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Amount: -1, Currency: "euro"},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "title", Rule: "required", Message: "title is required"},
+				{Field: "amount", Rule: "min", Message: "amount can't be negative"},
+				{Field: "currency", Rule: "iso4217", Message: "currency must be a three-letter code"},
+				{Field: "pipeline_id", Rule: "required", Message: "pipeline_id is required"},
+			}),
+		},
+		{
+			Name:         "NegativeUnknownContact",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Title: "Renewal", PipelineID: pipelineID, ContactIDs: []uuid.UUID{contactID}},
+			PositiveTest: false, WhatError: model.ErrInvalidContact,
+			UserID: contactOwnerID,
+			Mock:   makeList(ContactRepoCountMock),
+			MockData: [][]interface{}{
+				{
+					int64(0),
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownPipeline",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Title: "Renewal", PipelineID: pipelineID},
+			PositiveTest: false, WhatError: model.ErrInvalidPipeline,
+			UserID: contactOwnerID,
+			Mock:   makeList(PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeStageOfOtherPipeline",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Title: "Renewal", PipelineID: pipelineID, StageID: otherPipeline.Stages[0].ID},
+			PositiveTest: false, WhatError: model.ErrInvalidStage,
+			UserID: contactOwnerID,
+			Mock:   makeList(PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbiddenOwner",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Title: "Renewal", PipelineID: pipelineID, OwnerID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermDealsRead},
+		},
+		{
+			Name:         "NegativeDealRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/deals",
+			Data:         model.Deal{Title: "Renewal", PipelineID: pipelineID},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(PipelineRepoGetMock, DealRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:   "PositiveOwnOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/deals?status=WON&sort=-amount",
+			ExpectedData: crm.DealListResponse{
+				Deals:   []model.Deal{testDeal},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(DealRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.DealQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+						OwnerID:    contactOwnerID.String(),
+						Status:     model.StageWon,
+						Sorting:    model.Sorting{Sort: "-amount"},
+						TagFilter:  model.TagFilter{Match: model.TagsMatchAll},
+					},
+					[]model.Deal{testDeal},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidStatus",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/deals?status=pending",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+	"Get": {
+		{
+			Name:         "PositiveWithRead",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			ExpectedData: otherDeal,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermDealsRead},
+			Mock:         makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+	},
+	"Update": {
+		{
+			Name:   "PositiveKeepsStage",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			Data:   model.Deal{Title: "Renewal 2027", Amount: 200000, PipelineID: pipelineID},
+			ExpectedData: model.Deal{
+				ID:         dealID,
+				Title:      "Renewal 2027",
+				Amount:     200000,
+				Currency:   "USD",
+				PipelineID: pipelineID,
+				StageID:    stageLeadID,
+				OwnerID:    &contactOwnerID,
+				ContactIDs: []uuid.UUID{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(DealRepoGetMock, PipelineRepoGetMock, DealRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					&testPipeline,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "PositiveOtherPipeline",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			Data:   model.Deal{Title: "Renewal", PipelineID: otherPipelineID},
+			ExpectedData: model.Deal{
+				ID:         dealID,
+				Title:      "Renewal",
+				Currency:   "USD",
+				PipelineID: otherPipelineID,
+				StageID:    otherPipeline.Stages[0].ID,
+				OwnerID:    &contactOwnerID,
+				ContactIDs: []uuid.UUID{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(DealRepoGetMock, PipelineRepoGetMock, DealRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					&otherPipeline,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeReadOnly",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			Data:         model.Deal{Title: "Migration", PipelineID: pipelineID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermDealsRead},
+			Mock:        makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+	},
+	"Move": {
+		{
+			Name:   "PositiveWon",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/deals/" + dealID.String() + "/stage",
+			Data:   model.DealStageMove{StageID: stageWonID},
+			ExpectedData: model.Deal{
+				ID:         dealID,
+				Title:      "Renewal",
+				Amount:     150000,
+				Currency:   "USD",
+				PipelineID: pipelineID,
+				StageID:    stageWonID,
+				OwnerID:    &contactOwnerID,
+				ContactIDs: []uuid.UUID{},
+			},
+			SkipFields:   []string{"closed_at"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(DealRepoGetMock, PipelineRepoGetMock, DealRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					stageWonID,
+				},
+			},
+		},
+		{
+			Name:         "PositiveSameStage",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String() + "/stage",
+			Data:         model.DealStageMove{StageID: stageLeadID},
+			ExpectedData: testDeal,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(DealRepoGetMock, PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					&testPipeline,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeStageOfOtherPipeline",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String() + "/stage",
+			Data:         model.DealStageMove{StageID: otherPipeline.Stages[0].ID},
+			PositiveTest: false, WhatError: model.ErrInvalidStage,
+			UserID: contactOwnerID,
+			Mock:   makeList(DealRepoGetMock, PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					&testPipeline,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeMissingStage",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String() + "/stage",
+			Data:         model.DealStageMove{},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+			UserID: contactOwnerID,
+			Mock:   makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeReadOnly",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String() + "/stage",
+			Data:         model.DealStageMove{StageID: stageWonID},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermDealsRead},
+			Mock:        makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+	},
+	"History": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String() + "/history",
+			ExpectedData: crm.DealHistoryResponse{Changes: []model.DealStageChange{dealChange}},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(DealRepoGetMock, DealRepoHistoryMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{
+					[]model.DealStageChange{dealChange},
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			ExpectedData: crm.DealDeleteResponse{Status: "deal deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(DealRepoGetMock, DealRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testDeal,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotOwner",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/deals/" + dealID.String(),
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermDealsRead, model.PermDealsUpdate},
+			Mock:        makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+	},
+}
+
+func TestDealHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	dealRepo := mockpostgresstore.NewMockDealRepository(mockCtrl)
+	mockPostgresStore.Deal = dealRepo
+	repos = append(repos, dealRepo)
+
+	pipelineRepo := mockpostgresstore.NewMockPipelineRepository(mockCtrl)
+	mockPostgresStore.Pipeline = pipelineRepo
+	repos = append(repos, pipelineRepo)
+
+	companyRepo := mockpostgresstore.NewMockCompanyRepository(mockCtrl)
+	mockPostgresStore.Company = companyRepo
+	repos = append(repos, companyRepo)
+
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapDealHandler)
+}
+
+func DealRepoCreateMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().Create(gomock.Any(), contactOwnerID).Return(err).Times(1)
+}
+
+func DealRepoGetMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var result *model.Deal
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Deal:
+			// the handler may change the deal it gets
+			deal := *t
+			result = &deal
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().Get(dealID).Return(result, exist).Times(1)
+}
+
+func DealRepoListMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var query interface{} = gomock.Any()
+	var result []model.Deal
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.DealQuery:
+			query = t
+		case []model.Deal:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().List(query).Return(result, total, err).Times(1)
+}
+
+func DealRepoTotalsMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var result []model.DealTotal
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.DealTotal:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().Totals(gomock.Any()).Return(result, err).Times(1)
+}
+
+func DealRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var stageID *uuid.UUID
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case uuid.UUID:
+			stageID = &t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().Update(gomock.Any(), contactOwnerID).DoAndReturn(func(deal *model.Deal, _ uuid.UUID) error {
+		// a move to a won stage closes the deal
+		if stageID != nil && (deal.StageID != *stageID || deal.ClosedAt == nil) {
+			return errors.New("deal is not closed in the stage")
+		}
+
+		return err
+	}).Times(1)
+}
+
+func DealRepoHistoryMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var result []model.DealStageChange
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.DealStageChange:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().History(dealID).Return(result, err).Times(1)
+}
+
+func DealRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var dealMock *mockpostgresstore.MockDealRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockDealRepository:
+			dealMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	dealMock.EXPECT().Delete(dealID).Return(err).Times(1)
+}
+
+func ContactRepoCountMock(repos []interface{}, data []interface{}) {
+	var contactMock *mockpostgresstore.MockContactRepository
+	var count int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockContactRepository:
+			contactMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case int64:
+			count = t
+		default:
+			continue
+		}
+	}
+
+	contactMock.EXPECT().Count(gomock.Any()).Return(count, err).Times(1)
+}
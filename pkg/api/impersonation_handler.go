@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type ImpersonationHandler struct {
+	api *api
+}
+
+func NewImpersonationHandler(a *api) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		api: a,
+	}
+}
+
+// Impersonate
+// @Summary act as a user
+// @Description requires users:impersonate and every permission of the user. The access token lasts 15 minutes at most and ends with the session of the admin, it can't change passwords, second factors, sessions or API keys. Every request made with it is written to the impersonation log
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param id  path string  true "User ID"
+// @Success 200 {object} admin.ImpersonationResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+//
+//nolint:varnamelen
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Impersonate.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	// the token lives as long as the session of the admin, API keys have none
+	if principal.SessionID == uuid.Nil {
+		logger.Errorf("Impersonate.no session", principal.UserID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
+	}
+
+	userDB, ok := h.api.Admin().otherUserParam(c)
+	if !ok {
+		return
+	}
+
+	if !userDB.Active || userDB.ServiceAccount {
+		logger.Errorf("Impersonate.inactive or service account", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrNotImpersonable)
+
+		return
+	}
+
+	permissions, err := h.api.postgresStore.Role.Permissions(userDB.Role)
+	if err != nil {
+		logger.Errorf("Impersonate.Permissions", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	// impersonating must not grant more than the admin already has
+	if !principal.CanAll(permissions) {
+		logger.Errorf("Impersonate.CanAll", userDB.ID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
+	}
+
+	token, err := h.api.auth.Impersonate(userDB.ID, userDB.Role, principal.UserID, principal.SessionID)
+	if err != nil {
+		logger.Errorf("Impersonate.Impersonate", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.ImpersonationResponse{
+		AccessToken: token,
+		ExpiresIn:   int(authmiddleware.ImpersonationTokenTTL.Seconds()),
+	})
+}
+
+// ListLogs
+// @Summary list the requests made while impersonating
+// @Description requires users:read, newest first
+// @Produce json
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Param user_id          query string false "Impersonated user ID"
+// @Param impersonator_id  query string false "Impersonator ID"
+// @Param page             query int    false "Page, starts at 1"
+// @Param per_page         query int    false "Records per page, 20 by default, at most 100"
+// @Success 200 {object} admin.ImpersonationLogListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/impersonation-logs [get]
+//
+//nolint:varnamelen
+func (h *ImpersonationHandler) ListLogs(c *gin.Context) {
+	query := model.ImpersonationLogQuery{}
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("ListLogs.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !query.IsValid() {
+		logger.Errorf("ListLogs.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	logs, total, err := h.api.postgresStore.ImpersonationLog.List(query)
+	if err != nil {
+		logger.Errorf("ListLogs.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.ImpersonationLogListResponse{
+		Logs:    logs,
+		Total:   total,
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	impersonatorID        = uuid.NewV4()
+	impersonatorSessionID = uuid.NewV4()
+	impersonatedUser      = &model.AuthUser{ID: uuid.NewV4(), Username: "jane", Role: model.BaseUserRole, Active: true}
+	impersonationLogs     = []model.ImpersonationLog{
+		{
+			ID:             uuid.NewV4(),
+			ImpersonatorID: impersonatorID,
+			UserID:         impersonatedUser.ID,
+			TokenID:        uuid.NewV4().String(),
+			Method:         http.MethodGet,
+			Path:           "/api/v1/user/",
+			Status:         http.StatusOK,
+			IP:             "10.0.0.1",
+			CreatedAt:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+)
+
+var testMapImpersonationHandler = map[string][]model.TestStructure{
+	"Impersonate": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			ExpectedData: admin.ImpersonationResponse{
+				AccessToken: "impersonation-token",
+				ExpiresIn:   int(authmiddleware.ImpersonationTokenTTL.Seconds()),
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       impersonatorID,
+			SessionID:    impersonatorSessionID,
+			Mock:         makeList(AuthRepoGetMock, RoleRepoPermissionsMock, MiddlewareImpersonateMock),
+			MockData: [][]interface{}{
+				{
+					impersonatedUser,
+					true,
+				},
+				{
+					[]model.Permission{model.PermUsersRead},
+				},
+				{
+					"impersonation-token",
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      impersonatorID,
+			SessionID:   impersonatorSessionID,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+		{
+			Name:         "NegativeAlreadyImpersonating",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrImpersonation,
+			UserID:         uuid.NewV4(),
+			ImpersonatorID: impersonatorID,
+		},
+		{
+			Name:         "NegativeAPIKey",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID: impersonatorID,
+		},
+		{
+			Name:         "NegativeSelf",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatorID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID:    impersonatorID,
+			SessionID: impersonatorSessionID,
+		},
+		{
+			Name:         "NegativeSelfBraces",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/{" + impersonatorID.String() + "}/impersonate",
+			PositiveTest: false, WhatError: model.ErrSelfAction,
+			UserID:    impersonatorID,
+			SessionID: impersonatorSessionID,
+		},
+		{
+			Name:         "NegativeDeactivated",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrNotImpersonable,
+			UserID:    impersonatorID,
+			SessionID: impersonatorSessionID,
+			Mock:      makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: impersonatedUser.ID, Role: model.BaseUserRole},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeServiceAccount",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrNotImpersonable,
+			UserID:    impersonatorID,
+			SessionID: impersonatorSessionID,
+			Mock:      makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: impersonatedUser.ID, Role: model.BaseUserRole, Active: true, ServiceAccount: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeMorePermissions",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      impersonatorID,
+			SessionID:   impersonatorSessionID,
+			Permissions: []model.Permission{model.PermUsersImpersonate, model.PermUsersRead},
+			Mock:        makeList(AuthRepoGetMock, RoleRepoPermissionsMock),
+			MockData: [][]interface{}{
+				{
+					impersonatedUser,
+					true,
+				},
+				{
+					[]model.Permission{model.PermUsersRead, model.PermUsersDelete},
+				},
+			},
+		},
+		{
+			Name:         "NegativeMiddlewareImpersonateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + impersonatedUser.ID.String() + "/impersonate",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID:    impersonatorID,
+			SessionID: impersonatorSessionID,
+			Mock:      makeList(AuthRepoGetMock, RoleRepoPermissionsMock, MiddlewareImpersonateMock),
+			MockData: [][]interface{}{
+				{
+					impersonatedUser,
+					true,
+				},
+				{
+					[]model.Permission{},
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"ListLogs": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/impersonation-logs?user_id=" + impersonatedUser.ID.String(),
+			ExpectedData: admin.ImpersonationLogListResponse{
+				Logs:    impersonationLogs,
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(ImpersonationLogRepoListMock),
+			MockData: [][]interface{}{
+				{
+					impersonationLogs,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidUserID",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/impersonation-logs?impersonator_id=1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeImpersonationLogRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/impersonation-logs",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(ImpersonationLogRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"UserOnly": {
+		{
+			Name:         "NegativeChangePassword",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/change-password",
+			Data:         model.ChangePassword{OldPassword: "old", NewPassword: "new"},
+			PositiveTest: false, WhatError: model.ErrImpersonation,
+			UserID:         impersonatedUser.ID,
+			ImpersonatorID: impersonatorID,
+		},
+		{
+			Name:         "NegativeLogoutAll",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/logout-all",
+			PositiveTest: false, WhatError: model.ErrImpersonation,
+			UserID:         impersonatedUser.ID,
+			ImpersonatorID: impersonatorID,
+		},
+		{
+			Name:         "NegativeEnrollMFA",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/user/2fa/enroll",
+			PositiveTest: false, WhatError: model.ErrImpersonation,
+			UserID:         impersonatedUser.ID,
+			ImpersonatorID: impersonatorID,
+		},
+		{
+			Name:         "NegativeCreateAPIKey",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/api-keys",
+			PositiveTest: false, WhatError: model.ErrImpersonation,
+			UserID:         impersonatedUser.ID,
+			ImpersonatorID: impersonatorID,
+		},
+	},
+}
+
+func TestImpersonationHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	impersonationLogRepo := mockpostgresstore.NewMockImpersonationLogRepository(mockCtrl)
+	mockPostgresStore.ImpersonationLog = impersonationLogRepo
+	repos = append(repos, impersonationLogRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapImpersonationHandler)
+}
+
+func RoleRepoPermissionsMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var result []model.Permission
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Permission:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().Permissions(gomock.Any()).Return(result, err).Times(1)
+}
+
+func MiddlewareImpersonateMock(repos []interface{}, data []interface{}) {
+	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
+	var result string
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockauthmiddleware.MockAuthMiddleware:
+			middlewareMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case string:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	middlewareMock.EXPECT().Impersonate(gomock.Any(), gomock.Any(), impersonatorID, impersonatorSessionID).
+		Return(result, err).Times(1)
+}
+
+func ImpersonationLogRepoListMock(repos []interface{}, data []interface{}) {
+	var logMock *mockpostgresstore.MockImpersonationLogRepository
+	var result []model.ImpersonationLog
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImpersonationLogRepository:
+			logMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.ImpersonationLog:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	logMock.EXPECT().List(gomock.Any()).Return(result, int64(len(result)), err).Times(1)
+}
@@ -0,0 +1,468 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/importer"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	importPreviewRows = 5
+	// multipartOverhead is the room the form takes besides the file.
+	multipartOverhead = 64 << 10
+)
+
+type ImportHandler struct {
+	api *api
+}
+
+func NewImportHandler(a *api) *ImportHandler {
+	return &ImportHandler{
+		api: a,
+	}
+}
+
+// Upload
+// @Summary upload a CSV file of contacts
+// @Description requires imports:create. The first row is the header, comma or semicolon separated. The response has the first rows and a mapping suggested from the headers, nothing is imported before the import is run
+// @Accept multipart/form-data
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param file  formData file  true "CSV file"
+// @Success 200 {object} crm.ImportPreviewResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/imports [post]
+//
+//nolint:varnamelen
+func (h *ImportHandler) Upload(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Upload.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	maxBytes := h.api.config.Import.MaxBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		logger.Errorf("Upload.FormFile", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if header.Size > maxBytes {
+		logger.Errorf("Upload.Size", header.Size)
+		c.JSON(http.StatusBadRequest, model.NewValidationError([]model.FieldError{
+			{Field: "file", Rule: "max_size", Message: fmt.Sprintf("the file must be at most %d bytes", maxBytes)},
+		}))
+
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Errorf("Upload.Open", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		logger.Errorf("Upload.ReadAll", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	headers, rows, err := importer.ReadCSV(data, h.api.config.Import.MaxRows)
+	if err != nil {
+		logger.Errorf("Upload.ReadCSV", err)
+		c.JSON(http.StatusBadRequest, model.NewValidationError([]model.FieldError{
+			{Field: "file", Rule: "format", Message: err.Error()},
+		}))
+
+		return
+	}
+
+	job := &model.Import{
+		OwnerID:   principal.UserID,
+		FileName:  header.Filename,
+		Headers:   headers,
+		Mapping:   model.SuggestImportMapping(headers),
+		Status:    model.ImportUploaded,
+		TotalRows: len(rows),
+		Data:      data,
+	}
+
+	err = h.api.postgresStore.Import.Create(job)
+	if err != nil {
+		logger.Errorf("Upload.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if len(rows) > importPreviewRows {
+		rows = rows[:importPreviewRows]
+	}
+
+	c.JSON(http.StatusOK, crm.ImportPreviewResponse{Import: *job, Preview: rows})
+}
+
+// List
+// @Summary list the caller's imports
+// @Description requires imports:create, newest first
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param page      query int false "Page, starts at 1"
+// @Param per_page  query int false "Imports per page, 20 by default, at most 100"
+// @Success 200 {object} crm.ImportListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/imports [get]
+//
+//nolint:varnamelen
+func (h *ImportHandler) List(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("List.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	page := model.Pagination{}
+	err = c.ShouldBindQuery(&page)
+	if err != nil {
+		logger.Errorf("List.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	page.Normalize()
+
+	jobs, total, err := h.api.postgresStore.Import.List(principal.UserID, page)
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.ImportListResponse{
+		Imports: jobs,
+		Total:   total,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+	})
+}
+
+// Get
+// @Summary get an import and its progress
+// @Description requires imports:create, processed_rows of total_rows were imported or failed so far
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param id  path string  true "Import ID"
+// @Success 200 {object} model.Import
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/imports/{id} [get]
+//
+//nolint:varnamelen
+func (h *ImportHandler) Get(c *gin.Context) {
+	job, ok := h.importParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Mapping
+// @Summary map the columns of an import to contact fields
+// @Description requires imports:create, only before the import is run. Maps column headers to first_name, last_name, job_title, source, lifecycle_stage, email and phone; several columns can be emails and phones, unmapped columns are skipped
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param id       path string  true "Import ID"
+// @Param mapping  body model.ImportMapping  true "Column header to contact field"
+// @Success 200 {object} model.Import
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/imports/{id}/mapping [put]
+//
+//nolint:varnamelen
+func (h *ImportHandler) Mapping(c *gin.Context) {
+	job, ok := h.importParam(c)
+	if !ok {
+		return
+	}
+
+	mapping := model.ImportMapping{}
+	err := c.ShouldBindJSON(&mapping)
+	if err != nil {
+		logger.Errorf("Mapping.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := mapping.Validate(job.Headers); len(fields) > 0 {
+		logger.Errorf("Mapping.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	updated, err := h.api.postgresStore.Import.UpdateMapping(job.ID, mapping)
+	if err != nil {
+		logger.Errorf("Mapping.UpdateMapping", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !updated {
+		logger.Errorf("Mapping.UpdateMapping", job.Status)
+		c.JSON(http.StatusBadRequest, model.ErrImportStarted)
+
+		return
+	}
+
+	job.Mapping = mapping
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DryRun
+// @Summary check every row of an import
+// @Description requires imports:create, the rows are validated with the mapping like contacts created one by one and nothing is saved
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param id  path string  true "Import ID"
+// @Success 200 {object} crm.ImportDryRunResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/imports/{id}/dry-run [post]
+//
+//nolint:varnamelen
+func (h *ImportHandler) DryRun(c *gin.Context) {
+	job, ok := h.importParam(c)
+	if !ok || !h.checkMapping(c, job) {
+		return
+	}
+
+	_, rows, ok := h.importRows(c, job)
+	if !ok {
+		return
+	}
+
+	contacts, rowErrors := importer.Check(job, rows, 0)
+
+	c.JSON(http.StatusOK, crm.ImportDryRunResponse{
+		TotalRows:  len(rows),
+		ValidRows:  len(contacts),
+		FailedRows: len(rowErrors),
+		Errors:     rowErrors,
+	})
+}
+
+// Run
+// @Summary run an import
+// @Description requires imports:create. The import is queued and run in the background in batches, every batch in one transaction; the rows that fail are skipped and listed by the error report
+// @Produce json
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param id  path string  true "Import ID"
+// @Success 200 {object} model.Import
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/imports/{id}/run [post]
+//
+//nolint:varnamelen
+func (h *ImportHandler) Run(c *gin.Context) {
+	job, ok := h.importParam(c)
+	if !ok || !h.checkMapping(c, job) {
+		return
+	}
+
+	queued, err := h.api.postgresStore.Import.Queue(job.ID)
+	if err != nil {
+		logger.Errorf("Run.Queue", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !queued {
+		logger.Errorf("Run.Queue", job.Status)
+		c.JSON(http.StatusBadRequest, model.ErrImportStarted)
+
+		return
+	}
+
+	job.Status = model.ImportQueued
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Errors
+// @Summary download the rows of an import that failed
+// @Description requires imports:create. The CSV has the columns of the file, the import_row and the import_errors of every failed row; fixed rows can be uploaded again as they are
+// @Produce text/csv
+// @Tags Imports
+// @Security ApiKeyAuth
+// @Param id  path string  true "Import ID"
+// @Success 200 {string} string "CSV"
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/imports/{id}/errors [get]
+//
+//nolint:varnamelen
+func (h *ImportHandler) Errors(c *gin.Context) {
+	job, ok := h.importParam(c)
+	if !ok {
+		return
+	}
+
+	headers, rows, ok := h.importRows(c, job)
+	if !ok {
+		return
+	}
+
+	rowErrors, err := h.api.postgresStore.Import.Errors(job.ID)
+	if err != nil {
+		logger.Errorf("Errors.Errors", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-errors.csv"`, job.ID))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+
+	records := [][]string{append(append([]string{}, headers...), "import_row", "import_errors")}
+	for _, rowError := range rowErrors {
+		if rowError.Row < 1 || rowError.Row > len(rows) {
+			continue
+		}
+
+		records = append(records, importErrorRecord(headers, rows[rowError.Row-1], rowError))
+	}
+
+	err = writer.WriteAll(records)
+	if err != nil {
+		logger.Errorf("Errors.WriteAll", err)
+	}
+}
+
+// importParam loads the caller's import of the id path parameter, the imports
+// of others respond as missing.
+//
+//nolint:varnamelen
+func (h *ImportHandler) importParam(c *gin.Context) (*model.Import, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("importParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	importID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("importParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, false
+	}
+
+	job, exists := h.api.postgresStore.Import.Get(importID)
+	if !exists || job.OwnerID != principal.UserID {
+		logger.Errorf("importParam.Get", importID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, false
+	}
+
+	return job, true
+}
+
+// checkMapping responds with the rules the stored mapping breaks, the suggested
+// mapping may lack a name column.
+//
+//nolint:varnamelen
+func (h *ImportHandler) checkMapping(c *gin.Context, job *model.Import) bool {
+	if fields := job.Mapping.Validate(job.Headers); len(fields) > 0 {
+		logger.Errorf("checkMapping.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return false
+	}
+
+	return true
+}
+
+// importRows reads the uploaded file of the import.
+//
+//nolint:varnamelen
+func (h *ImportHandler) importRows(c *gin.Context, job *model.Import) ([]string, [][]string, bool) {
+	data, err := h.api.postgresStore.Import.Data(job.ID)
+	if err != nil {
+		logger.Errorf("importRows.Data", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, nil, false
+	}
+
+	headers, rows, err := importer.ReadCSV(data, 0)
+	if err != nil {
+		logger.Errorf("importRows.ReadCSV", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, nil, false
+	}
+
+	return headers, rows, true
+}
+
+// importErrorRecord is the row padded to the header with its errors, cells are
+// escaped like the audit export.
+func importErrorRecord(headers, row []string, rowError model.ImportRowError) []string {
+	record := make([]string, 0, len(headers)+2)
+
+	for column := range headers {
+		value := ""
+		if column < len(row) {
+			value = row[column]
+		}
+
+		record = append(record, csvCell(value))
+	}
+
+	messages := make([]string, 0, len(rowError.Errors))
+	for _, field := range rowError.Errors {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return append(record, strconv.Itoa(rowError.Row), csvCell(strings.Join(messages, "; ")))
+}
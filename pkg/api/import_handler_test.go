@@ -0,0 +1,558 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const importCSV = "First Name,Email\nAnn,ann@example.com\n=cmd,bad\nBob,\n"
+
+var (
+	importID   = uuid.NewV4()
+	testImport = model.Import{
+		ID:        importID,
+		OwnerID:   contactOwnerID,
+		FileName:  "contacts.csv",
+		Headers:   model.StringList{"First Name", "Email"},
+		Mapping:   model.ImportMapping{"First Name": model.ImportFirstName, "Email": model.ImportEmail},
+		Status:    model.ImportUploaded,
+		TotalRows: 3,
+	}
+	invalidEmailRow = model.ImportRowError{ImportID: importID, Row: 2, Errors: model.FieldErrors{
+		{Field: "emails", Rule: "email", Message: "invalid email address"},
+	}}
+)
+
+func importWith(change func(job *model.Import)) model.Import {
+	job := testImport
+	change(&job)
+
+	return job
+}
+
+var testMapImportHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/imports",
+			ExpectedData: crm.ImportListResponse{
+				Imports: []model.Import{testImport},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Import{testImport},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/imports",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermContactsUpdate},
+		},
+	},
+	"Get": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String(),
+			ExpectedData: testImport,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherOwner",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID: contactOtherID,
+			Mock:   makeList(ImportRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+			},
+		},
+	},
+	"Mapping": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/imports/" + importID.String() + "/mapping",
+			Data:   model.ImportMapping{"First Name": "Last_Name ", "Email": "EMAIL"},
+			ExpectedData: importWith(func(job *model.Import) {
+				job.Mapping = model.ImportMapping{"First Name": model.ImportLastName, "Email": model.ImportEmail}
+			}),
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoGetMock, ImportRepoUpdateMappingMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/mapping",
+			Data:         model.ImportMapping{"First Name": "nickname", "Phone": "phone"},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "mapping.First Name", Rule: "oneof", Message: "unknown contact field"},
+				{Field: "mapping.Phone", Rule: "unknown", Message: "the file has no such column"},
+				{Field: "mapping", Rule: "required", Message: "map a column to first_name or last_name"},
+			}),
+			UserID: contactOwnerID,
+			Mock:   makeList(ImportRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeStarted",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/mapping",
+			Data:         model.ImportMapping{"First Name": "first_name"},
+			PositiveTest: false, WhatError: model.ErrImportStarted,
+			UserID: contactOwnerID,
+			Mock:   makeList(ImportRepoGetMock, ImportRepoUpdateMappingMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+	},
+	"DryRun": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/imports/" + importID.String() + "/dry-run",
+			ExpectedData: crm.ImportDryRunResponse{
+				TotalRows:  3,
+				ValidRows:  2,
+				FailedRows: 1,
+				Errors:     []model.ImportRowError{invalidEmailRow},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoGetMock, ImportRepoDataMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					[]byte(importCSV),
+				},
+			},
+		},
+		{
+			Name:         "NegativeSuggestedMappingWithoutName",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/dry-run",
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "mapping", Rule: "required", Message: "map a column to first_name or last_name"},
+			}),
+			UserID: contactOwnerID,
+			Mock:   makeList(ImportRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Import{ID: importID, OwnerID: contactOwnerID, Headers: model.StringList{"Email"},
+						Mapping: model.ImportMapping{"Email": model.ImportEmail}},
+					true,
+				},
+			},
+		},
+	},
+	"Run": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/run",
+			ExpectedData: importWith(func(job *model.Import) { job.Status = model.ImportQueued }),
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoGetMock, ImportRepoQueueMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeStarted",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/run",
+			PositiveTest: false, WhatError: model.ErrImportStarted,
+			UserID: contactOwnerID,
+			Mock:   makeList(ImportRepoGetMock, ImportRepoQueueMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeImportRepoQueueMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/imports/" + importID.String() + "/run",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(ImportRepoGetMock, ImportRepoQueueMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Errors": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/imports/" + importID.String() + "/errors",
+			ExpectedData: "First Name,Email,import_row,import_errors\n" +
+				"'=cmd,bad,2,emails: invalid email address\n",
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(ImportRepoGetMock, ImportRepoDataMock, ImportRepoErrorsMock),
+			MockData: [][]interface{}{
+				{
+					&testImport,
+					true,
+				},
+				{
+					[]byte(importCSV),
+				},
+				{
+					[]model.ImportRowError{invalidEmailRow},
+				},
+			},
+		},
+	},
+}
+
+func newImportTestAPI(t *testing.T) (*api, []interface{}) {
+	t.Helper()
+
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	importRepo := mockpostgresstore.NewMockImportRepository(mockCtrl)
+	mockPostgresStore.Import = importRepo
+	repos = append(repos, importRepo)
+
+	return testAPI, repos
+}
+
+func TestImportHandlers(t *testing.T) {
+	testAPI, repos := newImportTestAPI(t)
+
+	runHandlerTests(t, testAPI, repos, testMapImportHandler)
+}
+
+func TestImportUpload(t *testing.T) {
+	testAPI, repos := newImportTestAPI(t)
+
+	upload := func(content string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		file, err := form.CreateFormFile("file", "contacts.csv")
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req, err := http.NewRequest(http.MethodPost, "https://localhost:8000/api/v1/imports", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req = req.WithContext(context.WithValue(req.Context(), testCaseKey{}, model.TestStructure{UserID: contactOwnerID}))
+
+		rr := httptest.NewRecorder()
+		testAPI.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("Positive", func(t *testing.T) {
+		var created *model.Import
+		for _, r := range repos {
+			if importMock, ok := r.(*mockpostgresstore.MockImportRepository); ok {
+				importMock.EXPECT().Create(gomock.Any()).DoAndReturn(func(job *model.Import) error {
+					created = job
+
+					return nil
+				}).Times(1)
+			}
+		}
+
+		rr := upload(importCSV)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var response crm.ImportPreviewResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, [][]string{{"Ann", "ann@example.com"}, {"=cmd", "bad"}, {"Bob", ""}}, response.Preview)
+		assert.Equal(t, testImport.Mapping, response.Import.Mapping)
+		assert.Equal(t, model.ImportUploaded, response.Import.Status)
+		assert.Equal(t, 3, response.Import.TotalRows)
+		assert.Equal(t, contactOwnerID, created.OwnerID)
+		assert.Equal(t, []byte(importCSV), created.Data)
+	})
+
+	t.Run("NegativeTooManyRows", func(t *testing.T) {
+		rr := upload("First Name\n" + strings.Repeat("Ann\n", 11))
+
+		body, err := json.Marshal(model.NewValidationError([]model.FieldError{
+			{Field: "file", Rule: "format", Message: "the file has more than 10 rows"},
+		}))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(body), rr.Body.String())
+	})
+
+	t.Run("NegativeTooLarge", func(t *testing.T) {
+		rr := upload("First Name\n" + strings.Repeat("Ann\n", 300))
+
+		body, err := json.Marshal(model.NewValidationError([]model.FieldError{
+			{Field: "file", Rule: "max_size", Message: "the file must be at most 1024 bytes"},
+		}))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(body), rr.Body.String())
+	})
+}
+
+func ImportRepoGetMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	var result *model.Import
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Import:
+			// the handler may change the import it gets
+			job := *t
+			result = &job
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().Get(importID).Return(result, exist).Times(1)
+}
+
+func ImportRepoListMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	result := []model.Import{}
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Import:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().List(contactOwnerID, gomock.Any()).Return(result, total, err).Times(1)
+}
+
+func ImportRepoUpdateMappingMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	var updated bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			updated = t
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().UpdateMapping(importID, gomock.Any()).Return(updated, err).Times(1)
+}
+
+func ImportRepoQueueMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	var queued bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			queued = t
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().Queue(importID).Return(queued, err).Times(1)
+}
+
+func ImportRepoDataMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	var result []byte
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []byte:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().Data(importID).Return(result, err).Times(1)
+}
+
+func ImportRepoErrorsMock(repos []interface{}, data []interface{}) {
+	var importMock *mockpostgresstore.MockImportRepository
+	result := []model.ImportRowError{}
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockImportRepository:
+			importMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.ImportRowError:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	importMock.EXPECT().Errors(importID).Return(result, err).Times(1)
+}
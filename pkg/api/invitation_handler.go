@@ -0,0 +1,320 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/model/ui/auth"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type InvitationHandler struct {
+	api *api
+}
+
+func NewInvitationHandler(a *api) *InvitationHandler {
+	return &InvitationHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary invite a user by email
+// @Description requires users:create, the invitee sets their own password through the emailed link. A pending invitation to the same email is revoked.
+// @Description the caller must hold every permission of the role
+// @Produce json
+// @Tags Invitations
+// @Security ApiKeyAuth
+// @Param invitation  body model.InvitationCreate  true "Invitation"
+// @Success 200 {object} model.Invitation
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/invitations [post]
+//
+//nolint:varnamelen
+func (h *InvitationHandler) Create(c *gin.Context) {
+	request := &model.InvitationCreate{}
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !request.IsValid(time.Now()) {
+		logger.Errorf("Create.IsValid", request.Email)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !h.api.Role().checkGrantable(c, request.Role) {
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Auth.GetByEmail(request.Email); exists {
+		logger.Errorf("Create.Email exist", request.Email)
+		c.JSON(http.StatusBadRequest, model.ErrEmailExist)
+
+		return
+	}
+
+	userID, err := authmiddleware.CurrentUserID(c)
+	if err != nil {
+		logger.Errorf("Create.CurrentUserID", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	token, hash, err := authmiddleware.GenerateEmailToken()
+	if err != nil {
+		logger.Errorf("Create.GenerateEmailToken", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	expiresAt := time.Now().Add(h.api.config.Password.InvitationTTL.Duration)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	}
+
+	invitation := &model.Invitation{
+		Email:     request.Email,
+		Role:      request.Role,
+		TokenHash: hash,
+		InvitedBy: userID,
+		ExpiresAt: expiresAt,
+	}
+
+	err = h.api.postgresStore.Invitation.Create(invitation)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.sendInvitation(invitation, token)
+	if err != nil {
+		logger.Errorf("Create.sendInvitation", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// List
+// @Summary list pending invitations
+// @Description requires users:read, accepted, revoked and expired invitations are left out
+// @Produce json
+// @Tags Invitations
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Invitation
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/invitations [get]
+//
+//nolint:varnamelen
+func (h *InvitationHandler) List(c *gin.Context) {
+	invitations, err := h.api.postgresStore.Invitation.ListPending()
+	if err != nil {
+		logger.Errorf("List.ListPending", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Revoke
+// @Summary revoke a pending invitation
+// @Description requires users:create, the emailed link stops working
+// @Produce json
+// @Tags Invitations
+// @Security ApiKeyAuth
+// @Param id  path string  true "Invitation ID"
+// @Success 200 {object} admin.InvitationRevokeResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/invitations/{id} [delete]
+//
+//nolint:varnamelen
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	invitationID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("Revoke.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Invitation.Get(invitationID); !exists {
+		logger.Errorf("Revoke.Get", invitationID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	err = h.api.postgresStore.Invitation.Revoke(invitationID)
+	if err != nil {
+		logger.Errorf("Revoke.Revoke", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.InvitationRevokeResponse{Status: "invitation revoked"})
+}
+
+// Accept
+// @Summary create an account with an emailed invitation
+// @Description the account gets the invited email and role, username defaults to the email
+// @Produce json
+// @Tags Invitations
+// @Param invitation  body model.InvitationAccept  true "Account"
+// @Success 200 {object} auth.InvitationAcceptResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation "invalid body or invitation, or the password breaks the policy"
+// @Router /api/v1/invitations/accept [post]
+//
+//nolint:varnamelen
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	accept := &model.InvitationAccept{}
+	err := c.ShouldBindJSON(&accept)
+	if err != nil {
+		logger.Errorf("Accept.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !accept.IsValid() {
+		logger.Errorf("Accept.Empty token or pass", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	invitation, exists := h.api.postgresStore.Invitation.GetByHash(authmiddleware.HashEmailToken(accept.Token))
+	if !exists {
+		logger.Errorf("Accept.GetByHash", "invalid token")
+		c.JSON(http.StatusBadRequest, model.ErrInvalidInvitation)
+
+		return
+	}
+
+	// the role may have been deleted since the invitation was sent
+	if _, exists := h.api.postgresStore.Role.Get(invitation.Role); !exists {
+		logger.Errorf("Accept.Role.Get", invitation.Role)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRole)
+
+		return
+	}
+
+	username := accept.Username
+	if username == "" {
+		username = invitation.Email
+	}
+
+	userDB, err := h.api.postgresStore.Auth.GetByUsername(username)
+	if err != nil {
+		logger.Errorf("Accept.GetByUsername", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if userDB.ID != uuid.Nil {
+		logger.Errorf("Accept.Username exist", username)
+		c.JSON(http.StatusBadRequest, model.ErrUsenameExist)
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Auth.GetByEmail(invitation.Email); exists {
+		logger.Errorf("Accept.Email exist", invitation.Email)
+		c.JSON(http.StatusBadRequest, model.ErrEmailExist)
+
+		return
+	}
+
+	if !h.api.checkPassword(c, "password", accept.Password, &model.AuthUser{Username: username}) {
+		return
+	}
+
+	hash, err := authmiddleware.CreateHashPassword(accept.Password)
+	if err != nil {
+		logger.Errorf("Accept.CreateHashPassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	email := invitation.Email
+	user := &model.AuthUser{
+		Username: username,
+		Password: hash,
+		Email:    &email,
+		Role:     invitation.Role,
+		Active:   true,
+	}
+
+	accepted, err := h.api.postgresStore.Invitation.Accept(invitation.ID, user, accept.Profile())
+	if err != nil {
+		logger.Errorf("Accept.Accept", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !accepted {
+		logger.Errorf("Accept.Accept", "invitation used concurrently")
+		c.JSON(http.StatusBadRequest, model.ErrInvalidInvitation)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventRegistration, user).Succeeded(string(user.Role)))
+
+	c.JSON(http.StatusOK, auth.InvitationAcceptResponse{Status: "user created"})
+}
+
+// sendInvitation mails the link in the background, a failed delivery is only
+// logged since the admin can invite again.
+func (h *InvitationHandler) sendInvitation(invitation *model.Invitation, token string) error {
+	link, err := url.Parse(h.api.config.Password.InvitationURL)
+	if err != nil {
+		return err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: "Invitation to CRM System",
+		Body: fmt.Sprintf("Hello,\n\nyou have been invited to CRM System. Open the link below to create your account, "+
+			"it is valid until %s.\n\n%s\n\nIf you did not expect this invitation, ignore this email.\n",
+			invitation.ExpiresAt.UTC().Format(time.RFC1123), link),
+	}
+
+	go func() {
+		err := h.api.mailer.Send(msg)
+		if err != nil {
+			logger.Errorf("sendInvitation.Send", err)
+		}
+	}()
+
+	return nil
+}
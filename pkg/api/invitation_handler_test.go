@@ -0,0 +1,635 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/model/ui/auth"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	invitationID   = uuid.NewV4()
+	inviterID      = uuid.NewV4()
+	testInvitation = model.Invitation{
+		ID:        invitationID,
+		Email:     "invitee@example.com",
+		Role:      model.BaseUserRole,
+		InvitedBy: inviterID,
+		ExpiresAt: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+var testMapInvitationHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/invitations",
+			Data:   model.InvitationCreate{Email: " Invitee@Example.com ", Role: "base"},
+			ExpectedData: map[string]interface{}{
+				"email":      "invitee@example.com",
+				"role":       model.BaseUserRole,
+				"invited_by": inviterID,
+			},
+			SkipFields:   []string{"id", "expires_at", "created_at"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       inviterID,
+			Mock:         makeList(RoleRepoGetMock, AuthRepoGetByEmailMock, InvitationRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeRoleBeyondOwnPermissions",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: "invitee@example.com", Role: model.AdminUserRole},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      inviterID,
+			Permissions: []model.Permission{model.PermUsersCreate},
+			Mock:        makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole, Permissions: model.AllPermissions},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidEmail",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: "invitee", Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeExpired",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: "invitee@example.com", Role: model.BaseUserRole, ExpiresAt: &time.Time{}},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidRole",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: "invitee@example.com", Role: "GHOST"},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeEmailExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: testEmail, Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrEmailExist,
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByEmailMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{ID: uuid.NewV4(), Email: &testEmail},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvitationRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			Data:         model.InvitationCreate{Email: "invitee@example.com", Role: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoGetMock, AuthRepoGetByEmailMock, InvitationRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			ExpectedData: []model.Invitation{testInvitation},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(InvitationRepoListPendingMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Invitation{testInvitation},
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvitationRepoListPendingMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/invitations",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(InvitationRepoListPendingMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Revoke": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/invitations/" + invitationID.String(),
+			ExpectedData: admin.InvitationRevokeResponse{Status: "invitation revoked"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(InvitationRepoGetMock, InvitationRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeInvalidID",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/invitations/invitation",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeNotPending",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/invitations/" + invitationID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(InvitationRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvitationRepoRevokeMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/invitations/" + invitationID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(InvitationRepoGetMock, InvitationRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Accept": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Username: "invitee", Password: "pass", Name: "Jane"},
+			ExpectedData: auth.InvitationAcceptResponse{Status: "user created"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(InvitationRepoGetByHashMock, RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoGetByEmailMock, InvitationRepoAcceptMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{
+					false,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativePasswordEmpty",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: " "},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidToken",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrInvalidInvitation,
+			Mock: makeList(InvitationRepoGetByHashMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleDeleted",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			Mock: makeList(InvitationRepoGetByHashMock, RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUsernameExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Username: "user", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrUsenameExist,
+			Mock: makeList(InvitationRepoGetByHashMock, RoleRepoGetMock, AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{ID: uuid.NewV4(), Username: "user"},
+				},
+			},
+		},
+		{
+			Name:         "NegativeEmailExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrEmailExist,
+			Mock: makeList(InvitationRepoGetByHashMock, RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoGetByEmailMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{
+					&model.AuthUser{ID: uuid.NewV4()},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAlreadyAccepted",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrInvalidInvitation,
+			Mock: makeList(InvitationRepoGetByHashMock, RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoGetByEmailMock, InvitationRepoAcceptMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{
+					false,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvitationRepoAcceptMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/invitations/accept",
+			Data:         model.InvitationAccept{Token: "token", Password: "pass"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(InvitationRepoGetByHashMock, RoleRepoGetMock, AuthRepoGetByUsernameMock, AuthRepoGetByEmailMock, InvitationRepoAcceptMock),
+			MockData: [][]interface{}{
+				{
+					&testInvitation,
+					true,
+				},
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+				{
+					&model.AuthUser{},
+				},
+				{
+					false,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestInvitationHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	invitationRepo := mockpostgresstore.NewMockInvitationRepository(mockCtrl)
+	mockPostgresStore.Invitation = invitationRepo
+	repos = append(repos, invitationRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapInvitationHandler)
+}
+
+func TestInvitationAcceptCreatesInvitedUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	invitationRepo := mockpostgresstore.NewMockInvitationRepository(mockCtrl)
+
+	testAPI := initTestAPI(t, mockauthmiddleware.NewMockAuthMiddleware(mockCtrl), &store.Store{
+		Auth:       authRepo,
+		Role:       roleRepo,
+		Invitation: invitationRepo,
+		AuthEvent:  memorystore.NewAuthEventRepository(),
+	})
+
+	invitationRepo.EXPECT().GetByHash(authmiddleware.HashEmailToken("token")).Return(&testInvitation, true)
+	roleRepo.EXPECT().Get(model.BaseUserRole).Return(&model.Role{Name: model.BaseUserRole}, true)
+	authRepo.EXPECT().GetByUsername(testInvitation.Email).Return(&model.AuthUser{}, nil)
+	authRepo.EXPECT().GetByEmail(testInvitation.Email).Return(nil, false)
+	invitationRepo.EXPECT().Accept(invitationID, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ uuid.UUID, user *model.AuthUser, profile *model.User) (bool, error) {
+			assert.Equal(t, testInvitation.Email, user.Username)
+			assert.Equal(t, testInvitation.Email, *user.Email)
+			assert.Equal(t, model.BaseUserRole, user.Role)
+			assert.True(t, user.Active)
+			assert.True(t, authmiddleware.IsPasswordMatch("pass", user.Password))
+			assert.Equal(t, "Doe", profile.Surname)
+
+			return true, nil
+		})
+
+	runHandlerTests(t, testAPI, nil, map[string][]model.TestStructure{
+		"Accept": {
+			{
+				Name:         "PositiveDefaultUsername",
+				Method:       http.MethodPost,
+				URL:          "https://localhost:8000/api/v1/invitations/accept",
+				Data:         model.InvitationAccept{Token: "token", Password: "pass", Surname: " Doe "},
+				ExpectedData: auth.InvitationAcceptResponse{Status: "user created"},
+				PositiveTest: true,
+			},
+		},
+	})
+}
+
+func InvitationRepoCreateMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func InvitationRepoListPendingMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var result []model.Invitation
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Invitation:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().ListPending().Return(result, err).Times(1)
+}
+
+func InvitationRepoGetMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var result *model.Invitation
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Invitation:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func InvitationRepoGetByHashMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var result *model.Invitation
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Invitation:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().GetByHash(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func InvitationRepoRevokeMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().Revoke(gomock.Any()).Return(err).Times(1)
+}
+
+func InvitationRepoAcceptMock(repos []interface{}, data []interface{}) {
+	var invitationMock *mockpostgresstore.MockInvitationRepository
+	var accepted bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockInvitationRepository:
+			invitationMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			accepted = t
+		default:
+			continue
+		}
+	}
+
+	invitationMock.EXPECT().Accept(gomock.Any(), gomock.Any(), gomock.Any()).Return(accepted, err).Times(1)
+}
@@ -0,0 +1,646 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/totp"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	api *api
+}
+
+func NewMFAHandler(a *api) *MFAHandler {
+	return &MFAHandler{
+		api: a,
+	}
+}
+
+// Login
+// @Summary second login step with a TOTP or recovery code
+// @Produce json
+// @Tags MFA
+// @Param MFALogin  body model.MFALogin  true "MFA Login"
+// @Success 200 {object} authmiddleware.Tokens
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many failed attempts, see Retry-After"
+// @Router /api/v1/login/2fa [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) Login(c *gin.Context) {
+	mfaLogin := &model.MFALogin{}
+	err := c.ShouldBindJSON(&mfaLogin)
+	if err != nil {
+		logger.Errorf("Login.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !mfaLogin.IsValid() {
+		logger.Errorf("Login.Empty token or code", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, claims, ok := h.challengeUser(c, mfaLogin.MFAToken, false)
+	if !ok {
+		return
+	}
+
+	if !userDB.TOTPEnabled {
+		logger.Errorf("Login.TOTPEnabled", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFANotEnrolled)
+
+		return
+	}
+
+	keys := attemptKeys(c, userDB.Username)
+	if !h.api.checkAttempts(c, keys...) {
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Failed(model.ReasonTooManyAttempts))
+
+		return
+	}
+
+	var valid bool
+	if mfaLogin.Code != "" {
+		valid, err = h.useCode(userDB, mfaLogin.Code)
+	} else {
+		valid, err = h.api.postgresStore.RecoveryCode.Use(userDB.ID, authmiddleware.HashRecoveryCode(mfaLogin.RecoveryCode))
+	}
+
+	if err != nil {
+		logger.Errorf("Login.Use", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !valid {
+		logger.Errorf("Login.invalid code", userDB.ID)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Failed(model.ReasonInvalidMFACode))
+		h.api.failAttempt(c, keys...)
+		c.JSON(http.StatusUnauthorized, model.ErrInvalidMFACode)
+
+		return
+	}
+
+	// a challenge logs in once, a replayed token is refused even with a new code
+	unused, err := h.api.postgresStore.MFAChallenge.Use(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		logger.Errorf("Login.MFAChallenge.Use", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !unused {
+		logger.Errorf("Login.challenge already used", userDB.ID)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	h.api.resetAttempts(userDB.Username)
+
+	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role, authmiddleware.ClientFromContext(c))
+	if err != nil {
+		logger.Errorf("Login.CreateTokens", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Succeeded("mfa"))
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LoginEnroll
+// @Summary enroll TOTP during login when the role requires it
+// @Produce json
+// @Tags MFA
+// @Param MFACode  body model.MFACode  true "MFA token"
+// @Success 200 {object} auth.MFAEnrollResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/login/2fa/enroll [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) LoginEnroll(c *gin.Context) {
+	mfaCode := &model.MFACode{}
+	err := c.ShouldBindJSON(&mfaCode)
+	if err != nil {
+		logger.Errorf("LoginEnroll.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, claims, ok := h.challengeUser(c, mfaCode.MFAToken, true)
+	if !ok {
+		return
+	}
+
+	if userDB.TOTPEnabled {
+		logger.Errorf("LoginEnroll.TOTPEnabled", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFAEnrolled)
+
+		return
+	}
+
+	// a replayed challenge would replace the secret the user is confirming
+	unused, err := h.api.postgresStore.MFAChallenge.Use(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		logger.Errorf("LoginEnroll.MFAChallenge.Use", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !unused {
+		logger.Errorf("LoginEnroll.challenge already used", userDB.ID)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	h.enroll(c, userDB)
+}
+
+// LoginConfirm
+// @Summary confirm TOTP enrolled during login and finish the login
+// @Produce json
+// @Tags MFA
+// @Param MFACode  body model.MFACode  true "MFA token and code"
+// @Success 200 {object} auth.MFAConfirmResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/login/2fa/confirm [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) LoginConfirm(c *gin.Context) {
+	mfaCode := &model.MFACode{}
+	err := c.ShouldBindJSON(&mfaCode)
+	if err != nil {
+		logger.Errorf("LoginConfirm.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !mfaCode.IsValid() {
+		logger.Errorf("LoginConfirm.Empty code", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, _, ok := h.challengeUser(c, mfaCode.MFAToken, true)
+	if !ok {
+		return
+	}
+
+	codes, ok := h.confirm(c, userDB, mfaCode.Code)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role, authmiddleware.ClientFromContext(c))
+	if err != nil {
+		logger.Errorf("LoginConfirm.CreateTokens", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Succeeded("mfa"))
+
+	c.JSON(http.StatusOK, auth.MFAConfirmResponse{RecoveryCodes: codes, Tokens: tokens})
+}
+
+// Enroll
+// @Summary start TOTP enrollment, returns the secret and otpauth URI
+// @Produce json
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Success 200 {object} auth.MFAEnrollResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/2fa/enroll [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userDB, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if userDB.TOTPEnabled {
+		logger.Errorf("Enroll.TOTPEnabled", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFAEnrolled)
+
+		return
+	}
+
+	h.enroll(c, userDB)
+}
+
+// Confirm
+// @Summary confirm TOTP enrollment, returns recovery codes
+// @Produce json
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Param MFACode  body model.MFACode  true "TOTP code"
+// @Success 200 {object} auth.MFAConfirmResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/2fa/confirm [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	mfaCode := &model.MFACode{}
+	err := c.ShouldBindJSON(&mfaCode)
+	if err != nil {
+		logger.Errorf("Confirm.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !mfaCode.IsValid() {
+		logger.Errorf("Confirm.Empty code", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, ok := h.confirm(c, userDB, mfaCode.Code)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.MFAConfirmResponse{RecoveryCodes: codes})
+}
+
+// Disable
+// @Summary disable TOTP, not allowed when the role requires it
+// @Produce json
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Param MFADisable  body model.MFADisable  true "Password and TOTP code"
+// @Success 200 {object} auth.MFADisableResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many failed attempts, see Retry-After"
+// @Router /api/v1/user/2fa/disable [post]
+//
+//nolint:varnamelen
+func (h *MFAHandler) Disable(c *gin.Context) {
+	disable := &model.MFADisable{}
+	err := c.ShouldBindJSON(&disable)
+	if err != nil {
+		logger.Errorf("Disable.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !disable.IsValid() {
+		logger.Errorf("Disable.Empty password or code", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	userDB, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !userDB.TOTPEnabled {
+		logger.Errorf("Disable.TOTPEnabled", userDB.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFANotEnrolled)
+
+		return
+	}
+
+	keys := attemptKeys(c, userDB.Username)
+	if !h.api.checkAttempts(c, keys...) {
+		return
+	}
+
+	valid := authmiddleware.IsPasswordMatch(disable.Password, userDB.Password)
+	if valid {
+		valid, err = h.useCode(userDB, disable.Code)
+		if err != nil {
+			logger.Errorf("Disable.useCode", err)
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+			return
+		}
+	}
+
+	if !valid {
+		logger.Errorf("Disable.invalid password or code", userDB.ID)
+		h.api.failAttempt(c, keys...)
+		c.JSON(http.StatusUnauthorized, model.ErrInvalidMFACode)
+
+		return
+	}
+
+	h.api.resetAttempts(userDB.Username)
+
+	required, err := h.api.postgresStore.MFAPolicy.IsRequired(userDB.Role)
+	if err != nil {
+		logger.Errorf("Disable.IsRequired", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if required {
+		logger.Errorf("Disable.required for role", userDB.Role)
+		c.JSON(http.StatusForbidden, model.ErrMFARequired)
+
+		return
+	}
+
+	err = h.api.postgresStore.Auth.SetTOTP(userDB.ID, "", false)
+	if err != nil {
+		logger.Errorf("Disable.SetTOTP", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.postgresStore.RecoveryCode.Replace(userDB.ID, nil)
+	if err != nil {
+		logger.Errorf("Disable.RecoveryCode.Replace", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.MFADisableResponse{Status: "two-factor authentication disabled"})
+}
+
+// GetPolicies
+// @Summary list roles that require two-factor authentication
+// @Description requires mfa-policies:read
+// @Produce json
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Success 200 {array} model.MFAPolicy
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/2fa-policies [get]
+//
+//nolint:varnamelen
+func (h *MFAHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.api.postgresStore.MFAPolicy.List()
+	if err != nil {
+		logger.Errorf("GetPolicies.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// SetPolicy
+// @Summary require or stop requiring two-factor authentication for a role
+// @Description requires mfa-policies:update
+// @Produce json
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Param MFAPolicy  body model.MFAPolicy  true "MFA Policy"
+// @Success 200 {object} model.MFAPolicy
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/2fa-policies [put]
+//
+//nolint:varnamelen
+func (h *MFAHandler) SetPolicy(c *gin.Context) {
+	policy := &model.MFAPolicy{}
+	err := c.ShouldBindJSON(&policy)
+	if err != nil {
+		logger.Errorf("SetPolicy.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !policy.IsValid() {
+		logger.Errorf("SetPolicy.Empty role", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Role.Get(policy.Role); !exists {
+		logger.Errorf("SetPolicy.Role.Get", policy.Role)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRole)
+
+		return
+	}
+
+	err = h.api.postgresStore.MFAPolicy.Set(policy)
+	if err != nil {
+		logger.Errorf("SetPolicy.Set", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// challenge returns the response replacing tokens on login when the user has 2FA
+// enabled or the role requires it, or nil when the password is enough.
+//
+//nolint:nilnil
+func (h *MFAHandler) challenge(user *model.AuthUser) (*auth.MFAChallengeResponse, error) {
+	enroll := false
+
+	if !user.TOTPEnabled {
+		required, err := h.api.postgresStore.MFAPolicy.IsRequired(user.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		if !required {
+			return nil, nil
+		}
+
+		enroll = true
+	}
+
+	token, err := h.api.auth.CreateMFAToken(user.ID, user.Role, enroll)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.MFAChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: enroll,
+		MFAToken:           token,
+	}, nil
+}
+
+//nolint:varnamelen
+func (h *MFAHandler) enroll(c *gin.Context, user *model.AuthUser) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Errorf("enroll.GenerateSecret", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.postgresStore.Auth.SetTOTP(user.ID, secret, false)
+	if err != nil {
+		logger.Errorf("enroll.SetTOTP", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.MFAEnrollResponse{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(h.api.config.MFA.Issuer, user.Username, secret),
+	})
+}
+
+// confirm enables the pending secret and returns new recovery codes.
+//
+//nolint:varnamelen
+func (h *MFAHandler) confirm(c *gin.Context, user *model.AuthUser, code string) ([]string, bool) {
+	if user.TOTPEnabled {
+		logger.Errorf("confirm.TOTPEnabled", user.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFAEnrolled)
+
+		return nil, false
+	}
+
+	if user.TOTPSecret == "" {
+		logger.Errorf("confirm.no pending secret", user.ID)
+		c.JSON(http.StatusBadRequest, model.ErrMFANotEnrolled)
+
+		return nil, false
+	}
+
+	valid, err := h.useCode(user, code)
+	if err != nil {
+		logger.Errorf("confirm.useCode", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	if !valid {
+		logger.Errorf("confirm.useCode", user.ID)
+		c.JSON(http.StatusUnauthorized, model.ErrInvalidMFACode)
+
+		return nil, false
+	}
+
+	codes, hashes, err := authmiddleware.GenerateRecoveryCodes(authmiddleware.RecoveryCodesCount)
+	if err != nil {
+		logger.Errorf("confirm.GenerateRecoveryCodes", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	err = h.api.postgresStore.RecoveryCode.Replace(user.ID, hashes)
+	if err != nil {
+		logger.Errorf("confirm.RecoveryCode.Replace", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	err = h.api.postgresStore.Auth.SetTOTP(user.ID, user.TOTPSecret, true)
+	if err != nil {
+		logger.Errorf("confirm.SetTOTP", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return nil, false
+	}
+
+	return codes, true
+}
+
+// useCode checks the TOTP code of the user and records its time step, the
+// codes of the step accepted last and of earlier ones are refused (RFC 6238
+// section 5.2).
+func (h *MFAHandler) useCode(user *model.AuthUser, code string) (bool, error) {
+	step, valid := totp.ValidateStep(user.TOTPSecret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	return h.api.postgresStore.Auth.UseTOTPStep(user.ID, step)
+}
+
+// challengeUser loads the user and the claims of an MFA challenge token of the expected kind.
+//
+//nolint:varnamelen
+func (h *MFAHandler) challengeUser(
+	c *gin.Context,
+	mfaToken string,
+	enroll bool,
+) (*model.AuthUser, *authmiddleware.MFAClaims, bool) {
+	claims, err := h.api.auth.ValidateMFAToken(mfaToken)
+	if err != nil || claims.Enroll != enroll {
+		logger.Errorf("challengeUser.ValidateMFAToken", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	userDB, exists := h.api.postgresStore.Auth.Get(claims.BaseClaims.ID)
+	if !exists {
+		logger.Errorf("challengeUser.UserNotExist", claims.BaseClaims.ID)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	if !userDB.Active {
+		logger.Errorf("challengeUser.Active", userDB.ID)
+		c.JSON(http.StatusForbidden, model.ErrAccountDisabled)
+
+		return nil, nil, false
+	}
+
+	return userDB, claims, true
+}
+
+//nolint:varnamelen
+func (h *MFAHandler) currentUser(c *gin.Context) (*model.AuthUser, bool) {
+	userID, err := authmiddleware.CurrentUserID(c)
+	if err != nil {
+		logger.Errorf("currentUser.CurrentUserID", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	userDB, exists := h.api.postgresStore.Auth.Get(userID)
+	if !exists {
+		logger.Errorf("currentUser.UserNotExist", userID)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, false
+	}
+
+	return userDB, true
+}
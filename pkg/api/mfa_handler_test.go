@@ -0,0 +1,1018 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/bruteforce"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/authmiddleware/totp"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func testTOTPCode() string {
+	code, _ := totp.Code(testTOTPSecret, time.Now())
+
+	return code
+}
+
+var testMapMFAHandler = map[string][]model.TestStructure{
+	"Login": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     testTOTPCode(),
+			},
+			ExpectedData: &authmiddleware.Tokens{
+				Access:  "access_token",
+				Refresh: "refresh_token",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, AuthRepoUseTOTPStepMock,
+				MFAChallengeRepoUseMock, MiddlewareCreateTokensMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{
+					true,
+				},
+				{
+					&authmiddleware.Tokens{
+						Access:  "access_token",
+						Refresh: "refresh_token",
+					},
+				},
+			},
+		},
+		{
+			Name:   "PositiveRecoveryCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken:     "mfa-token",
+				RecoveryCode: "abcde-fghij",
+			},
+			ExpectedData: &authmiddleware.Tokens{
+				Access:  "access_token",
+				Refresh: "refresh_token",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, RecoveryCodeRepoUseMock,
+				MFAChallengeRepoUseMock, MiddlewareCreateTokensMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{
+					true,
+				},
+				{
+					&authmiddleware.Tokens{
+						Access:  "access_token",
+						Refresh: "refresh_token",
+					},
+				},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/login/2fa",
+			Data:         "{",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeEmptyCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeMiddlewareValidateMFATokenMock",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     "123456",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareValidateMFATokenMock),
+			MockData: [][]interface{}{
+				{
+					model.ErrUnauthorized,
+				},
+			},
+		},
+		{
+			Name:   "NegativeEnrollmentToken",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     "123456",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareValidateMFATokenMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+			},
+		},
+		{
+			Name:   "NegativeInvalidCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     "000000x",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidMFACode,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeRecoveryCodeUsed",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken:     "mfa-token",
+				RecoveryCode: "abcde-fghij",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidMFACode,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, RecoveryCodeRepoUseMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:   "NegativeReplayedCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     testTOTPCode(),
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidMFACode,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, AuthRepoUseTOTPStepMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:   "NegativeReplayedChallenge",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa",
+			Data: model.MFALogin{
+				MFAToken: "mfa-token",
+				Code:     testTOTPCode(),
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, AuthRepoUseTOTPStepMock,
+				MFAChallengeRepoUseMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+	},
+	"LoginEnroll": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/enroll",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+			},
+			ExpectedData: auth.MFAEnrollResponse{},
+			SkipFields:   []string{"secret", "otpauth_uri"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, MFAChallengeRepoUseMock,
+				AuthRepoSetTOTPMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+				{
+					&model.AuthUser{Username: "user", Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeAlreadyEnrolled",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/enroll",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+			},
+			PositiveTest: false, WhatError: model.ErrMFAEnrolled,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeChallengeUsed",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/enroll",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, MFAChallengeRepoUseMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, Active: true},
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:   "NegativeLoginToken",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/enroll",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(MiddlewareValidateMFATokenMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{},
+				},
+			},
+		},
+	},
+	"LoginConfirm": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/confirm",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+				Code:     testTOTPCode(),
+			},
+			ExpectedData: auth.MFAConfirmResponse{
+				Tokens: &authmiddleware.Tokens{
+					Access:  "access_token",
+					Refresh: "refresh_token",
+				},
+			},
+			SkipFields:   []string{"recovery_codes"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock, AuthRepoUseTOTPStepMock,
+				RecoveryCodeRepoReplaceMock, AuthRepoSetTOTPMock, MiddlewareCreateTokensMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{},
+				{},
+				{
+					&authmiddleware.Tokens{
+						Access:  "access_token",
+						Refresh: "refresh_token",
+					},
+				},
+			},
+		},
+		{
+			Name:   "NegativeNotEnrolled",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login/2fa/confirm",
+			Data: model.MFACode{
+				MFAToken: "mfa-token",
+				Code:     "123456",
+			},
+			PositiveTest: false, WhatError: model.ErrMFANotEnrolled,
+			Mock: makeList(MiddlewareValidateMFATokenMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&authmiddleware.MFAClaims{Enroll: true},
+				},
+				{
+					&model.AuthUser{Active: true},
+					true,
+				},
+			},
+		},
+	},
+	"Enroll": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/user/2fa/enroll",
+			ExpectedData: auth.MFAEnrollResponse{},
+			SkipFields:   []string{"secret", "otpauth_uri"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(AuthRepoGetMock, AuthRepoSetTOTPMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{Username: "user", Active: true},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeAlreadyEnrolled",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/user/2fa/enroll",
+			PositiveTest: false, WhatError: model.ErrMFAEnrolled,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, TOTPEnabled: true, Active: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoSetTOTPMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/user/2fa/enroll",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, AuthRepoSetTOTPMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{Username: "user", Active: true},
+					true,
+				},
+				{
+					model.ErrUnhealthy,
+				},
+			},
+		},
+	},
+	"Confirm": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/confirm",
+			Data: model.MFACode{
+				Code: testTOTPCode(),
+			},
+			ExpectedData: auth.MFAConfirmResponse{},
+			SkipFields:   []string{"recovery_codes"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(AuthRepoGetMock, AuthRepoUseTOTPStepMock, RecoveryCodeRepoReplaceMock, AuthRepoSetTOTPMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, Active: true},
+					true,
+				},
+				{
+					true,
+				},
+				{},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeInvalidCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/confirm",
+			Data: model.MFACode{
+				Code: "000000x",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidMFACode,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{TOTPSecret: testTOTPSecret, Active: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeEmptyCode",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/confirm",
+			Data: model.MFACode{
+				Code: " ",
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+	"Disable": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/disable",
+			Data: model.MFADisable{
+				Password: "password",
+				Code:     testTOTPCode(),
+			},
+			ExpectedData: auth.MFADisableResponse{
+				Status: "two-factor authentication disabled",
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock: makeList(AuthRepoGetMock, AuthRepoUseTOTPStepMock, MFAPolicyRepoIsRequiredMock, AuthRepoSetTOTPMock,
+				RecoveryCodeRepoReplaceMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						Active:      true,
+						Password:    hashPassword("password"),
+						TOTPSecret:  testTOTPSecret,
+						TOTPEnabled: true,
+					},
+					true,
+				},
+				{
+					true,
+				},
+				{
+					false,
+				},
+				{},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeRequiredForRole",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/disable",
+			Data: model.MFADisable{
+				Password: "password",
+				Code:     testTOTPCode(),
+			},
+			PositiveTest: false, WhatError: model.ErrMFARequired,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, AuthRepoUseTOTPStepMock, MFAPolicyRepoIsRequiredMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						Active:      true,
+						Password:    hashPassword("password"),
+						TOTPSecret:  testTOTPSecret,
+						TOTPEnabled: true,
+					},
+					true,
+				},
+				{
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeIncorrectPassword",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/disable",
+			Data: model.MFADisable{
+				Password: "password",
+				Code:     testTOTPCode(),
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidMFACode,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						Active:      true,
+						Password:    hashPassword("incorrect-password"),
+						TOTPSecret:  testTOTPSecret,
+						TOTPEnabled: true,
+					},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeTooManyAttempts",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/user/2fa/disable",
+			Data: model.MFADisable{
+				Password: "password",
+				Code:     testTOTPCode(),
+			},
+			PositiveTest: false, WhatError: model.ErrTooManyAttempts,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(AuthRepoGetMock, LoginAttemptLockMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{
+						Active:      true,
+						Username:    "locked",
+						Password:    hashPassword("password"),
+						TOTPSecret:  testTOTPSecret,
+						TOTPEnabled: true,
+					},
+					true,
+				},
+				{
+					bruteforce.UsernameKey("locked"),
+				},
+			},
+		},
+	},
+	"GetPolicies": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/2fa-policies",
+			ExpectedData: []model.MFAPolicy{
+				{Role: model.AdminUserRole, Required: true},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(MFAPolicyRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.MFAPolicy{
+						{Role: model.AdminUserRole, Required: true},
+					},
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/2fa-policies",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermMFAPoliciesUpdate},
+		},
+	},
+	"SetPolicy": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/2fa-policies",
+			Data: model.MFAPolicy{
+				Role:     model.AdminUserRole,
+				Required: true,
+			},
+			ExpectedData: model.MFAPolicy{
+				Role:     model.AdminUserRole,
+				Required: true,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock, MFAPolicyRepoSetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeEmptyRole",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/2fa-policies",
+			Data: model.MFAPolicy{
+				Required: true,
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeRoleRepoGetMock",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/2fa-policies",
+			Data: model.MFAPolicy{
+				Role:     "UNKNOWN",
+				Required: true,
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidRole,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/2fa-policies",
+			Data:         model.MFAPolicy{Role: model.AdminUserRole, Required: true},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermMFAPoliciesRead},
+		},
+		{
+			Name:   "NegativeMFAPolicyRepoSetMock",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/2fa-policies",
+			Data: model.MFAPolicy{
+				Role:     model.AdminUserRole,
+				Required: true,
+			},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoGetMock, MFAPolicyRepoSetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole},
+					true,
+				},
+				{
+					model.ErrUnhealthy,
+				},
+			},
+		},
+	},
+}
+
+func TestMFAHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	//all repos mock what need for tests
+	userAuthRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = userAuthRepo
+	repos = append(repos, userAuthRepo)
+
+	recoveryCodeRepo := mockpostgresstore.NewMockRecoveryCodeRepository(mockCtrl)
+	mockPostgresStore.RecoveryCode = recoveryCodeRepo
+	repos = append(repos, recoveryCodeRepo)
+
+	mfaPolicyRepo := mockpostgresstore.NewMockMFAPolicyRepository(mockCtrl)
+	mockPostgresStore.MFAPolicy = mfaPolicyRepo
+	repos = append(repos, mfaPolicyRepo)
+
+	mfaChallengeRepo := mockpostgresstore.NewMockMFAChallengeRepository(mockCtrl)
+	mockPostgresStore.MFAChallenge = mfaChallengeRepo
+	repos = append(repos, mfaChallengeRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	loginAttemptRepo := memorystore.NewLoginAttemptRepository()
+	mockPostgresStore.LoginAttempt = loginAttemptRepo
+	repos = append(repos, loginAttemptRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	runHandlerTests(t, testAPI, repos, testMapMFAHandler)
+}
+
+func MiddlewareCreateMFATokenMock(repos []interface{}, data []interface{}) {
+	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
+	var result string
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockauthmiddleware.MockAuthMiddleware:
+			middlewareMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case string:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	middlewareMock.EXPECT().CreateMFAToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(result, err).Times(1)
+}
+
+func MiddlewareValidateMFATokenMock(repos []interface{}, data []interface{}) {
+	var middlewareMock *mockauthmiddleware.MockAuthMiddleware
+	var result *authmiddleware.MFAClaims
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockauthmiddleware.MockAuthMiddleware:
+			middlewareMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case *authmiddleware.MFAClaims:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	middlewareMock.EXPECT().ValidateMFAToken(gomock.Any()).Return(result, err).Times(1)
+}
+
+func AuthRepoSetTOTPMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().SetTOTP(gomock.Any(), gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
+
+func AuthRepoUseTOTPStepMock(repos []interface{}, data []interface{}) {
+	var authMock *mockpostgresstore.MockAuthRepository
+	var result bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockAuthRepository:
+			authMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	authMock.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Return(result, err).Times(1)
+}
+
+func RecoveryCodeRepoUseMock(repos []interface{}, data []interface{}) {
+	var recoveryCodeMock *mockpostgresstore.MockRecoveryCodeRepository
+	var result bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRecoveryCodeRepository:
+			recoveryCodeMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	recoveryCodeMock.EXPECT().Use(gomock.Any(), gomock.Any()).Return(result, err).Times(1)
+}
+
+func RecoveryCodeRepoReplaceMock(repos []interface{}, data []interface{}) {
+	var recoveryCodeMock *mockpostgresstore.MockRecoveryCodeRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRecoveryCodeRepository:
+			recoveryCodeMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	recoveryCodeMock.EXPECT().Replace(gomock.Any(), gomock.Any()).Return(err).Times(1)
+}
+
+func MFAChallengeRepoUseMock(repos []interface{}, data []interface{}) {
+	var mfaChallengeMock *mockpostgresstore.MockMFAChallengeRepository
+	var result bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockMFAChallengeRepository:
+			mfaChallengeMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	mfaChallengeMock.EXPECT().Use(gomock.Any(), gomock.Any()).Return(result, err).Times(1)
+}
+
+func MFAPolicyRepoIsRequiredMock(repos []interface{}, data []interface{}) {
+	var mfaPolicyMock *mockpostgresstore.MockMFAPolicyRepository
+	var result bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockMFAPolicyRepository:
+			mfaPolicyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case bool:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	mfaPolicyMock.EXPECT().IsRequired(gomock.Any()).Return(result, err).Times(1)
+}
+
+func MFAPolicyRepoListMock(repos []interface{}, data []interface{}) {
+	var mfaPolicyMock *mockpostgresstore.MockMFAPolicyRepository
+	var result []model.MFAPolicy
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockMFAPolicyRepository:
+			mfaPolicyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.MFAPolicy:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	mfaPolicyMock.EXPECT().List().Return(result, err).Times(1)
+}
+
+func MFAPolicyRepoSetMock(repos []interface{}, data []interface{}) {
+	var mfaPolicyMock *mockpostgresstore.MockMFAPolicyRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockMFAPolicyRepository:
+			mfaPolicyMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	mfaPolicyMock.EXPECT().Set(gomock.Any()).Return(err).Times(1)
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/oidc"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// subjectSuffixLen is the length of the subject hash appended to a taken username.
+	subjectSuffixLen = 8
+	// oidcStateCookie binds the state to the browser that started the login.
+	oidcStateCookie = "__Host-oidc_state"
+)
+
+var (
+	errIdentityUser = errors.New("linked user does not exist")
+	errUnknownRole  = errors.New("mapped role does not exist")
+)
+
+type OIDCHandler struct {
+	api *api
+}
+
+func NewOIDCHandler(a *api) *OIDCHandler {
+	return &OIDCHandler{
+		api: a,
+	}
+}
+
+// Login
+// @Summary single sign-on with the identity provider
+// @Description redirects to the identity provider, which redirects back to /api/v1/oidc/callback,
+// @Description the state is also set in the __Host-oidc_state cookie that the callback checks
+// @Tags Auth
+// @Success 302
+// @Failure 500 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/oidc/login [get]
+//
+//nolint:varnamelen
+func (h *OIDCHandler) Login(c *gin.Context) {
+	state, err := oidc.NewLoginState()
+	if err != nil {
+		logger.Errorf("OIDCLogin.NewLoginState", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	authURL, err := h.api.OIDCProvider().AuthCodeURL(c.Request.Context(), state)
+	if err != nil {
+		logger.Errorf("OIDCLogin.AuthCodeURL", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.postgresStore.OIDCState.Create(&model.OIDCState{
+		StateHash:    oidc.HashState(state.State),
+		Nonce:        state.Nonce,
+		CodeVerifier: state.Verifier,
+		ExpiresAt:    time.Now().Add(h.api.config.OIDC.StateTTL.Duration),
+	})
+	if err != nil {
+		logger.Errorf("OIDCLogin.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	// Lax, the callback is a top-level redirect from the identity provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state.State, int(h.api.config.OIDC.StateTTL.Seconds()), "/", "", true, true)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback
+// @Summary finish single sign-on
+// @Description the user is found by the identity, linked by verified email or created,
+// @Description when OIDC_ROLE_MAPPING is set the role follows the identity provider groups,
+// @Description for users with two-factor authentication enabled auth.MFAChallengeResponse is returned instead of tokens
+// @Produce json
+// @Tags Auth
+// @Param code  query string  true "authorization code"
+// @Param state  query string  true "state"
+// @Success 200 {object} authmiddleware.Tokens
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 401 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/oidc/callback [get]
+//
+//nolint:varnamelen
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		logger.Errorf("OIDCCallback.error", providerError+" "+c.Query("error_description"))
+		c.JSON(http.StatusUnauthorized, model.ErrOIDCLogin)
+
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		logger.Errorf("OIDCCallback.Empty code or state", nil)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		logger.Errorf("OIDCCallback.Cookie", "state does not match the browser")
+		c.JSON(http.StatusUnauthorized, model.ErrOIDCLogin)
+
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", true, true)
+
+	stored, exists, err := h.api.postgresStore.OIDCState.Use(oidc.HashState(state))
+	if err != nil {
+		logger.Errorf("OIDCCallback.Use", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !exists {
+		logger.Errorf("OIDCCallback.Use", "unknown or expired state")
+		c.JSON(http.StatusUnauthorized, model.ErrOIDCLogin)
+
+		return
+	}
+
+	claims, err := h.api.OIDCProvider().Exchange(c.Request.Context(), code, &oidc.LoginState{
+		State:    state,
+		Nonce:    stored.Nonce,
+		Verifier: stored.CodeVerifier,
+	})
+	if err != nil {
+		logger.Errorf("OIDCCallback.Exchange", err)
+		c.JSON(http.StatusUnauthorized, model.ErrOIDCLogin)
+
+		return
+	}
+
+	userDB, err := h.user(claims)
+	if err != nil {
+		logger.Errorf("OIDCCallback.user", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if userDB.ServiceAccount {
+		logger.Errorf("OIDCCallback.ServiceAccount", userDB.ID)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	if !userDB.Active {
+		logger.Errorf("OIDCCallback.Active", userDB.ID)
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Failed(model.ReasonAccountDisabled))
+		c.JSON(http.StatusForbidden, model.ErrAccountDisabled)
+
+		return
+	}
+
+	err = h.syncRole(userDB, claims)
+	if err != nil {
+		logger.Errorf("OIDCCallback.syncRole", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	// a local second factor, e.g. of an account linked by email, is still asked for;
+	// requiring one for the role is left to the identity provider
+	if userDB.TOTPEnabled {
+		challenge, err := h.api.MFA().challenge(userDB)
+		if err != nil {
+			logger.Errorf("OIDCCallback.challenge", err)
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, challenge)
+
+		return
+	}
+
+	tokens, err := h.api.auth.CreateTokens(userDB.ID, userDB.Role, authmiddleware.ClientFromContext(c))
+	if err != nil {
+		logger.Errorf("OIDCCallback.CreateTokens", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventLogin, userDB).Succeeded("oidc"))
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// user returns the user linked to the identity. An identity seen for the first
+// time is linked to the user with the same verified email, otherwise a new user
+// is created for it.
+func (h *OIDCHandler) user(claims *oidc.Claims) (*model.AuthUser, error) {
+	identity, exists := h.api.postgresStore.Identity.Get(claims.Issuer, claims.Subject)
+	if exists {
+		userDB, exists := h.api.postgresStore.Auth.Get(identity.UserID)
+		if !exists {
+			return nil, errIdentityUser
+		}
+
+		return userDB, nil
+	}
+
+	identity = &model.AuthIdentity{Issuer: claims.Issuer, Subject: claims.Subject}
+
+	if claims.EmailVerified && claims.Email != "" {
+		if userDB, exists := h.api.postgresStore.Auth.GetByEmail(claims.Email); exists {
+			identity.UserID = userDB.ID
+
+			return userDB, h.api.postgresStore.Identity.Link(identity)
+		}
+	}
+
+	role, err := h.role(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := h.username(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.AuthUser{
+		Username: username,
+		Role:     role,
+		Active:   true,
+	}
+
+	if claims.EmailVerified && claims.Email != "" {
+		email := strings.ToLower(claims.Email)
+		user.Email = &email
+	}
+
+	profile := &model.User{Name: claims.GivenName, Surname: claims.FamilyName}
+
+	err = h.api.postgresStore.Identity.Provision(user, profile, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// syncRole makes the identity provider the source of truth for the role when
+// a role mapping is configured.
+func (h *OIDCHandler) syncRole(user *model.AuthUser, claims *oidc.Claims) error {
+	if !h.api.OIDCProvider().MapsRoles() {
+		return nil
+	}
+
+	role, err := h.role(claims)
+	if err != nil || role == user.Role {
+		return err
+	}
+
+	err = h.api.postgresStore.Auth.SetRole(user.ID, role)
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+
+	return nil
+}
+
+func (h *OIDCHandler) role(claims *oidc.Claims) (model.UserRole, error) {
+	role := h.api.OIDCProvider().Role(claims.Groups)
+	if _, exists := h.api.postgresStore.Role.Get(role); !exists {
+		return "", fmt.Errorf("%w: %s", errUnknownRole, role)
+	}
+
+	return role, nil
+}
+
+// username is the first free of the preferred username, the email and the
+// preferred username suffixed with a hash of the subject.
+func (h *OIDCHandler) username(claims *oidc.Claims) (string, error) {
+	sum := sha256.Sum256([]byte(claims.Issuer + " " + claims.Subject))
+	suffix := hex.EncodeToString(sum[:])[:subjectSuffixLen]
+
+	base := claims.PreferredUsername
+	if base == "" {
+		base = "oidc"
+	}
+
+	for _, candidate := range []string{claims.PreferredUsername, claims.Email, base + "-" + suffix} {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+
+		userDB, err := h.api.postgresStore.Auth.GetByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+
+		if userDB.ID == uuid.Nil {
+			return candidate, nil
+		}
+	}
+
+	return "", model.ErrUsenameExist
+}
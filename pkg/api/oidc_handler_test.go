@@ -0,0 +1,330 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/authmiddleware/oidc/oidctest"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type oidcTestRepos struct {
+	auth       *mockpostgresstore.MockAuthRepository
+	role       *mockpostgresstore.MockRoleRepository
+	identity   *mockpostgresstore.MockIdentityRepository
+	oidcState  *mockpostgresstore.MockOIDCStateRepository
+	middleware *mockauthmiddleware.MockAuthMiddleware
+}
+
+func initOIDCTestAPI(t *testing.T, server *oidctest.Server) (*api, *oidcTestRepos) {
+	t.Helper()
+
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	repos := &oidcTestRepos{
+		auth:       mockpostgresstore.NewMockAuthRepository(mockCtrl),
+		role:       mockpostgresstore.NewMockRoleRepository(mockCtrl),
+		identity:   mockpostgresstore.NewMockIdentityRepository(mockCtrl),
+		oidcState:  mockpostgresstore.NewMockOIDCStateRepository(mockCtrl),
+		middleware: mockauthmiddleware.NewMockAuthMiddleware(mockCtrl),
+	}
+
+	conf := testConfig()
+	conf.OIDC = config.OIDCConfig{
+		Issuer:       server.URL,
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost:8000/api/v1/oidc/callback",
+		Scopes:       "openid profile email",
+		GroupsClaim:  "groups",
+		RoleMapping:  "crm-admins=ADMIN",
+		DefaultRole:  "BASE",
+		StateTTL:     config.Duration{Duration: time.Minute},
+	}
+
+	testAPI := initTestAPIWithConfig(t, repos.middleware, &store.Store{
+		Auth:      repos.auth,
+		Role:      repos.role,
+		Identity:  repos.identity,
+		OIDCState: repos.oidcState,
+		AuthEvent: memorystore.NewAuthEventRepository(),
+	}, conf)
+
+	repos.role.EXPECT().Get(gomock.Any()).Return(&model.Role{}, true).AnyTimes()
+
+	return testAPI, repos
+}
+
+// oidcLogin runs the whole flow: login redirect, the user logging in at the
+// provider with claims and the callback.
+func oidcLogin(t *testing.T, testAPI *api, repos *oidcTestRepos, server *oidctest.Server, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var stored *model.OIDCState
+
+	repos.oidcState.EXPECT().Use(gomock.Any()).DoAndReturn(func(stateHash string) (*model.OIDCState, bool, error) {
+		if stored == nil || stored.StateHash != stateHash {
+			return nil, false, nil
+		}
+
+		return stored, true, nil
+	}).Times(1)
+
+	code, state, cookies := oidcAuthorize(t, testAPI, repos, server, claims, &stored)
+
+	return oidcCallback(testAPI, code, state, cookies)
+}
+
+// oidcAuthorize starts the login and logs in at the provider, returning the
+// code, the state and the cookies set by the login.
+func oidcAuthorize(
+	t *testing.T,
+	testAPI *api,
+	repos *oidcTestRepos,
+	server *oidctest.Server,
+	claims jwt.MapClaims,
+	stored **model.OIDCState,
+) (string, string, []*http.Cookie) {
+	t.Helper()
+
+	repos.oidcState.EXPECT().Create(gomock.Any()).DoAndReturn(func(state *model.OIDCState) error {
+		*stored = state
+
+		return nil
+	}).Times(1)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil)
+	testAPI.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusFound, rr.Code)
+
+	code, state, err := server.Authorize(rr.Header().Get("Location"), claims)
+	require.NoError(t, err)
+
+	return code, state, rr.Result().Cookies()
+}
+
+func oidcCallback(testAPI *api, code, state string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?"+url.Values{
+		"code":  {code},
+		"state": {state},
+	}.Encode(), nil)
+
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	testAPI.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestOIDCHandlers(t *testing.T) {
+	server := oidctest.NewServer("crm", "secret")
+	defer server.Close()
+
+	tokens := &authmiddleware.Tokens{Access: "access", Refresh: "refresh"}
+	userID := uuid.NewV4()
+
+	t.Run("ProvisionNewUser", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		repos.identity.EXPECT().Get(server.URL, "subject-1").Return(nil, false)
+		repos.auth.EXPECT().GetByEmail("jane@example.com").Return(nil, false)
+		repos.auth.EXPECT().GetByUsername("jane").Return(&model.AuthUser{ID: uuid.NewV4()}, nil)
+		repos.auth.EXPECT().GetByUsername("jane@example.com").Return(&model.AuthUser{}, nil)
+		repos.identity.EXPECT().Provision(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(user *model.AuthUser, profile *model.User, identity *model.AuthIdentity) error {
+				assert.Equal(t, "jane@example.com", user.Username)
+				assert.Equal(t, "jane@example.com", *user.Email)
+				assert.Equal(t, model.AdminUserRole, user.Role)
+				assert.Equal(t, "Jane", profile.Name)
+				assert.Equal(t, "subject-1", identity.Subject)
+				user.ID = userID
+
+				return nil
+			})
+		repos.middleware.EXPECT().CreateTokens(userID, model.AdminUserRole, gomock.Any()).Return(tokens, nil)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{
+			"sub":                "subject-1",
+			"email":              "jane@example.com",
+			"email_verified":     true,
+			"preferred_username": "jane",
+			"given_name":         "Jane",
+			"groups":             []string{"crm-admins"},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"accessToken":"access","refreshToken":"refresh"}`, rr.Body.String())
+	})
+
+	t.Run("LinkByVerifiedEmail", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		existing := &model.AuthUser{ID: userID, Role: model.BaseUserRole, Active: true}
+
+		repos.identity.EXPECT().Get(server.URL, "subject-2").Return(nil, false)
+		repos.auth.EXPECT().GetByEmail("john@example.com").Return(existing, true)
+		repos.identity.EXPECT().Link(&model.AuthIdentity{Issuer: server.URL, Subject: "subject-2", UserID: userID}).Return(nil)
+		repos.auth.EXPECT().SetRole(userID, model.AdminUserRole).Return(nil)
+		repos.middleware.EXPECT().CreateTokens(userID, model.AdminUserRole, gomock.Any()).Return(tokens, nil)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{
+			"sub":            "subject-2",
+			"email":          "john@example.com",
+			"email_verified": "true",
+			"groups":         []string{"crm-admins"},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("LinkByVerifiedEmailAsksForTOTP", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		existing := &model.AuthUser{ID: userID, Role: model.BaseUserRole, Active: true, TOTPEnabled: true}
+
+		repos.identity.EXPECT().Get(server.URL, "subject-6").Return(nil, false)
+		repos.auth.EXPECT().GetByEmail("totp@example.com").Return(existing, true)
+		repos.identity.EXPECT().Link(gomock.Any()).Return(nil)
+		repos.auth.EXPECT().SetRole(userID, model.AdminUserRole).Return(nil)
+		repos.middleware.EXPECT().CreateMFAToken(userID, model.AdminUserRole, false).Return("mfa", nil)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{
+			"sub":            "subject-6",
+			"email":          "totp@example.com",
+			"email_verified": true,
+			"groups":         []string{"crm-admins"},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"mfa_required":true,"enrollment_required":false,"mfa_token":"mfa"}`, rr.Body.String())
+	})
+
+	t.Run("LinkedIdentityLosesRole", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		repos.identity.EXPECT().Get(server.URL, "subject-3").Return(&model.AuthIdentity{UserID: userID}, true)
+		repos.auth.EXPECT().Get(userID).Return(&model.AuthUser{ID: userID, Role: model.AdminUserRole, Active: true}, true)
+		repos.auth.EXPECT().SetRole(userID, model.BaseUserRole).Return(nil)
+		repos.middleware.EXPECT().CreateTokens(userID, model.BaseUserRole, gomock.Any()).Return(tokens, nil)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{"sub": "subject-3"})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("NegativeDeactivated", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		repos.identity.EXPECT().Get(server.URL, "subject-4").Return(&model.AuthIdentity{UserID: userID}, true)
+		repos.auth.EXPECT().Get(userID).Return(&model.AuthUser{ID: userID, Role: model.BaseUserRole}, true)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{"sub": "subject-4"})
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assertErrorBody(t, model.ErrAccountDisabled, rr)
+	})
+
+	t.Run("NegativeUnverifiedEmailNotLinked", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		repos.identity.EXPECT().Get(server.URL, "subject-5").Return(nil, false)
+		repos.auth.EXPECT().GetByUsername("admin@example.com").Return(&model.AuthUser{}, nil)
+		repos.identity.EXPECT().Provision(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(user *model.AuthUser, _ *model.User, _ *model.AuthIdentity) error {
+				assert.Nil(t, user.Email)
+				user.ID = userID
+
+				return nil
+			})
+		repos.middleware.EXPECT().CreateTokens(userID, model.BaseUserRole, gomock.Any()).Return(tokens, nil)
+
+		rr := oidcLogin(t, testAPI, repos, server, jwt.MapClaims{
+			"sub":            "subject-5",
+			"email":          "admin@example.com",
+			"email_verified": false,
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("NegativeUnknownState", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		repos.oidcState.EXPECT().Use(gomock.Any()).Return(nil, false, nil)
+
+		rr := oidcCallback(testAPI, "code", "forged", []*http.Cookie{{Name: oidcStateCookie, Value: "forged"}})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assertErrorBody(t, model.ErrOIDCLogin, rr)
+	})
+
+	t.Run("NegativeStateFromAnotherBrowser", func(t *testing.T) {
+		testAPI, repos := initOIDCTestAPI(t, server)
+
+		var stored *model.OIDCState
+
+		code, state, cookies := oidcAuthorize(t, testAPI, repos, server, jwt.MapClaims{"sub": "subject-7"}, &stored)
+		require.Len(t, cookies, 1)
+		assert.Equal(t, oidcStateCookie, cookies[0].Name)
+		assert.True(t, cookies[0].Secure)
+		assert.True(t, cookies[0].HttpOnly)
+
+		rr := oidcCallback(testAPI, code, state, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assertErrorBody(t, model.ErrOIDCLogin, rr)
+
+		rr = oidcCallback(testAPI, code, state, []*http.Cookie{{Name: oidcStateCookie, Value: "other"}})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("NegativeProviderError", func(t *testing.T) {
+		testAPI, _ := initOIDCTestAPI(t, server)
+
+		rr := httptest.NewRecorder()
+		testAPI.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?error=access_denied", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		testAPI := initTestAPI(t, mockauthmiddleware.NewMockAuthMiddleware(mockCtrl), &store.Store{})
+
+		rr := httptest.NewRecorder()
+		testAPI.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/oidc/login", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func assertErrorBody(t *testing.T, expected model.Error, rr *httptest.ResponseRecorder) {
+	t.Helper()
+
+	body, err := json.Marshal(expected)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(body), rr.Body.String())
+}
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/bruteforce"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	api *api
+}
+
+func NewPasswordHandler(a *api) *PasswordHandler {
+	return &PasswordHandler{
+		api: a,
+	}
+}
+
+// Forgot
+// @Summary email a password reset link
+// @Description the response is the same whether the email is registered or not, requests are limited per email and IP
+// @Produce json
+// @Tags Auth
+// @Param ForgotPassword  body model.ForgotPassword  true "Email"
+// @Success 200 {object} auth.PasswordResetResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many requests, see Retry-After"
+// @Router /api/v1/password/forgot [post]
+//
+//nolint:varnamelen
+func (h *PasswordHandler) Forgot(c *gin.Context) {
+	forgot := &model.ForgotPassword{}
+	err := c.ShouldBindJSON(&forgot)
+	if err != nil {
+		logger.Errorf("Forgot.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !forgot.IsValid() {
+		logger.Errorf("Forgot.Empty email", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	keys := bruteforce.ResetKeys(forgot.Email, c.ClientIP())
+	if !h.api.checkAttempts(c, keys...) {
+		return
+	}
+
+	h.api.failAttempt(c, keys...)
+
+	email := forgot.Email
+	h.api.inBackground(func() { h.sendResetToken(email) })
+
+	c.JSON(http.StatusOK, auth.PasswordResetResponse{Status: "if the email is registered, a reset link has been sent"})
+}
+
+// Reset
+// @Summary set a new password with the emailed reset token
+// @Description every session of the user is revoked
+// @Produce json
+// @Tags Auth
+// @Param ResetPassword  body model.ResetPassword  true "Reset Password"
+// @Success 200 {object} auth.PasswordResetResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation "invalid body or token, or the password breaks the policy"
+// @Failure 403 {object} errors.UIResponseErrorBadRequest "the password is managed by the directory or identity provider"
+// @Router /api/v1/password/reset [post]
+//
+//nolint:varnamelen
+func (h *PasswordHandler) Reset(c *gin.Context) {
+	reset := &model.ResetPassword{}
+	err := c.ShouldBindJSON(&reset)
+	if err != nil {
+		logger.Errorf("Reset.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !reset.IsValid() {
+		logger.Errorf("Reset.Empty token or pass", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	tokenHash := authmiddleware.HashEmailToken(reset.Token)
+
+	// the token is only used once the new password is accepted, so a rejected one can be retried
+	userID, ok := h.api.postgresStore.PasswordReset.Get(tokenHash)
+	if !ok {
+		logger.Errorf("Reset.Get", "invalid token")
+		h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, nil).Failed(model.ReasonInvalidToken))
+		c.JSON(http.StatusBadRequest, model.ErrInvalidResetToken)
+
+		return
+	}
+
+	userDB, exists := h.api.postgresStore.Auth.Get(userID)
+	if !exists {
+		logger.Errorf("Reset.Auth.Get", userID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidResetToken)
+
+		return
+	}
+
+	if !h.checkLocalPassword(c, userDB) {
+		return
+	}
+
+	if !h.api.checkPassword(c, "new_password", reset.NewPassword, userDB) {
+		return
+	}
+
+	hash, err := authmiddleware.CreateHashPassword(reset.NewPassword)
+	if err != nil {
+		logger.Errorf("Reset.CreateHashPassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	_, ok, err = h.api.postgresStore.PasswordReset.Use(tokenHash)
+	if err != nil {
+		logger.Errorf("Reset.Use", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if !ok {
+		logger.Errorf("Reset.Use", "invalid token")
+		c.JSON(http.StatusBadRequest, model.ErrInvalidResetToken)
+
+		return
+	}
+
+	err = h.api.postgresStore.Auth.ChangePassword(userID, hash, h.api.PasswordPolicy().History())
+	if err != nil {
+		logger.Errorf("Reset.ChangePassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.auth.LogoutAll(userID)
+	if err != nil {
+		logger.Errorf("Reset.LogoutAll", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	// the user proved access to the mailbox, so a lockout by failed logins is lifted
+	h.api.resetAttempts(userDB.Username)
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, userDB).Succeeded("reset"))
+
+	c.JSON(http.StatusOK, auth.PasswordResetResponse{Status: "password changed"})
+}
+
+// ChangeExpired
+// @Summary replace an expired password
+// @Description login answers 403 once the password is older than PASSWORD_MAX_AGE, the new password is set here with the old one
+// @Produce json
+// @Tags Auth
+// @Param ExpiredPasswordChange  body model.ExpiredPasswordChange  true "Expired Password Change"
+// @Success 200 {object} auth.PasswordResetResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation "invalid body or the password breaks the policy"
+// @Failure 403 {object} errors.UIResponseErrorBadRequest "the password is managed by the directory or identity provider"
+// @Failure 429 {object} errors.UIResponseErrorBadRequest "too many failed attempts, see Retry-After"
+// @Router /api/v1/password/expired [post]
+//
+//nolint:varnamelen
+func (h *PasswordHandler) ChangeExpired(c *gin.Context) {
+	change := &model.ExpiredPasswordChange{}
+	err := c.ShouldBindJSON(&change)
+	if err != nil {
+		logger.Errorf("ChangeExpired.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !change.IsValid() {
+		logger.Errorf("ChangeExpired.Empty username or pass", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	keys := attemptKeys(c, change.Username)
+	if !h.api.checkAttempts(c, keys...) {
+		return
+	}
+
+	userDB, err := h.api.Authenticator().Authenticate(change.Username, change.OldPassword)
+	if err != nil {
+		logger.Errorf("ChangeExpired.Authenticate", err)
+		if errors.Is(err, authmiddleware.ErrUnknownUser) || errors.Is(err, authmiddleware.ErrInvalidCredentials) {
+			h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, &model.AuthUser{Username: change.Username}).
+				Failed(model.ReasonInvalidCredentials))
+			h.api.failAttempt(c, keys...)
+			c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+		} else {
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+		}
+
+		return
+	}
+
+	h.api.resetAttempts(userDB.Username)
+
+	if !userDB.Active {
+		logger.Errorf("ChangeExpired.Active", userDB.ID)
+		c.JSON(http.StatusForbidden, model.ErrAccountDisabled)
+
+		return
+	}
+
+	// without a login the second factor is never asked, so only expired passwords are changed here
+	if !h.api.PasswordPolicy().Expired(userDB) {
+		logger.Errorf("ChangeExpired.Expired", userDB.ID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
+	}
+
+	if !h.checkLocalPassword(c, userDB) {
+		return
+	}
+
+	if !h.api.checkPassword(c, "new_password", change.NewPassword, userDB) {
+		return
+	}
+
+	hash, err := authmiddleware.CreateHashPassword(change.NewPassword)
+	if err != nil {
+		logger.Errorf("ChangeExpired.CreateHashPassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	err = h.api.postgresStore.Auth.ChangePassword(userDB.ID, hash, h.api.PasswordPolicy().History())
+	if err != nil {
+		logger.Errorf("ChangeExpired.ChangePassword", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.api.authEvent(c, model.NewAuthEvent(model.AuthEventPasswordChange, userDB).Succeeded("expired"))
+
+	c.JSON(http.StatusOK, auth.PasswordResetResponse{Status: "password changed"})
+}
+
+// checkLocalPassword refuses users linked to a directory entry or an identity
+// provider, their password is set there.
+//
+//nolint:varnamelen
+func (h *PasswordHandler) checkLocalPassword(c *gin.Context, user *model.AuthUser) bool {
+	linked, err := h.api.postgresStore.Identity.IsLinked(user.ID)
+	if err != nil {
+		logger.Errorf("checkLocalPassword.IsLinked", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if linked {
+		logger.Errorf("checkLocalPassword.IsLinked", user.ID)
+		c.JSON(http.StatusForbidden, model.ErrExternalPassword)
+
+		return false
+	}
+
+	return true
+}
+
+// sendResetToken mails a new token when the email is registered to a user that
+// is not linked to a directory or identity provider. It runs in the background,
+// so neither the response nor its time tell whether the email is registered,
+// and errors are only logged for the same reason.
+func (h *PasswordHandler) sendResetToken(email string) {
+	user, exists := h.api.postgresStore.Auth.GetByEmail(email)
+	if !exists {
+		return
+	}
+
+	linked, err := h.api.postgresStore.Identity.IsLinked(user.ID)
+	if err != nil || linked {
+		logger.Errorf("sendResetToken.IsLinked", user.ID)
+
+		return
+	}
+
+	token, hash, err := authmiddleware.GenerateEmailToken()
+	if err != nil {
+		logger.Errorf("sendResetToken.GenerateEmailToken", err)
+
+		return
+	}
+
+	ttl := h.api.config.Password.ResetTokenTTL.Duration
+	err = h.api.postgresStore.PasswordReset.Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		logger.Errorf("sendResetToken.Create", err)
+
+		return
+	}
+
+	link, err := url.Parse(h.api.config.Password.ResetURL)
+	if err != nil {
+		logger.Errorf("sendResetToken.Parse", err)
+
+		return
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg := mailer.Message{
+		To:      *user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\nopen the link below to set a new password, it is valid for %d minutes.\n\n%s\n\n"+
+			"If you did not ask to reset your password, ignore this email.\n",
+			user.Username, int(ttl.Minutes()), link),
+	}
+
+	err = h.api.mailer.Send(msg)
+	if err != nil {
+		logger.Errorf("sendResetToken.Send", err)
+	}
+}
@@ -0,0 +1,462 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var resetUserID = uuid.NewV4()
+
+var testMapPasswordHandler = map[string][]model.TestStructure{
+	"Forgot": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         model.ForgotPassword{Email: testEmail},
+			ExpectedData: auth.PasswordResetResponse{Status: "if the email is registered, a reset link has been sent"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByEmailMock, IdentityRepoIsLinkedMock, PasswordResetRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: uuid.NewV4(), Username: "user", Email: &testEmail},
+					true,
+				},
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "PositiveUnknownEmail",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         model.ForgotPassword{Email: "unknown@example.com"},
+			ExpectedData: auth.PasswordResetResponse{Status: "if the email is registered, a reset link has been sent"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByEmailMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "PositivePasswordResetRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         model.ForgotPassword{Email: testEmail},
+			ExpectedData: auth.PasswordResetResponse{Status: "if the email is registered, a reset link has been sent"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByEmailMock, IdentityRepoIsLinkedMock, PasswordResetRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: uuid.NewV4(), Username: "user", Email: &testEmail},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "PositiveLinkedUser",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         model.ForgotPassword{Email: testEmail},
+			ExpectedData: auth.PasswordResetResponse{Status: "if the email is registered, a reset link has been sent"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByEmailMock, IdentityRepoIsLinkedMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: uuid.NewV4(), Username: "user", Email: &testEmail},
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeEmailEmpty",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/forgot",
+			Data:         model.ForgotPassword{Email: " "},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+	"Reset": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			ExpectedData: auth.PasswordResetResponse{Status: "password changed"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock, PasswordResetRepoUseMock,
+				AuthRepoChangePasswordMock, MiddlewareLogoutAllMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					resetUserID,
+					true,
+				},
+				{},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeTokenAndPasswordEmpty",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidToken",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrInvalidResetToken,
+			Mock: makeList(PasswordResetRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeLinkedUser",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrExternalPassword,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeIdentityRepoIsLinkedMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeTokenUsedConcurrently",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrInvalidResetToken,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock, PasswordResetRepoUseMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativePasswordResetRepoUseMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock, PasswordResetRepoUseMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeAuthRepoChangePasswordMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock, PasswordResetRepoUseMock,
+				AuthRepoChangePasswordMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					resetUserID,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeMiddlewareLogoutAllMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/reset",
+			Data:         model.ResetPassword{Token: "token", NewPassword: "new-pass"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(PasswordResetRepoGetMock, AuthRepoGetMock, IdentityRepoIsLinkedMock, PasswordResetRepoUseMock,
+				AuthRepoChangePasswordMock, MiddlewareLogoutAllMock),
+			MockData: [][]interface{}{
+				{
+					resetUserID,
+					true,
+				},
+				{
+					&model.AuthUser{ID: resetUserID, Username: "user"},
+					true,
+				},
+				{
+					false,
+				},
+				{
+					resetUserID,
+					true,
+				},
+				{},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestPasswordHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	//all repos mock what need for tests
+	userAuthRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = userAuthRepo
+	repos = append(repos, userAuthRepo)
+
+	passwordResetRepo := mockpostgresstore.NewMockPasswordResetRepository(mockCtrl)
+	mockPostgresStore.PasswordReset = passwordResetRepo
+	repos = append(repos, passwordResetRepo)
+
+	identityRepo := mockpostgresstore.NewMockIdentityRepository(mockCtrl)
+	mockPostgresStore.Identity = identityRepo
+	repos = append(repos, identityRepo)
+
+	loginAttemptRepo := memorystore.NewLoginAttemptRepository()
+	mockPostgresStore.LoginAttempt = loginAttemptRepo
+	repos = append(repos, loginAttemptRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	runHandlerTests(t, testAPI, repos, testMapPasswordHandler)
+}
+
+func IdentityRepoIsLinkedMock(repos []interface{}, data []interface{}) {
+	var identityMock *mockpostgresstore.MockIdentityRepository
+	var linked bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockIdentityRepository:
+			identityMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			linked = t
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	identityMock.EXPECT().IsLinked(gomock.Any()).Return(linked, err).Times(1)
+}
+
+func PasswordResetRepoCreateMock(repos []interface{}, data []interface{}) {
+	var passwordResetMock *mockpostgresstore.MockPasswordResetRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPasswordResetRepository:
+			passwordResetMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	passwordResetMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func PasswordResetRepoGetMock(repos []interface{}, data []interface{}) {
+	var passwordResetMock *mockpostgresstore.MockPasswordResetRepository
+	var result uuid.UUID
+	var ok bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPasswordResetRepository:
+			passwordResetMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case uuid.UUID:
+			result = t
+		case bool:
+			ok = t
+		default:
+			continue
+		}
+	}
+
+	passwordResetMock.EXPECT().Get(gomock.Any()).Return(result, ok).Times(1)
+}
+
+func PasswordResetRepoUseMock(repos []interface{}, data []interface{}) {
+	var passwordResetMock *mockpostgresstore.MockPasswordResetRepository
+	var result uuid.UUID
+	var ok bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPasswordResetRepository:
+			passwordResetMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case uuid.UUID:
+			result = t
+		case bool:
+			ok = t
+		default:
+			continue
+		}
+	}
+
+	passwordResetMock.EXPECT().Use(gomock.Any()).Return(result, ok, err).Times(1)
+}
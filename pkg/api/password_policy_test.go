@@ -0,0 +1,239 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/authmiddleware/passwordpolicy"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/memorystore"
+	"crm-system/pkg/store/mockpostgresstore"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	policyUserID = uuid.NewV4()
+	expiredUser  = model.AuthUser{
+		ID:                policyUserID,
+		Username:          "user",
+		Password:          hashPassword("Old-password-1"),
+		Role:              model.BaseUserRole,
+		Active:            true,
+		PasswordChangedAt: time.Now().AddDate(0, 0, -100),
+	}
+	freshUser = model.AuthUser{
+		ID:                policyUserID,
+		Username:          "user",
+		Password:          expiredUser.Password,
+		Role:              model.BaseUserRole,
+		Active:            true,
+		PasswordChangedAt: time.Now(),
+	}
+)
+
+var testMapPasswordPolicy = map[string][]model.TestStructure{
+	"Register": {
+		{
+			Name:   "NegativeWeakPassword",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/registration",
+			Data: model.AuthUser{
+				Username: "admin",
+				Password: "admin1",
+				Role:     model.BaseUserRole,
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "password", Rule: passwordpolicy.RuleMinLength, Message: "must be at least 12 characters long"},
+				{Field: "password", Rule: "upper", Message: "must contain an uppercase letter"},
+				{Field: "password", Rule: passwordpolicy.RuleUsername, Message: "must not contain the username"},
+			}),
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+			},
+		},
+	},
+	"Login": {
+		{
+			Name:   "NegativeExpired",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/login",
+			Data: model.AuthUser{
+				Username: "user",
+				Password: "Old-password-1",
+			},
+			PositiveTest: false, WhatError: model.ErrPasswordExpired,
+			Mock: makeList(AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&expiredUser,
+				},
+			},
+		},
+	},
+	"ChangeExpired": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/password/expired",
+			Data: model.ExpiredPasswordChange{
+				Username:    "user",
+				OldPassword: "Old-password-1",
+				NewPassword: "New-password-2",
+			},
+			ExpectedData: auth.PasswordResetResponse{Status: "password changed"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetByUsernameMock, IdentityRepoIsLinkedMock, AuthRepoChangePasswordMock),
+			MockData: [][]interface{}{
+				{
+					&expiredUser,
+				},
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeCurrentPasswordReused",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/password/expired",
+			Data: model.ExpiredPasswordChange{
+				Username:    "user",
+				OldPassword: "Old-password-1",
+				NewPassword: "Old-password-1",
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "new_password", Rule: passwordpolicy.RuleHistory, Message: "must differ from the current password"},
+			}),
+			Mock: makeList(AuthRepoGetByUsernameMock, IdentityRepoIsLinkedMock),
+			MockData: [][]interface{}{
+				{
+					&expiredUser,
+				},
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:   "NegativeLinkedUser",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/password/expired",
+			Data: model.ExpiredPasswordChange{
+				Username:    "user",
+				OldPassword: "Old-password-1",
+				NewPassword: "New-password-2",
+			},
+			PositiveTest: false, WhatError: model.ErrExternalPassword,
+			Mock: makeList(AuthRepoGetByUsernameMock, IdentityRepoIsLinkedMock),
+			MockData: [][]interface{}{
+				{
+					&expiredUser,
+				},
+				{
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeNotExpired",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/password/expired",
+			Data: model.ExpiredPasswordChange{
+				Username:    "user",
+				OldPassword: "Old-password-1",
+				NewPassword: "New-password-2",
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Mock: makeList(AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&freshUser,
+				},
+			},
+		},
+		{
+			Name:   "NegativeWrongPassword",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/password/expired",
+			Data: model.ExpiredPasswordChange{
+				Username:    "user",
+				OldPassword: "Wrong-password-1",
+				NewPassword: "New-password-2",
+			},
+			PositiveTest: false, WhatError: model.ErrUnauthorized,
+			Mock: makeList(AuthRepoGetByUsernameMock),
+			MockData: [][]interface{}{
+				{
+					&expiredUser,
+				},
+			},
+		},
+		{
+			Name:         "NegativeEmpty",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/password/expired",
+			Data:         model.ExpiredPasswordChange{Username: "user"},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+}
+
+func TestPasswordPolicyHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	conf := testConfig()
+	conf.PasswordPolicy = config.PasswordPolicyConfig{
+		MinLength:        12,
+		CharacterClasses: []string{"lower", "upper", "digit"},
+		RejectUsername:   true,
+		History:          1,
+		MaxAge:           config.Duration{Duration: 90 * 24 * time.Hour},
+	}
+
+	//all repos mock what need for tests
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	identityRepo := mockpostgresstore.NewMockIdentityRepository(mockCtrl)
+	mockPostgresStore.Identity = identityRepo
+	repos = append(repos, identityRepo)
+
+	loginAttemptRepo := memorystore.NewLoginAttemptRepository()
+	mockPostgresStore.LoginAttempt = loginAttemptRepo
+	repos = append(repos, loginAttemptRepo)
+
+	authEventRepo := memorystore.NewAuthEventRepository()
+	mockPostgresStore.AuthEvent = authEventRepo
+	repos = append(repos, authEventRepo)
+
+	testAPI := initTestAPIWithConfig(t, mockAuthMiddleware, mockPostgresStore, conf)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	runHandlerTests(t, testAPI, repos, testMapPasswordPolicy)
+}
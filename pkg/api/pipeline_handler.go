@@ -0,0 +1,359 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type PipelineHandler struct {
+	api *api
+}
+
+func NewPipelineHandler(a *api) *PipelineHandler {
+	return &PipelineHandler{
+		api: a,
+	}
+}
+
+// List
+// @Summary list pipelines with their stages
+// @Description pipelines are visible to every user
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Success 200 {object} crm.PipelineListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/pipelines [get]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) List(c *gin.Context) {
+	pipelines, err := h.api.postgresStore.Pipeline.List()
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.PipelineListResponse{Pipelines: pipelines})
+}
+
+// Get
+// @Summary get a pipeline with its stages
+// @Description pipelines are visible to every user
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Param id  path string  true "Pipeline ID"
+// @Success 200 {object} model.Pipeline
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/pipelines/{id} [get]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) Get(c *gin.Context) {
+	pipeline, ok := h.pipelineParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// Create
+// @Summary create a pipeline
+// @Description requires pipelines:create, stages are ordered as listed, their kind is open, won or lost
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Param pipeline  body model.Pipeline  true "Pipeline"
+// @Success 200 {object} model.Pipeline
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/pipelines [post]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) Create(c *gin.Context) {
+	pipeline := &model.Pipeline{}
+	err := c.ShouldBindJSON(&pipeline)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := pipeline.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	pipeline.ID = uuid.Nil
+
+	err = h.api.postgresStore.Pipeline.Create(pipeline)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// Update
+// @Summary replace a pipeline and its stages
+// @Description requires pipelines:update, stages with the id of an existing stage are kept, the others are created and missing ones removed, stages with deals can't be removed
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Param id        path string  true "Pipeline ID"
+// @Param pipeline  body model.Pipeline  true "Pipeline"
+// @Success 200 {object} model.Pipeline
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/pipelines/{id} [put]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) Update(c *gin.Context) {
+	pipelineDB, ok := h.pipelineParam(c)
+	if !ok {
+		return
+	}
+
+	pipeline := &model.Pipeline{}
+	err := c.ShouldBindJSON(&pipeline)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := pipeline.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	for _, stage := range pipeline.Stages {
+		if _, exists := pipelineDB.Stage(stage.ID); stage.ID != uuid.Nil && !exists {
+			logger.Errorf("Update.Stage", stage.ID)
+			c.JSON(http.StatusBadRequest, model.NewValidationError([]model.FieldError{
+				{Field: "stages", Rule: "exists", Message: "unknown stage id"},
+			}))
+
+			return
+		}
+	}
+
+	pipeline.ID = pipelineDB.ID
+	pipeline.CreatedAt = pipelineDB.CreatedAt
+
+	err = h.api.postgresStore.Pipeline.Update(pipeline)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		if errors.Is(err, model.ErrStageInUse) {
+			c.JSON(http.StatusBadRequest, model.ErrStageHasDeals)
+		} else {
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+// Delete
+// @Summary delete a pipeline without deals
+// @Description requires pipelines:delete
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Param id  path string  true "Pipeline ID"
+// @Success 200 {object} crm.PipelineDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/pipelines/{id} [delete]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) Delete(c *gin.Context) {
+	pipeline, ok := h.pipelineParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Pipeline.Delete(pipeline.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		if errors.Is(err, model.ErrPipelineInUse) {
+			c.JSON(http.StatusBadRequest, model.ErrPipelineHasDeals)
+		} else {
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.PipelineDeleteResponse{Status: "pipeline deleted"})
+}
+
+// Board
+// @Summary get the deals of a pipeline grouped by stage with totals
+// @Description only the caller's deals without deals:read, every stage has its first 100 deals and the totals of all its deals by currency
+// @Produce json
+// @Tags Pipelines
+// @Security ApiKeyAuth
+// @Param id          path  string true  "Pipeline ID"
+// @Param search      query string false "Search"
+// @Param owner_id    query string false "Owner ID"
+// @Param company_id  query string false "Company ID"
+// @Param contact_id  query string false "Contact ID"
+// @Param tags        query string false "Tag names, comma separated, e.g. vip,partner"
+// @Param tags_match  query string false "all tags (default) or any of them"
+// @Param sort        query string false "Order of the deals in a stage, like the deal list"
+// @Success 200 {object} crm.PipelineBoardResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/pipelines/{id}/board [get]
+//
+//nolint:varnamelen
+func (h *PipelineHandler) Board(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Board.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	pipeline, ok := h.pipelineParam(c)
+	if !ok {
+		return
+	}
+
+	query := model.DealQuery{}
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("Board.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	query.Pagination = model.Pagination{Page: 1, PerPage: model.MaxPerPage}
+	query.Status = ""
+	query.StageID = ""
+
+	if !query.IsValid() {
+		logger.Errorf("Board.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	query.PipelineID = pipeline.ID.String()
+	if !principal.Can(model.PermDealsRead) {
+		query.OwnerID = principal.UserID.String()
+	}
+
+	totals, err := h.api.postgresStore.Deal.Totals(query)
+	if err != nil {
+		logger.Errorf("Board.Totals", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	board := crm.PipelineBoardResponse{
+		Pipeline: *pipeline,
+		Stages:   make([]crm.BoardStage, 0, len(pipeline.Stages)),
+		Totals:   []model.DealTotal{},
+	}
+
+	for _, stage := range pipeline.Stages {
+		column := crm.BoardStage{PipelineStage: stage, Deals: []model.Deal{}, Totals: []model.DealTotal{}}
+
+		for _, total := range totals {
+			if total.StageID == stage.ID {
+				column.Totals = append(column.Totals, total)
+				column.Count += total.Count
+			}
+		}
+
+		if column.Count > 0 {
+			query.StageID = stage.ID.String()
+
+			column.Deals, _, err = h.api.postgresStore.Deal.List(query)
+			if err != nil {
+				logger.Errorf("Board.List", err)
+				c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+				return
+			}
+		}
+
+		if !stage.IsClosed() {
+			board.Totals = addTotals(board.Totals, column.Totals)
+		}
+
+		board.Stages = append(board.Stages, column)
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// pipelineParam loads the pipeline of the id path parameter.
+//
+//nolint:varnamelen
+func (h *PipelineHandler) pipelineParam(c *gin.Context) (*model.Pipeline, bool) {
+	pipelineID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("pipelineParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, false
+	}
+
+	pipeline, exists := h.api.postgresStore.Pipeline.Get(pipelineID)
+	if !exists {
+		logger.Errorf("pipelineParam.Get", pipelineID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, false
+	}
+
+	return pipeline, true
+}
+
+// addTotals adds the stage totals to the sums by currency, keeping the order
+// currencies first appear in.
+func addTotals(sums, totals []model.DealTotal) []model.DealTotal {
+	for _, total := range totals {
+		found := false
+
+		for i := range sums {
+			if sums[i].Currency == total.Currency {
+				sums[i].Count += total.Count
+				sums[i].Amount += total.Amount
+				sums[i].WeightedAmount += total.WeightedAmount
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			total.StageID = uuid.Nil
+			sums = append(sums, total)
+		}
+	}
+
+	return sums
+}
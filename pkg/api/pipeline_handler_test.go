@@ -0,0 +1,498 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	pipelineID   = uuid.NewV4()
+	stageLeadID  = uuid.NewV4()
+	stageWonID   = uuid.NewV4()
+	stageLostID  = uuid.NewV4()
+	testPipeline = model.Pipeline{
+		ID:   pipelineID,
+		Name: "Sales",
+		Stages: []model.PipelineStage{
+			{ID: stageLeadID, Name: "Lead", Probability: 20, Kind: model.StageOpen},
+			{ID: stageWonID, Name: "Won", Probability: 100, Kind: model.StageWon},
+			{ID: stageLostID, Name: "Lost", Probability: 0, Kind: model.StageLost},
+		},
+	}
+	leadDeal = model.Deal{
+		ID:         uuid.NewV4(),
+		Title:      "Renewal",
+		Amount:     150000,
+		Currency:   "USD",
+		PipelineID: pipelineID,
+		StageID:    stageLeadID,
+		OwnerID:    &contactOwnerID,
+		ContactIDs: []uuid.UUID{},
+	}
+	wonDeal = model.Deal{
+		ID:         uuid.NewV4(),
+		Title:      "Expansion",
+		Amount:     50000,
+		Currency:   "USD",
+		PipelineID: pipelineID,
+		StageID:    stageWonID,
+		OwnerID:    &contactOwnerID,
+		ContactIDs: []uuid.UUID{},
+	}
+)
+
+var testMapPipelineHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/pipelines",
+			ExpectedData: crm.PipelineListResponse{Pipelines: []model.Pipeline{testPipeline}},
+			PositiveTest: true,
+			WhatError:    nil,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(PipelineRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Pipeline{testPipeline},
+				},
+			},
+		},
+	},
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/pipelines",
+			Data: model.Pipeline{
+				Name: " Sales ",
+				Stages: []model.PipelineStage{
+					{Name: "Lead", Probability: 20},
+					{Name: "Won", Kind: "won"},
+					{Name: "Lost", Kind: "LOST", Probability: 50},
+				},
+			},
+			ExpectedData: model.Pipeline{
+				Name: "Sales",
+				Stages: []model.PipelineStage{
+					{Name: "Lead", Probability: 20, Kind: model.StageOpen},
+					{Name: "Won", Probability: 100, Kind: model.StageWon},
+					{Name: "Lost", Probability: 0, Kind: model.StageLost},
+				},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(PipelineRepoCreateMock),
+			MockData: [][]interface{}{
+				{},
+			},
+		},
+		{
+			Name:   "NegativeValidation",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/pipelines",
+			Data: model.Pipeline{
+				Stages: []model.PipelineStage{{Name: "Lead"}, {Name: "lead"}},
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "name", Rule: "required", Message: "name is required"},
+				{Field: "stages", Rule: "unique", Message: "duplicate stage name"},
+			}),
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/pipelines",
+			Data:         model.Pipeline{Name: "Sales", Stages: []model.PipelineStage{{Name: "Lead"}}},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermDealsRead},
+		},
+	},
+	"Update": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			Data: model.Pipeline{
+				Name: "Sales",
+				Stages: []model.PipelineStage{
+					{ID: stageLeadID, Name: "Qualified", Probability: 30},
+					{Name: "Proposal", Probability: 60},
+					{ID: stageWonID, Name: "Won", Kind: model.StageWon},
+				},
+			},
+			ExpectedData: model.Pipeline{
+				ID:   pipelineID,
+				Name: "Sales",
+				Stages: []model.PipelineStage{
+					{ID: stageLeadID, Name: "Qualified", Probability: 30, Kind: model.StageOpen},
+					{Name: "Proposal", Probability: 60, Kind: model.StageOpen},
+					{ID: stageWonID, Name: "Won", Probability: 100, Kind: model.StageWon},
+				},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(PipelineRepoGetMock, PipelineRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeUnknownStage",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			Data: model.Pipeline{
+				Name:   "Sales",
+				Stages: []model.PipelineStage{{ID: uuid.NewV4(), Name: "Lead"}},
+			},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "stages", Rule: "exists", Message: "unknown stage id"},
+			}),
+			Mock: makeList(PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativeStageInUse",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			Data: model.Pipeline{
+				Name:   "Sales",
+				Stages: []model.PipelineStage{{ID: stageWonID, Name: "Won", Kind: model.StageWon}},
+			},
+			PositiveTest: false, WhatError: model.ErrStageHasDeals,
+			Mock: makeList(PipelineRepoGetMock, PipelineRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					model.ErrStageInUse,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotFound",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			Data:         model.Pipeline{Name: "Sales"},
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			ExpectedData: crm.PipelineDeleteResponse{Status: "pipeline deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(PipelineRepoGetMock, PipelineRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeInUse",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/pipelines/" + pipelineID.String(),
+			PositiveTest: false, WhatError: model.ErrPipelineHasDeals,
+			Mock: makeList(PipelineRepoGetMock, PipelineRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					model.ErrPipelineInUse,
+				},
+			},
+		},
+	},
+	"Board": {
+		{
+			Name:   "PositiveOwnOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/pipelines/" + pipelineID.String() + "/board?owner_id=" + contactOtherID.String(),
+			ExpectedData: crm.PipelineBoardResponse{
+				Pipeline: testPipeline,
+				Stages: []crm.BoardStage{
+					{
+						PipelineStage: testPipeline.Stages[0],
+						Deals:         []model.Deal{leadDeal},
+						Count:         3,
+						Totals: []model.DealTotal{
+							{Currency: "EUR", Count: 1, Amount: 1000, WeightedAmount: 200},
+							{Currency: "USD", Count: 2, Amount: 3000, WeightedAmount: 600},
+						},
+					},
+					{
+						PipelineStage: testPipeline.Stages[1],
+						Deals:         []model.Deal{wonDeal},
+						Count:         1,
+						Totals:        []model.DealTotal{{Currency: "USD", Count: 1, Amount: 500, WeightedAmount: 500}},
+					},
+					{
+						PipelineStage: testPipeline.Stages[2],
+						Deals:         []model.Deal{},
+						Totals:        []model.DealTotal{},
+					},
+				},
+				Totals: []model.DealTotal{
+					{Currency: "EUR", Count: 1, Amount: 1000, WeightedAmount: 200},
+					{Currency: "USD", Count: 2, Amount: 3000, WeightedAmount: 600},
+				},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(PipelineRepoGetMock, DealRepoTotalsMock, DealRepoListMock, DealRepoListMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					[]model.DealTotal{
+						{StageID: stageLeadID, Currency: "EUR", Count: 1, Amount: 1000, WeightedAmount: 200},
+						{StageID: stageLeadID, Currency: "USD", Count: 2, Amount: 3000, WeightedAmount: 600},
+						{StageID: stageWonID, Currency: "USD", Count: 1, Amount: 500, WeightedAmount: 500},
+					},
+				},
+				{
+					model.DealQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+						OwnerID:    contactOwnerID.String(),
+						PipelineID: pipelineID.String(),
+						StageID:    stageLeadID.String(),
+						Sorting:    model.Sorting{Sort: "-created_at"},
+						TagFilter:  model.TagFilter{Match: model.TagsMatchAll},
+					},
+					[]model.Deal{leadDeal},
+					int64(3),
+				},
+				{
+					model.DealQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.MaxPerPage},
+						OwnerID:    contactOwnerID.String(),
+						PipelineID: pipelineID.String(),
+						StageID:    stageWonID.String(),
+						Sorting:    model.Sorting{Sort: "-created_at"},
+						TagFilter:  model.TagFilter{Match: model.TagsMatchAll},
+					},
+					[]model.Deal{wonDeal},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidSort",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/pipelines/" + pipelineID.String() + "/board?sort=stage",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+			Mock: makeList(PipelineRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeDealRepoTotalsMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/pipelines/" + pipelineID.String() + "/board",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(PipelineRepoGetMock, DealRepoTotalsMock),
+			MockData: [][]interface{}{
+				{
+					&testPipeline,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+}
+
+func TestPipelineHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	pipelineRepo := mockpostgresstore.NewMockPipelineRepository(mockCtrl)
+	mockPostgresStore.Pipeline = pipelineRepo
+	repos = append(repos, pipelineRepo)
+
+	dealRepo := mockpostgresstore.NewMockDealRepository(mockCtrl)
+	mockPostgresStore.Deal = dealRepo
+	repos = append(repos, dealRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapPipelineHandler)
+}
+
+func PipelineRepoCreateMock(repos []interface{}, data []interface{}) {
+	var pipelineMock *mockpostgresstore.MockPipelineRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPipelineRepository:
+			pipelineMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	pipelineMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func PipelineRepoGetMock(repos []interface{}, data []interface{}) {
+	var pipelineMock *mockpostgresstore.MockPipelineRepository
+	var result *model.Pipeline
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPipelineRepository:
+			pipelineMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Pipeline:
+			// the handler may change the pipeline it gets
+			pipeline := *t
+			pipeline.Stages = append([]model.PipelineStage{}, t.Stages...)
+			result = &pipeline
+		default:
+			continue
+		}
+	}
+
+	pipelineMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func PipelineRepoListMock(repos []interface{}, data []interface{}) {
+	var pipelineMock *mockpostgresstore.MockPipelineRepository
+	var result []model.Pipeline
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPipelineRepository:
+			pipelineMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Pipeline:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	pipelineMock.EXPECT().List().Return(result, err).Times(1)
+}
+
+func PipelineRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var pipelineMock *mockpostgresstore.MockPipelineRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPipelineRepository:
+			pipelineMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	pipelineMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func PipelineRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var pipelineMock *mockpostgresstore.MockPipelineRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockPipelineRepository:
+			pipelineMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	pipelineMock.EXPECT().Delete(pipelineID).Return(err).Times(1)
+}
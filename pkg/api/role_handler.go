@@ -0,0 +1,271 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	api *api
+}
+
+func NewRoleHandler(a *api) *RoleHandler {
+	return &RoleHandler{
+		api: a,
+	}
+}
+
+// List
+// @Summary list roles with their permissions
+// @Description requires roles:read
+// @Produce json
+// @Tags Roles
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Role
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/roles [get]
+//
+//nolint:varnamelen
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.api.postgresStore.Role.List()
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// Get
+// @Summary get a role with its permissions
+// @Description requires roles:read
+// @Produce json
+// @Tags Roles
+// @Security ApiKeyAuth
+// @Param name  path string  true "Role name"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/roles/{name} [get]
+//
+//nolint:varnamelen
+func (h *RoleHandler) Get(c *gin.Context) {
+	role, exists := h.api.postgresStore.Role.Get(roleParam(c))
+	if !exists {
+		logger.Errorf("Get.Role.Get", c.Param("name"))
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Create
+// @Summary create a role
+// @Description requires roles:create, permissions must be known ones the caller holds, e.g. users:read
+// @Produce json
+// @Tags Roles
+// @Security ApiKeyAuth
+// @Param role  body model.Role  true "Role"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/roles [post]
+//
+//nolint:varnamelen
+func (h *RoleHandler) Create(c *gin.Context) {
+	role := &model.Role{}
+	err := c.ShouldBindJSON(&role)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if !role.IsValid() {
+		logger.Errorf("Create.IsValid", role.Name)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Role.Get(role.Name); exists {
+		logger.Errorf("Create.Role exist", role.Name)
+		c.JSON(http.StatusBadRequest, model.ErrRoleExist)
+
+		return
+	}
+
+	if !h.canGrant(c, role.Permissions) {
+		return
+	}
+
+	role.Builtin = false
+
+	err = h.api.postgresStore.Role.Create(role)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Update
+// @Summary replace the description and the permissions of a role
+// @Description requires roles:update and every granted permission, the change applies to logged in users at once. Built-in roles can't be changed
+// @Produce json
+// @Tags Roles
+// @Security ApiKeyAuth
+// @Param name  path string  true "Role name"
+// @Param role  body model.Role  true "Role"
+// @Success 200 {object} model.Role
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/roles/{name} [put]
+//
+//nolint:varnamelen
+func (h *RoleHandler) Update(c *gin.Context) {
+	role := &model.Role{}
+	err := c.ShouldBindJSON(&role)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	role.Name = roleParam(c)
+	if !role.IsValid() {
+		logger.Errorf("Update.IsValid", role.Name)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	roleDB, exists := h.api.postgresStore.Role.Get(role.Name)
+	if !exists {
+		logger.Errorf("Update.Role.Get", role.Name)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	// ADMIN must keep every permission and BASE is what users get by default
+	if roleDB.Builtin {
+		logger.Errorf("Update.Builtin", roleDB.Name)
+		c.JSON(http.StatusBadRequest, model.ErrRoleBuiltin)
+
+		return
+	}
+
+	if !h.canGrant(c, role.Permissions) {
+		return
+	}
+
+	err = h.api.postgresStore.Role.Update(role)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete
+// @Summary delete a role that is not assigned to any user
+// @Description requires roles:delete, built-in roles can't be deleted
+// @Produce json
+// @Tags Roles
+// @Security ApiKeyAuth
+// @Param name  path string  true "Role name"
+// @Success 200 {object} admin.RoleDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/roles/{name} [delete]
+//
+//nolint:varnamelen
+func (h *RoleHandler) Delete(c *gin.Context) {
+	roleDB, exists := h.api.postgresStore.Role.Get(roleParam(c))
+	if !exists {
+		logger.Errorf("Delete.Role.Get", c.Param("name"))
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	if roleDB.Builtin {
+		logger.Errorf("Delete.Builtin", roleDB.Name)
+		c.JSON(http.StatusBadRequest, model.ErrRoleBuiltin)
+
+		return
+	}
+
+	err := h.api.postgresStore.Role.Delete(roleDB.Name)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		if errors.Is(err, model.ErrRoleInUse) {
+			c.JSON(http.StatusBadRequest, model.ErrRoleAssigned)
+		} else {
+			c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.RoleDeleteResponse{Status: "role deleted"})
+}
+
+// checkGrantable refuses to give a user the role unless the principal holds every
+// permission of it, so no one grants more than they have.
+//
+//nolint:varnamelen
+func (h *RoleHandler) checkGrantable(c *gin.Context, name model.UserRole) bool {
+	role, exists := h.api.postgresStore.Role.Get(name)
+	if !exists {
+		logger.Errorf("checkGrantable.Role.Get", name)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRole)
+
+		return false
+	}
+
+	return h.canGrant(c, role.Permissions)
+}
+
+// canGrant responds with 403 unless the principal holds every permission.
+//
+//nolint:varnamelen
+func (h *RoleHandler) canGrant(c *gin.Context, permissions []model.Permission) bool {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("canGrant.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return false
+	}
+
+	if !principal.CanAll(permissions) {
+		logger.Errorf("canGrant.CanAll", principal.UserID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return false
+	}
+
+	return true
+}
+
+//nolint:varnamelen
+func roleParam(c *gin.Context) model.UserRole {
+	return model.UserRole(strings.ToUpper(c.Param("name")))
+}
@@ -0,0 +1,481 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+var testMapRoleHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:   "Positive",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/admin/roles",
+			ExpectedData: []model.Role{
+				{Name: model.AdminUserRole, Builtin: true, Permissions: []model.Permission{model.PermUsersCreate}},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Role{
+						{Name: model.AdminUserRole, Builtin: true, Permissions: []model.Permission{model.PermUsersCreate}},
+					},
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+		{
+			Name:         "NegativeRoleRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Get": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/roles/base",
+			ExpectedData: model.Role{Name: model.BaseUserRole, Builtin: true, Permissions: []model.Permission{}},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole, Builtin: true, Permissions: []model.Permission{}},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleRepoGetMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/roles/unknown",
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Create": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/roles",
+			Data: model.Role{
+				Name:        "manager",
+				Description: "Sales manager",
+				Builtin:     true,
+				Permissions: []model.Permission{model.PermUsersRead, model.PermUsersCreate, model.PermUsersRead},
+			},
+			ExpectedData: model.Role{
+				Name:        "MANAGER",
+				Description: "Sales manager",
+				Permissions: []model.Permission{model.PermUsersCreate, model.PermUsersRead},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock, RoleRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativePermissionNotHeld",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/roles",
+			Data: model.Role{
+				Name:        "MANAGER",
+				Permissions: []model.Permission{model.PermRolesCreate, model.PermUsersDelete},
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermRolesCreate},
+			Mock:        makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeInvalidName",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			Data:         model.Role{Name: "sales manager"},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:   "NegativeUnknownPermission",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/admin/roles",
+			Data: model.Role{
+				Name:        "MANAGER",
+				Permissions: []model.Permission{"users:fly"},
+			},
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeRoleExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			Data:         model.Role{Name: model.BaseUserRole},
+			PositiveTest: false, WhatError: model.ErrRoleExist,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.BaseUserRole},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			Data:         model.Role{Name: "MANAGER"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoGetMock, RoleRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/roles",
+			Data:         model.Role{Name: "MANAGER"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermRolesRead},
+		},
+	},
+	"Update": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/roles/MANAGER",
+			Data: model.Role{
+				Description: "Sales manager",
+				Permissions: []model.Permission{model.PermUsersRead},
+			},
+			ExpectedData: model.Role{
+				Name:        "MANAGER",
+				Description: "Sales manager",
+				Permissions: []model.Permission{model.PermUsersRead},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock, RoleRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: "MANAGER"},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeBuiltin",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/roles/ADMIN",
+			Data: model.Role{
+				Permissions: []model.Permission{model.PermUsersRead},
+			},
+			PositiveTest: false, WhatError: model.ErrRoleBuiltin,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole, Builtin: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:   "NegativePermissionNotHeld",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/admin/roles/MANAGER",
+			Data: model.Role{
+				Permissions: []model.Permission{model.PermRolesUpdate, model.PermUsersDelete},
+			},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermRolesUpdate},
+			Mock:        makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: "MANAGER"},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleRepoGetMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/roles/UNKNOWN",
+			Data:         model.Role{},
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleRepoUpdateMock",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/roles/MANAGER",
+			Data:         model.Role{},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(RoleRepoGetMock, RoleRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: "MANAGER"},
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/roles/MANAGER",
+			ExpectedData: admin.RoleDeleteResponse{Status: "role deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(RoleRepoGetMock, RoleRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: "MANAGER"},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeBuiltin",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/roles/ADMIN",
+			PositiveTest: false, WhatError: model.ErrRoleBuiltin,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: model.AdminUserRole, Builtin: true},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleInUse",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/roles/MANAGER",
+			PositiveTest: false, WhatError: model.ErrRoleAssigned,
+			Mock: makeList(RoleRepoGetMock, RoleRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&model.Role{Name: "MANAGER"},
+					true,
+				},
+				{
+					model.ErrRoleInUse,
+				},
+			},
+		},
+		{
+			Name:         "NegativeRoleRepoGetMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/roles/UNKNOWN",
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(RoleRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+}
+
+func TestRoleHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	roleRepo := mockpostgresstore.NewMockRoleRepository(mockCtrl)
+	mockPostgresStore.Role = roleRepo
+	repos = append(repos, roleRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapRoleHandler)
+}
+
+func RoleRepoListMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var result []model.Role
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Role:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().List().Return(result, err).Times(1)
+}
+
+func RoleRepoGetMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var result *model.Role
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Role:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func RoleRepoCreateMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func RoleRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func RoleRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var roleMock *mockpostgresstore.MockRoleRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockRoleRepository:
+			roleMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	roleMock.EXPECT().Delete(gomock.Any()).Return(err).Times(1)
+}
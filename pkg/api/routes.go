@@ -1,6 +1,8 @@
 package api
 
 import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
 	"crm-system/pkg/model"
 
 	"github.com/gin-gonic/gin"
@@ -11,24 +13,168 @@ import (
 func configureRouter(api *api) *gin.Engine {
 	router := gin.Default()
 
+	// the client IP keys login limits, sessions and the audit log, so forwarded headers are only believed from proxies
+	if err := router.SetTrustedProxies(api.config.Server.TrustedProxies); err != nil {
+		logger.Fatalf("configureRouter.SetTrustedProxies: %s", err)
+	}
+
 	router.Use(CORSMiddleware())
 
+	router.GET("/.well-known/jwks.json", api.Auth().JWKS)
+
 	public := router.Group("api/v1")
 
 	public.POST("/login", api.Auth().Login)
 	public.POST("/refresh", api.Auth().Refresh)
-	public.PATCH("/change-password", api.Auth().ChangePassword)
+	public.POST("/logout", api.Auth().Logout)
+	public.POST("/login/2fa", api.MFA().Login)
+	public.POST("/login/2fa/enroll", api.MFA().LoginEnroll)
+	public.POST("/login/2fa/confirm", api.MFA().LoginConfirm)
+	public.POST("/password/forgot", api.Password().Forgot)
+	public.POST("/password/reset", api.Password().Reset)
+	public.POST("/password/expired", api.Password().ChangeExpired)
+	public.POST("/invitations/accept", api.Invitation().Accept)
+
+	if api.config.OIDC.Issuer != "" {
+		public.GET("/oidc/login", api.OIDC().Login)
+		public.GET("/oidc/callback", api.OIDC().Callback)
+	}
 
 	private := router.Group("api/v1")
 
 	private.Use(api.auth.Authorize)
 
-	private.POST("/registration", api.Auth().Register)
+	private.POST("/registration", authmiddleware.RequirePermission(model.PermUsersCreate), api.Auth().Register)
+	// actions only the user may take, an impersonating admin can't
+	userOnly := authmiddleware.ForbidImpersonation()
+
+	private.POST("/logout-all", userOnly, api.Auth().LogoutAll)
+	private.PATCH("/change-password", userOnly, api.Auth().ChangePassword)
 
 	privateUser := private.Group("/user")
 
 	privateUser.PATCH("/update-info", api.User().UpdateInfo)
 	privateUser.GET("/", api.User().Get)
+	privateUser.POST("/2fa/enroll", userOnly, api.MFA().Enroll)
+	privateUser.POST("/2fa/confirm", userOnly, api.MFA().Confirm)
+	privateUser.POST("/2fa/disable", userOnly, api.MFA().Disable)
+	privateUser.GET("/sessions", api.Session().List)
+	privateUser.DELETE("/sessions/:id", userOnly, api.Session().Revoke)
+
+	privateContacts := private.Group("/contacts")
+
+	privateContacts.GET("", api.Contact().List)
+	privateContacts.POST("", api.Contact().Create)
+	privateContacts.GET("/:id", api.Contact().Get)
+	privateContacts.PUT("/:id", api.Contact().Update)
+	privateContacts.DELETE("/:id", api.Contact().Delete)
+
+	privateCompanies := private.Group("/companies")
+
+	privateCompanies.GET("", api.Company().List)
+	privateCompanies.POST("", api.Company().Create)
+	privateCompanies.GET("/:id", api.Company().Get)
+	privateCompanies.PUT("/:id", api.Company().Update)
+	privateCompanies.DELETE("/:id", api.Company().Delete)
+
+	privatePipelines := private.Group("/pipelines")
+
+	privatePipelines.GET("", api.Pipeline().List)
+	privatePipelines.POST("", authmiddleware.RequirePermission(model.PermPipelinesCreate), api.Pipeline().Create)
+	privatePipelines.GET("/:id", api.Pipeline().Get)
+	privatePipelines.PUT("/:id", authmiddleware.RequirePermission(model.PermPipelinesUpdate), api.Pipeline().Update)
+	privatePipelines.DELETE("/:id", authmiddleware.RequirePermission(model.PermPipelinesDelete), api.Pipeline().Delete)
+	privatePipelines.GET("/:id/board", api.Pipeline().Board)
+
+	privateDeals := private.Group("/deals")
+
+	privateDeals.GET("", api.Deal().List)
+	privateDeals.POST("", api.Deal().Create)
+	privateDeals.GET("/:id", api.Deal().Get)
+	privateDeals.PUT("/:id", api.Deal().Update)
+	privateDeals.PATCH("/:id/stage", api.Deal().Move)
+	privateDeals.GET("/:id/history", api.Deal().History)
+	privateDeals.DELETE("/:id", api.Deal().Delete)
+
+	privateTasks := private.Group("/tasks")
+
+	privateTasks.GET("", api.Task().List)
+	privateTasks.POST("", api.Task().Create)
+	privateTasks.GET("/my", api.Task().My)
+	privateTasks.GET("/overdue", api.Task().Overdue)
+	privateTasks.GET("/:id", api.Task().Get)
+	privateTasks.PUT("/:id", api.Task().Update)
+	privateTasks.DELETE("/:id", api.Task().Delete)
+
+	privateActivities := private.Group("/activities")
+
+	privateActivities.POST("", api.Activity().Create)
+	privateActivities.GET("/:id", api.Activity().Get)
+	privateActivities.PUT("/:id", api.Activity().Update)
+	privateActivities.DELETE("/:id", api.Activity().Delete)
+
+	private.GET("/timeline/:type/:id", api.Activity().Timeline)
+
+	private.GET("/custom-fields", api.CustomField().List)
+	private.GET("/custom-fields/:id", api.CustomField().Get)
+
+	private.GET("/tags", api.Tag().List)
+	private.POST("/tags/bulk", api.Tag().Bulk)
+	private.GET("/records/:type/:id/tags", api.Tag().RecordTags)
+	private.POST("/records/:type/:id/tags", api.Tag().Attach)
+	private.DELETE("/records/:type/:id/tags/:tag_id", api.Tag().Detach)
+
+	privateImports := private.Group("/imports", authmiddleware.RequirePermission(model.PermImportsCreate))
+
+	privateImports.POST("", api.Import().Upload)
+	privateImports.GET("", api.Import().List)
+	privateImports.GET("/:id", api.Import().Get)
+	privateImports.PUT("/:id/mapping", api.Import().Mapping)
+	privateImports.POST("/:id/dry-run", api.Import().DryRun)
+	privateImports.POST("/:id/run", api.Import().Run)
+	privateImports.GET("/:id/errors", api.Import().Errors)
+
+	privateAdmin := private.Group("/admin")
+
+	privateAdmin.GET("/2fa-policies", authmiddleware.RequirePermission(model.PermMFAPoliciesRead), api.MFA().GetPolicies)
+	privateAdmin.PUT("/2fa-policies", authmiddleware.RequirePermission(model.PermMFAPoliciesUpdate), api.MFA().SetPolicy)
+	privateAdmin.GET("/users", authmiddleware.RequirePermission(model.PermUsersRead), api.Admin().ListUsers)
+	privateAdmin.GET("/users/:id", authmiddleware.RequirePermission(model.PermUsersRead), api.Admin().GetUser)
+	privateAdmin.PUT("/users/:id/role", authmiddleware.RequirePermission(model.PermUsersUpdate), api.Admin().SetUserRole)
+	privateAdmin.POST("/users/:id/deactivate", authmiddleware.RequirePermission(model.PermUsersUpdate), api.Admin().DeactivateUser)
+	privateAdmin.POST("/users/:id/reactivate", authmiddleware.RequirePermission(model.PermUsersUpdate), api.Admin().ReactivateUser)
+	privateAdmin.POST("/users/:id/unlock", authmiddleware.RequirePermission(model.PermUsersUpdate), api.Admin().Unlock)
+	privateAdmin.GET("/users/:id/sessions", authmiddleware.RequirePermission(model.PermUsersRead), api.Admin().ListUserSessions)
+	privateAdmin.DELETE("/users/:id/sessions/:sid", authmiddleware.RequirePermission(model.PermUsersUpdate), api.Admin().RevokeUserSession)
+	privateAdmin.DELETE("/users/:id", authmiddleware.RequirePermission(model.PermUsersDelete), api.Admin().DeleteUser)
+	privateAdmin.POST("/service-accounts", authmiddleware.RequirePermission(model.PermUsersCreate), api.Admin().CreateServiceAccount)
+	privateAdmin.POST("/users/:id/impersonate", userOnly, authmiddleware.RequirePermission(model.PermUsersImpersonate), api.Impersonation().Impersonate)
+	privateAdmin.GET("/impersonation-logs", authmiddleware.RequirePermission(model.PermUsersRead), api.Impersonation().ListLogs)
+
+	privateAdmin.GET("/invitations", authmiddleware.RequirePermission(model.PermUsersRead), api.Invitation().List)
+	privateAdmin.POST("/invitations", authmiddleware.RequirePermission(model.PermUsersCreate), api.Invitation().Create)
+	privateAdmin.DELETE("/invitations/:id", authmiddleware.RequirePermission(model.PermUsersCreate), api.Invitation().Revoke)
+
+	privateAdmin.GET("/api-keys", authmiddleware.RequirePermission(model.PermAPIKeysRead), api.APIKey().List)
+	privateAdmin.POST("/api-keys", userOnly, authmiddleware.RequirePermission(model.PermAPIKeysCreate), api.APIKey().Create)
+	privateAdmin.DELETE("/api-keys/:id", authmiddleware.RequirePermission(model.PermAPIKeysDelete), api.APIKey().Revoke)
+
+	privateAdmin.GET("/audit/auth", authmiddleware.RequirePermission(model.PermAuditRead), api.Audit().ListAuthEvents)
+
+	privateAdmin.GET("/roles", authmiddleware.RequirePermission(model.PermRolesRead), api.Role().List)
+	privateAdmin.GET("/roles/:name", authmiddleware.RequirePermission(model.PermRolesRead), api.Role().Get)
+	privateAdmin.POST("/roles", authmiddleware.RequirePermission(model.PermRolesCreate), api.Role().Create)
+	privateAdmin.PUT("/roles/:name", authmiddleware.RequirePermission(model.PermRolesUpdate), api.Role().Update)
+	privateAdmin.DELETE("/roles/:name", authmiddleware.RequirePermission(model.PermRolesDelete), api.Role().Delete)
+
+	privateAdmin.POST("/custom-fields", authmiddleware.RequirePermission(model.PermCustomFieldsCreate), api.CustomField().Create)
+	privateAdmin.PUT("/custom-fields/:id", authmiddleware.RequirePermission(model.PermCustomFieldsUpdate), api.CustomField().Update)
+	privateAdmin.DELETE("/custom-fields/:id", authmiddleware.RequirePermission(model.PermCustomFieldsDelete), api.CustomField().Delete)
+
+	privateAdmin.POST("/tags", authmiddleware.RequirePermission(model.PermTagsCreate), api.Tag().Create)
+	privateAdmin.PUT("/tags/:id", authmiddleware.RequirePermission(model.PermTagsUpdate), api.Tag().Update)
+	privateAdmin.DELETE("/tags/:id", authmiddleware.RequirePermission(model.PermTagsDelete), api.Tag().Delete)
+	privateAdmin.POST("/tags/:id/merge", authmiddleware.RequirePermission(model.PermTagsDelete), api.Tag().Merge)
 
 	router.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
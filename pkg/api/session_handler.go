@@ -0,0 +1,117 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type SessionHandler struct {
+	api *api
+}
+
+func NewSessionHandler(a *api) *SessionHandler {
+	return &SessionHandler{
+		api: a,
+	}
+}
+
+// List
+// @Summary list the active sessions of the user
+// @Description the session of the request is marked as current
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Session
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/sessions [get]
+//
+//nolint:varnamelen
+func (h *SessionHandler) List(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("List.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	h.list(c, principal.UserID, principal.SessionID)
+}
+
+// Revoke
+// @Summary log out a session of the user
+// @Description its access and refresh tokens are rejected at once
+// @Produce json
+// @Tags User
+// @Security ApiKeyAuth
+// @Param id  path string  true "Session ID"
+// @Success 200 {object} auth.SessionRevokeResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/user/sessions/{id} [delete]
+//
+//nolint:varnamelen
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID, err := authmiddleware.CurrentUserID(c)
+	if err != nil {
+		logger.Errorf("Revoke.CurrentUserID", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	h.revoke(c, userID, c.Param("id"))
+}
+
+//nolint:varnamelen
+func (h *SessionHandler) list(c *gin.Context, userID, currentID uuid.UUID) {
+	sessions, err := h.api.postgresStore.Session.ListActive(userID)
+	if err != nil {
+		logger.Errorf("list.ListActive", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = currentID != uuid.Nil && sessions[i].ID == currentID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// revoke ends a session of the user, sessions of other users are reported as not found.
+//
+//nolint:varnamelen
+func (h *SessionHandler) revoke(c *gin.Context, userID uuid.UUID, rawID string) {
+	sessionID, err := uuid.FromString(rawID)
+	if err != nil {
+		logger.Errorf("revoke.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	session, exists := h.api.postgresStore.Session.Get(sessionID)
+	if !exists || session.UserID != userID {
+		logger.Errorf("revoke.Get", sessionID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return
+	}
+
+	err = h.api.postgresStore.Session.Revoke(session.ID)
+	if err != nil {
+		logger.Errorf("revoke.Revoke", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.SessionRevokeResponse{Status: "session revoked"})
+}
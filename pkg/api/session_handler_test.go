@@ -0,0 +1,306 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/auth"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	sessionUserID = uuid.NewV4()
+	sessionTime   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testSessions  = []model.Session{
+		{
+			ID:         uuid.NewV4(),
+			UserID:     sessionUserID,
+			UserAgent:  "curl/8.0",
+			IP:         "10.0.0.1",
+			CreatedAt:  sessionTime,
+			LastSeenAt: sessionTime,
+			ExpiresAt:  sessionTime.Add(time.Hour),
+		},
+		{
+			ID:         uuid.NewV4(),
+			UserID:     sessionUserID,
+			UserAgent:  "Firefox",
+			IP:         "10.0.0.2",
+			CreatedAt:  sessionTime,
+			LastSeenAt: sessionTime,
+			ExpiresAt:  sessionTime.Add(time.Hour),
+		},
+	}
+)
+
+func currentSessions(current uuid.UUID) []model.Session {
+	sessions := make([]model.Session, len(testSessions))
+	copy(sessions, testSessions)
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	return sessions
+}
+
+var testMapSessionHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/user/sessions",
+			ExpectedData: currentSessions(testSessions[1].ID),
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       sessionUserID,
+			SessionID:    testSessions[1].ID,
+			Mock:         makeList(SessionRepoListActiveMock),
+			MockData: [][]interface{}{
+				{
+					currentSessions(uuid.Nil),
+				},
+			},
+		},
+		{
+			Name:         "NegativeSessionRepoListActiveMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/user/sessions",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: sessionUserID,
+			Mock:   makeList(SessionRepoListActiveMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Revoke": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/user/sessions/" + testSessions[0].ID.String(),
+			ExpectedData: auth.SessionRevokeResponse{Status: "session revoked"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       sessionUserID,
+			Mock:         makeList(SessionRepoGetMock, SessionRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testSessions[0],
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeInvalidID",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/user/sessions/1",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+			UserID: sessionUserID,
+		},
+		{
+			Name:         "NegativeOtherUser",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/user/sessions/" + testSessions[0].ID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(SessionRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testSessions[0],
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeSessionRepoRevokeMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/user/sessions/" + testSessions[0].ID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: sessionUserID,
+			Mock:   makeList(SessionRepoGetMock, SessionRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&testSessions[0],
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"AdminList": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + sessionUserID.String() + "/sessions",
+			ExpectedData: currentSessions(uuid.Nil),
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetMock, SessionRepoListActiveMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: sessionUserID},
+					true,
+				},
+				{
+					currentSessions(uuid.Nil),
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/admin/users/" + sessionUserID.String() + "/sessions",
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{},
+		},
+	},
+	"AdminRevoke": {
+		{
+			Name:   "Positive",
+			Method: http.MethodDelete,
+			URL: "https://localhost:8000/api/v1/admin/users/" + sessionUserID.String() +
+				"/sessions/" + testSessions[1].ID.String(),
+			ExpectedData: auth.SessionRevokeResponse{Status: "session revoked"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(AuthRepoGetMock, SessionRepoGetMock, SessionRepoRevokeMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: sessionUserID},
+					true,
+				},
+				{
+					&testSessions[1],
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "NegativeAuthRepoGetMock",
+			Method: http.MethodDelete,
+			URL: "https://localhost:8000/api/v1/admin/users/" + sessionUserID.String() +
+				"/sessions/" + testSessions[1].ID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+}
+
+func TestSessionHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	sessionRepo := mockpostgresstore.NewMockSessionRepository(mockCtrl)
+	mockPostgresStore.Session = sessionRepo
+	repos = append(repos, sessionRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapSessionHandler)
+}
+
+func SessionRepoListActiveMock(repos []interface{}, data []interface{}) {
+	var sessionMock *mockpostgresstore.MockSessionRepository
+	var result []model.Session
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockSessionRepository:
+			sessionMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Session:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	sessionMock.EXPECT().ListActive(gomock.Any()).Return(result, err).Times(1)
+}
+
+func SessionRepoGetMock(repos []interface{}, data []interface{}) {
+	var sessionMock *mockpostgresstore.MockSessionRepository
+	var result *model.Session
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockSessionRepository:
+			sessionMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Session:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	sessionMock.EXPECT().Get(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func SessionRepoRevokeMock(repos []interface{}, data []interface{}) {
+	var sessionMock *mockpostgresstore.MockSessionRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockSessionRepository:
+			sessionMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	sessionMock.EXPECT().Revoke(gomock.Any()).Return(err).Times(1)
+}
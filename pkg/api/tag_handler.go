@@ -0,0 +1,528 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type TagHandler struct {
+	api *api
+}
+
+func NewTagHandler(a *api) *TagHandler {
+	return &TagHandler{
+		api: a,
+	}
+}
+
+// List
+// @Summary list tags
+// @Description every tag by name with the number of records having it
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tags [get]
+//
+//nolint:varnamelen
+func (h *TagHandler) List(c *gin.Context) {
+	tags, err := h.api.postgresStore.Tag.List()
+	if err != nil {
+		logger.Errorf("List.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// Create
+// @Summary create a tag
+// @Description requires tags:create, names are unique case-insensitively and can't contain commas, the color is #rrggbb
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param tag  body model.Tag  true "Tag"
+// @Success 200 {object} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/admin/tags [post]
+//
+//nolint:varnamelen
+func (h *TagHandler) Create(c *gin.Context) {
+	tag := &model.Tag{}
+	err := c.ShouldBindJSON(&tag)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := tag.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if _, exists := h.api.postgresStore.Tag.GetByName(tag.Name); exists {
+		logger.Errorf("Create.GetByName", tag.Name)
+		c.JSON(http.StatusBadRequest, model.ErrTagExist)
+
+		return
+	}
+
+	tag.ID = uuid.Nil
+	tag.Records = 0
+
+	err = h.api.postgresStore.Tag.Create(tag)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Update
+// @Summary rename a tag or change its color and description
+// @Description requires tags:update
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param id   path string  true "Tag ID"
+// @Param tag  body model.Tag  true "Tag"
+// @Success 200 {object} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/admin/tags/{id} [put]
+//
+//nolint:varnamelen
+func (h *TagHandler) Update(c *gin.Context) {
+	tagDB, ok := h.tagParam(c)
+	if !ok {
+		return
+	}
+
+	tag := &model.Tag{}
+	err := c.ShouldBindJSON(&tag)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := tag.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if other, exists := h.api.postgresStore.Tag.GetByName(tag.Name); exists && other.ID != tagDB.ID {
+		logger.Errorf("Update.GetByName", tag.Name)
+		c.JSON(http.StatusBadRequest, model.ErrTagExist)
+
+		return
+	}
+
+	tag.ID = tagDB.ID
+	tag.CreatedAt = tagDB.CreatedAt
+	tag.Records = 0
+
+	err = h.api.postgresStore.Tag.Update(tag)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Delete
+// @Summary delete a tag
+// @Description requires tags:delete, the tag is taken away from every record
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param id  path string  true "Tag ID"
+// @Success 200 {object} admin.TagDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/admin/tags/{id} [delete]
+//
+//nolint:varnamelen
+func (h *TagHandler) Delete(c *gin.Context) {
+	tag, ok := h.tagParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.api.postgresStore.Tag.Delete(tag.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, admin.TagDeleteResponse{Status: "tag deleted"})
+}
+
+// Merge
+// @Summary merge tags into a tag
+// @Description requires tags:delete, the records of the source tags get the tag and the sources are deleted
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param id     path string  true "Tag ID"
+// @Param merge  body model.TagMerge  true "Source tags"
+// @Success 200 {object} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/admin/tags/{id}/merge [post]
+//
+//nolint:varnamelen
+func (h *TagHandler) Merge(c *gin.Context) {
+	tag, ok := h.tagParam(c)
+	if !ok {
+		return
+	}
+
+	merge := &model.TagMerge{}
+	err := c.ShouldBindJSON(&merge)
+	if err != nil {
+		logger.Errorf("Merge.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := merge.Validate(tag.ID); len(fields) > 0 {
+		logger.Errorf("Merge.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if !h.checkTags(c, merge.SourceIDs) {
+		return
+	}
+
+	err = h.api.postgresStore.Tag.Merge(tag.ID, merge.SourceIDs)
+	if err != nil {
+		logger.Errorf("Merge.Merge", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// RecordTags
+// @Summary get the tags of a record
+// @Description the profiles of other users require users:read, the contacts, companies and deals of other owners the read permission of their type
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param type  path string  true "Entity type, user, contact, company or deal"
+// @Param id    path string  true "Entity ID, the account ID for user"
+// @Success 200 {array} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/records/{type}/{id}/tags [get]
+//
+//nolint:varnamelen
+func (h *TagHandler) RecordTags(c *gin.Context) {
+	entityType, entityID, ok := h.recordParam(c, false)
+	if !ok {
+		return
+	}
+
+	h.respondRecordTags(c, entityType, entityID)
+}
+
+// Attach
+// @Summary tag a record
+// @Description the profiles of other users require users:update, the contacts, companies and deals of other owners the read and update permissions of their type, tags the record has already are kept
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param type  path string  true "Entity type, user, contact, company or deal"
+// @Param id    path string  true "Entity ID, the account ID for user"
+// @Param tags  body model.TagAttach  true "Tags"
+// @Success 200 {array} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/records/{type}/{id}/tags [post]
+//
+//nolint:varnamelen
+func (h *TagHandler) Attach(c *gin.Context) {
+	entityType, entityID, ok := h.recordParam(c, true)
+	if !ok {
+		return
+	}
+
+	attach := &model.TagAttach{}
+	err := c.ShouldBindJSON(&attach)
+	if err != nil {
+		logger.Errorf("Attach.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := attach.Validate(); len(fields) > 0 {
+		logger.Errorf("Attach.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if !h.checkTags(c, attach.TagIDs) {
+		return
+	}
+
+	err = h.api.postgresStore.Tag.Bulk(&model.TagBulk{EntityType: entityType, EntityIDs: []uuid.UUID{entityID}, Add: attach.TagIDs})
+	if err != nil {
+		logger.Errorf("Attach.Bulk", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.respondRecordTags(c, entityType, entityID)
+}
+
+// Detach
+// @Summary take a tag away from a record
+// @Description the profiles of other users require users:update, the contacts, companies and deals of other owners the read and update permissions of their type
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param type    path string  true "Entity type, user, contact, company or deal"
+// @Param id      path string  true "Entity ID, the account ID for user"
+// @Param tag_id  path string  true "Tag ID"
+// @Success 200 {array} model.Tag
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/records/{type}/{id}/tags/{tag_id} [delete]
+//
+//nolint:varnamelen
+func (h *TagHandler) Detach(c *gin.Context) {
+	entityType, entityID, ok := h.recordParam(c, true)
+	if !ok {
+		return
+	}
+
+	tagID, err := uuid.FromString(c.Param("tag_id"))
+	if err != nil {
+		logger.Errorf("Detach.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	err = h.api.postgresStore.Tag.Bulk(&model.TagBulk{EntityType: entityType, EntityIDs: []uuid.UUID{entityID}, Remove: []uuid.UUID{tagID}})
+	if err != nil {
+		logger.Errorf("Detach.Bulk", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	h.respondRecordTags(c, entityType, entityID)
+}
+
+// Bulk
+// @Summary add and remove tags on many records
+// @Description the profiles of other users require users:update, the contacts, companies and deals of other owners the read and update permissions of their type, up to 500 records and 50 tags to add and to remove, in one transaction
+// @Produce json
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Param bulk  body model.TagBulk  true "Bulk operation"
+// @Success 200 {object} crm.TagBulkResponse
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/tags/bulk [post]
+//
+//nolint:varnamelen
+func (h *TagHandler) Bulk(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Bulk.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	bulk := &model.TagBulk{}
+	err = c.ShouldBindJSON(&bulk)
+	if err != nil {
+		logger.Errorf("Bulk.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := bulk.Validate(); len(fields) > 0 {
+		logger.Errorf("Bulk.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	if !h.canChangeAll(principal, bulk.EntityType, bulk.EntityIDs) {
+		logger.Errorf("Bulk.canChangeAll", bulk.EntityType)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
+	}
+
+	existing, err := h.api.postgresStore.Tag.ExistingEntities(bulk.EntityType, bulk.EntityIDs)
+	if err != nil {
+		logger.Errorf("Bulk.ExistingEntities", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if len(existing) != len(bulk.EntityIDs) {
+		logger.Errorf("Bulk.ExistingEntities", len(existing))
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRecord)
+
+		return
+	}
+
+	if !h.checkTags(c, bulk.TagIDs()) {
+		return
+	}
+
+	err = h.api.postgresStore.Tag.Bulk(bulk)
+	if err != nil {
+		logger.Errorf("Bulk.Bulk", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.TagBulkResponse{Status: "tags updated", Records: len(bulk.EntityIDs)})
+}
+
+// tagParam loads the tag of the id path parameter.
+//
+//nolint:varnamelen
+func (h *TagHandler) tagParam(c *gin.Context) (*model.Tag, bool) {
+	tagID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("tagParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, false
+	}
+
+	tag, exists := h.api.postgresStore.Tag.Get(tagID)
+	if !exists {
+		logger.Errorf("tagParam.Get", tagID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, false
+	}
+
+	return tag, true
+}
+
+// recordParam parses the record of the type and id path parameters. Records the
+// caller doesn't see respond as missing, changing them takes the change access.
+//
+//nolint:varnamelen
+func (h *TagHandler) recordParam(c *gin.Context, change bool) (model.RecordType, uuid.UUID, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("recordParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return "", uuid.Nil, false
+	}
+
+	entityType := model.RecordType(strings.ToLower(c.Param("type")))
+
+	entityID, err := uuid.FromString(c.Param("id"))
+	if err != nil || !entityType.IsKnown() {
+		logger.Errorf("recordParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return "", uuid.Nil, false
+	}
+
+	if !h.api.canSeeRecord(principal, entityType, entityID) {
+		logger.Errorf("recordParam.canSeeRecord", entityID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return "", uuid.Nil, false
+	}
+
+	if change && !h.canChangeAll(principal, entityType, []uuid.UUID{entityID}) {
+		logger.Errorf("recordParam.canChangeAll", entityID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return "", uuid.Nil, false
+	}
+
+	return entityType, entityID, true
+}
+
+// checkTags responds with an error unless every id is a tag.
+//
+//nolint:varnamelen
+func (h *TagHandler) checkTags(c *gin.Context, tagIDs []uuid.UUID) bool {
+	count, err := h.api.postgresStore.Tag.Count(tagIDs)
+	if err != nil {
+		logger.Errorf("checkTags.Count", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return false
+	}
+
+	if count != int64(len(tagIDs)) {
+		logger.Errorf("checkTags.Count", count)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidTag)
+
+		return false
+	}
+
+	return true
+}
+
+//nolint:varnamelen
+func (h *TagHandler) respondRecordTags(c *gin.Context, entityType model.RecordType, entityID uuid.UUID) {
+	tags, err := h.api.postgresStore.Tag.ForEntity(entityType, entityID)
+	if err != nil {
+		logger.Errorf("respondRecordTags.ForEntity", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// canChangeAll reports whether the principal may change every record.
+func (h *TagHandler) canChangeAll(principal *authmiddleware.Principal, entityType model.RecordType, entityIDs []uuid.UUID) bool {
+	for _, entityID := range entityIDs {
+		if !h.api.canChangeRecord(principal, entityType, entityID) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,837 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/admin"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	tagID      = uuid.NewV4()
+	partnerTag = model.Tag{ID: uuid.NewV4(), Name: "partner", Color: "#00aa00"}
+	vipTag     = model.Tag{ID: tagID, Name: "VIP", Color: "#ffaa00", Description: "Top customers", Records: 2}
+)
+
+var testMapTagHandler = map[string][]model.TestStructure{
+	"List": {
+		{
+			Name:         "PositiveWithoutPermissions",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tags",
+			ExpectedData: []model.Tag{partnerTag, vipTag},
+			PositiveTest: true,
+			WhatError:    nil,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(TagRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Tag{partnerTag, vipTag},
+				},
+			},
+		},
+	},
+	"Create": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags",
+			Data:         model.Tag{Name: " VIP ", Color: "#FFAA00", Description: "Top customers", Records: 10},
+			ExpectedData: model.Tag{Name: "VIP", Color: "#ffaa00", Description: "Top customers"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(TagRepoGetByNameMock, TagRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags",
+			Data:         model.Tag{Name: "vip,partner", Color: "orange"},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "name", Rule: "format", Message: "name can't contain commas"},
+				{Field: "color", Rule: "format", Message: "color must be #rrggbb"},
+			}),
+		},
+		{
+			Name:         "NegativeTagExist",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags",
+			Data:         model.Tag{Name: "vip"},
+			PositiveTest: false, WhatError: model.ErrTagExist,
+			Mock: makeList(TagRepoGetByNameMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeForbidden",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags",
+			Data:         model.Tag{Name: "vip"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			Permissions: []model.Permission{model.PermTagsUpdate},
+		},
+	},
+	"Update": {
+		{
+			Name:         "PositiveRename",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String(),
+			Data:         model.Tag{Name: "Vip", Color: "#ffaa00"},
+			ExpectedData: model.Tag{ID: tagID, Name: "Vip", Color: "#ffaa00"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(TagRepoGetMock, TagRepoGetByNameMock, TagRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{
+					&vipTag,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNameTaken",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String(),
+			Data:         model.Tag{Name: "Partner"},
+			PositiveTest: false, WhatError: model.ErrTagExist,
+			Mock: makeList(TagRepoGetMock, TagRepoGetByNameMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{
+					&partnerTag,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotFound",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String(),
+			Data:         model.Tag{Name: "vip"},
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(TagRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String(),
+			ExpectedData: admin.TagDeleteResponse{Status: "tag deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(TagRepoGetMock, TagRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeTagRepoDeleteMock",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String(),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(TagRepoGetMock, TagRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"Merge": {
+		{
+			Name:         "Positive",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String() + "/merge",
+			Data:         model.TagMerge{SourceIDs: []uuid.UUID{partnerTag.ID, partnerTag.ID}},
+			ExpectedData: vipTag,
+			PositiveTest: true,
+			WhatError:    nil,
+			Mock:         makeList(TagRepoGetMock, TagRepoCountMock, TagRepoMergeMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{
+					int64(1),
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeIntoItself",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String() + "/merge",
+			Data:         model.TagMerge{SourceIDs: []uuid.UUID{tagID}},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "source_ids", Rule: "oneof", Message: "a tag can't be merged into itself"},
+			}),
+			Mock: makeList(TagRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownSource",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/admin/tags/" + tagID.String() + "/merge",
+			Data:         model.TagMerge{SourceIDs: []uuid.UUID{partnerTag.ID}},
+			PositiveTest: false, WhatError: model.ErrInvalidTag,
+			Mock: makeList(TagRepoGetMock, TagRepoCountMock),
+			MockData: [][]interface{}{
+				{
+					&vipTag,
+					true,
+				},
+				{
+					int64(0),
+				},
+			},
+		},
+	},
+	"RecordTags": {
+		{
+			Name:         "PositiveOwnProfile",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOwnerID.String() + "/tags",
+			ExpectedData: []model.Tag{vipTag},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, TagRepoForEntityMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					[]model.Tag{vipTag},
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherProfileWithoutRead",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOtherID.String() + "/tags",
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+		},
+		{
+			Name:         "NegativeUnknownEntityType",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/records/planet/" + contactOwnerID.String() + "/tags",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+	},
+	"Attach": {
+		{
+			Name:         "PositiveOtherProfile",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOtherID.String() + "/tags",
+			Data:         model.TagAttach{TagIDs: []uuid.UUID{tagID}},
+			ExpectedData: []model.Tag{vipTag},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermUsersRead, model.PermUsersUpdate},
+			Mock:         makeList(AuthRepoGetMock, TagRepoCountMock, TagRepoBulkMock, TagRepoForEntityMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+				{
+					int64(1),
+				},
+				{
+					&model.TagBulk{EntityType: model.RecordUser, EntityIDs: []uuid.UUID{contactOtherID}, Add: []uuid.UUID{tagID}},
+				},
+				{
+					[]model.Tag{vipTag},
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherProfileWithoutUpdate",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOtherID.String() + "/tags",
+			Data:         model.TagAttach{TagIDs: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermUsersRead},
+			Mock:        makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+			},
+		},
+		{
+			Name:         "PositiveOwnContact",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/records/contact/" + contactID.String() + "/tags",
+			Data:         model.TagAttach{TagIDs: []uuid.UUID{tagID}},
+			ExpectedData: []model.Tag{vipTag},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoGetMock, ContactRepoGetMock, TagRepoCountMock, TagRepoBulkMock, TagRepoForEntityMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{
+					&testContact,
+					true,
+				},
+				{
+					int64(1),
+				},
+				{
+					&model.TagBulk{EntityType: model.RecordContact, EntityIDs: []uuid.UUID{contactID}, Add: []uuid.UUID{tagID}},
+				},
+				{
+					model.RecordContact,
+					[]model.Tag{vipTag},
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherDealWithoutUpdate",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/records/deal/" + dealID.String() + "/tags",
+			Data:         model.TagAttach{TagIDs: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermDealsRead},
+			Mock:        makeList(DealRepoGetMock, DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherDeal,
+					true,
+				},
+				{
+					&otherDeal,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownTag",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOwnerID.String() + "/tags",
+			Data:         model.TagAttach{TagIDs: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrInvalidTag,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(AuthRepoGetMock, TagRepoCountMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					int64(0),
+				},
+			},
+		},
+	},
+	"Detach": {
+		{
+			Name:         "PositiveOwnProfile",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/records/user/" + contactOwnerID.String() + "/tags/" + tagID.String(),
+			ExpectedData: []model.Tag{},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, TagRepoBulkMock, TagRepoForEntityMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{
+					&model.TagBulk{EntityType: model.RecordUser, EntityIDs: []uuid.UUID{contactOwnerID}, Remove: []uuid.UUID{tagID}},
+				},
+				{
+					[]model.Tag{},
+				},
+			},
+		},
+	},
+	"Bulk": {
+		{
+			Name:   "Positive",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/tags/bulk",
+			Data: model.TagBulk{
+				EntityType: "USER",
+				EntityIDs:  []uuid.UUID{contactOwnerID, contactOtherID, contactOwnerID},
+				Add:        []uuid.UUID{tagID},
+				Remove:     []uuid.UUID{partnerTag.ID},
+			},
+			ExpectedData: crm.TagBulkResponse{Status: "tags updated", Records: 2},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermUsersUpdate},
+			Mock:         makeList(TagRepoExistingEntitiesMock, TagRepoCountMock, TagRepoBulkMock),
+			MockData: [][]interface{}{
+				{
+					[]uuid.UUID{contactOwnerID, contactOtherID},
+				},
+				{
+					int64(2),
+				},
+				{
+					&model.TagBulk{
+						EntityType: model.RecordUser,
+						EntityIDs:  []uuid.UUID{contactOwnerID, contactOtherID},
+						Add:        []uuid.UUID{tagID},
+						Remove:     []uuid.UUID{partnerTag.ID},
+					},
+				},
+			},
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tags/bulk",
+			Data:         model.TagBulk{EntityType: model.RecordUser, Add: []uuid.UUID{tagID}, Remove: []uuid.UUID{tagID}},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "entity_ids", Rule: "max_items", Message: "1 to 500 records are required"},
+				{Field: "remove", Rule: "unique", Message: "a tag can't be added and removed"},
+			}),
+		},
+		{
+			Name:         "NegativeOtherProfilesWithoutUpdate",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tags/bulk",
+			Data:         model.TagBulk{EntityType: model.RecordUser, EntityIDs: []uuid.UUID{contactOwnerID, contactOtherID}, Add: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermUsersRead},
+		},
+		{
+			Name:         "PositiveOtherCompaniesWithUpdate",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tags/bulk",
+			Data:         model.TagBulk{EntityType: model.RecordCompany, EntityIDs: []uuid.UUID{companyID}, Add: []uuid.UUID{tagID}},
+			ExpectedData: crm.TagBulkResponse{Status: "tags updated", Records: 1},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermCompaniesRead, model.PermCompaniesUpdate},
+			Mock:         makeList(TagRepoExistingEntitiesMock, TagRepoCountMock, TagRepoBulkMock),
+			MockData: [][]interface{}{
+				{
+					model.RecordCompany,
+					[]uuid.UUID{companyID},
+				},
+				{
+					int64(1),
+				},
+				{
+					&model.TagBulk{EntityType: model.RecordCompany, EntityIDs: []uuid.UUID{companyID}, Add: []uuid.UUID{tagID}, Remove: []uuid.UUID{}},
+				},
+			},
+		},
+		{
+			Name:         "NegativeOtherContactWithoutUpdate",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tags/bulk",
+			Data:         model.TagBulk{EntityType: model.RecordContact, EntityIDs: []uuid.UUID{contactID}, Add: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermContactsRead},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownRecord",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tags/bulk",
+			Data:         model.TagBulk{EntityType: model.RecordUser, EntityIDs: []uuid.UUID{contactOwnerID, contactOtherID}, Add: []uuid.UUID{tagID}},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			Mock: makeList(TagRepoExistingEntitiesMock),
+			MockData: [][]interface{}{
+				{
+					[]uuid.UUID{contactOwnerID},
+				},
+			},
+		},
+	},
+}
+
+func TestTagHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	tagRepo := mockpostgresstore.NewMockTagRepository(mockCtrl)
+	mockPostgresStore.Tag = tagRepo
+	repos = append(repos, tagRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	dealRepo := mockpostgresstore.NewMockDealRepository(mockCtrl)
+	mockPostgresStore.Deal = dealRepo
+	repos = append(repos, dealRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapTagHandler)
+}
+
+func TagRepoListMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	result := []model.Tag{}
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case []model.Tag:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().List().Return(result, err).Times(1)
+}
+
+func TagRepoGetMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var result *model.Tag
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Tag:
+			// the handler may change the tag it gets
+			tag := *t
+			result = &tag
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Get(tagID).Return(result, exist).Times(1)
+}
+
+func TagRepoGetByNameMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var result *model.Tag
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Tag:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().GetByName(gomock.Any()).Return(result, exist).Times(1)
+}
+
+func TagRepoCreateMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func TagRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Update(gomock.Any()).Return(err).Times(1)
+}
+
+func TagRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Delete(tagID).Return(err).Times(1)
+}
+
+func TagRepoMergeMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Merge(tagID, []uuid.UUID{partnerTag.ID}).Return(err).Times(1)
+}
+
+func TagRepoCountMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var count int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case int64:
+			count = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Count(gomock.Any()).Return(count, err).Times(1)
+}
+
+func TagRepoForEntityMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var recordType = model.RecordUser
+	result := []model.Tag{}
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.RecordType:
+			recordType = t
+		case []model.Tag:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().ForEntity(recordType, gomock.Any()).Return(result, err).Times(1)
+}
+
+func TagRepoBulkMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var bulk *model.TagBulk
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case *model.TagBulk:
+			bulk = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().Bulk(bulk).Return(err).Times(1)
+}
+
+func TagRepoExistingEntitiesMock(repos []interface{}, data []interface{}) {
+	var tagMock *mockpostgresstore.MockTagRepository
+	var recordType = model.RecordUser
+	result := []uuid.UUID{}
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTagRepository:
+			tagMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.RecordType:
+			recordType = t
+		case []uuid.UUID:
+			result = t
+		default:
+			continue
+		}
+	}
+
+	tagMock.EXPECT().ExistingEntities(recordType, gomock.Any()).Return(result, err).Times(1)
+}
@@ -0,0 +1,443 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+type TaskHandler struct {
+	api *api
+}
+
+func NewTaskHandler(a *api) *TaskHandler {
+	return &TaskHandler{
+		api: a,
+	}
+}
+
+// Create
+// @Summary create a task
+// @Description the task is assigned to the caller unless assignee_id is set, any user may be the assignee, record_type is user, contact, company or deal and the caller must see the record
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param task  body model.Task  true "Task"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/tasks [post]
+//
+//nolint:varnamelen
+func (h *TaskHandler) Create(c *gin.Context) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("Create.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	task := &model.Task{}
+	err = c.ShouldBindJSON(&task)
+	if err != nil {
+		logger.Errorf("Create.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := task.Validate(); len(fields) > 0 {
+		logger.Errorf("Create.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	task.ID = uuid.Nil
+	task.CreatorID = &principal.UserID
+
+	if task.AssigneeID == nil {
+		task.AssigneeID = &principal.UserID
+	}
+
+	if !h.checkAssignee(c, principal, task.AssigneeID) || !h.checkRecord(c, principal, task.RecordType, task.RecordID) {
+		return
+	}
+
+	task.Stamp(nil, time.Now())
+
+	err = h.api.postgresStore.Task.Create(task)
+	if err != nil {
+		logger.Errorf("Create.Create", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// List
+// @Summary list tasks
+// @Description only the tasks assigned to or created by the caller without tasks:read, search matches the title and the description, open keeps the open and in progress tasks
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param search       query string false "Search"
+// @Param assignee_id  query string false "Assignee ID"
+// @Param creator_id   query string false "Creator ID"
+// @Param status       query string false "open, in_progress, done or canceled"
+// @Param open         query bool   false "Only open and in progress tasks"
+// @Param priority     query string false "low, normal, high or urgent"
+// @Param record_type  query string false "user, contact, company or deal"
+// @Param record_id    query string false "Record ID"
+// @Param due_before   query string false "Due before, RFC 3339"
+// @Param due_after    query string false "Due at or after, RFC 3339"
+// @Param sort         query string false "due_at, priority, created_at or updated_at, prefixed with - for descending order, -created_at by default"
+// @Param page         query int    false "Page, starts at 1"
+// @Param per_page     query int    false "Tasks per page, 20 by default, at most 100"
+// @Success 200 {object} crm.TaskListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tasks [get]
+//
+//nolint:varnamelen
+func (h *TaskHandler) List(c *gin.Context) {
+	principal, query, ok := h.taskQuery(c, "-created_at")
+	if !ok {
+		return
+	}
+
+	if !principal.Can(model.PermTasksRead) {
+		query.ParticipantID = principal.UserID.String()
+	}
+
+	h.list(c, query)
+}
+
+// My
+// @Summary list the tasks assigned to the caller
+// @Description open and in progress tasks unless status is set, the soonest due first by default, takes the filters of the task list
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param search    query string false "Search"
+// @Param status    query string false "open, in_progress, done or canceled"
+// @Param priority  query string false "low, normal, high or urgent"
+// @Param sort      query string false "Like the task list, due_at by default"
+// @Param page      query int    false "Page, starts at 1"
+// @Param per_page  query int    false "Tasks per page, 20 by default, at most 100"
+// @Success 200 {object} crm.TaskListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tasks/my [get]
+//
+//nolint:varnamelen
+func (h *TaskHandler) My(c *gin.Context) {
+	principal, query, ok := h.taskQuery(c, "due_at")
+	if !ok {
+		return
+	}
+
+	query.AssigneeID = principal.UserID.String()
+	query.Open = query.Open || query.Status == ""
+
+	h.list(c, query)
+}
+
+// Overdue
+// @Summary list the open tasks past their due time
+// @Description only the caller's tasks without tasks:read, the most overdue first by default, takes the filters of the task list
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param search       query string false "Search"
+// @Param assignee_id  query string false "Assignee ID"
+// @Param priority     query string false "low, normal, high or urgent"
+// @Param sort         query string false "Like the task list, due_at by default"
+// @Param page         query int    false "Page, starts at 1"
+// @Param per_page     query int    false "Tasks per page, 20 by default, at most 100"
+// @Success 200 {object} crm.TaskListResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tasks/overdue [get]
+//
+//nolint:varnamelen
+func (h *TaskHandler) Overdue(c *gin.Context) {
+	principal, query, ok := h.taskQuery(c, "due_at")
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	query.DueBefore = &now
+	query.Status = ""
+	query.Open = true
+
+	if !principal.Can(model.PermTasksRead) {
+		query.AssigneeID = principal.UserID.String()
+	}
+
+	h.list(c, query)
+}
+
+// Get
+// @Summary get a task
+// @Description tasks the caller isn't assigned to and didn't create require tasks:read
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param id  path string  true "Task ID"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tasks/{id} [get]
+//
+//nolint:varnamelen
+func (h *TaskHandler) Get(c *gin.Context) {
+	task, _, ok := h.taskParam(c, model.PermTasksRead)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// Update
+// @Summary replace a task
+// @Description tasks the caller isn't assigned to and didn't create require tasks:update, the assignee is kept without assignee_id, done tasks are stamped with completed_at and a new due time sends a new reminder
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param id    path string  true "Task ID"
+// @Param task  body model.Task  true "Task"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} errors.UIResponseErrorValidation
+// @Router /api/v1/tasks/{id} [put]
+//
+//nolint:varnamelen
+func (h *TaskHandler) Update(c *gin.Context) {
+	taskDB, principal, ok := h.taskParam(c, model.PermTasksUpdate)
+	if !ok {
+		return
+	}
+
+	task := &model.Task{}
+	err := c.ShouldBindJSON(&task)
+	if err != nil {
+		logger.Errorf("Update.ShouldBindJSON", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	if fields := task.Validate(); len(fields) > 0 {
+		logger.Errorf("Update.Validate", fields)
+		c.JSON(http.StatusBadRequest, model.NewValidationError(fields))
+
+		return
+	}
+
+	task.ID = taskDB.ID
+	task.CreatorID = taskDB.CreatorID
+	task.CreatedAt = taskDB.CreatedAt
+
+	if task.AssigneeID == nil {
+		task.AssigneeID = taskDB.AssigneeID
+	}
+
+	assigneeChanged := task.AssigneeID != nil && (taskDB.AssigneeID == nil || *task.AssigneeID != *taskDB.AssigneeID)
+	if assigneeChanged && !h.checkAssignee(c, principal, task.AssigneeID) {
+		return
+	}
+
+	recordChanged := task.RecordID != nil &&
+		(taskDB.RecordID == nil || *task.RecordID != *taskDB.RecordID || task.RecordType != taskDB.RecordType)
+	if recordChanged && !h.checkRecord(c, principal, task.RecordType, task.RecordID) {
+		return
+	}
+
+	task.Stamp(taskDB, time.Now())
+
+	err = h.api.postgresStore.Task.Update(task)
+	if err != nil {
+		logger.Errorf("Update.Update", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// Delete
+// @Summary delete a task
+// @Description tasks the caller didn't create require tasks:delete, the assignee can't delete the task
+// @Produce json
+// @Tags Tasks
+// @Security ApiKeyAuth
+// @Param id  path string  true "Task ID"
+// @Success 200 {object} crm.TaskDeleteResponse
+// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Router /api/v1/tasks/{id} [delete]
+//
+//nolint:varnamelen
+func (h *TaskHandler) Delete(c *gin.Context) {
+	task, principal, ok := h.taskParam(c, model.PermTasksRead)
+	if !ok {
+		return
+	}
+
+	if !task.IsCreatedBy(principal.UserID) && !principal.Can(model.PermTasksDelete) {
+		logger.Errorf("Delete.Can", model.PermTasksDelete)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
+	}
+
+	err := h.api.postgresStore.Task.Delete(task.ID)
+	if err != nil {
+		logger.Errorf("Delete.Delete", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.TaskDeleteResponse{Status: "task deleted"})
+}
+
+// taskQuery binds the task list query, sort is the default order.
+//
+//nolint:varnamelen
+func (h *TaskHandler) taskQuery(c *gin.Context, sort string) (*authmiddleware.Principal, model.TaskQuery, bool) {
+	query := model.TaskQuery{}
+
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("taskQuery.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, query, false
+	}
+
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		logger.Errorf("taskQuery.ShouldBindQuery", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, query, false
+	}
+
+	if query.Sort == "" {
+		query.Sort = sort
+	}
+
+	if !query.IsValid() {
+		logger.Errorf("taskQuery.IsValid", query)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, query, false
+	}
+
+	return principal, query, true
+}
+
+//nolint:varnamelen
+func (h *TaskHandler) list(c *gin.Context, query model.TaskQuery) {
+	tasks, total, err := h.api.postgresStore.Task.List(query)
+	if err != nil {
+		logger.Errorf("list.List", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, crm.TaskListResponse{
+		Tasks:   tasks,
+		Total:   total,
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	})
+}
+
+// taskParam loads the task of the id path parameter. Tasks the caller isn't
+// assigned to and didn't create need the permission, they respond as missing
+// without tasks:read.
+//
+//nolint:varnamelen
+func (h *TaskHandler) taskParam(c *gin.Context, permission model.Permission) (*model.Task, *authmiddleware.Principal, bool) {
+	principal, err := authmiddleware.GetPrincipal(c)
+	if err != nil {
+		logger.Errorf("taskParam.GetPrincipal", err)
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return nil, nil, false
+	}
+
+	taskID, err := uuid.FromString(c.Param("id"))
+	if err != nil {
+		logger.Errorf("taskParam.FromString", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return nil, nil, false
+	}
+
+	task, exists := h.api.postgresStore.Task.Get(taskID)
+	if !exists {
+		logger.Errorf("taskParam.Get", taskID)
+		c.JSON(http.StatusNotFound, model.ErrRecordNotFound)
+
+		return nil, nil, false
+	}
+
+	participant := task.IsAssignedTo(principal.UserID) || task.IsCreatedBy(principal.UserID)
+	if !checkRecordAccess(c, principal, participant, model.PermTasksRead, permission) {
+		return nil, nil, false
+	}
+
+	return task, principal, true
+}
+
+// checkRecord responds with an error unless the linked record exists and the
+// principal sees it.
+//
+//nolint:varnamelen
+func (h *TaskHandler) checkRecord(
+	c *gin.Context,
+	principal *authmiddleware.Principal,
+	recordType model.RecordType,
+	recordID *uuid.UUID,
+) bool {
+	if recordID == nil {
+		return true
+	}
+
+	if !h.api.canSeeRecord(principal, recordType, *recordID) {
+		logger.Errorf("checkRecord.canSeeRecord", *recordID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidRecord)
+
+		return false
+	}
+
+	return true
+}
+
+// checkAssignee responds with an error unless the assignee exists.
+//
+//nolint:varnamelen
+func (h *TaskHandler) checkAssignee(c *gin.Context, principal *authmiddleware.Principal, assigneeID *uuid.UUID) bool {
+	if *assigneeID == principal.UserID {
+		return true
+	}
+
+	if _, exists := h.api.postgresStore.Auth.Get(*assigneeID); !exists {
+		logger.Errorf("checkAssignee.Get", *assigneeID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidAssignee)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,705 @@
+package api
+
+import (
+	"crm-system/pkg/authmiddleware/mockauthmiddleware"
+	"crm-system/pkg/model"
+	"crm-system/pkg/model/ui/crm"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	taskID    = uuid.NewV4()
+	taskDueAt = time.Date(2026, time.November, 2, 15, 0, 0, 0, time.UTC)
+	testTask  = model.Task{
+		ID:         taskID,
+		Title:      "Call back",
+		DueAt:      &taskDueAt,
+		Priority:   model.TaskPriorityNormal,
+		Status:     model.TaskOpen,
+		AssigneeID: &contactOwnerID,
+		CreatorID:  &contactOtherID,
+	}
+	otherTask = model.Task{
+		ID:         taskID,
+		Title:      "Send the quote",
+		Priority:   model.TaskPriorityHigh,
+		Status:     model.TaskOpen,
+		AssigneeID: &contactOtherID,
+		CreatorID:  &contactOtherID,
+		RemindedAt: &taskDueAt,
+	}
+)
+
+var testMapTaskHandler = map[string][]model.TestStructure{
+	"Create": {
+		{
+			Name:   "PositiveAssignedToCaller",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/tasks",
+			Data: model.Task{
+				Title:      " Call back ",
+				DueAt:      &taskDueAt,
+				Priority:   "HIGH",
+				RecordType: model.RecordContact,
+				RecordID:   &contactID,
+			},
+			ExpectedData: model.Task{
+				Title:      "Call back",
+				DueAt:      &taskDueAt,
+				Priority:   model.TaskPriorityHigh,
+				Status:     model.TaskOpen,
+				AssigneeID: &contactOwnerID,
+				CreatorID:  &contactOwnerID,
+				RecordType: model.RecordContact,
+				RecordID:   &contactID,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(ContactRepoGetMock, TaskRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&testContact,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:   "PositiveAssignedToOther",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/tasks",
+			Data:   model.Task{Title: "Send the quote", AssigneeID: &contactOtherID},
+			ExpectedData: model.Task{
+				Title:      "Send the quote",
+				Priority:   model.TaskPriorityNormal,
+				Status:     model.TaskOpen,
+				AssigneeID: &contactOtherID,
+				CreatorID:  &contactOwnerID,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, TaskRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOtherID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeJsonData",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         "",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeValidation",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Priority: "asap", Status: "later", RecordType: model.RecordDeal},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "title", Rule: "required", Message: "title is required"},
+				{Field: "priority", Rule: "oneof", Message: "unknown priority"},
+				{Field: "status", Rule: "oneof", Message: "unknown status"},
+				{Field: "record_id", Rule: "required_with", Message: "record_type and record_id go together"},
+			}),
+		},
+		{
+			Name:         "NegativeUnknownAssignee",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Title: "Call back", AssigneeID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrInvalidAssignee,
+			UserID: contactOwnerID,
+			Mock:   makeList(AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownRecord",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Title: "Call back", RecordType: model.RecordDeal, RecordID: &dealID},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			UserID: contactOwnerID,
+			Mock:   makeList(DealRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+		{
+			Name:   "PositiveOwnProfile",
+			Method: http.MethodPost,
+			URL:    "https://localhost:8000/api/v1/tasks",
+			Data:   model.Task{Title: "Review", RecordType: model.RecordUser, RecordID: &contactOwnerID},
+			ExpectedData: model.Task{
+				Title:      "Review",
+				Priority:   model.TaskPriorityNormal,
+				Status:     model.TaskOpen,
+				AssigneeID: &contactOwnerID,
+				CreatorID:  &contactOwnerID,
+				RecordType: model.RecordUser,
+				RecordID:   &contactOwnerID,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(AuthRepoGetMock, TaskRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					&model.AuthUser{ID: contactOwnerID},
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeOtherProfileWithoutRead",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Title: "Review", RecordType: model.RecordUser, RecordID: &contactOtherID},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+		},
+		{
+			Name:         "NegativeOtherContactWithoutRead",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Title: "Call back", RecordType: model.RecordContact, RecordID: &contactID},
+			PositiveTest: false, WhatError: model.ErrInvalidRecord,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(ContactRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherContact,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeTaskRepoCreateMock",
+			Method:       http.MethodPost,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			Data:         model.Task{Title: "Call back"},
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: contactOwnerID,
+			Mock:   makeList(TaskRepoCreateMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"List": {
+		{
+			Name:   "PositiveParticipantOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/tasks?status=OPEN&sort=-priority",
+			ExpectedData: crm.TaskListResponse{
+				Tasks:   []model.Task{testTask},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(TaskRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.TaskQuery{
+						Pagination:    model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+						Status:        model.TaskOpen,
+						Sorting:       model.Sorting{Sort: "-priority"},
+						ParticipantID: contactOwnerID.String(),
+					},
+					[]model.Task{testTask},
+					int64(1),
+				},
+			},
+		},
+		{
+			Name:         "NegativeInvalidQuery",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tasks?priority=asap",
+			PositiveTest: false, WhatError: model.ErrInvalidBody,
+		},
+		{
+			Name:         "NegativeTaskRepoListMock",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tasks",
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			Mock: makeList(TaskRepoListMock),
+			MockData: [][]interface{}{
+				{
+					errors.New("error"),
+				},
+			},
+		},
+	},
+	"My": {
+		{
+			Name:   "PositiveOpenBySoonestDue",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/tasks/my?assignee_id=" + contactOtherID.String(),
+			ExpectedData: crm.TaskListResponse{
+				Tasks:   []model.Task{testTask},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Mock:         makeList(TaskRepoListMock),
+			MockData: [][]interface{}{
+				{
+					model.TaskQuery{
+						Pagination: model.Pagination{Page: 1, PerPage: model.DefaultPerPage},
+						AssigneeID: contactOwnerID.String(),
+						Open:       true,
+						Sorting:    model.Sorting{Sort: "due_at"},
+					},
+					[]model.Task{testTask},
+					int64(1),
+				},
+			},
+		},
+	},
+	"Overdue": {
+		{
+			Name:   "PositiveOwnOnly",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/tasks/overdue?status=done",
+			ExpectedData: crm.TaskListResponse{
+				Tasks:   []model.Task{testTask},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(TaskRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Task{testTask},
+					int64(1),
+					overdueQuery{assigneeID: contactOwnerID.String()},
+				},
+			},
+		},
+		{
+			Name:   "PositiveEveryoneWithRead",
+			Method: http.MethodGet,
+			URL:    "https://localhost:8000/api/v1/tasks/overdue",
+			ExpectedData: crm.TaskListResponse{
+				Tasks:   []model.Task{otherTask},
+				Total:   1,
+				Page:    1,
+				PerPage: model.DefaultPerPage,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermTasksRead},
+			Mock:         makeList(TaskRepoListMock),
+			MockData: [][]interface{}{
+				{
+					[]model.Task{otherTask},
+					int64(1),
+					overdueQuery{},
+				},
+			},
+		},
+	},
+	"Get": {
+		{
+			Name:         "PositiveAssignee",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			ExpectedData: testTask,
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(TaskRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testTask,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotParticipant",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{},
+			Mock:        makeList(TaskRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherTask,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeNotFound",
+			Method:       http.MethodGet,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			PositiveTest: false, WhatError: model.ErrRecordNotFound,
+			Mock: makeList(TaskRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Update": {
+		{
+			Name:   "PositiveDoneKeepsAssigneeAndReminder",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			Data:   model.Task{Title: "Send the quote", Status: model.TaskDone, Priority: model.TaskPriorityHigh},
+			ExpectedData: model.Task{
+				ID:         taskID,
+				Title:      "Send the quote",
+				Priority:   model.TaskPriorityHigh,
+				Status:     model.TaskDone,
+				AssigneeID: &contactOtherID,
+				CreatorID:  &contactOtherID,
+				RemindedAt: &taskDueAt,
+			},
+			SkipFields:   []string{"completed_at"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermTasksRead, model.PermTasksUpdate},
+			Mock:         makeList(TaskRepoGetMock, TaskRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&otherTask,
+					true,
+				},
+				{
+					model.TaskDone,
+				},
+			},
+		},
+		{
+			Name:   "PositiveNewDueTimeResetsReminder",
+			Method: http.MethodPut,
+			URL:    "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			Data:   model.Task{Title: "Send the quote", DueAt: &taskDueAt},
+			ExpectedData: model.Task{
+				ID:         taskID,
+				Title:      "Send the quote",
+				DueAt:      &taskDueAt,
+				Priority:   model.TaskPriorityNormal,
+				Status:     model.TaskOpen,
+				AssigneeID: &contactOtherID,
+				CreatorID:  &contactOtherID,
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOwnerID,
+			Permissions:  []model.Permission{model.PermTasksRead, model.PermTasksUpdate},
+			Mock:         makeList(TaskRepoGetMock, TaskRepoUpdateMock),
+			MockData: [][]interface{}{
+				{
+					&otherTask,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeNotParticipant",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			Data:         model.Task{Title: "Send the quote"},
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermTasksRead},
+			Mock:        makeList(TaskRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&otherTask,
+					true,
+				},
+			},
+		},
+		{
+			Name:         "NegativeUnknownAssignee",
+			Method:       http.MethodPut,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			Data:         model.Task{Title: "Call back", AssigneeID: &parentCompanyID},
+			PositiveTest: false, WhatError: model.ErrInvalidAssignee,
+			UserID: contactOwnerID,
+			Mock:   makeList(TaskRepoGetMock, AuthRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testTask,
+					true,
+				},
+				{
+					false,
+				},
+			},
+		},
+	},
+	"Delete": {
+		{
+			Name:         "PositiveCreator",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			ExpectedData: crm.TaskDeleteResponse{Status: "task deleted"},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       contactOtherID,
+			Permissions:  []model.Permission{},
+			Mock:         makeList(TaskRepoGetMock, TaskRepoDeleteMock),
+			MockData: [][]interface{}{
+				{
+					&testTask,
+					true,
+				},
+				{},
+			},
+		},
+		{
+			Name:         "NegativeAssignee",
+			Method:       http.MethodDelete,
+			URL:          "https://localhost:8000/api/v1/tasks/" + taskID.String(),
+			PositiveTest: false, WhatError: model.ErrForbidden,
+			UserID:      contactOwnerID,
+			Permissions: []model.Permission{model.PermTasksRead, model.PermTasksUpdate},
+			Mock:        makeList(TaskRepoGetMock),
+			MockData: [][]interface{}{
+				{
+					&testTask,
+					true,
+				},
+			},
+		},
+	},
+}
+
+func TestTaskHandlers(t *testing.T) {
+	var repos []interface{}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAuthMiddleware := mockauthmiddleware.NewMockAuthMiddleware(mockCtrl)
+	repos = append(repos, mockAuthMiddleware)
+
+	mockPostgresStore := &store.Store{}
+
+	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
+
+	//all repos mock what need for tests
+	taskRepo := mockpostgresstore.NewMockTaskRepository(mockCtrl)
+	mockPostgresStore.Task = taskRepo
+	repos = append(repos, taskRepo)
+
+	contactRepo := mockpostgresstore.NewMockContactRepository(mockCtrl)
+	mockPostgresStore.Contact = contactRepo
+	repos = append(repos, contactRepo)
+
+	dealRepo := mockpostgresstore.NewMockDealRepository(mockCtrl)
+	mockPostgresStore.Deal = dealRepo
+	repos = append(repos, dealRepo)
+
+	authRepo := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	mockPostgresStore.Auth = authRepo
+	repos = append(repos, authRepo)
+
+	runHandlerTests(t, testAPI, repos, testMapTaskHandler)
+}
+
+// overdueQuery matches the query of the overdue view, its due time is the
+// time of the request.
+type overdueQuery struct {
+	assigneeID string
+}
+
+func (m overdueQuery) Matches(x interface{}) bool {
+	query, ok := x.(model.TaskQuery)
+
+	return ok && query.Open && query.Status == "" && query.Sort == "due_at" && query.AssigneeID == m.assigneeID &&
+		query.DueBefore != nil && time.Since(*query.DueBefore) < time.Minute
+}
+
+func (m overdueQuery) String() string {
+	return "is the overdue query of " + m.assigneeID
+}
+
+func TaskRepoCreateMock(repos []interface{}, data []interface{}) {
+	var taskMock *mockpostgresstore.MockTaskRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTaskRepository:
+			taskMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	taskMock.EXPECT().Create(gomock.Any()).Return(err).Times(1)
+}
+
+func TaskRepoGetMock(repos []interface{}, data []interface{}) {
+	var taskMock *mockpostgresstore.MockTaskRepository
+	var result *model.Task
+	var exist bool
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTaskRepository:
+			taskMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case bool:
+			exist = t
+		case *model.Task:
+			// the handler may change the task it gets
+			task := *t
+			result = &task
+		default:
+			continue
+		}
+	}
+
+	taskMock.EXPECT().Get(taskID).Return(result, exist).Times(1)
+}
+
+func TaskRepoListMock(repos []interface{}, data []interface{}) {
+	var taskMock *mockpostgresstore.MockTaskRepository
+	var query interface{} = gomock.Any()
+	var result []model.Task
+	var total int64
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTaskRepository:
+			taskMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.TaskQuery, gomock.Matcher:
+			query = t
+		case []model.Task:
+			result = t
+		case int64:
+			total = t
+		default:
+			continue
+		}
+	}
+
+	taskMock.EXPECT().List(query).Return(result, total, err).Times(1)
+}
+
+func TaskRepoUpdateMock(repos []interface{}, data []interface{}) {
+	var taskMock *mockpostgresstore.MockTaskRepository
+	var done bool
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTaskRepository:
+			taskMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		case model.TaskStatus:
+			done = t == model.TaskDone
+		default:
+			continue
+		}
+	}
+
+	taskMock.EXPECT().Update(gomock.Any()).DoAndReturn(func(task *model.Task) error {
+		// completing the task stamps it
+		if done && task.CompletedAt == nil {
+			return errors.New("task is not completed")
+		}
+
+		return err
+	}).Times(1)
+}
+
+func TaskRepoDeleteMock(repos []interface{}, data []interface{}) {
+	var taskMock *mockpostgresstore.MockTaskRepository
+	var err error
+
+	for _, r := range repos {
+		switch t := r.(type) {
+		case *mockpostgresstore.MockTaskRepository:
+			taskMock = t
+		}
+	}
+
+	for _, i := range data {
+		switch t := i.(type) {
+		case error:
+			err = t
+		default:
+			continue
+		}
+	}
+
+	taskMock.EXPECT().Delete(taskID).Return(err).Times(1)
+}
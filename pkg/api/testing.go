@@ -3,9 +3,13 @@ package api
 
 import (
 	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/config"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
 	"crm-system/pkg/store"
 
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,16 +17,37 @@ import (
 func initTestAPI(t *testing.T, middleware authmiddleware.AuthMiddleware, postgres *store.Store) *api {
 	t.Helper()
 
+	return initTestAPIWithConfig(t, middleware, postgres, testConfig())
+}
+
+func initTestAPIWithConfig(t *testing.T, middleware authmiddleware.AuthMiddleware, postgres *store.Store, config *config.Configs) *api {
+	t.Helper()
+
 	gin.SetMode(gin.ReleaseMode)
-	api := &api{
-		router:        gin.New(),
-		auth:          middleware,
-		postgresStore: postgres,
-	}
 
-	api.router = configureRouter(api)
+	return newAPI(config, postgres, middleware, mailer.NewLogMailer())
+}
 
-	return api
+func testConfig() *config.Configs {
+	return &config.Configs{
+		MFA: config.MFAConfig{Issuer: "CRM System"},
+		Password: config.PasswordConfig{
+			ResetURL:      "http://localhost:8000/reset-password",
+			ResetTokenTTL: config.Duration{Duration: time.Hour},
+			InvitationURL: "http://localhost:8000/accept-invitation",
+			InvitationTTL: config.Duration{Duration: 72 * time.Hour},
+		},
+		BruteForce: config.BruteForceConfig{
+			FreeAttempts:     5,
+			BaseDelay:        config.Duration{Duration: time.Second},
+			MaxDelay:         config.Duration{Duration: time.Minute},
+			AccountThreshold: 10,
+			IPThreshold:      100,
+			LockoutDuration:  config.Duration{Duration: 15 * time.Minute},
+			Window:           config.Duration{Duration: time.Hour},
+		},
+		Import: config.ImportConfig{MaxBytes: 1024, MaxRows: 10},
+	}
 }
 
 func makeList(f ...func([]interface{}, []interface{})) []func([]interface{}, []interface{}) {
@@ -32,3 +57,32 @@ func makeList(f ...func([]interface{}, []interface{})) []func([]interface{}, []i
 	}
 	return funcs
 }
+
+// hashPassword hashes a password of the test data.
+func hashPassword(password string) string {
+	hash, err := authmiddleware.CreateHashPassword(password)
+	if err != nil {
+		panic(err)
+	}
+
+	return hash
+}
+
+type testCaseKey struct{}
+
+// authorizeStub stands in for Authorize, it sets the principal of the test case.
+func authorizeStub(c *gin.Context) {
+	data, _ := c.Request.Context().Value(testCaseKey{}).(model.TestStructure)
+
+	permissions := data.Permissions
+	if permissions == nil {
+		permissions = model.AllPermissions
+	}
+
+	authmiddleware.SetPrincipal(c, &authmiddleware.Principal{
+		UserID:         data.UserID,
+		SessionID:      data.SessionID,
+		Permissions:    permissions,
+		ImpersonatorID: data.ImpersonatorID,
+	})
+}
@@ -1,14 +1,13 @@
 package api
 
 import (
+	"crm-system/pkg/authmiddleware"
 	"crm-system/pkg/logger"
 	"crm-system/pkg/model"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
-
-	"net/http"
-	"strings"
 )
 
 type UserHandler struct {
@@ -23,12 +22,14 @@ func NewUserHandler(a *api) *UserHandler {
 
 // UpdateInfo
 // @Summary update user info
+// @Description empty fields are kept, the changed fields are recorded on the timeline of the profile
+// @Description custom_fields are merged into the stored ones by key, null removes a value, the response has all of them
 // @Produce json
 // @Tags User
 // @Security ApiKeyAuth
 // @Param User  body model.User  true "User"
 // @Success 200 {object} model.User
-// @Failure 400 {object} errors.UIResponseErrorBadRequest
+// @Failure 400 {object} errors.UIResponseErrorValidation
 // @Router /api/v1/user/update-info [patch]
 //
 //nolint:varnamelen
@@ -42,14 +43,33 @@ func (h *UserHandler) UpdateInfo(c *gin.Context) {
 		return
 	}
 
-	user.UserID, err = h.getUserIDFromHeader(c)
+	user.UserID, err = authmiddleware.CurrentUserID(c)
 	if err != nil {
-		logger.Errorf("UpdatePersonalInfo.getUserRoleFromHeader", err)
+		logger.Errorf("UpdatePersonalInfo.CurrentUserID", err)
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
+	userDB, err := h.api.postgresStore.User.Get(user.UserID)
+	if err != nil {
+		logger.Errorf("UpdatePersonalInfo.Get", err)
+		c.JSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if userDB == nil {
+		userDB = &model.User{}
+	}
+
+	customFields, ok := h.api.CustomField().values(c, model.RecordUser, user.UserID, userDB.CustomFields, user.CustomFields)
+	if !ok {
+		return
+	}
+
+	user.CustomFields = customFields
+
 	err = h.api.postgresStore.User.Update(user)
 	if err != nil {
 		logger.Errorf("UpdatePersonalInfo.Update", err)
@@ -58,11 +78,21 @@ func (h *UserHandler) UpdateInfo(c *gin.Context) {
 		return
 	}
 
+	if changes := userDB.Changes(user); len(changes) > 0 {
+		h.api.Activity().timelineEvent(c, &model.TimelineEvent{
+			EntityType: model.RecordUser,
+			EntityID:   user.UserID,
+			Kind:       model.EventProfileUpdated,
+			Changes:    changes,
+		})
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
 // Get
 // @Summary get user info
+// @Description custom_fields has the values of the custom fields defined for users
 // @Produce json
 // @Tags User
 // @Security ApiKeyAuth
@@ -72,9 +102,9 @@ func (h *UserHandler) UpdateInfo(c *gin.Context) {
 //
 //nolint:varnamelen
 func (h *UserHandler) Get(c *gin.Context) {
-	userID, err := h.getUserIDFromHeader(c)
+	userID, err := authmiddleware.CurrentUserID(c)
 	if err != nil {
-		logger.Errorf("Get.getUserRoleFromHeader", err)
+		logger.Errorf("Get.CurrentUserID", err)
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
@@ -92,10 +122,46 @@ func (h *UserHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-//nolint:gocritic
-func (h *UserHandler) getUserIDFromHeader(c *gin.Context) (uuid.UUID, error) {
-	token := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", -1)
-	userID, err := h.api.auth.GetUserID(token)
+// checkOwner responds with an error unless the principal may give a record to
+// the owner, which takes the update permission, and the owner exists.
+//
+//nolint:varnamelen
+func (h *UserHandler) checkOwner(c *gin.Context, principal *authmiddleware.Principal, ownerID *uuid.UUID, update model.Permission) bool {
+	if *ownerID == principal.UserID {
+		return true
+	}
+
+	if !principal.Can(update) {
+		logger.Errorf("checkOwner.Can", *ownerID)
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return false
+	}
+
+	if _, exists := h.api.postgresStore.Auth.Get(*ownerID); !exists {
+		logger.Errorf("checkOwner.Get", *ownerID)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidOwner)
+
+		return false
+	}
+
+	return true
+}
+
+// canSee reports whether the user exists and the principal may see the
+// profile: users see their own, the profiles of others take users:read.
+func (h *UserHandler) canSee(principal *authmiddleware.Principal, userID uuid.UUID) bool {
+	if userID != principal.UserID && !principal.Can(model.PermUsersRead) {
+		return false
+	}
+
+	_, exists := h.api.postgresStore.Auth.Get(userID)
+
+	return exists
+}
 
-	return userID, err
+// canChange reports whether the principal may change the profile: users change
+// their own, the profiles of others take users:update.
+func (h *UserHandler) canChange(principal *authmiddleware.Principal, userID uuid.UUID) bool {
+	return userID == principal.UserID || principal.Can(model.PermUsersUpdate)
 }
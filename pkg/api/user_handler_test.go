@@ -1,22 +1,15 @@
 package api
 
 import (
-	"bytes"
 	"crm-system/pkg/authmiddleware/mockauthmiddleware"
 	"crm-system/pkg/model"
 	"crm-system/pkg/store"
 	"crm-system/pkg/store/mockpostgresstore"
-	"encoding/json"
 	"net/http"
-	"net/http/httptest"
-	"reflect"
-	"sort"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	uuid "github.com/satori/go.uuid"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
 var testMapUserHandler = map[string][]model.TestStructure{
@@ -32,19 +25,144 @@ var testMapUserHandler = map[string][]model.TestStructure{
 				Address: "Address",
 			},
 			ExpectedData: &model.User{
-				Name:    "Name",
-				Surname: "Surname",
-				Phone:   "Phone",
-				Address: "Address",
+				Name:         "Name",
+				Surname:      "Surname",
+				Phone:        "Phone",
+				Address:      "Address",
+				CustomFields: model.CustomFieldValues{},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(UserRepoGetMock, CustomFieldRepoListMock, UserRepoUpdateInfoMock, ActivityRepoCreateEventMock),
+			MockData: [][]interface{}{
+				{
+					&model.User{Name: "Name", Surname: "Old", Phone: "Phone"},
+				},
+				{},
+				{},
+				{
+					model.FieldChanges{
+						{Field: "surname", From: "Old", To: "Surname"},
+						{Field: "address", From: "", To: "Address"},
+					},
+				},
+			},
+		},
+		{
+			Name:   "PositiveUnchanged",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/update-info",
+			Data:   model.User{Name: "Name"},
+			ExpectedData: &model.User{
+				Name:         "Name",
+				CustomFields: model.CustomFieldValues{"department": "Sales"},
+			},
+			PositiveTest: true,
+			WhatError:    nil,
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(UserRepoGetMock, CustomFieldRepoListMock, UserRepoUpdateInfoMock),
+			MockData: [][]interface{}{
+				{
+					&model.User{Name: "Name", Surname: "Surname", CustomFields: model.CustomFieldValues{"department": "Sales"}},
+				},
+				{
+					[]model.CustomFieldDefinition{departmentField},
+				},
+				{},
+			},
+		},
+		{
+			Name:   "PositiveCustomFields",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/update-info",
+			Data: model.User{CustomFields: model.CustomFieldValues{
+				"department": "sales",
+				"badge":      "B-17",
+				"languages":  []string{"EN", "uk", "en"},
+				"remote":     nil,
+			}},
+			ExpectedData: &model.User{
+				CustomFields: model.CustomFieldValues{
+					"department": "Sales",
+					"badge":      "B-17",
+					"languages":  []string{"en", "uk"},
+				},
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(MiddlewareGetUserIDMock, UserRepoUpdateInfoMock),
+			UserID:       uuid.NewV4(),
+			Mock: makeList(UserRepoGetMock, CustomFieldRepoListMock, CustomFieldRepoIsTakenMock,
+				UserRepoUpdateInfoMock, ActivityRepoCreateEventMock),
 			MockData: [][]interface{}{
 				{
-					uuid.NewV4(),
+					&model.User{CustomFields: model.CustomFieldValues{"remote": true}},
+				},
+				{
+					[]model.CustomFieldDefinition{badgeField, departmentField, languagesField, remoteField},
+				},
+				{
+					false,
 				},
 				{},
+				{
+					model.FieldChanges{
+						{Field: "custom_fields.badge", From: "", To: "B-17"},
+						{Field: "custom_fields.department", From: "", To: "Sales"},
+						{Field: "custom_fields.languages", From: "", To: `["en","uk"]`},
+						{Field: "custom_fields.remote", From: "true", To: ""},
+					},
+				},
+			},
+		},
+		{
+			Name:   "NegativeCustomFields",
+			Method: http.MethodPatch,
+			URL:    "https://localhost:8000/api/v1/user/update-info",
+			Data: model.User{CustomFields: model.CustomFieldValues{
+				"department": "Legal",
+				"remote":     "yes",
+				"shoe_size":  42,
+			}},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "custom_fields.department", Rule: "oneof", Message: "value is not one of the options"},
+				{Field: "custom_fields.remote", Rule: "type", Message: "value must be a boolean"},
+				{Field: "custom_fields.shoe_size", Rule: "unknown", Message: "unknown custom field"},
+				{Field: "custom_fields.badge", Rule: "required", Message: "Badge is required"},
+			}),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(UserRepoGetMock, CustomFieldRepoListMock),
+			MockData: [][]interface{}{
+				{
+					&model.User{},
+				},
+				{
+					[]model.CustomFieldDefinition{badgeField, departmentField, remoteField},
+				},
+			},
+		},
+		{
+			Name:         "NegativeUniqueCustomField",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/user/update-info",
+			Data:         model.User{CustomFields: model.CustomFieldValues{"badge": "B-17"}},
+			PositiveTest: false,
+			WhatError: model.NewValidationError([]model.FieldError{
+				{Field: "custom_fields.badge", Rule: "unique", Message: "Badge is already taken"},
+			}),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(UserRepoGetMock, CustomFieldRepoListMock, CustomFieldRepoIsTakenMock),
+			MockData: [][]interface{}{
+				{
+					&model.User{},
+				},
+				{
+					[]model.CustomFieldDefinition{badgeField},
+				},
+				{
+					true,
+				},
 			},
 		},
 		{
@@ -55,7 +173,7 @@ var testMapUserHandler = map[string][]model.TestStructure{
 			PositiveTest: false, WhatError: model.ErrInvalidBody,
 		},
 		{
-			Name:   "NegativeMiddlewareGetUserIDMock",
+			Name:   "NegativeUserRepoUpdateInfoMock",
 			Method: http.MethodPatch,
 			URL:    "https://localhost:8000/api/v1/user/update-info",
 			Data: model.User{
@@ -64,30 +182,28 @@ var testMapUserHandler = map[string][]model.TestStructure{
 				Phone:   "Phone",
 				Address: "Address",
 			},
-			PositiveTest: false, WhatError: model.ErrUnauthorized,
-			Mock: makeList(MiddlewareGetUserIDMock),
+			PositiveTest: false, WhatError: model.ErrUnhealthy,
+			UserID: uuid.NewV4(),
+			Mock:   makeList(UserRepoGetMock, CustomFieldRepoListMock, UserRepoUpdateInfoMock),
 			MockData: [][]interface{}{
+				{
+					&model.User{},
+				},
+				{},
 				{
 					model.ErrUnhealthy,
 				},
 			},
 		},
 		{
-			Name:   "NegativeMiddlewareGetUserIDMock",
-			Method: http.MethodPatch,
-			URL:    "https://localhost:8000/api/v1/user/update-info",
-			Data: model.User{
-				Name:    "Name",
-				Surname: "Surname",
-				Phone:   "Phone",
-				Address: "Address",
-			},
+			Name:         "NegativeUserRepoGetMock",
+			Method:       http.MethodPatch,
+			URL:          "https://localhost:8000/api/v1/user/update-info",
+			Data:         model.User{Name: "Name"},
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserIDMock, UserRepoUpdateInfoMock),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(UserRepoGetMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					model.ErrUnhealthy,
 				},
@@ -107,11 +223,9 @@ var testMapUserHandler = map[string][]model.TestStructure{
 			},
 			PositiveTest: true,
 			WhatError:    nil,
-			Mock:         makeList(MiddlewareGetUserIDMock, UserRepoGetMock),
+			UserID:       uuid.NewV4(),
+			Mock:         makeList(UserRepoGetMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					&model.User{
 						Name:    "Name",
@@ -122,28 +236,14 @@ var testMapUserHandler = map[string][]model.TestStructure{
 				},
 			},
 		},
-		{
-			Name:         "NegativeMiddlewareGetUserIDMock",
-			Method:       http.MethodGet,
-			URL:          "https://localhost:8000/api/v1/user/",
-			PositiveTest: false, WhatError: model.ErrUnauthorized,
-			Mock: makeList(MiddlewareGetUserIDMock),
-			MockData: [][]interface{}{
-				{
-					model.ErrUnhealthy,
-				},
-			},
-		},
 		{
 			Name:         "NegativeUserRepoGetMock",
 			Method:       http.MethodGet,
 			URL:          "https://localhost:8000/api/v1/user/",
 			PositiveTest: false, WhatError: model.ErrUnhealthy,
-			Mock: makeList(MiddlewareGetUserIDMock, UserRepoGetMock),
+			UserID: uuid.NewV4(),
+			Mock:   makeList(UserRepoGetMock),
 			MockData: [][]interface{}{
-				{
-					uuid.NewV4(),
-				},
 				{
 					model.ErrUnhealthy,
 				},
@@ -163,115 +263,22 @@ func TestUserHandlers(t *testing.T) {
 	mockPostgresStore := &store.Store{}
 
 	testAPI := initTestAPI(t, mockAuthMiddleware, mockPostgresStore)
-	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Return().AnyTimes()
+	mockAuthMiddleware.EXPECT().Authorize(gomock.Any()).Do(authorizeStub).AnyTimes()
 
 	//all repos mock what need for tests
 	promoUserRepo := mockpostgresstore.NewMockUserRepository(mockCtrl)
 	mockPostgresStore.User = promoUserRepo
 	repos = append(repos, promoUserRepo)
 
-	//execute tests
-	for apiName, testsUserHandlers := range testMapUserHandler {
-		t.Run(apiName, func(t *testing.T) {
-			for _, data := range testsUserHandlers {
-				t.Run(data.Name, func(t *testing.T) {
-					if data.Mock != nil {
-						for i, m := range data.Mock {
-							m(repos, data.MockData[i])
-						}
-					}
-					body, err := json.Marshal(data.Data)
-					require.NoError(t, err)
-					req, err := http.NewRequest(data.Method, data.URL, bytes.NewBuffer(body))
-					require.NoError(t, err)
+	activityRepo := mockpostgresstore.NewMockActivityRepository(mockCtrl)
+	mockPostgresStore.Activity = activityRepo
+	repos = append(repos, activityRepo)
 
-					rr := httptest.NewRecorder()
-					testAPI.ServeHTTP(rr, req)
+	customFieldRepo := mockpostgresstore.NewMockCustomFieldRepository(mockCtrl)
+	mockPostgresStore.CustomField = customFieldRepo
+	repos = append(repos, customFieldRepo)
 
-					if data.PositiveTest {
-						assert.Equal(t, http.StatusOK, rr.Code, "handler return wrong status code")
-						if reflect.TypeOf(data.ExpectedData).String() != "string" { // if func don't have answered or answer is string
-							body, err = json.Marshal(data.ExpectedData)
-							require.NoError(t, err)
-							if data.SkipFields != nil {
-								var bodyActual, bodyExpectet interface{}
-								if data.SkipRoot != "" {
-									expect := data.ExpectedData.(map[string]interface{})
-									body, err = json.Marshal(expect[data.SkipRoot])
-									require.NoError(t, err)
-									var actualMap, expectMap []map[string]interface{}
-									err = json.Unmarshal(body, &expectMap)
-									require.NoError(t, err)
-									keys := make([]string, 0, len(expect))
-									for k := range expect {
-										keys = append(keys, k)
-									}
-									sort.Strings(keys)
-									for _, key := range keys {
-										if key != data.SkipRoot {
-											expectMap = append(expectMap, map[string]interface{}{key: expect[key]})
-										}
-									}
-
-									var actual map[string]interface{}
-									err = json.Unmarshal(rr.Body.Bytes(), &actual)
-									require.NoError(t, err)
-									body, err = json.Marshal(actual[data.SkipRoot])
-									require.NoError(t, err)
-									err = json.Unmarshal(body, &actualMap)
-									require.NoError(t, err)
-									keys = make([]string, 0, len(actual))
-									for k := range actual {
-										keys = append(keys, k)
-									}
-									sort.Strings(keys)
-									for _, key := range keys {
-										if key != data.SkipRoot {
-											actualMap = append(actualMap, map[string]interface{}{key: actual[key]})
-										}
-									}
-
-									for _, skip := range data.SkipFields {
-										require.Equal(t, len(actualMap), len(expectMap))
-										for i := range actualMap {
-											delete(actualMap[i], skip)
-											delete(expectMap[i], skip)
-										}
-									}
-									bodyActual = actualMap
-									bodyExpectet = expectMap
-								} else {
-									var actual, expect map[string]interface{}
-									err = json.Unmarshal(body, &expect)
-									require.NoError(t, err)
-									err = json.Unmarshal(rr.Body.Bytes(), &actual)
-									require.NoError(t, err)
-									for _, skip := range data.SkipFields {
-										delete(actual, skip)
-										delete(expect, skip)
-									}
-									bodyActual = actual
-									bodyExpectet = expect
-								}
-								body, err = json.Marshal(bodyExpectet)
-								require.NoError(t, err)
-								resActual, err := json.Marshal(bodyActual)
-								require.NoError(t, err)
-								rr.Body = bytes.NewBuffer(resActual)
-							}
-							assert.JSONEq(t, string(body), rr.Body.String())
-						} else {
-							assert.Equal(t, data.ExpectedData, rr.Body.String())
-						}
-					} else {
-						body, err = json.Marshal(data.WhatError)
-						require.NoError(t, err)
-						assert.JSONEq(t, string(body), rr.Body.String())
-					}
-				})
-			}
-		})
-	}
+	runHandlerTests(t, testAPI, repos, testMapUserHandler)
 }
 
 func UserRepoUpdateInfoMock(repos []interface{}, data []interface{}) {
@@ -0,0 +1,61 @@
+package authmiddleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// APIKeyScheme is the Authorization scheme accepted besides Bearer, "Authorization: ApiKey <key>".
+	APIKeyScheme = "ApiKey"
+
+	apiKeyBrand       = "crm"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// GenerateAPIKey returns a new key "crm_<id>_<secret>", its non-secret prefix
+// "crm_<id>" and the hash to store.
+func GenerateAPIKey() (string, string, string, error) {
+	id := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix := apiKeyBrand + "_" + hex.EncodeToString(id)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes an API key, keys are random so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// ExtractAPIKey returns the key of the X-API-Key header or of the ApiKey
+// Authorization scheme, empty when the request carries none.
+func ExtractAPIKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, APIKeyScheme) {
+		return strings.TrimSpace(key)
+	}
+
+	return ""
+}
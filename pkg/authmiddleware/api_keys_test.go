@@ -0,0 +1,38 @@
+package authmiddleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, prefix+"_"))
+	assert.True(t, strings.HasPrefix(prefix, "crm_"))
+	assert.Equal(t, HashAPIKey(key), hash)
+	assert.NotContains(t, hash, key)
+
+	other, _, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestExtractAPIKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", ExtractAPIKey(req))
+
+	req.Header.Set("Authorization", "Bearer token")
+	assert.Equal(t, "", ExtractAPIKey(req))
+
+	req.Header.Set("Authorization", "ApiKey crm_key")
+	assert.Equal(t, "crm_key", ExtractAPIKey(req))
+
+	req.Header.Set(APIKeyHeader, "crm_header")
+	assert.Equal(t, "crm_header", ExtractAPIKey(req))
+}
@@ -5,7 +5,7 @@ import (
 	"crm-system/pkg/logger"
 	"crm-system/pkg/model"
 	"crm-system/pkg/store"
-	"crypto/ecdsa"
+	"errors"
 
 	"net/http"
 	"strings"
@@ -17,29 +17,44 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const StringsNumber = 2
+const (
+	StringsNumber = 2
+	// touchInterval bounds how often the last use of an API key or a session is written.
+	touchInterval = time.Minute
+)
 
 type AuthMiddleware struct {
 	postgres *store.Store
 	loc      *time.Location
-	atKey    *ecdsa.PrivateKey
-	rtKey    *ecdsa.PrivateKey
+	atKeys   *authmiddleware.KeyRing
+	rtKeys   *authmiddleware.KeyRing
+	// mfaKey signs the MFA challenge tokens, see authmiddleware.MFATokenKey.
+	mfaKey []byte
 }
 
-func NewAuthMiddleware(postgres *store.Store, atKey, rtKey *ecdsa.PrivateKey) *AuthMiddleware {
+func NewAuthMiddleware(postgres *store.Store, atKeys, rtKeys *authmiddleware.KeyRing, mfaKey []byte) *AuthMiddleware {
 	loc, _ := time.LoadLocation("Europe/Moscow")
 	var middleware = &AuthMiddleware{
 		loc:      loc,
 		postgres: postgres,
-		atKey:    atKey,
-		rtKey:    rtKey,
+		atKeys:   atKeys,
+		rtKeys:   rtKeys,
+		mfaKey:   mfaKey,
 	}
 
 	return middleware
 }
 
+// Authorize accepts an access token or, for service accounts, an API key.
+//
 //nolint:varnamelen
 func (m *AuthMiddleware) Authorize(c *gin.Context) {
+	if key := authmiddleware.ExtractAPIKey(c.Request); key != "" {
+		m.authorizeAPIKey(c, key)
+
+		return
+	}
+
 	tokenString := m.ExtractToken(c.Request)
 	claims, err := m.Validate(tokenString)
 	if err != nil {
@@ -64,6 +79,13 @@ func (m *AuthMiddleware) Authorize(c *gin.Context) {
 		return
 	}
 
+	if !userDB.Active {
+		logger.Errorf("Authorize.Active", userDB.ID)
+		c.AbortWithStatusJSON(http.StatusForbidden, model.ErrAccountDisabled)
+
+		return
+	}
+
 	if claims.BaseClaims.Role != userDB.Role {
 		logger.Errorf("Authorize.User role", err)
 		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrRefreshExpired)
@@ -71,100 +93,316 @@ func (m *AuthMiddleware) Authorize(c *gin.Context) {
 		return
 	}
 
+	permissions, err := m.postgres.Role.Permissions(userDB.Role)
+	if err != nil {
+		logger.Errorf("Authorize.Permissions", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
+	}
+
+	if claims.IsImpersonation() {
+		m.authorizeImpersonation(c, claims, userDB, permissions)
+
+		return
+	}
+
+	session, ok := m.activeSession(claims.SessionID, userDB.ID)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	authmiddleware.SetPrincipal(c, &authmiddleware.Principal{
+		UserID:      userDB.ID,
+		Role:        userDB.Role,
+		TokenID:     claims.Id,
+		SessionID:   session.ID,
+		Permissions: permissions,
+	})
+
 	c.Next()
 }
 
-func (m *AuthMiddleware) GetUserID(accessToken string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &authmiddleware.AccessClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-				logger.Errorf("GetUserId.unexpected signing method: %v", token.Header["alg"])
+// authorizeImpersonation accepts the token while the actor is active, its session
+// is and it may still impersonate the user. Every request is written to the audit log.
+//
+//nolint:varnamelen
+func (m *AuthMiddleware) authorizeImpersonation(
+	c *gin.Context,
+	claims *authmiddleware.AccessClaims,
+	userDB *model.AuthUser,
+	permissions []model.Permission,
+) {
+	actorDB, exists := m.postgres.Auth.Get(claims.Actor.ID)
+	if !exists || !actorDB.Active {
+		logger.Errorf("Authorize.Actor", claims.Actor.ID)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
-				return nil, model.ErrUnauthorized
-			}
+		return
+	}
 
-			return &m.atKey.PublicKey, nil
-		})
-	if err != nil {
-		return uuid.Nil, model.ErrUnauthorized
+	if _, ok := m.activeSession(claims.SessionID, actorDB.ID); !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
 	}
 
-	claims, ok := token.Claims.(*authmiddleware.AccessClaims)
-	if !ok {
-		logger.Errorf("Refresh.invalid token claims: %v", token.Claims)
+	actorPermissions, err := m.postgres.Role.Permissions(actorDB.Role)
+	if err != nil {
+		logger.Errorf("Authorize.Actor.Permissions", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, model.ErrUnhealthy)
 
-		return uuid.Nil, model.ErrUnauthorized
+		return
 	}
 
-	if !token.Valid {
-		return uuid.Nil, model.ErrUnauthorized
+	actor := &authmiddleware.Principal{UserID: actorDB.ID, Permissions: actorPermissions}
+	if !actor.Can(model.PermUsersImpersonate) || !actor.CanAll(permissions) {
+		logger.Errorf("Authorize.Actor may not impersonate", actorDB.ID)
+		c.AbortWithStatusJSON(http.StatusForbidden, model.ErrForbidden)
+
+		return
 	}
 
-	return claims.BaseClaims.ID, nil
+	authmiddleware.SetPrincipal(c, &authmiddleware.Principal{
+		UserID:         userDB.ID,
+		Role:           userDB.Role,
+		TokenID:        claims.Id,
+		Permissions:    permissions,
+		ImpersonatorID: actorDB.ID,
+	})
+
+	c.Next()
+
+	err = m.postgres.ImpersonationLog.Create(&model.ImpersonationLog{
+		ImpersonatorID: actorDB.ID,
+		UserID:         userDB.ID,
+		TokenID:        claims.Id,
+		Method:         c.Request.Method,
+		Path:           c.Request.URL.Path,
+		Status:         c.Writer.Status(),
+		IP:             c.ClientIP(),
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		logger.Errorf("Authorize.ImpersonationLog.Create", err)
+	}
 }
 
-func (m *AuthMiddleware) GetUserRole(accessToken string) (model.UserRole, error) {
-	// Create new pairs of refresh and access tokens
-	token, err := jwt.ParseWithClaims(accessToken, &authmiddleware.AccessClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-				logger.Errorf("GetUserId.unexpected signing method: %v", token.Header["alg"])
+//nolint:varnamelen
+func (m *AuthMiddleware) authorizeAPIKey(c *gin.Context, key string) {
+	now := time.Now()
+
+	apiKey, exists := m.postgres.APIKey.GetByHash(authmiddleware.HashAPIKey(key))
+	if !exists || !apiKey.IsUsable(now) {
+		logger.Errorf("authorizeAPIKey.GetByHash", "unknown, revoked or expired key")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	userDB, exists := m.postgres.Auth.Get(apiKey.UserID)
+	if !exists {
+		logger.Errorf("authorizeAPIKey.Get", apiKey.UserID)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
 
-				return nil, model.ErrUnauthorized
-			}
+	if !userDB.Active {
+		logger.Errorf("authorizeAPIKey.Active", userDB.ID)
+		c.AbortWithStatusJSON(http.StatusForbidden, model.ErrAccountDisabled)
 
-			return &m.atKey.PublicKey, nil
-		})
+		return
+	}
+
+	permissions, err := m.postgres.Role.Permissions(userDB.Role)
 	if err != nil {
-		return "", model.ErrUnauthorized
+		logger.Errorf("authorizeAPIKey.Permissions", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, model.ErrUnhealthy)
+
+		return
 	}
 
-	claims, ok := token.Claims.(*authmiddleware.AccessClaims)
-	if !ok {
-		logger.Errorf("Refresh.invalid token claims: %v", token.Claims)
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > touchInterval {
+		err = m.postgres.APIKey.Touch(apiKey.ID, now)
+		if err != nil {
+			logger.Errorf("authorizeAPIKey.Touch", err)
+		}
+	}
+
+	authmiddleware.SetPrincipal(c, &authmiddleware.Principal{
+		UserID:      userDB.ID,
+		Role:        userDB.Role,
+		TokenID:     apiKey.ID.String(),
+		Permissions: apiKey.Scope(permissions),
+	})
+
+	c.Next()
+}
+
+// activeSession loads the session of an access token, tokens of revoked sessions are rejected.
+func (m *AuthMiddleware) activeSession(sessionID, userID uuid.UUID) (*model.Session, bool) {
+	now := time.Now()
+
+	session, exists := m.postgres.Session.Get(sessionID)
+	if !exists || session.UserID != userID || !session.IsActive(now) {
+		logger.Errorf("Authorize.Session", sessionID)
 
-		return "", model.ErrUnauthorized
+		return nil, false
 	}
 
-	if !token.Valid {
-		return "", model.ErrUnauthorized
+	if now.Sub(session.LastSeenAt) > touchInterval {
+		err := m.postgres.Session.Touch(session.ID, now)
+		if err != nil {
+			logger.Errorf("Authorize.Session.Touch", err)
+		}
 	}
 
-	return claims.BaseClaims.Role, nil
+	return session, true
 }
 
-func (m *AuthMiddleware) CreateTokens(id uuid.UUID, role model.UserRole) (*authmiddleware.Tokens, error) {
-	accessClaims, refreshClaims := authmiddleware.GenerateClaims(id, role)
+// CreateTokens starts a new session for the client and issues its first token pair.
+func (m *AuthMiddleware) CreateTokens(
+	id uuid.UUID,
+	role model.UserRole,
+	client authmiddleware.Client,
+) (*authmiddleware.Tokens, error) {
+	sessionID := uuid.NewV4()
 
-	at := jwt.NewWithClaims(jwt.SigningMethodES256, accessClaims)
-	accessToken, err := at.SignedString(m.atKey)
+	tokens, refreshToken, err := m.signTokens(id, role, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	rt := jwt.NewWithClaims(jwt.SigningMethodES256, refreshClaims)
-	refreshToken, err := rt.SignedString(m.rtKey)
+	now := time.Now()
+
+	err = m.postgres.Session.Create(&model.Session{
+		ID:         sessionID,
+		UserID:     id,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  refreshToken.ExpiresAt,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &authmiddleware.Tokens{
-		Access:  accessToken,
-		Refresh: refreshToken,
-	}, nil
+	err = m.postgres.RefreshToken.Create(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
 }
 
+// Refresh rotates the refresh token: the presented token is revoked and a new pair
+// from the same family is issued. Presenting a token that was already rotated is
+// treated as theft and revokes the whole family.
 func (m *AuthMiddleware) Refresh(tokens authmiddleware.Tokens) (*authmiddleware.Tokens, error) {
-	token, err := jwt.ParseWithClaims(tokens.Refresh, &authmiddleware.RefreshClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-				logger.Errorf("Refresh.unexpected signing method: %v", token.Header["alg"])
+	claims, err := m.parseRefresh(tokens.Refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, exists := m.postgres.RefreshToken.Get(uuid.FromStringOrNil(claims.Id))
+	if !exists {
+		logger.Errorf("Refresh.RefreshToken.Get", "unknown refresh token")
+
+		return nil, model.ErrUnauthorized
+	}
+
+	if stored.IsRevoked() {
+		m.revokeFamily(stored.FamilyID)
 
-				return nil, model.ErrUnauthorized
-			}
+		return nil, model.ErrUnauthorized
+	}
 
-			return &m.rtKey.PublicKey, nil
-		})
+	userDB, exists := m.postgres.Auth.Get(claims.BaseClaims.ID)
+	if !exists || !userDB.Active {
+		logger.Errorf("Refresh.Get", claims.BaseClaims.ID)
+
+		return nil, model.ErrUnauthorized
+	}
+
+	newTokens, refreshToken, err := m.signTokens(userDB.ID, userDB.Role, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.postgres.RefreshToken.Rotate(stored.ID, refreshToken)
+	if err != nil {
+		logger.Errorf("Refresh.RefreshToken.Rotate", err)
+		if errors.Is(err, model.ErrTokenReused) {
+			m.revokeFamily(stored.FamilyID)
+		}
+
+		return nil, model.ErrUnauthorized
+	}
+
+	err = m.postgres.Session.Extend(stored.FamilyID, time.Now(), refreshToken.ExpiresAt)
+	if err != nil {
+		logger.Errorf("Refresh.Session.Extend", err)
+	}
+
+	return newTokens, nil
+}
+
+// Logout revokes the session the refresh token belongs to.
+func (m *AuthMiddleware) Logout(refreshToken string) error {
+	claims, err := m.parseRefresh(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	stored, exists := m.postgres.RefreshToken.Get(uuid.FromStringOrNil(claims.Id))
+	if !exists {
+		return model.ErrUnauthorized
+	}
+
+	return m.postgres.Session.Revoke(stored.FamilyID)
+}
+
+// LogoutAll revokes every session of the user.
+func (m *AuthMiddleware) LogoutAll(userID uuid.UUID) error {
+	return m.postgres.Session.RevokeAllByUser(userID)
+}
+
+func (m *AuthMiddleware) signTokens(
+	id uuid.UUID,
+	role model.UserRole,
+	familyID uuid.UUID,
+) (*authmiddleware.Tokens, *model.RefreshToken, error) {
+	accessClaims, refreshClaims := authmiddleware.GenerateClaims(id, role, familyID)
+
+	accessToken, err := m.atKeys.Sign(accessClaims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := m.rtKeys.Sign(refreshClaims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := &model.RefreshToken{
+		ID:        uuid.FromStringOrNil(refreshClaims.Id),
+		UserID:    id,
+		FamilyID:  familyID,
+		ExpiresAt: time.Unix(refreshClaims.ExpiresAt, 0),
+	}
+
+	return &authmiddleware.Tokens{
+		Access:  accessToken,
+		Refresh: refreshToken,
+	}, stored, nil
+}
+
+func (m *AuthMiddleware) parseRefresh(raw string) (*authmiddleware.RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(raw, &authmiddleware.RefreshClaims{}, m.rtKeys.Keyfunc)
 	if err != nil {
 		return nil, model.ErrUnauthorized
 	}
@@ -180,14 +418,21 @@ func (m *AuthMiddleware) Refresh(tokens authmiddleware.Tokens) (*authmiddleware.
 		return nil, model.ErrUnauthorized
 	}
 
-	userDB, exists := m.postgres.Auth.Get(claims.BaseClaims.ID)
-	if !exists {
-		logger.Errorf("Refresh.Get", err)
+	return claims, nil
+}
 
-		return nil, model.ErrUnauthorized
+func (m *AuthMiddleware) revokeFamily(familyID uuid.UUID) {
+	logger.Errorf("Refresh.reuse detected, revoking family", familyID)
+
+	err := m.postgres.Session.Revoke(familyID)
+	if err != nil {
+		logger.Errorf("Refresh.Session.Revoke", err)
 	}
+}
 
-	return m.CreateTokens(claims.ID, userDB.Role)
+// JWKS publishes the public access token keys so other services can verify access tokens.
+func (m *AuthMiddleware) JWKS() authmiddleware.JWKSet {
+	return m.atKeys.JWKS()
 }
 
 func (m *AuthMiddleware) ExtractToken(r *http.Request) string {
@@ -202,16 +447,7 @@ func (m *AuthMiddleware) ExtractToken(r *http.Request) string {
 
 // Validate verifies token signature.
 func (m *AuthMiddleware) Validate(raw string) (*authmiddleware.AccessClaims, error) {
-	token, err := jwt.ParseWithClaims(raw, &authmiddleware.AccessClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-			logger.Errorf("Validate.unexpected signing method: %v", token.Header["alg"])
-
-			return nil, model.ErrUnauthorized
-		}
-
-		return &m.atKey.PublicKey, nil
-	})
-
+	token, err := jwt.ParseWithClaims(raw, &authmiddleware.AccessClaims{}, m.atKeys.Keyfunc)
 	if err != nil {
 		return nil, model.ErrUnauthorized
 	}
@@ -229,3 +465,46 @@ func (m *AuthMiddleware) Validate(raw string) (*authmiddleware.AccessClaims, err
 
 	return claims, nil
 }
+
+// CreateMFAToken issues the short-lived challenge token of the second login step,
+// it is signed with the MFA key so no access or refresh token parser accepts it.
+func (m *AuthMiddleware) CreateMFAToken(id uuid.UUID, role model.UserRole, enroll bool) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, authmiddleware.NewMFAClaims(id, role, enroll))
+	token.Header[authmiddleware.TypeHeader] = authmiddleware.MFATokenType
+
+	return token.SignedString(m.mfaKey)
+}
+
+// Impersonate signs the token with the access key, Validate accepts it like any access token.
+func (m *AuthMiddleware) Impersonate(
+	id uuid.UUID,
+	role model.UserRole,
+	actorID, actorSessionID uuid.UUID,
+) (string, error) {
+	return m.atKeys.Sign(authmiddleware.NewImpersonationClaims(id, role, actorID, actorSessionID))
+}
+
+func (m *AuthMiddleware) ValidateMFAToken(raw string) (*authmiddleware.MFAClaims, error) {
+	token, err := jwt.ParseWithClaims(raw, &authmiddleware.MFAClaims{}, m.mfaKeyfunc)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	claims, ok := token.Claims.(*authmiddleware.MFAClaims)
+	if !ok || !token.Valid {
+		return nil, model.ErrUnauthorized
+	}
+
+	return claims, nil
+}
+
+// mfaKeyfunc accepts only HS256 tokens of the MFA challenge type.
+func (m *AuthMiddleware) mfaKeyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodHS256 || authmiddleware.TokenType(token) != authmiddleware.MFATokenType {
+		logger.Errorf("mfaKeyfunc.unexpected token", token.Header)
+
+		return nil, model.ErrUnauthorized
+	}
+
+	return m.mfaKey, nil
+}
@@ -0,0 +1,72 @@
+package appauth
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/model"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMiddleware(t *testing.T) *AuthMiddleware {
+	t.Helper()
+
+	newRing := func() *authmiddleware.KeyRing {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		ring, err := authmiddleware.NewKeyRing("key", map[string]*ecdsa.PrivateKey{"key": key})
+		require.NoError(t, err)
+
+		return ring
+	}
+
+	mfaKey, err := authmiddleware.MFATokenKey("")
+	require.NoError(t, err)
+
+	return NewAuthMiddleware(nil, newRing(), newRing(), mfaKey)
+}
+
+func TestMFAToken(t *testing.T) {
+	middleware := newTestMiddleware(t)
+	userID := uuid.NewV4()
+
+	signed, err := middleware.CreateMFAToken(userID, model.AdminUserRole, true)
+	require.NoError(t, err)
+
+	token, _, err := new(jwt.Parser).ParseUnverified(signed, &authmiddleware.MFAClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, authmiddleware.MFATokenType, token.Header[authmiddleware.TypeHeader])
+	assert.Equal(t, "HS256", token.Header["alg"])
+
+	claims, err := middleware.ValidateMFAToken(signed)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.BaseClaims.ID)
+	assert.True(t, claims.Enroll)
+
+	_, err = middleware.Validate(signed)
+	assert.ErrorIs(t, err, model.ErrUnauthorized, "challenge tokens are no access tokens")
+
+	_, err = middleware.parseRefresh(signed)
+	assert.ErrorIs(t, err, model.ErrUnauthorized, "challenge tokens are no refresh tokens")
+
+	_, err = newTestMiddleware(t).ValidateMFAToken(signed)
+	assert.ErrorIs(t, err, model.ErrUnauthorized, "another MFA key")
+}
+
+func TestValidateMFATokenAccessToken(t *testing.T) {
+	middleware := newTestMiddleware(t)
+
+	access, _ := authmiddleware.GenerateClaims(uuid.NewV4(), model.AdminUserRole, uuid.NewV4())
+	signed, err := middleware.atKeys.Sign(access)
+	require.NoError(t, err)
+
+	_, err = middleware.ValidateMFAToken(signed)
+	assert.ErrorIs(t, err, model.ErrUnauthorized)
+}
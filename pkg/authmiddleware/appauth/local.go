@@ -0,0 +1,61 @@
+package appauth
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// LocalAuthenticator checks the password hash of auth_users. Users without a
+// password (directory, single sign-on and service accounts) are unknown to it.
+type LocalAuthenticator struct {
+	postgres *store.Store
+}
+
+func NewLocalAuthenticator(postgres *store.Store) *LocalAuthenticator {
+	return &LocalAuthenticator{postgres: postgres}
+}
+
+func (a *LocalAuthenticator) Authenticate(username, password string) (*model.AuthUser, error) {
+	userDB, err := a.postgres.Auth.GetByUsername(username)
+	if err != nil {
+		if err.Error() == model.NotFound {
+			return nil, authmiddleware.ErrUnknownUser
+		}
+
+		return nil, err
+	}
+
+	if userDB == nil || userDB.ID == uuid.Nil || userDB.Password == "" || userDB.ServiceAccount {
+		return nil, authmiddleware.ErrUnknownUser
+	}
+
+	if !authmiddleware.IsPasswordMatch(password, userDB.Password) {
+		return nil, authmiddleware.ErrInvalidCredentials
+	}
+
+	if authmiddleware.NeedsRehash(userDB.Password) {
+		a.rehashPassword(userDB, password)
+	}
+
+	return userDB, nil
+}
+
+// rehashPassword upgrades a hash made by an outdated algorithm or parameters.
+// The login itself must not fail because of it, so errors are only logged.
+func (a *LocalAuthenticator) rehashPassword(user *model.AuthUser, password string) {
+	hash, err := authmiddleware.CreateHashPassword(password)
+	if err != nil {
+		logger.Errorf("Authenticate.CreateHashPassword", err)
+
+		return
+	}
+
+	err = a.postgres.Auth.RehashPassword(user.ID, hash)
+	if err != nil {
+		logger.Errorf("Authenticate.rehashPassword", err)
+	}
+}
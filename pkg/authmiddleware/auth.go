@@ -14,30 +14,28 @@ import (
 )
 
 const (
+	// AuthSalt is the static salt of legacy SHA3 password hashes, kept to verify them until rehash.
 	AuthSalt = "crm-system"
 	MaxAge   = 32000000
 )
 
 type AuthMiddleware interface {
 	Authorize(c *gin.Context)
-	CreateTokens(id uuid.UUID, role model.UserRole) (*Tokens, error)
+	CreateTokens(id uuid.UUID, role model.UserRole, client Client) (*Tokens, error)
 	Refresh(tokens Tokens) (*Tokens, error)
+	Logout(refreshToken string) error
+	LogoutAll(userID uuid.UUID) error
 	ExtractToken(r *http.Request) string
 	Validate(raw string) (*AccessClaims, error)
-	GetUserRole(accessToken string) (model.UserRole, error)
-	GetUserID(accessToken string) (uuid.UUID, error)
-}
-
-func IsPasswordMatch(password, hashedPassword string) bool {
-	userPasswordHash := H3hash(password + AuthSalt)
-
-	return userPasswordHash == hashedPassword
-}
-
-func CreateHashPassword(password string) string {
-	return H3hash(password + AuthSalt)
+	JWKS() JWKSet
+	CreateMFAToken(id uuid.UUID, role model.UserRole, enroll bool) (string, error)
+	ValidateMFAToken(raw string) (*MFAClaims, error)
+	// Impersonate issues a short-lived access token of the user for the actor,
+	// it works while the actor's session does.
+	Impersonate(id uuid.UUID, role model.UserRole, actorID, actorSessionID uuid.UUID) (string, error)
 }
 
+// H3hash is the legacy password digest, see IsPasswordMatch.
 func H3hash(s string) string {
 	h3 := sha3.New512()
 	if _, err := io.WriteString(h3, s); err != nil {
@@ -0,0 +1,32 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/model"
+	"errors"
+)
+
+var (
+	// ErrUnknownUser lets the next authenticator of Authenticators try the credentials.
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrInvalidCredentials ends the login, the user belongs to the authenticator.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator verifies a username and password and returns the local user.
+type Authenticator interface {
+	Authenticate(username, password string) (*model.AuthUser, error)
+}
+
+// Authenticators tries each authenticator in order until one knows the user.
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(username, password string) (*model.AuthUser, error) {
+	for _, authenticator := range a {
+		user, err := authenticator.Authenticate(username, password)
+		if !errors.Is(err, ErrUnknownUser) {
+			return user, err
+		}
+	}
+
+	return nil, ErrUnknownUser
+}
@@ -0,0 +1,37 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authenticatorFunc func(username, password string) (*model.AuthUser, error)
+
+func (f authenticatorFunc) Authenticate(username, password string) (*model.AuthUser, error) {
+	return f(username, password)
+}
+
+func TestAuthenticators(t *testing.T) {
+	unknown := authenticatorFunc(func(string, string) (*model.AuthUser, error) {
+		return nil, ErrUnknownUser
+	})
+	invalid := authenticatorFunc(func(string, string) (*model.AuthUser, error) {
+		return nil, ErrInvalidCredentials
+	})
+	valid := authenticatorFunc(func(username, _ string) (*model.AuthUser, error) {
+		return &model.AuthUser{Username: username}, nil
+	})
+
+	user, err := Authenticators{unknown, valid}.Authenticate("user", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Username)
+
+	_, err = Authenticators{invalid, valid}.Authenticate("user", "password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "the first authenticator knowing the user decides")
+
+	_, err = Authenticators{unknown, unknown}.Authenticate("user", "password")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+}
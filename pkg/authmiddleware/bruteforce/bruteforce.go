@@ -0,0 +1,149 @@
+// Package bruteforce slows down password guessing. Failed attempts are counted
+// per username and per client IP, every failure past the free ones delays the
+// next attempt twice as long as the previous one, and reaching the threshold
+// locks the key for the lockout duration.
+package bruteforce
+
+import (
+	"crm-system/pkg/config"
+	"crm-system/pkg/store"
+	"strings"
+	"time"
+)
+
+const (
+	usernamePrefix = "user:"
+	ipPrefix       = "ip:"
+	resetPrefix    = "reset-"
+)
+
+type Guard struct {
+	attempts store.LoginAttemptRepository
+	conf     config.BruteForceConfig
+	now      func() time.Time
+}
+
+func NewGuard(attempts store.LoginAttemptRepository, conf config.BruteForceConfig) *Guard {
+	return &Guard{
+		attempts: attempts,
+		conf:     conf,
+		now:      time.Now,
+	}
+}
+
+func UsernameKey(username string) string {
+	return usernamePrefix + strings.ToLower(username)
+}
+
+func IPKey(ip string) string {
+	return ipPrefix + ip
+}
+
+// ResetKeys are the keys of a password reset request, every request counts.
+// They are apart from the login keys, so asking for links doesn't slow down logins.
+func ResetKeys(email, ip string) []string {
+	return []string{resetPrefix + "email:" + strings.ToLower(email), resetPrefix + IPKey(ip)}
+}
+
+// Username returns the username of an account key.
+func Username(key string) (string, bool) {
+	return strings.CutPrefix(key, usernamePrefix)
+}
+
+// Check returns how long the caller has to wait before the next attempt,
+// the longest delay of all keys wins.
+func (g *Guard) Check(keys ...string) (time.Duration, error) {
+	var wait time.Duration
+
+	now := g.now()
+	for _, key := range keys {
+		attempt, err := g.attempts.Get(key)
+		if err != nil {
+			return 0, err
+		}
+
+		if retryAfter := attempt.RetryAfter(now); retryAfter > wait {
+			wait = retryAfter
+		}
+	}
+
+	return wait, nil
+}
+
+// Fail counts a failed attempt for every key and locks the keys that reached a delay.
+// It returns the keys that reached the threshold and are locked out.
+func (g *Guard) Fail(keys ...string) ([]string, error) {
+	var lockedOut []string
+
+	for _, key := range keys {
+		failures, err := g.attempts.RegisterFailure(key, g.conf.Window.Duration)
+		if err != nil {
+			return lockedOut, err
+		}
+
+		delay := g.delay(key, failures)
+		if delay == 0 {
+			continue
+		}
+
+		err = g.attempts.Lock(key, g.now().Add(delay))
+		if err != nil {
+			return lockedOut, err
+		}
+
+		if g.reachedThreshold(key, failures) {
+			lockedOut = append(lockedOut, key)
+		}
+	}
+
+	return lockedOut, nil
+}
+
+// Succeed clears the counters after a successful attempt. Only pass account keys:
+// resetting the IP counter would let an attacker with one valid account keep
+// guessing passwords of other accounts from the same address.
+func (g *Guard) Succeed(keys ...string) error {
+	for _, key := range keys {
+		err := g.attempts.Reset(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Unlock removes the lockout and the failed attempts of the account.
+func (g *Guard) Unlock(username string) error {
+	return g.attempts.Reset(UsernameKey(username))
+}
+
+func (g *Guard) reachedThreshold(key string, failures int) bool {
+	threshold := g.conf.AccountThreshold
+	if strings.HasPrefix(strings.TrimPrefix(key, resetPrefix), ipPrefix) {
+		threshold = g.conf.IPThreshold
+	}
+
+	return threshold > 0 && failures >= threshold
+}
+
+func (g *Guard) delay(key string, failures int) time.Duration {
+	if g.reachedThreshold(key, failures) {
+		return g.conf.LockoutDuration.Duration
+	}
+
+	if failures <= g.conf.FreeAttempts {
+		return 0
+	}
+
+	delay := g.conf.BaseDelay.Duration
+	for i := g.conf.FreeAttempts + 1; i < failures && delay < g.conf.MaxDelay.Duration; i++ {
+		delay *= 2
+	}
+
+	if delay > g.conf.MaxDelay.Duration {
+		delay = g.conf.MaxDelay.Duration
+	}
+
+	return delay
+}
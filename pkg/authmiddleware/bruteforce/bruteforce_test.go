@@ -0,0 +1,103 @@
+package bruteforce
+
+import (
+	"crm-system/pkg/config"
+	"crm-system/pkg/store/memorystore"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGuard() *Guard {
+	return NewGuard(memorystore.NewLoginAttemptRepository(), config.BruteForceConfig{
+		FreeAttempts:     2,
+		BaseDelay:        config.Duration{Duration: time.Second},
+		MaxDelay:         config.Duration{Duration: 4 * time.Second},
+		AccountThreshold: 6,
+		IPThreshold:      20,
+		LockoutDuration:  config.Duration{Duration: time.Hour},
+		Window:           config.Duration{Duration: time.Hour},
+	})
+}
+
+func TestGuardProgressiveDelay(t *testing.T) {
+	guard := testGuard()
+	key := UsernameKey("User")
+
+	// failures 1..5 below the threshold: free, free, 1s, 2s, 4s
+	expected := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, delay := range expected {
+		assert.Equal(t, delay, guard.delay(key, i+1), "failure %d", i+1)
+	}
+
+	assert.Equal(t, time.Hour, guard.delay(key, 6))
+	assert.Equal(t, 4*time.Second, guard.delay(IPKey("127.0.0.1"), 6))
+	assert.Equal(t, time.Hour, guard.delay(IPKey("127.0.0.1"), 20))
+}
+
+func TestGuardLockout(t *testing.T) {
+	guard := testGuard()
+	keys := []string{UsernameKey("user"), IPKey("127.0.0.1")}
+
+	for i := 0; i < 2; i++ {
+		lockedOut, err := guard.Fail(keys...)
+		require.NoError(t, err)
+		assert.Empty(t, lockedOut)
+	}
+
+	wait, err := guard.Check(keys...)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	for i := 0; i < 3; i++ {
+		lockedOut, err := guard.Fail(keys...)
+		require.NoError(t, err)
+		assert.Empty(t, lockedOut)
+	}
+
+	lockedOut, err := guard.Fail(keys...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{UsernameKey("user")}, lockedOut)
+
+	wait, err = guard.Check(UsernameKey("USER"))
+	require.NoError(t, err)
+	assert.Greater(t, wait, 30*time.Minute)
+
+	wait, err = guard.Check(IPKey("127.0.0.1"))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, wait, 4*time.Second)
+
+	require.NoError(t, guard.Unlock("user"))
+
+	wait, err = guard.Check(UsernameKey("user"))
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	wait, err = guard.Check(IPKey("127.0.0.1"))
+	require.NoError(t, err)
+	assert.NotZero(t, wait)
+}
+
+func TestGuardResetKeys(t *testing.T) {
+	guard := testGuard()
+	keys := ResetKeys("User@Example.com", "127.0.0.1")
+
+	assert.Equal(t, ResetKeys("user@example.com", "127.0.0.1"), keys)
+	assert.Equal(t, time.Hour, guard.delay(keys[0], 6))
+	assert.Equal(t, 4*time.Second, guard.delay(keys[1], 6), "the IP threshold applies to the reset IP key")
+
+	for i := 0; i < 3; i++ {
+		_, err := guard.Fail(keys...)
+		require.NoError(t, err)
+	}
+
+	wait, err := guard.Check(keys...)
+	require.NoError(t, err)
+	assert.NotZero(t, wait)
+
+	wait, err = guard.Check(UsernameKey("user@example.com"), IPKey("127.0.0.1"))
+	require.NoError(t, err)
+	assert.Zero(t, wait, "reset requests don't slow down logins")
+}
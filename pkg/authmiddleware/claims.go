@@ -11,6 +11,15 @@ import (
 const (
 	AccessTokenTTL  = time.Hour * 8
 	RefreshTokenTTL = time.Hour * 24 * 7
+	MFATokenTTL     = time.Minute * 5
+	// ImpersonationTokenTTL bounds an impersonation, there is no refresh token to extend it.
+	ImpersonationTokenTTL = time.Minute * 15
+
+	// TypeHeader is the JWT header naming the kind of the token.
+	TypeHeader = "typ"
+	// MFATokenType marks challenge tokens. They are signed with the HMAC key of
+	// MFATokenKey, which JWKS never publishes, and the key rings refuse them.
+	MFATokenType = "mfa+jwt"
 )
 
 type BaseClaims struct {
@@ -22,6 +31,22 @@ type BaseClaims struct {
 type AccessClaims struct {
 	BaseClaims
 	AccessUUID string `json:"access_uuid"`
+	// SessionID is the session the token was issued for, Authorize rejects the
+	// token once the session is revoked.
+	SessionID uuid.UUID `json:"sid"`
+	// Actor is set on impersonation tokens: the token acts as ID on behalf of
+	// Actor.ID and SessionID is the session of the actor.
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Actor is the act claim of RFC 8693, the party acting on behalf of the subject.
+type Actor struct {
+	ID uuid.UUID `json:"sub"`
+}
+
+// IsImpersonation reports whether the token was issued to impersonate its user.
+func (c *AccessClaims) IsImpersonation() bool {
+	return c.Actor != nil && c.Actor.ID != uuid.Nil
 }
 
 type RefreshClaims struct {
@@ -29,6 +54,13 @@ type RefreshClaims struct {
 	RefreshUUID string `json:"refresh_uuid"`
 }
 
+// MFAClaims are carried by the challenge token issued after the password step.
+// Enroll is set when the user has to enroll a second factor before logging in.
+type MFAClaims struct {
+	BaseClaims
+	Enroll bool `json:"enroll,omitempty"`
+}
+
 type Tokens struct {
 	Access  string `json:"accessToken"`
 	Refresh string `json:"refreshToken"`
@@ -46,9 +78,10 @@ func NewClaims(idClaims uuid.UUID, role model.UserRole, ttl time.Duration) BaseC
 	}
 }
 
-func GenerateClaims(idClaims uuid.UUID, role model.UserRole) (*AccessClaims, *RefreshClaims) {
+func GenerateClaims(idClaims uuid.UUID, role model.UserRole, sessionID uuid.UUID) (*AccessClaims, *RefreshClaims) {
 	access := AccessClaims{
 		BaseClaims: NewClaims(idClaims, role, AccessTokenTTL),
+		SessionID:  sessionID,
 	}
 
 	refresh := RefreshClaims{
@@ -60,3 +93,27 @@ func GenerateClaims(idClaims uuid.UUID, role model.UserRole) (*AccessClaims, *Re
 
 	return &access, &refresh
 }
+
+// NewImpersonationClaims issues an access token of the user for the actor, it is
+// bound to the session of the actor and has no refresh token.
+func NewImpersonationClaims(idClaims uuid.UUID, role model.UserRole, actorID, actorSessionID uuid.UUID) *AccessClaims {
+	return &AccessClaims{
+		BaseClaims: NewClaims(idClaims, role, ImpersonationTokenTTL),
+		SessionID:  actorSessionID,
+		Actor:      &Actor{ID: actorID},
+	}
+}
+
+func NewMFAClaims(idClaims uuid.UUID, role model.UserRole, enroll bool) *MFAClaims {
+	return &MFAClaims{
+		BaseClaims: NewClaims(idClaims, role, MFATokenTTL),
+		Enroll:     enroll,
+	}
+}
+
+// TokenType returns the typ header of the token, empty when it has none.
+func TokenType(token *jwt.Token) string {
+	typ, _ := token.Header[TypeHeader].(string)
+
+	return typ
+}
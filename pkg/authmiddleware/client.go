@@ -0,0 +1,28 @@
+package authmiddleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxUserAgentLength = 512
+
+// Client describes where a session was started from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+//nolint:varnamelen
+func ClientFromContext(c *gin.Context) Client {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	return Client{
+		UserAgent: userAgent,
+		IP:        c.ClientIP(),
+	}
+}
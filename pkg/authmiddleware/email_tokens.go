@@ -0,0 +1,30 @@
+package authmiddleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const emailTokenBytes = 32
+
+// GenerateEmailToken returns a single-use token to email, such as a password
+// reset or an invitation link, and its hash to store.
+func GenerateEmailToken() (string, string, error) {
+	raw := make([]byte, emailTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, HashEmailToken(token), nil
+}
+
+// HashEmailToken hashes an emailed token, tokens are random so a fast hash is enough.
+func HashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,220 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	KeyIDHeader = "kid"
+	keyFileExt  = ".pem"
+	// MFATokenKeySize is the size of the generated key and the least size of a
+	// configured secret, the size of the HS256 hash.
+	MFATokenKeySize = 32
+)
+
+var (
+	ErrNoSigningKeys    = errors.New("no signing keys")
+	ErrUnsupportedCurve = errors.New("ES256 requires a prime256v1 key")
+	ErrShortMFASecret   = fmt.Errorf("the MFA token secret needs at least %d bytes", MFATokenKeySize)
+)
+
+// KeyRing holds every ECDSA key that may have signed a live token.
+// New tokens are signed with the active key and carry its id in the kid header,
+// so keys can be rotated without invalidating tokens signed by the previous one.
+type KeyRing struct {
+	keys     map[string]*ecdsa.PrivateKey
+	activeID string
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeyRing(activeID string, keys map[string]*ecdsa.PrivateKey) (*KeyRing, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q: %w", activeID, ErrNoSigningKeys)
+	}
+
+	for id, key := range keys {
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %q: %w", id, ErrUnsupportedCurve)
+		}
+	}
+
+	return &KeyRing{
+		keys:     keys,
+		activeID: activeID,
+	}, nil
+}
+
+// LoadKeyRing loads every *.pem file of the directory, the file name without
+// extension is the key id. When activeID is empty the last id in lexical order
+// is used, so naming keys by date makes the newest one active.
+func LoadKeyRing(dir, activeID string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s: %w", dir, ErrNoSigningKeys)
+	}
+
+	sort.Strings(paths)
+
+	keys := make(map[string]*ecdsa.PrivateKey, len(paths))
+	for _, path := range paths {
+		key, err := LoadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		keys[keyID(path)] = key
+	}
+
+	if activeID == "" {
+		activeID = keyID(paths[len(paths)-1])
+	}
+
+	return NewKeyRing(activeID, keys)
+}
+
+// LoadKeyRingFile builds a ring of the single key stored in the file.
+func LoadKeyRingFile(path string) (*KeyRing, error) {
+	key, err := LoadKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyRing(keyID(path), map[string]*ecdsa.PrivateKey{keyID(path): key})
+}
+
+func LoadKey(path string) (*ecdsa.PrivateKey, error) {
+	txt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(txt)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// MFATokenKey returns the HMAC key of the MFA challenge tokens. Without a
+// secret a random key is generated, the challenges issued by this instance
+// then can't be answered at another one or after a restart.
+func MFATokenKey(secret string) ([]byte, error) {
+	if secret != "" {
+		if len(secret) < MFATokenKeySize {
+			return nil, ErrShortMFASecret
+		}
+
+		return []byte(secret), nil
+	}
+
+	key := make([]byte, MFATokenKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header[KeyIDHeader] = r.activeID
+
+	return token.SignedString(r.keys[r.activeID])
+}
+
+// Keyfunc selects the verification key by the kid header, only ES256 tokens are
+// accepted and never MFA challenge tokens. Tokens signed before key rotation was
+// introduced have no kid and are checked against the active key.
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodES256 {
+		logger.Errorf("Keyfunc.unexpected signing method", token.Header["alg"])
+
+		return nil, model.ErrUnauthorized
+	}
+
+	if TokenType(token) == MFATokenType {
+		logger.Errorf("Keyfunc.MFA challenge token", token.Header[KeyIDHeader])
+
+		return nil, model.ErrUnauthorized
+	}
+
+	kid, _ := token.Header[KeyIDHeader].(string)
+	if kid == "" {
+		kid = r.activeID
+	}
+
+	key, ok := r.keys[kid]
+	if !ok {
+		logger.Errorf("Keyfunc.unknown kid", kid)
+
+		return nil, model.ErrUnauthorized
+	}
+
+	return &key.PublicKey, nil
+}
+
+// JWKS returns the public part of every key in the ring.
+func (r *KeyRing) JWKS() JWKSet {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		pub := r.keys[id].PublicKey
+		size := (pub.Curve.Params().BitSize + 7) / 8 //nolint:gomnd
+
+		set.Keys = append(set.Keys, JWK{
+			Kty: "EC",
+			Crv: pub.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+			Kid: id,
+			Use: "sig",
+			Alg: jwt.SigningMethodES256.Alg(),
+		})
+	}
+
+	return set
+}
+
+func keyID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
@@ -0,0 +1,198 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/model"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+
+	return key
+}
+
+func writeTestKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+}
+
+func TestKeyRingSign(t *testing.T) {
+	old, active := newTestKey(t, elliptic.P256()), newTestKey(t, elliptic.P256())
+
+	ring, err := NewKeyRing("2024-02", map[string]*ecdsa.PrivateKey{"2024-01": old, "2024-02": active})
+	require.NoError(t, err)
+
+	signed, err := ring.Sign(jwt.StandardClaims{Subject: "user"})
+	require.NoError(t, err)
+
+	token, err := jwt.ParseWithClaims(signed, &jwt.StandardClaims{}, ring.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", token.Header[KeyIDHeader])
+	assert.Equal(t, "ES256", token.Header["alg"])
+
+	_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return &old.PublicKey, nil })
+	assert.Error(t, err, "signed with the active key only")
+}
+
+func TestKeyRingKeyfunc(t *testing.T) {
+	old, active := newTestKey(t, elliptic.P256()), newTestKey(t, elliptic.P256())
+
+	ring, err := NewKeyRing("new", map[string]*ecdsa.PrivateKey{"old": old, "new": active})
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.StandardClaims{Subject: "user"})
+		if kid != nil {
+			token.Header[KeyIDHeader] = kid
+		}
+
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		return signed
+	}
+
+	token, err := jwt.Parse(sign(jwt.SigningMethodES256, "old", old), ring.Keyfunc)
+	require.NoError(t, err, "tokens of rotated keys stay valid")
+	assert.True(t, token.Valid)
+
+	_, err = jwt.Parse(sign(jwt.SigningMethodES256, nil, active), ring.Keyfunc)
+	assert.NoError(t, err, "tokens without kid are checked against the active key")
+
+	_, err = jwt.Parse(sign(jwt.SigningMethodES256, "old", active), ring.Keyfunc)
+	assert.Error(t, err, "the kid selects the key")
+
+	challenge := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.StandardClaims{Subject: "user"})
+	challenge.Header[KeyIDHeader] = "new"
+	challenge.Header[TypeHeader] = MFATokenType
+	mfaSigned, err := challenge.SignedString(active)
+	require.NoError(t, err)
+
+	for name, signed := range map[string]string{
+		"mfa+jwt":     mfaSigned,
+		"unknown kid": sign(jwt.SigningMethodES256, "other", active),
+		"ES384":       sign(jwt.SigningMethodES384, "new", newTestKey(t, elliptic.P384())),
+		"HS256":       sign(jwt.SigningMethodHS256, "new", []byte("secret")),
+		"none":        sign(jwt.SigningMethodNone, "new", jwt.UnsafeAllowNoneSignatureType),
+	} {
+		_, err = jwt.Parse(signed, ring.Keyfunc)
+
+		var validation *jwt.ValidationError
+		require.ErrorAs(t, err, &validation, name)
+		assert.ErrorIs(t, validation.Inner, model.ErrUnauthorized, name)
+	}
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	// a key with a coordinate below 2^248 must still be encoded on 32 bytes
+	var short *ecdsa.PrivateKey
+	for short == nil {
+		key := newTestKey(t, elliptic.P256())
+		if key.X.BitLen() <= 248 || key.Y.BitLen() <= 248 {
+			short = key
+		}
+	}
+
+	ring, err := NewKeyRing("b", map[string]*ecdsa.PrivateKey{"b": newTestKey(t, elliptic.P256()), "a": short})
+	require.NoError(t, err)
+
+	set := ring.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "a", set.Keys[0].Kid, "keys are ordered by id")
+	assert.Equal(t, "b", set.Keys[1].Kid)
+
+	jwk := set.Keys[0]
+	assert.Equal(t, "EC", jwk.Kty)
+	assert.Equal(t, "P-256", jwk.Crv)
+	assert.Equal(t, "sig", jwk.Use)
+	assert.Equal(t, "ES256", jwk.Alg)
+
+	for coordinate, encoded := range map[*big.Int]string{short.X: jwk.X, short.Y: jwk.Y} {
+		assert.Len(t, encoded, 43)
+
+		decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		assert.Len(t, decoded, 32)
+		assert.Zero(t, new(big.Int).SetBytes(decoded).Cmp(coordinate))
+	}
+}
+
+func TestMFATokenKey(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+
+	key, err := MFATokenKey(secret)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(secret), key)
+
+	_, err = MFATokenKey("short")
+	assert.ErrorIs(t, err, ErrShortMFASecret)
+
+	key, err = MFATokenKey("")
+	require.NoError(t, err)
+	assert.Len(t, key, MFATokenKeySize)
+
+	other, err := MFATokenKey("")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other, "generated keys are random")
+}
+
+func TestNewKeyRing(t *testing.T) {
+	_, err := NewKeyRing("missing", map[string]*ecdsa.PrivateKey{"key": newTestKey(t, elliptic.P256())})
+	assert.ErrorIs(t, err, ErrNoSigningKeys)
+
+	_, err = NewKeyRing("key", map[string]*ecdsa.PrivateKey{"key": newTestKey(t, elliptic.P384())})
+	assert.ErrorIs(t, err, ErrUnsupportedCurve)
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	keys := map[string]*ecdsa.PrivateKey{}
+
+	for _, id := range []string{"2024-01", "2024-03", "2024-02"} {
+		keys[id] = newTestKey(t, elliptic.P256())
+		writeTestKey(t, filepath.Join(dir, id+keyFileExt), keys[id])
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a key"), 0o600))
+
+	ring, err := LoadKeyRing(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03", ring.activeID, "the last id by name is active")
+	assert.Len(t, ring.keys, 3)
+	assert.True(t, keys["2024-01"].Equal(ring.keys["2024-01"]))
+
+	ring, err = LoadKeyRing(dir, "2024-01")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", ring.activeID)
+
+	_, err = LoadKeyRing(dir, "2023-12")
+	assert.ErrorIs(t, err, ErrNoSigningKeys)
+
+	_, err = LoadKeyRing(t.TempDir(), "")
+	assert.ErrorIs(t, err, ErrNoSigningKeys)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+keyFileExt), []byte("not a key"), 0o600))
+
+	_, err = LoadKeyRing(dir, "")
+	assert.Error(t, err)
+}
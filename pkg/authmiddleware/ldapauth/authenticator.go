@@ -0,0 +1,267 @@
+// Package ldapauth verifies passwords against an LDAP directory or Active
+// Directory and keeps a local user for every directory user that logged in.
+package ldapauth
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IdentityIssuer is the issuer of the identities linking users to directory entries.
+const IdentityIssuer = "ldap"
+
+const (
+	usernamePlaceholder = "{username}"
+	// searchSizeLimit is enough to tell a unique entry from an ambiguous filter.
+	searchSizeLimit = 2
+)
+
+var (
+	errIdentityUser = errors.New("linked user does not exist")
+	errUnknownRole  = errors.New("mapped role does not exist")
+)
+
+type Authenticator struct {
+	config   config.LDAPConfig
+	postgres *store.Store
+	mappings []authmiddleware.RoleMapping
+}
+
+func NewAuthenticator(cfg config.LDAPConfig, postgres *store.Store) *Authenticator {
+	return &Authenticator{
+		config:   cfg,
+		postgres: postgres,
+		mappings: authmiddleware.ParseRoleMapping(cfg.RoleMapping),
+	}
+}
+
+// Authenticate finds the entry of the username with the search account, binds
+// as the entry with the password and returns its local user, creating it on the
+// first login. When a role mapping is set the role follows the directory groups.
+func (a *Authenticator) Authenticate(username, password string) (*model.AuthUser, error) {
+	// a simple bind with an empty password is an unauthenticated bind that succeeds
+	if password == "" {
+		return nil, authmiddleware.ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := a.find(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Bind(entry.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, authmiddleware.ErrInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	user, err := a.user(username, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, a.syncRole(user, entry)
+}
+
+func (a *Authenticator) dial() (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: a.config.Timeout.Duration}
+
+	conn, err := ldap.DialURL(a.config.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(a.config.Timeout.Duration)
+
+	if a.config.StartTLS {
+		parsed, err := url.Parse(a.config.URL)
+		if err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+
+		err = conn.StartTLS(&tls.Config{ServerName: parsed.Hostname(), MinVersion: tls.VersionTLS12})
+		if err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+	}
+
+	if a.config.BindDN != "" {
+		err = conn.Bind(a.config.BindDN, a.config.BindPassword)
+		if err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("search account bind: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (a *Authenticator) find(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(a.config.UserFilter, usernamePlaceholder, ldap.EscapeFilter(username))
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		searchSizeLimit,
+		int(a.config.Timeout.Seconds()),
+		false,
+		filter,
+		[]string{a.config.EmailAttribute, a.config.GroupAttribute, "givenName", "sn"},
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			logger.Errorf("Authenticate.Search ambiguous filter", username)
+
+			return nil, authmiddleware.ErrInvalidCredentials
+		}
+
+		return nil, err
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return nil, authmiddleware.ErrUnknownUser
+	case 1:
+		return result.Entries[0], nil
+	default:
+		logger.Errorf("Authenticate.Search ambiguous filter", username)
+
+		return nil, authmiddleware.ErrInvalidCredentials
+	}
+}
+
+// user returns the local user linked to the directory user. A username taken
+// by a local account that is not linked is never taken over.
+func (a *Authenticator) user(username string, entry *ldap.Entry) (*model.AuthUser, error) {
+	subject := strings.ToLower(username)
+
+	identity, exists := a.postgres.Identity.Get(IdentityIssuer, subject)
+	if exists {
+		userDB, exists := a.postgres.Auth.Get(identity.UserID)
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", errIdentityUser, identity.UserID)
+		}
+
+		return userDB, nil
+	}
+
+	userDB, err := a.postgres.Auth.GetByUsername(username)
+	if err != nil && err.Error() != model.NotFound {
+		return nil, err
+	}
+
+	if userDB != nil && userDB.ID != uuid.Nil {
+		logger.Errorf("Authenticate.username is taken by a local account", username)
+
+		return nil, authmiddleware.ErrInvalidCredentials
+	}
+
+	role, err := a.role(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.AuthUser{
+		Username: username,
+		Role:     role,
+		Active:   true,
+	}
+
+	if email := strings.ToLower(entry.GetAttributeValue(a.config.EmailAttribute)); email != "" {
+		if _, exists := a.postgres.Auth.GetByEmail(email); !exists {
+			user.Email = &email
+		}
+	}
+
+	profile := &model.User{
+		Name:    entry.GetAttributeValue("givenName"),
+		Surname: entry.GetAttributeValue("sn"),
+	}
+
+	err = a.postgres.Identity.Provision(user, profile, &model.AuthIdentity{Issuer: IdentityIssuer, Subject: subject})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (a *Authenticator) syncRole(user *model.AuthUser, entry *ldap.Entry) error {
+	if len(a.mappings) == 0 {
+		return nil
+	}
+
+	role, err := a.role(entry)
+	if err != nil || role == user.Role {
+		return err
+	}
+
+	err = a.postgres.Auth.SetRole(user.ID, role)
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+
+	return nil
+}
+
+func (a *Authenticator) role(entry *ldap.Entry) (model.UserRole, error) {
+	role, ok := authmiddleware.MapRole(a.mappings, groupNames(entry.GetAttributeValues(a.config.GroupAttribute)))
+	if !ok {
+		role = model.UserRole(strings.ToUpper(a.config.DefaultRole))
+	}
+
+	if _, exists := a.postgres.Role.Get(role); !exists {
+		return "", fmt.Errorf("%w: %s", errUnknownRole, role)
+	}
+
+	return role, nil
+}
+
+// groupNames returns the common name of every group DN, mappings can't hold
+// whole DNs since they are comma separated.
+func groupNames(groups []string) []string {
+	names := make([]string, 0, len(groups))
+
+	for _, group := range groups {
+		dn, err := ldap.ParseDN(group)
+		if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+			names = append(names, group)
+
+			continue
+		}
+
+		names = append(names, dn.RDNs[0].Attributes[0].Value)
+	}
+
+	return names
+}
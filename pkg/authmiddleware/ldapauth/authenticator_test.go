@@ -0,0 +1,183 @@
+package ldapauth_test
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/authmiddleware/ldapauth"
+	"crm-system/pkg/authmiddleware/ldapauth/ldaptest"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"crm-system/pkg/store/mockpostgresstore"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	searchDN = "cn=crm,ou=services,dc=example,dc=com"
+	janeDN   = "uid=jane,ou=people,dc=example,dc=com"
+)
+
+type repos struct {
+	auth     *mockpostgresstore.MockAuthRepository
+	role     *mockpostgresstore.MockRoleRepository
+	identity *mockpostgresstore.MockIdentityRepository
+}
+
+func newDirectory() *ldaptest.Server {
+	return ldaptest.NewServer(
+		ldaptest.Entry{DN: searchDN, Password: "search-secret"},
+		ldaptest.Entry{
+			DN:       janeDN,
+			Password: "jane-secret",
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"uid":         {"jane"},
+				"mail":        {"Jane@Example.com"},
+				"givenName":   {"Jane"},
+				"sn":          {"Doe"},
+				"memberOf":    {"cn=staff,ou=groups,dc=example,dc=com", "cn=crm-admins,ou=groups,dc=example,dc=com"},
+			},
+		},
+		ldaptest.Entry{
+			DN:         "uid=twin,ou=people,dc=example,dc=com",
+			Password:   "twin-secret",
+			Attributes: map[string][]string{"objectClass": {"person"}, "uid": {"twin"}},
+		},
+		ldaptest.Entry{
+			DN:         "uid=twin,ou=contractors,dc=example,dc=com",
+			Password:   "twin-secret",
+			Attributes: map[string][]string{"objectClass": {"person"}, "uid": {"twin"}},
+		},
+	)
+}
+
+func newAuthenticator(t *testing.T, directory *ldaptest.Server, roleMapping string) (*ldapauth.Authenticator, *repos) {
+	t.Helper()
+
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mocks := &repos{
+		auth:     mockpostgresstore.NewMockAuthRepository(mockCtrl),
+		role:     mockpostgresstore.NewMockRoleRepository(mockCtrl),
+		identity: mockpostgresstore.NewMockIdentityRepository(mockCtrl),
+	}
+	mocks.role.EXPECT().Get(gomock.Any()).Return(&model.Role{}, true).AnyTimes()
+
+	authenticator := ldapauth.NewAuthenticator(config.LDAPConfig{
+		URL:            directory.URL(),
+		BindDN:         searchDN,
+		BindPassword:   "search-secret",
+		BaseDN:         "dc=example,dc=com",
+		UserFilter:     "(&(objectClass=person)(uid={username}))",
+		EmailAttribute: "mail",
+		GroupAttribute: "memberOf",
+		RoleMapping:    roleMapping,
+		DefaultRole:    "BASE",
+		Timeout:        config.Duration{Duration: time.Second},
+	}, &store.Store{Auth: mocks.auth, Role: mocks.role, Identity: mocks.identity})
+
+	return authenticator, mocks
+}
+
+func TestAuthenticateProvisionsUser(t *testing.T) {
+	directory := newDirectory()
+	defer directory.Close()
+
+	authenticator, mocks := newAuthenticator(t, directory, "crm-admins=ADMIN")
+	userID := uuid.NewV4()
+
+	mocks.identity.EXPECT().Get(ldapauth.IdentityIssuer, "jane").Return(nil, false)
+	mocks.auth.EXPECT().GetByUsername("jane").Return(&model.AuthUser{}, nil)
+	mocks.auth.EXPECT().GetByEmail("jane@example.com").Return(nil, false)
+	mocks.identity.EXPECT().Provision(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(user *model.AuthUser, profile *model.User, identity *model.AuthIdentity) error {
+			assert.Equal(t, "jane", user.Username)
+			assert.Equal(t, "jane@example.com", *user.Email)
+			assert.Equal(t, model.AdminUserRole, user.Role)
+			assert.Empty(t, user.Password)
+			assert.Equal(t, "Doe", profile.Surname)
+			assert.Equal(t, "jane", identity.Subject)
+			user.ID = userID
+
+			return nil
+		})
+
+	user, err := authenticator.Authenticate("jane", "jane-secret")
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+	assert.Equal(t, []string{searchDN, janeDN}, directory.Binds())
+}
+
+func TestAuthenticateLinkedUserSyncsRole(t *testing.T) {
+	directory := newDirectory()
+	defer directory.Close()
+
+	authenticator, mocks := newAuthenticator(t, directory, "sales=MANAGER")
+	userID := uuid.NewV4()
+
+	mocks.identity.EXPECT().Get(ldapauth.IdentityIssuer, "jane").Return(&model.AuthIdentity{UserID: userID}, true)
+	mocks.auth.EXPECT().Get(userID).Return(&model.AuthUser{ID: userID, Role: model.AdminUserRole, Active: true}, true)
+	mocks.auth.EXPECT().SetRole(userID, model.BaseUserRole).Return(nil)
+
+	user, err := authenticator.Authenticate("Jane", "jane-secret")
+	require.NoError(t, err)
+	assert.Equal(t, model.BaseUserRole, user.Role)
+}
+
+func TestAuthenticateRejected(t *testing.T) {
+	directory := newDirectory()
+	defer directory.Close()
+
+	testCases := []struct {
+		name     string
+		username string
+		password string
+		expected error
+	}{
+		{name: "WrongPassword", username: "jane", password: "wrong", expected: authmiddleware.ErrInvalidCredentials},
+		{name: "EmptyPassword", username: "jane", password: "", expected: authmiddleware.ErrInvalidCredentials},
+		{name: "UnknownUser", username: "nobody", password: "secret", expected: authmiddleware.ErrUnknownUser},
+		{name: "FilterInjection", username: "*", password: "jane-secret", expected: authmiddleware.ErrUnknownUser},
+		{name: "Ambiguous", username: "twin", password: "twin-secret", expected: authmiddleware.ErrInvalidCredentials},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator, _ := newAuthenticator(t, directory, "")
+
+			_, err := authenticator.Authenticate(tc.username, tc.password)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestAuthenticateUsernameTakenLocally(t *testing.T) {
+	directory := newDirectory()
+	defer directory.Close()
+
+	authenticator, mocks := newAuthenticator(t, directory, "")
+
+	mocks.identity.EXPECT().Get(ldapauth.IdentityIssuer, "jane").Return(nil, false)
+	mocks.auth.EXPECT().GetByUsername("jane").Return(&model.AuthUser{ID: uuid.NewV4(), Username: "jane"}, nil)
+
+	_, err := authenticator.Authenticate("jane", "jane-secret")
+	assert.ErrorIs(t, err, authmiddleware.ErrInvalidCredentials)
+}
+
+func TestAuthenticateSearchAccountRejected(t *testing.T) {
+	directory := ldaptest.NewServer()
+	defer directory.Close()
+
+	authenticator, _ := newAuthenticator(t, directory, "")
+
+	_, err := authenticator.Authenticate("jane", "jane-secret")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, authmiddleware.ErrInvalidCredentials)
+	assert.NotErrorIs(t, err, authmiddleware.ErrUnknownUser)
+}
@@ -0,0 +1,253 @@
+// Package ldaptest runs an in-process LDAP server for tests. It answers simple
+// binds and subtree searches with and, or, not, equality and presence filters
+// over a fixed set of entries; other operations are refused.
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	filterAnd      = 0
+	filterOr       = 1
+	filterNot      = 2
+	filterEquality = 3
+	filterPresent  = 7
+)
+
+// Entry is a directory entry, Password is its userPassword in clear text.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+type Server struct {
+	listener net.Listener
+	entries  []Entry
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	binds []string
+}
+
+// NewServer listens on a local port until Close.
+func NewServer(entries ...Entry) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	server := &Server{listener: listener, entries: entries}
+
+	server.wg.Add(1)
+
+	go server.serve()
+
+	return server
+}
+
+// URL is the ldap:// address of the server.
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// Binds returns the DNs of the successful binds so far.
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.binds...)
+}
+
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 { //nolint:gomnd
+			return
+		}
+
+		messageID, _ := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		var responses []*ber.Packet
+
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			responses = []*ber.Packet{s.bind(request)}
+		case ldap.ApplicationSearchRequest:
+			responses = s.search(request)
+		case ldap.ApplicationUnbindRequest:
+			return
+		default:
+			responses = []*ber.Packet{result(ldap.ApplicationExtendedResponse, ldap.LDAPResultUnwillingToPerform)}
+		}
+
+		for _, response := range responses {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(response)
+
+			if _, err = conn.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) bind(request *ber.Packet) *ber.Packet {
+	if len(request.Children) < 3 { //nolint:gomnd
+		return result(ldap.ApplicationBindResponse, ldap.LDAPResultProtocolError)
+	}
+
+	dn := stringValue(request.Children[1])
+	password := request.Children[2].Data.String()
+
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && password != "" && entry.Password == password {
+			s.mu.Lock()
+			s.binds = append(s.binds, entry.DN)
+			s.mu.Unlock()
+
+			return result(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess)
+		}
+	}
+
+	return result(ldap.ApplicationBindResponse, ldap.LDAPResultInvalidCredentials)
+}
+
+func (s *Server) search(request *ber.Packet) []*ber.Packet {
+	if len(request.Children) < 8 { //nolint:gomnd
+		return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)}
+	}
+
+	base := strings.ToLower(stringValue(request.Children[0]))
+	filter := request.Children[6]
+
+	responses := make([]*ber.Packet, 0)
+
+	for _, entry := range s.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), base) || !matches(filter, entry) {
+			continue
+		}
+
+		response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "DN"))
+
+		attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+
+		for name, values := range entry.Attributes {
+			attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+			attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+			for _, value := range values {
+				set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+			}
+
+			attribute.AppendChild(set)
+			attributes.AppendChild(attribute)
+		}
+
+		response.AppendChild(attributes)
+		responses = append(responses, response)
+	}
+
+	return append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+func matches(filter *ber.Packet, entry Entry) bool {
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			if !matches(child, entry) {
+				return false
+			}
+		}
+
+		return true
+	case filterOr:
+		for _, child := range filter.Children {
+			if matches(child, entry) {
+				return true
+			}
+		}
+
+		return false
+	case filterNot:
+		return len(filter.Children) == 1 && !matches(filter.Children[0], entry)
+	case filterEquality:
+		if len(filter.Children) != 2 { //nolint:gomnd
+			return false
+		}
+
+		for _, value := range attributeValues(entry, stringValue(filter.Children[0])) {
+			if strings.EqualFold(value, stringValue(filter.Children[1])) {
+				return true
+			}
+		}
+
+		return false
+	case filterPresent:
+		return len(attributeValues(entry, filter.Data.String())) > 0
+	default:
+		return false
+	}
+}
+
+func attributeValues(entry Entry, name string) []string {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func stringValue(packet *ber.Packet) string {
+	if value, ok := packet.Value.(string); ok {
+		return value
+	}
+
+	return packet.Data.String()
+}
+
+func result(operation ber.Tag, code uint16) *ber.Packet {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, operation, nil, "Result")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+
+	return response
+}
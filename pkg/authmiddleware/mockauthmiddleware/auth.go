@@ -50,19 +50,34 @@ func (mr *MockAuthMiddlewareMockRecorder) Authorize(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockAuthMiddleware)(nil).Authorize), arg0)
 }
 
+// CreateMFAToken mocks base method.
+func (m *MockAuthMiddleware) CreateMFAToken(arg0 uuid.UUID, arg1 model.UserRole, arg2 bool) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAToken indicates an expected call of CreateMFAToken.
+func (mr *MockAuthMiddlewareMockRecorder) CreateMFAToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAToken", reflect.TypeOf((*MockAuthMiddleware)(nil).CreateMFAToken), arg0, arg1, arg2)
+}
+
 // CreateTokens mocks base method.
-func (m *MockAuthMiddleware) CreateTokens(arg0 uuid.UUID, arg1 model.UserRole) (*authmiddleware.Tokens, error) {
+func (m *MockAuthMiddleware) CreateTokens(arg0 uuid.UUID, arg1 model.UserRole, arg2 authmiddleware.Client) (*authmiddleware.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTokens", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateTokens", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*authmiddleware.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTokens indicates an expected call of CreateTokens.
-func (mr *MockAuthMiddlewareMockRecorder) CreateTokens(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockAuthMiddlewareMockRecorder) CreateTokens(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokens", reflect.TypeOf((*MockAuthMiddleware)(nil).CreateTokens), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTokens", reflect.TypeOf((*MockAuthMiddleware)(nil).CreateTokens), arg0, arg1, arg2)
 }
 
 // ExtractToken mocks base method.
@@ -79,34 +94,61 @@ func (mr *MockAuthMiddlewareMockRecorder) ExtractToken(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractToken", reflect.TypeOf((*MockAuthMiddleware)(nil).ExtractToken), arg0)
 }
 
-// GetUserID mocks base method.
-func (m *MockAuthMiddleware) GetUserID(arg0 string) (uuid.UUID, error) {
+// Impersonate mocks base method.
+func (m *MockAuthMiddleware) Impersonate(arg0 uuid.UUID, arg1 model.UserRole, arg2, arg3 uuid.UUID) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserID", arg0)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "Impersonate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserID indicates an expected call of GetUserID.
-func (mr *MockAuthMiddlewareMockRecorder) GetUserID(arg0 interface{}) *gomock.Call {
+// Impersonate indicates an expected call of Impersonate.
+func (mr *MockAuthMiddlewareMockRecorder) Impersonate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*MockAuthMiddleware)(nil).GetUserID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Impersonate", reflect.TypeOf((*MockAuthMiddleware)(nil).Impersonate), arg0, arg1, arg2, arg3)
 }
 
-// GetUserRole mocks base method.
-func (m *MockAuthMiddleware) GetUserRole(arg0 string) (model.UserRole, error) {
+// JWKS mocks base method.
+func (m *MockAuthMiddleware) JWKS() authmiddleware.JWKSet {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserRole", arg0)
-	ret0, _ := ret[0].(model.UserRole)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(authmiddleware.JWKSet)
+	return ret0
 }
 
-// GetUserRole indicates an expected call of GetUserRole.
-func (mr *MockAuthMiddlewareMockRecorder) GetUserRole(arg0 interface{}) *gomock.Call {
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthMiddlewareMockRecorder) JWKS() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockAuthMiddleware)(nil).GetUserRole), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthMiddleware)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuthMiddleware) Logout(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthMiddlewareMockRecorder) Logout(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthMiddleware)(nil).Logout), arg0)
+}
+
+// LogoutAll mocks base method.
+func (m *MockAuthMiddleware) LogoutAll(arg0 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutAll", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutAll indicates an expected call of LogoutAll.
+func (mr *MockAuthMiddlewareMockRecorder) LogoutAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutAll", reflect.TypeOf((*MockAuthMiddleware)(nil).LogoutAll), arg0)
 }
 
 // Refresh mocks base method.
@@ -138,3 +180,18 @@ func (mr *MockAuthMiddlewareMockRecorder) Validate(arg0 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockAuthMiddleware)(nil).Validate), arg0)
 }
+
+// ValidateMFAToken mocks base method.
+func (m *MockAuthMiddleware) ValidateMFAToken(arg0 string) (*authmiddleware.MFAClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateMFAToken", arg0)
+	ret0, _ := ret[0].(*authmiddleware.MFAClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateMFAToken indicates an expected call of ValidateMFAToken.
+func (mr *MockAuthMiddlewareMockRecorder) ValidateMFAToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateMFAToken", reflect.TypeOf((*MockAuthMiddleware)(nil).ValidateMFAToken), arg0)
+}
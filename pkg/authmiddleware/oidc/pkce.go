@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const randomBytes = 32
+
+// LoginState is kept by the CRM between redirecting the user to the provider
+// and the callback. State comes back in the callback URL, Nonce in the ID token
+// and Verifier proves to the token endpoint that we started the flow (PKCE).
+type LoginState struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func NewLoginState() (*LoginState, error) {
+	values := make([]string, 3) //nolint:gomnd
+	for i := range values {
+		raw := make([]byte, randomBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+
+		values[i] = base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	return &LoginState{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// Challenge is the S256 PKCE code challenge of the verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// HashState hashes the state for storage, it travels in URLs and may end up in logs.
+func HashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,396 @@
+// Package oidc implements the OpenID Connect authorization code flow with PKCE
+// against an external identity provider, see https://openid.net/specs/openid-connect-core-1_0.html.
+package oidc
+
+import (
+	"context"
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// clockSkew is tolerated between us and the provider when checking exp and iat.
+	clockSkew        = time.Minute
+	maxResponseBytes = 1 << 20
+)
+
+var (
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrTokenExchange  = errors.New("oidc token exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+// Discovery is the part of the provider metadata the code flow needs.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the verified claims of an ID token the CRM uses.
+type Claims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	GivenName         string
+	FamilyName        string
+	Groups            []string
+}
+
+type Provider struct {
+	config   config.OIDCConfig
+	client   *http.Client
+	mappings []authmiddleware.RoleMapping
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]interface{}
+}
+
+func NewProvider(cfg config.OIDCConfig, client *http.Client) *Provider {
+	return &Provider{
+		config:   cfg,
+		client:   client,
+		mappings: authmiddleware.ParseRoleMapping(cfg.RoleMapping),
+	}
+}
+
+// Role maps the groups to a CRM role, DefaultRole when none matches.
+func (p *Provider) Role(groups []string) model.UserRole {
+	if role, ok := authmiddleware.MapRole(p.mappings, groups); ok {
+		return role
+	}
+
+	return model.UserRole(strings.ToUpper(p.config.DefaultRole))
+}
+
+// MapsRoles reports whether roles are managed by the provider, they are then
+// synced on every login.
+func (p *Provider) MapsRoles() bool {
+	return len(p.mappings) > 0
+}
+
+// AuthCodeURL is where the user is sent to log in at the provider.
+func (p *Provider) AuthCodeURL(ctx context.Context, state *LoginState) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {p.config.Scopes},
+		"state":                 {state.State},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {Challenge(state.Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the authorization code and verifies the returned ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, state *LoginState) (*Claims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {state.Verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var response struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	status, err := p.doJSON(req, &response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+
+	if status != http.StatusOK || response.IDToken == "" {
+		return nil, fmt.Errorf("%w: status %d %s %s", ErrTokenExchange, status, response.Error, response.ErrorDescription)
+	}
+
+	return p.Verify(ctx, response.IDToken, state.Nonce)
+}
+
+// Verify checks the signature of the ID token against the provider keys, its
+// issuer, audience, lifetime and nonce.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.Parser{
+		ValidMethods:         []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"},
+		SkipClaimsValidation: true,
+	}
+
+	mapClaims := jwt.MapClaims{}
+
+	_, err = parser.ParseWithClaims(rawIDToken, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var raw struct {
+		Issuer            string      `json:"iss"`
+		Subject           string      `json:"sub"`
+		Audience          audience    `json:"aud"`
+		ExpiresAt         json.Number `json:"exp"`
+		IssuedAt          json.Number `json:"iat"`
+		Nonce             string      `json:"nonce"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+		PreferredUsername string      `json:"preferred_username"`
+		GivenName         string      `json:"given_name"`
+		FamilyName        string      `json:"family_name"`
+	}
+
+	encoded, err := json.Marshal(mapClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(encoded)))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	now := time.Now()
+
+	switch {
+	case raw.Issuer != discovery.Issuer:
+		return nil, fmt.Errorf("%w: issuer %q", ErrInvalidIDToken, raw.Issuer)
+	case !raw.Audience.contains(p.config.ClientID):
+		return nil, fmt.Errorf("%w: audience", ErrInvalidIDToken)
+	case raw.Subject == "":
+		return nil, fmt.Errorf("%w: empty subject", ErrInvalidIDToken)
+	case nonce == "" || raw.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce", ErrInvalidIDToken)
+	case !unixAfter(raw.ExpiresAt, now.Add(-clockSkew)):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case raw.IssuedAt != "" && unixAfter(raw.IssuedAt, now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+
+	return &Claims{
+		Issuer:            raw.Issuer,
+		Subject:           raw.Subject,
+		Email:             raw.Email,
+		EmailVerified:     raw.EmailVerified == true || raw.EmailVerified == "true",
+		PreferredUsername: raw.PreferredUsername,
+		GivenName:         raw.GivenName,
+		FamilyName:        raw.FamilyName,
+		Groups:            stringList(mapClaims[p.config.GroupsClaim]),
+	}, nil
+}
+
+// Discover loads the provider metadata once and checks it belongs to the configured issuer.
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery Discovery
+
+	status, err := p.doJSON(req, &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, status)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscovery, discovery.Issuer, issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete metadata", ErrDiscovery)
+	}
+
+	p.discovery = &discovery
+
+	return p.discovery, nil
+}
+
+// key returns the provider key by kid, the key set is reloaded once for an
+// unknown kid in case the provider rotated its keys.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	jwksURI := p.discovery.JWKSURI
+	p.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwkSet
+
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks status %d", status)
+	}
+
+	keys := set.publicKeys()
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("%w: unknown kid %q", ErrUnsupportedKey, kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) doJSON(req *http.Request, target interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if err = json.Unmarshal(body, target); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, err
+	}
+
+	return resp.StatusCode, nil
+}
+
+// audience is the aud claim, a single string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, value := range a {
+		if value == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unixAfter(value json.Number, t time.Time) bool {
+	seconds, err := value.Float64()
+	if err != nil {
+		return false
+	}
+
+	return time.Unix(int64(seconds), 0).After(t)
+}
+
+// stringList reads a claim that is a string or an array of strings.
+func stringList(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		list := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
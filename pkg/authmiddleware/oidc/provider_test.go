@@ -0,0 +1,145 @@
+package oidc_test
+
+import (
+	"context"
+	"crm-system/pkg/authmiddleware/oidc"
+	"crm-system/pkg/authmiddleware/oidc/oidctest"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T, server *oidctest.Server) *oidc.Provider {
+	t.Helper()
+
+	return oidc.NewProvider(config.OIDCConfig{
+		Issuer:       server.URL,
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       "openid email",
+		GroupsClaim:  "groups",
+		RoleMapping:  "crm-admins=ADMIN,crm-managers=manager",
+		DefaultRole:  "BASE",
+	}, http.DefaultClient)
+}
+
+func TestProviderCodeFlow(t *testing.T) {
+	server := oidctest.NewServer("crm", "secret")
+	defer server.Close()
+
+	provider := newProvider(t, server)
+	ctx := context.Background()
+
+	state, err := oidc.NewLoginState()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, state)
+	require.NoError(t, err)
+
+	code, returnedState, err := server.Authorize(authURL, jwt.MapClaims{
+		"sub":            "user-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"groups":         []string{"staff", "crm-admins"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, state.State, returnedState)
+
+	claims, err := provider.Exchange(ctx, code, state)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, claims.Issuer)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, model.UserRole("ADMIN"), provider.Role(claims.Groups))
+
+	_, err = provider.Exchange(ctx, code, state)
+	assert.ErrorIs(t, err, oidc.ErrTokenExchange, "codes are single use")
+}
+
+func TestProviderExchangeWrongVerifier(t *testing.T) {
+	server := oidctest.NewServer("crm", "")
+	defer server.Close()
+
+	provider := newProvider(t, server)
+	ctx := context.Background()
+
+	state, err := oidc.NewLoginState()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, state)
+	require.NoError(t, err)
+
+	code, _, err := server.Authorize(authURL, jwt.MapClaims{"sub": "user-1"})
+	require.NoError(t, err)
+
+	other, err := oidc.NewLoginState()
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, other)
+	assert.ErrorIs(t, err, oidc.ErrTokenExchange)
+}
+
+func TestProviderVerify(t *testing.T) {
+	server := oidctest.NewServer("crm", "")
+	defer server.Close()
+
+	provider := newProvider(t, server)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name   string
+		change func(claims jwt.MapClaims)
+		valid  bool
+	}{
+		{name: "Valid", change: func(claims jwt.MapClaims) {}, valid: true},
+		{name: "AudienceList", change: func(claims jwt.MapClaims) { claims["aud"] = []string{"other", "crm"} }, valid: true},
+		{name: "WrongAudience", change: func(claims jwt.MapClaims) { claims["aud"] = "other" }},
+		{name: "WrongIssuer", change: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{name: "WrongNonce", change: func(claims jwt.MapClaims) { claims["nonce"] = "other" }},
+		{name: "EmptySubject", change: func(claims jwt.MapClaims) { claims["sub"] = "" }},
+		{name: "Expired", change: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "NoExpiry", change: func(claims jwt.MapClaims) { delete(claims, "exp") }},
+		{name: "IssuedInFuture", change: func(claims jwt.MapClaims) { claims["iat"] = time.Now().Add(time.Hour).Unix() }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := server.IDTokenClaims("user-1", "nonce")
+			tc.change(claims)
+
+			_, err := provider.Verify(ctx, server.SignIDToken(claims), "nonce")
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			}
+		})
+	}
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, server.IDTokenClaims("user-1", "nonce"))
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = provider.Verify(ctx, signed, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestProviderDiscoveryIssuerMismatch(t *testing.T) {
+	server := oidctest.NewServer("crm", "")
+	defer server.Close()
+
+	provider := oidc.NewProvider(config.OIDCConfig{Issuer: server.URL + "/tenant", ClientID: "crm"}, http.DefaultClient)
+
+	_, err := provider.Discover(context.Background())
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
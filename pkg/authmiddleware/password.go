@@ -0,0 +1,218 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Argon2idHasherName = "argon2id"
+	BcryptHasherName   = "bcrypt"
+
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHasher produces self-describing password hashes, so a stored hash
+// carries its algorithm and parameters and can be verified without a global salt.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether the hash was produced by another algorithm
+	// or with different parameters than the hasher currently uses.
+	NeedsRehash(hashedPassword string) bool
+}
+
+type Argon2idParams struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+// DefaultArgon2idParams follow the second recommended option of RFC 9106.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 4,
+	KeyLen:  32,
+}
+
+var passwordHasher PasswordHasher = NewArgon2idHasher(DefaultArgon2idParams)
+
+// SetPasswordHasher replaces the hasher used for new passwords.
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+}
+
+//nolint:ireturn
+func NewPasswordHasher(conf config.PasswordConfig) (PasswordHasher, error) {
+	switch conf.Hasher {
+	case Argon2idHasherName, "":
+		return NewArgon2idHasher(DefaultArgon2idParams), nil
+	case BcryptHasherName:
+		return NewBcryptHasher(conf.BcryptCost), nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", conf.Hasher)
+	}
+}
+
+// IsPasswordMatch verifies the password against a hash of any supported format:
+// argon2id, bcrypt or the legacy salted SHA3-512 hex digest.
+func IsPasswordMatch(password, hashedPassword string) bool {
+	var match bool
+	var err error
+
+	switch {
+	case strings.HasPrefix(hashedPassword, argon2idPrefix):
+		match, err = verifyArgon2id(password, hashedPassword)
+	case isBcryptHash(hashedPassword):
+		err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		match = err == nil
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			err = nil
+		}
+	default:
+		match = subtle.ConstantTimeCompare([]byte(H3hash(password+AuthSalt)), []byte(hashedPassword)) == 1
+	}
+
+	if err != nil {
+		logger.Errorf("IsPasswordMatch.verify", err)
+	}
+
+	return match
+}
+
+// CreateHashPassword hashes a new password with the current hasher.
+func CreateHashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// NeedsRehash reports whether a stored hash should be upgraded to the current hasher.
+func NeedsRehash(hashedPassword string) bool {
+	return passwordHasher.NeedsRehash(hashedPassword)
+}
+
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+// Hash returns the hash in PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Time,
+		h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+
+	return string(hash), err
+}
+
+func (h *BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+
+	return err != nil || cost != h.cost
+}
+
+func verifyArgon2id(password, hashedPassword string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func decodeArgon2id(hashedPassword string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	var version int
+
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, hash
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != Argon2idHasherName {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params.KeyLen = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+func isBcryptHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
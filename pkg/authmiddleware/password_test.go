@@ -0,0 +1,119 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 1024, Time: 1, Threads: 1, KeyLen: 16}
+
+func TestPasswordHashers(t *testing.T) {
+	for name, hasher := range map[string]PasswordHasher{
+		"argon2id": NewArgon2idHasher(testArgon2idParams),
+		"bcrypt":   NewBcryptHasher(bcrypt.MinCost),
+	} {
+		hash, err := hasher.Hash("password")
+		require.NoError(t, err, name)
+
+		other, err := hasher.Hash("password")
+		require.NoError(t, err, name)
+
+		assert.NotEqual(t, hash, other, "%s: every hash has its own salt", name)
+		assert.True(t, IsPasswordMatch("password", hash), name)
+		assert.False(t, IsPasswordMatch("Password", hash), name)
+		assert.False(t, hasher.NeedsRehash(hash), name)
+	}
+}
+
+func TestArgon2idFormat(t *testing.T) {
+	hash, err := NewArgon2idHasher(testArgon2idParams).Hash("password")
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{22}$`, hash)
+
+	params, salt, key, err := decodeArgon2id(hash)
+	require.NoError(t, err)
+	assert.Equal(t, testArgon2idParams, params)
+	assert.Len(t, salt, argon2SaltLen)
+	assert.Len(t, key, 16)
+}
+
+func TestLegacyPasswordMatch(t *testing.T) {
+	hash := H3hash("password" + AuthSalt)
+
+	assert.True(t, IsPasswordMatch("password", hash))
+	assert.False(t, IsPasswordMatch("other", hash))
+	assert.False(t, IsPasswordMatch("password", H3hash("password")), "the salt is part of legacy hashes")
+}
+
+func TestDecodeArgon2idMalformed(t *testing.T) {
+	for name, hash := range map[string]string{
+		"empty":           "",
+		"parts":           "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA",
+		"algorithm":       "$argon2i$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5aw",
+		"version":         "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5aw",
+		"params":          "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5aw",
+		"salt encoding":   "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5a2V5a2V5a2V5a2V5aw",
+		"key encoding":    "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$!!!",
+		"empty key":       "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$",
+		"padded encoding": "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA==$a2V5a2V5a2V5a2V5a2V5aw",
+	} {
+		_, _, _, err := decodeArgon2id(hash)
+		assert.ErrorIs(t, err, ErrInvalidPasswordHash, name)
+
+		if hash != "" {
+			assert.False(t, IsPasswordMatch("password", hash), name)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2id := NewArgon2idHasher(testArgon2idParams)
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost)
+
+	argon2idHash, err := argon2id.Hash("password")
+	require.NoError(t, err)
+
+	bcryptHash, err := bcryptHasher.Hash("password")
+	require.NoError(t, err)
+
+	legacyHash := H3hash("password" + AuthSalt)
+
+	stronger := testArgon2idParams
+	stronger.Time++
+
+	assert.True(t, NewArgon2idHasher(stronger).NeedsRehash(argon2idHash), "other parameters")
+	assert.True(t, argon2id.NeedsRehash(bcryptHash))
+	assert.True(t, argon2id.NeedsRehash(legacyHash))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(bcryptHash), "other cost")
+	assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+	assert.True(t, bcryptHasher.NeedsRehash(legacyHash))
+
+	previous := passwordHasher
+	t.Cleanup(func() { SetPasswordHasher(previous) })
+
+	SetPasswordHasher(bcryptHasher)
+	assert.True(t, NeedsRehash(argon2idHash))
+	assert.False(t, NeedsRehash(bcryptHash))
+
+	hash, err := CreateHashPassword("password")
+	require.NoError(t, err)
+	assert.True(t, isBcryptHash(hash), "new passwords use the current hasher")
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(config.PasswordConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &Argon2idHasher{}, hasher)
+
+	hasher, err = NewPasswordHasher(config.PasswordConfig{Hasher: BcryptHasherName, BcryptCost: 1})
+	require.NoError(t, err)
+	assert.Equal(t, &BcryptHasher{cost: bcrypt.DefaultCost}, hasher, "a cost below the minimum is the default")
+
+	_, err = NewPasswordHasher(config.PasswordConfig{Hasher: "md5"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // the breached password list is keyed by SHA-1
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// IsBreached looks the SHA-1 of the password up in the list file: the Pwned
+// Passwords list ordered by hash, one "HASH:COUNT" line per hash, as the
+// k-anonymity range downloads are concatenated. The file is binary searched,
+// so the multi-gigabyte list is never loaded and the password never leaves the server.
+func IsBreached(path, password string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha1.Sum([]byte(password)) //nolint:gosec
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// every line starting in [low, high) is a candidate
+	low, high := int64(0), info.Size()
+	for low < high {
+		mid := low + (high-low)/2 //nolint:gomnd
+
+		start, line, err := lineAt(file, mid)
+		if err != nil {
+			return false, err
+		}
+
+		if start >= high {
+			high = mid
+
+			continue
+		}
+
+		switch key := lineHash(line); {
+		case key == hash:
+			return true, nil
+		case key < hash:
+			low = start + int64(len(line))
+		default:
+			high = mid
+		}
+	}
+
+	return false, nil
+}
+
+// lineAt returns the first line starting at or after offset, with its line break.
+func lineAt(file io.ReaderAt, offset int64) (int64, string, error) {
+	start := offset
+
+	if offset > 0 {
+		// the line starts right after the break preceding it
+		start = offset - 1
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, 1<<62)) //nolint:gomnd
+
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return start + int64(len(skipped)), "", nil
+		}
+
+		if err != nil {
+			return 0, "", err
+		}
+
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+
+	return start, line, nil
+}
+
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+
+	return strings.ToUpper(hash)
+}
@@ -0,0 +1,47 @@
+package passwordpolicy
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBreachedSearchesEveryLine(t *testing.T) {
+	lines := make([]string, 0, 500)
+	for i := 0; i < cap(lines); i++ {
+		sum := sha1.Sum([]byte(fmt.Sprint("password", i))) //nolint:gosec
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+
+	sort.Strings(lines)
+
+	list := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(list, []byte(strings.Join(lines, "\n")), 0o600))
+
+	for i := 0; i < cap(lines); i++ {
+		breached, err := IsBreached(list, fmt.Sprint("password", i))
+		require.NoError(t, err)
+		assert.True(t, breached, i)
+	}
+
+	breached, err := IsBreached(list, "password")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
+
+func TestIsBreachedEmptyList(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(list, nil, 0o600))
+
+	breached, err := IsBreached(list, "password")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
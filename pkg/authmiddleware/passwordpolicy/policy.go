@@ -0,0 +1,168 @@
+// Package passwordpolicy checks new passwords against the configured rules: a
+// minimum length, character classes, the username, the previous passwords of
+// the user and a list of breached passwords.
+package passwordpolicy
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	RuleMinLength = "min_length"
+	RuleUsername  = "username"
+	RuleHistory   = "history"
+	RuleBreached  = "breached"
+
+	// minUsernameLength keeps very short usernames from ruling out common substrings.
+	minUsernameLength = 3
+)
+
+type characterClass struct {
+	is      func(r rune) bool
+	message string
+}
+
+var characterClasses = map[string]characterClass{
+	"lower":  {is: unicode.IsLower, message: "must contain a lowercase letter"},
+	"upper":  {is: unicode.IsUpper, message: "must contain an uppercase letter"},
+	"digit":  {is: unicode.IsDigit, message: "must contain a digit"},
+	"symbol": {is: isSymbol, message: "must contain a symbol"},
+}
+
+type Policy struct {
+	users   store.AuthRepository
+	conf    config.PasswordPolicyConfig
+	classes []string
+	now     func() time.Time
+}
+
+func NewPolicy(users store.AuthRepository, conf config.PasswordPolicyConfig) *Policy {
+	classes := make([]string, 0, len(conf.CharacterClasses))
+
+	for _, class := range conf.CharacterClasses {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if _, ok := characterClasses[class]; !ok {
+			if class != "" {
+				logger.Errorf("NewPolicy.unknown character class", class)
+			}
+
+			continue
+		}
+
+		classes = append(classes, class)
+	}
+
+	return &Policy{
+		users:   users,
+		conf:    conf,
+		classes: classes,
+		now:     time.Now,
+	}
+}
+
+// Check returns every rule the password of the user breaks, reported for the
+// request field. Previous passwords are only checked for an existing user.
+func (p *Policy) Check(field, password string, user *model.AuthUser) ([]model.FieldError, error) {
+	violations := make([]model.FieldError, 0)
+	violate := func(rule, message string) {
+		violations = append(violations, model.FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.conf.MinLength {
+		violate(RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.conf.MinLength))
+	}
+
+	for _, class := range p.classes {
+		if !strings.ContainsFunc(password, characterClasses[class].is) {
+			violate(class, characterClasses[class].message)
+		}
+	}
+
+	username := strings.ToLower(user.Username)
+	if p.conf.RejectUsername && utf8.RuneCountInString(username) >= minUsernameLength &&
+		strings.Contains(strings.ToLower(password), username) {
+		violate(RuleUsername, "must not contain the username")
+	}
+
+	reused, err := p.reused(password, user)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reused && p.conf.History == 1:
+		violate(RuleHistory, "must differ from the current password")
+	case reused:
+		violate(RuleHistory, fmt.Sprintf("must not be one of the last %d passwords", p.conf.History))
+	}
+
+	if p.conf.BreachedList != "" {
+		breached, err := IsBreached(p.conf.BreachedList, password)
+		if err != nil {
+			return nil, err
+		}
+
+		if breached {
+			violate(RuleBreached, "appears in a known data breach")
+		}
+	}
+
+	return violations, nil
+}
+
+// Expired reports whether the local password of the user is older than the max age.
+func (p *Policy) Expired(user *model.AuthUser) bool {
+	if p.conf.MaxAge.Duration <= 0 || user.Password == "" || user.PasswordChangedAt.IsZero() {
+		return false
+	}
+
+	return p.now().After(user.PasswordChangedAt.Add(p.conf.MaxAge.Duration))
+}
+
+// History is the number of passwords, the current one included, the user can't reuse.
+func (p *Policy) History() int {
+	return p.conf.History
+}
+
+func (p *Policy) reused(password string, user *model.AuthUser) (bool, error) {
+	if p.conf.History <= 0 || user.ID == uuid.Nil {
+		return false, nil
+	}
+
+	hashes := make([]string, 0, p.conf.History)
+	if user.Password != "" {
+		hashes = append(hashes, user.Password)
+	}
+
+	if p.conf.History > 1 {
+		previous, err := p.users.PasswordHistory(user.ID, p.conf.History-1)
+		if err != nil {
+			return false, err
+		}
+
+		hashes = append(hashes, previous...)
+	}
+
+	for _, hash := range hashes {
+		if authmiddleware.IsPasswordMatch(password, hash) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
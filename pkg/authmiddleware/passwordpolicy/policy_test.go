@@ -0,0 +1,146 @@
+package passwordpolicy
+
+import (
+	"crm-system/pkg/authmiddleware"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store/mockpostgresstore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rules(violations []model.FieldError) []string {
+	names := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		names = append(names, violation.Rule)
+	}
+
+	return names
+}
+
+func TestCheckStaticRules(t *testing.T) {
+	policy := NewPolicy(nil, config.PasswordPolicyConfig{
+		MinLength:        10,
+		CharacterClasses: []string{"lower", " Upper", "digit", "symbol", "unknown"},
+		RejectUsername:   true,
+	})
+	user := &model.AuthUser{Username: "Jane"}
+
+	testCases := []struct {
+		name     string
+		password string
+		expected []string
+	}{
+		{name: "Valid", password: "Correct-Horse-7", expected: []string{}},
+		{name: "Short", password: "aB3$", expected: []string{RuleMinLength}},
+		{name: "RunesNotBytes", password: "ÄäÖöÜü-1234", expected: []string{}},
+		{name: "MissingClasses", password: "correcthorsebattery", expected: []string{"upper", "digit", "symbol"}},
+		{name: "Username", password: "My-JANE-password-1", expected: []string{RuleUsername}},
+		{name: "Empty", password: "", expected: []string{RuleMinLength, "lower", "upper", "digit", "symbol"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := policy.Check("password", tc.password, user)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rules(violations))
+
+			for _, violation := range violations {
+				assert.Equal(t, "password", violation.Field)
+				assert.NotEmpty(t, violation.Message)
+			}
+		})
+	}
+}
+
+func TestCheckShortUsernameAllowed(t *testing.T) {
+	policy := NewPolicy(nil, config.PasswordPolicyConfig{RejectUsername: true})
+
+	violations, err := policy.Check("password", "amazing-password", &model.AuthUser{Username: "am"})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestCheckHistory(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	users := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	policy := NewPolicy(users, config.PasswordPolicyConfig{History: 3})
+	current, err := authmiddleware.CreateHashPassword("current")
+	require.NoError(t, err)
+
+	previous, err := authmiddleware.CreateHashPassword("previous")
+	require.NoError(t, err)
+
+	user := &model.AuthUser{ID: uuid.NewV4(), Password: current}
+
+	users.EXPECT().PasswordHistory(user.ID, 2).Return([]string{previous}, nil).Times(3)
+
+	for password, expected := range map[string][]string{
+		"current":  {RuleHistory},
+		"previous": {RuleHistory},
+		"new":      {},
+	} {
+		violations, err := policy.Check("new_password", password, user)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rules(violations), password)
+	}
+
+	// new users have no history
+	violations, err := policy.Check("password", "current", &model.AuthUser{Username: "new"})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestCheckBreached(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	require.NoError(t, os.WriteFile(list, []byte(breachedList), 0o600))
+
+	policy := NewPolicy(nil, config.PasswordPolicyConfig{BreachedList: list})
+
+	violations, err := policy.Check("password", "password", &model.AuthUser{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{RuleBreached}, rules(violations))
+
+	violations, err = policy.Check("password", "not in the list", &model.AuthUser{})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	missing := NewPolicy(nil, config.PasswordPolicyConfig{BreachedList: filepath.Join(t.TempDir(), "missing.txt")})
+	_, err = missing.Check("password", "password", &model.AuthUser{})
+	assert.Error(t, err)
+}
+
+func TestExpired(t *testing.T) {
+	policy := NewPolicy(nil, config.PasswordPolicyConfig{MaxAge: config.Duration{Duration: 24 * time.Hour}})
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	policy.now = func() time.Time { return now }
+
+	assert.True(t, policy.Expired(&model.AuthUser{Password: "hash", PasswordChangedAt: now.Add(-25 * time.Hour)}))
+	assert.False(t, policy.Expired(&model.AuthUser{Password: "hash", PasswordChangedAt: now.Add(-23 * time.Hour)}))
+	// directory and single sign-on users have no local password to expire
+	assert.False(t, policy.Expired(&model.AuthUser{PasswordChangedAt: now.Add(-25 * time.Hour)}))
+
+	disabled := NewPolicy(nil, config.PasswordPolicyConfig{})
+	assert.False(t, disabled.Expired(&model.AuthUser{Password: "hash", PasswordChangedAt: now.AddDate(-1, 0, 0)}))
+}
+
+// breachedList holds "password" (5BAA61E4...) and "123456" (7C4A8D09...) among
+// other hashes, ordered by hash with the counts of the Pwned Passwords list.
+var breachedList = strings.Join([]string{
+	"000000005AD76BD555C1D6D771DE417A4B87E4B4:10",
+	"00000000A8DAE4228F821FB418F59826079BF368:4",
+	"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+	"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD9:1",
+	"7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195",
+	"FFFFFFFEE791CBAC0F6305CAF0CEE06BBE131160:2",
+}, "\r\n") + "\r\n"
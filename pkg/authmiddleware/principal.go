@@ -0,0 +1,121 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// PrincipalKey holds the *Principal of the authorized request in the gin context.
+const PrincipalKey = "principal"
+
+// Principal is the identity Authorize established for the request. TokenID is the
+// access token ID or, for API key requests, the key ID; SessionID is empty for the
+// latter. Permissions are resolved per request so role changes apply to live tokens.
+// ImpersonatorID is the admin acting as the user with an impersonation token, the
+// session of the admin is not exposed as SessionID.
+type Principal struct {
+	UserID         uuid.UUID
+	Role           model.UserRole
+	TokenID        string
+	SessionID      uuid.UUID
+	Permissions    []model.Permission
+	ImpersonatorID uuid.UUID
+}
+
+func (p *Principal) IsImpersonated() bool {
+	return p.ImpersonatorID != uuid.Nil
+}
+
+func (p *Principal) Can(permission model.Permission) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanAll reports whether the principal has every listed permission.
+func (p *Principal) CanAll(permissions []model.Permission) bool {
+	for _, permission := range permissions {
+		if !p.Can(permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//nolint:varnamelen
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(PrincipalKey, principal)
+}
+
+// GetPrincipal returns the principal set by Authorize, ErrUnauthorized on routes without it.
+//
+//nolint:varnamelen
+func GetPrincipal(c *gin.Context) (*Principal, error) {
+	value, _ := c.Get(PrincipalKey)
+
+	principal, ok := value.(*Principal)
+	if !ok || principal == nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+//nolint:varnamelen
+func CurrentUserID(c *gin.Context) (uuid.UUID, error) {
+	principal, err := GetPrincipal(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return principal.UserID, nil
+}
+
+//nolint:varnamelen
+func HasPermission(c *gin.Context, permission model.Permission) bool {
+	principal, err := GetPrincipal(c)
+
+	return err == nil && principal.Can(permission)
+}
+
+// RequirePermission aborts with 403 unless the user has every listed permission.
+// It must run after Authorize.
+func RequirePermission(permissions ...model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				logger.Errorf("RequirePermission.missing", permission)
+				c.AbortWithStatusJSON(http.StatusForbidden, model.ErrForbidden)
+
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// ForbidImpersonation aborts with 403 on impersonated requests, it guards the
+// actions only the user may take. It must run after Authorize.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := GetPrincipal(c)
+		if err == nil && principal.IsImpersonated() {
+			logger.Errorf("ForbidImpersonation.impersonated", principal.ImpersonatorID)
+			c.AbortWithStatusJSON(http.StatusForbidden, model.ErrImpersonation)
+
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,84 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPrincipal(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	_, err := GetPrincipal(c)
+	assert.ErrorIs(t, err, model.ErrUnauthorized)
+	assert.False(t, HasPermission(c, model.PermUsersRead))
+
+	userID := uuid.NewV4()
+	SetPrincipal(c, &Principal{UserID: userID, Permissions: []model.Permission{model.PermUsersRead}})
+
+	currentID, err := CurrentUserID(c)
+	require.NoError(t, err)
+	assert.Equal(t, userID, currentID)
+	assert.True(t, HasPermission(c, model.PermUsersRead))
+	assert.False(t, HasPermission(c, model.PermUsersDelete))
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		SetPrincipal(c, &Principal{Permissions: []model.Permission{model.PermUsersRead}})
+	}, RequirePermission(model.PermUsersRead, model.PermUsersDelete), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/read", func(c *gin.Context) {
+		SetPrincipal(c, &Principal{Permissions: []model.Permission{model.PermUsersRead}})
+	}, RequirePermission(model.PermUsersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/read", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestForbidImpersonation(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/user", func(c *gin.Context) {
+		SetPrincipal(c, &Principal{UserID: uuid.NewV4()})
+	}, ForbidImpersonation(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/impersonated", func(c *gin.Context) {
+		SetPrincipal(c, &Principal{UserID: uuid.NewV4(), ImpersonatorID: uuid.NewV4()})
+	}, ForbidImpersonation(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/user", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/impersonated", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestPrincipalCanAll(t *testing.T) {
+	principal := &Principal{Permissions: []model.Permission{model.PermUsersRead, model.PermUsersImpersonate}}
+
+	assert.True(t, principal.CanAll(nil))
+	assert.True(t, principal.CanAll([]model.Permission{model.PermUsersRead}))
+	assert.False(t, principal.CanAll([]model.Permission{model.PermUsersRead, model.PermUsersDelete}))
+}
@@ -0,0 +1,52 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"strings"
+)
+
+// RoleMapping grants Role to members of a Group of the identity provider or directory.
+type RoleMapping struct {
+	Group string
+	Role  model.UserRole
+}
+
+// ParseRoleMapping parses "group=ROLE,other=ROLE", malformed entries are logged and skipped.
+func ParseRoleMapping(raw string) []RoleMapping {
+	mappings := make([]RoleMapping, 0)
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		group, role, found := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		role = strings.ToUpper(strings.TrimSpace(role))
+
+		if !found || group == "" || role == "" {
+			logger.Errorf("ParseRoleMapping.malformed entry", entry)
+
+			continue
+		}
+
+		mappings = append(mappings, RoleMapping{Group: group, Role: model.UserRole(role)})
+	}
+
+	return mappings
+}
+
+// MapRole returns the role of the first mapping the groups match.
+func MapRole(mappings []RoleMapping, groups []string) (model.UserRole, bool) {
+	for _, mapping := range mappings {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,21 @@
+package authmiddleware
+
+import (
+	"crm-system/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapRole(t *testing.T) {
+	mappings := ParseRoleMapping("crm-admins=admin, broken, =BASE,crm-managers=MANAGER")
+	require.Len(t, mappings, 2)
+
+	role, ok := MapRole(mappings, []string{"crm-managers", "crm-admins"})
+	assert.True(t, ok)
+	assert.Equal(t, model.UserRole("ADMIN"), role)
+
+	_, ok = MapRole(mappings, []string{"staff"})
+	assert.False(t, ok)
+}
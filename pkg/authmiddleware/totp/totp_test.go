@@ -0,0 +1,59 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of RFC 6238 appendix B (SHA1), truncated to 6 digits.
+var rfcVectors = map[int64]string{
+	59:         "287082",
+	1111111109: "081804",
+	1111111111: "050471",
+	1234567890: "005924",
+	2000000000: "279037",
+}
+
+func TestCode(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, expected := range rfcVectors {
+		code, err := Code(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+
+	assert.True(t, Validate(secret, code, now))
+	assert.True(t, Validate(secret, code, now.Add(Period)))
+	assert.False(t, Validate(secret, code, now.Add(3*Period)))
+	assert.False(t, Validate(secret, "12345", now))
+}
+
+func TestValidateStep(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+
+	step, valid := ValidateStep(secret, code, now)
+	assert.True(t, valid)
+	assert.Equal(t, now.Unix()/int64(Period.Seconds()), step)
+
+	later, valid := ValidateStep(secret, code, now.Add(Period))
+	assert.True(t, valid)
+	assert.Equal(t, step, later)
+}
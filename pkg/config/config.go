@@ -9,6 +9,15 @@ type Configs struct {
 	DBPostgresConfig DBPostgresConfig
 	Server           ServerConfig
 	Keys             Path
+	Password         PasswordConfig
+	PasswordPolicy   PasswordPolicyConfig
+	MFA              MFAConfig
+	BruteForce       BruteForceConfig
+	Mailer           MailerConfig
+	OIDC             OIDCConfig
+	LDAP             LDAPConfig
+	Reminder         ReminderConfig
+	Import           ImportConfig
 }
 
 type DBPostgresConfig struct {
@@ -19,14 +28,135 @@ type DBPostgresConfig struct {
 	Password string `env:"POSTGRES_PASSWORD"`
 }
 
+// Path points to the token signing keys. When a *Dir is set every *.pem file
+// in it is loaded and the one named by *KeyID (or the last by name) signs new tokens.
 type Path struct {
-	AccessKey  string `env:"HASH_KEY_ACCESS"`
-	RefreshKey string `env:"HASH_KEY_REFRESH"`
+	AccessKey     string `env:"HASH_KEY_ACCESS"`
+	RefreshKey    string `env:"HASH_KEY_REFRESH"`
+	AccessKeyDir  string `env:"HASH_KEY_ACCESS_DIR"`
+	RefreshKeyDir string `env:"HASH_KEY_REFRESH_DIR"`
+	AccessKeyID   string `env:"HASH_KEY_ACCESS_KID"`
+	RefreshKeyID  string `env:"HASH_KEY_REFRESH_KID"`
+}
+
+type PasswordConfig struct {
+	Hasher     string `env:"PASSWORD_HASHER"      envDefault:"argon2id"`
+	BcryptCost int    `env:"PASSWORD_BCRYPT_COST" envDefault:"12"`
+	// ResetURL is the frontend page the reset token is appended to as the token query parameter.
+	ResetURL      string   `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:8000/reset-password"`
+	ResetTokenTTL Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	// InvitationURL is the frontend page the invitation token is appended to as the token query parameter.
+	InvitationURL string   `env:"INVITATION_URL" envDefault:"http://localhost:8000/accept-invitation"`
+	InvitationTTL Duration `env:"INVITATION_TTL" envDefault:"72h"`
+}
+
+// PasswordPolicyConfig is enforced wherever a password is set. CharacterClasses
+// lists the classes every password needs: lower, upper, digit and symbol. The
+// last History passwords, the current one included, can't be reused and a
+// password older than MaxAge has to be changed before the next login, zero
+// disables both. BreachedList is the Pwned Passwords SHA-1 list ordered by hash.
+type PasswordPolicyConfig struct {
+	MinLength        int      `env:"PASSWORD_MIN_LENGTH"        envDefault:"12"`
+	CharacterClasses []string `env:"PASSWORD_CHARACTER_CLASSES" envDefault:"lower,upper,digit" envSeparator:","`
+	RejectUsername   bool     `env:"PASSWORD_REJECT_USERNAME"   envDefault:"true"`
+	History          int      `env:"PASSWORD_HISTORY"           envDefault:"5"`
+	MaxAge           Duration `env:"PASSWORD_MAX_AGE"           envDefault:"0s"`
+	BreachedList     string   `env:"PASSWORD_BREACHED_LIST"`
+}
+
+type MFAConfig struct {
+	// Issuer is the account label prefix shown by authenticator apps.
+	Issuer string `env:"MFA_ISSUER" envDefault:"CRM System"`
+	// TokenSecret signs the challenge tokens of the second login step, a
+	// random one is generated when it's empty.
+	TokenSecret string `env:"MFA_TOKEN_SECRET"`
+}
+
+// BruteForceConfig limits failed logins. After FreeAttempts failures the next
+// attempt is delayed by BaseDelay, doubling up to MaxDelay; reaching a threshold
+// locks the account or the IP for LockoutDuration. Failures older than Window are forgotten.
+type BruteForceConfig struct {
+	FreeAttempts     int      `env:"LOGIN_FREE_ATTEMPTS"        envDefault:"3"`
+	BaseDelay        Duration `env:"LOGIN_BASE_DELAY"           envDefault:"1s"`
+	MaxDelay         Duration `env:"LOGIN_MAX_DELAY"            envDefault:"1m"`
+	AccountThreshold int      `env:"LOGIN_LOCKOUT_THRESHOLD"    envDefault:"10"`
+	IPThreshold      int      `env:"LOGIN_IP_LOCKOUT_THRESHOLD" envDefault:"100"`
+	LockoutDuration  Duration `env:"LOGIN_LOCKOUT_DURATION"     envDefault:"15m"`
+	Window           Duration `env:"LOGIN_FAILURE_WINDOW"       envDefault:"1h"`
+}
+
+// MailerConfig selects how emails are sent: smtp, file (*.eml files in Dir) or log.
+type MailerConfig struct {
+	Driver       string `env:"MAILER"        envDefault:"log"`
+	From         string `env:"MAIL_FROM"     envDefault:"CRM System <no-reply@localhost>"`
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     string `env:"SMTP_PORT"     envDefault:"587"`
+	SMTPUser     string `env:"SMTP_USER"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	Dir          string `env:"MAILER_DIR"    envDefault:"mail"`
+}
+
+// ReminderConfig schedules the due-soon task reminders. Every Interval the
+// assignees of the open tasks due within Lead are notified once, Batch tasks at
+// a time; a zero Interval disables the scheduler. Notifier is mail or log.
+type ReminderConfig struct {
+	Notifier string   `env:"TASK_REMINDER_NOTIFIER" envDefault:"mail"`
+	Interval Duration `env:"TASK_REMINDER_INTERVAL" envDefault:"1m"`
+	Lead     Duration `env:"TASK_REMINDER_LEAD"     envDefault:"1h"`
+	Batch    int      `env:"TASK_REMINDER_BATCH"    envDefault:"100"`
+}
+
+// ImportConfig limits the CSV imports and runs them. Uploads have at most
+// MaxBytes and MaxRows rows. Every Interval the queued imports are run Batch
+// rows per transaction, running imports not updated within Stale are taken over
+// as their worker stopped; a zero Interval disables the worker.
+type ImportConfig struct {
+	MaxBytes int64    `env:"IMPORT_MAX_BYTES" envDefault:"10485760"`
+	MaxRows  int      `env:"IMPORT_MAX_ROWS"  envDefault:"50000"`
+	Batch    int      `env:"IMPORT_BATCH"     envDefault:"500"`
+	Interval Duration `env:"IMPORT_INTERVAL"  envDefault:"5s"`
+	Stale    Duration `env:"IMPORT_STALE"     envDefault:"10m"`
+}
+
+// OIDCConfig enables login with an external OpenID Connect provider when Issuer is set.
+// RoleMapping maps IdP groups to roles as "group=ROLE,other=ROLE", the first listed match wins
+// and users matching none get DefaultRole.
+type OIDCConfig struct {
+	Issuer       string   `env:"OIDC_ISSUER"`
+	ClientID     string   `env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `env:"OIDC_REDIRECT_URL"  envDefault:"http://localhost:8000/api/v1/oidc/callback"`
+	Scopes       string   `env:"OIDC_SCOPES"        envDefault:"openid profile email"`
+	GroupsClaim  string   `env:"OIDC_GROUPS_CLAIM"  envDefault:"groups"`
+	RoleMapping  string   `env:"OIDC_ROLE_MAPPING"`
+	DefaultRole  string   `env:"OIDC_DEFAULT_ROLE"  envDefault:"BASE"`
+	StateTTL     Duration `env:"OIDC_STATE_TTL"     envDefault:"10m"`
+}
+
+// LDAPConfig lets users log in with their directory password when URL is set.
+// The user entry is searched under BaseDN with UserFilter, where {username} is
+// the escaped login name, and the password is verified by binding as the entry.
+// RoleMapping maps the common names of the GroupAttribute groups like OIDCConfig.RoleMapping.
+type LDAPConfig struct {
+	URL            string   `env:"LDAP_URL"`
+	StartTLS       bool     `env:"LDAP_START_TLS"`
+	BindDN         string   `env:"LDAP_BIND_DN"`
+	BindPassword   string   `env:"LDAP_BIND_PASSWORD"`
+	BaseDN         string   `env:"LDAP_BASE_DN"`
+	UserFilter     string   `env:"LDAP_USER_FILTER"     envDefault:"(uid={username})"`
+	EmailAttribute string   `env:"LDAP_EMAIL_ATTRIBUTE" envDefault:"mail"`
+	GroupAttribute string   `env:"LDAP_GROUP_ATTRIBUTE" envDefault:"memberOf"`
+	RoleMapping    string   `env:"LDAP_ROLE_MAPPING"`
+	DefaultRole    string   `env:"LDAP_DEFAULT_ROLE"    envDefault:"BASE"`
+	Timeout        Duration `env:"LDAP_TIMEOUT"         envDefault:"5s"`
 }
 
 type ServerConfig struct {
 	ServerPort  string   `env:"SERVER_PORT"`
 	ReadTimeout Duration `env:"READ_TIMEOUT"`
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For is believed,
+	// without them the client IP is the address of the connection.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
 }
 
 func New() (*Configs, error) {
@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"bytes"
+	"crm-system/pkg/model"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNoHeader = errors.New("the file has no header row")
+	ErrNoRows   = errors.New("the file has no rows after the header")
+)
+
+// ReadCSV reads the header and the rows of a CSV file, comma or semicolon
+// separated. Headers have to be unique and not empty, rows may have fewer or more
+// columns than the header. A maxRows above zero limits the rows.
+func ReadCSV(data []byte, maxRows int) ([]string, [][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = separator(data)
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, ErrNoHeader
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(headers))
+	for i, header := range headers {
+		headers[i] = strings.TrimSpace(header)
+
+		if headers[i] == "" {
+			return nil, nil, fmt.Errorf("column %d has no header", i+1)
+		}
+
+		if seen[headers[i]] {
+			return nil, nil, fmt.Errorf("the header %q is repeated", headers[i])
+		}
+
+		seen[headers[i]] = true
+	}
+
+	rows := [][]string{}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isBlank(row) {
+			continue
+		}
+
+		if maxRows > 0 && len(rows) == maxRows {
+			return nil, nil, fmt.Errorf("the file has more than %d rows", maxRows)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, ErrNoRows
+	}
+
+	return headers, rows, nil
+}
+
+// Check makes the contacts of the rows and the errors of the rows that can't be
+// imported, first is the index of the first row in the file.
+func Check(job *model.Import, rows [][]string, first int) ([]model.Contact, []model.ImportRowError) {
+	contacts := make([]model.Contact, 0, len(rows))
+	rowErrors := []model.ImportRowError{}
+
+	for i, row := range rows {
+		contact, fields := job.Contact(row)
+		if len(fields) > 0 {
+			rowErrors = append(rowErrors, model.ImportRowError{ImportID: job.ID, Row: first + i + 1, Errors: fields})
+
+			continue
+		}
+
+		contacts = append(contacts, *contact)
+	}
+
+	return contacts, rowErrors
+}
+
+// separator picks the semicolon spreadsheets export in some locales when the
+// header line has more of them than commas.
+func separator(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+
+	return ','
+}
+
+func isBlank(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
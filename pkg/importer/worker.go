@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"context"
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store"
+	"errors"
+	"time"
+)
+
+const defaultBatch = 500
+
+// Worker runs the queued imports. Every batch of rows is saved with the progress
+// in one transaction, so an import taken over after a restart goes on after the
+// last saved batch.
+type Worker struct {
+	imports store.ImportRepository
+	conf    config.ImportConfig
+}
+
+func NewWorker(imports store.ImportRepository, conf config.ImportConfig) *Worker {
+	if conf.Batch <= 0 {
+		conf.Batch = defaultBatch
+	}
+
+	return &Worker{imports: imports, conf: conf}
+}
+
+// Run runs the queued imports every interval until the context is done, it
+// returns at once when the interval is zero.
+func (w *Worker) Run(ctx context.Context) {
+	if w.conf.Interval.Duration <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.conf.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		finished, err := w.RunQueued(ctx)
+		if err != nil {
+			logger.Errorf("Worker.RunQueued", err)
+		} else if finished > 0 {
+			logger.Infof("Finished %d imports", finished)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunQueued runs the imports it claims one by one until none is queued and
+// returns how many it finished. An import stopped by the context stays running
+// for another worker to take over.
+func (w *Worker) RunQueued(ctx context.Context) (int, error) {
+	finished := 0
+
+	for ctx.Err() == nil {
+		job, claimed, err := w.imports.Claim(time.Now().Add(-w.conf.Stale.Duration), time.Now())
+		if err != nil || !claimed {
+			return finished, err
+		}
+
+		err = w.Process(ctx, job)
+		if errors.Is(err, model.ErrImportTakenOver) || errors.Is(err, context.Canceled) {
+			logger.Infof("Import %s stopped: %s", job.ID, err)
+
+			continue
+		}
+
+		status, message := model.ImportCompleted, ""
+		if err != nil {
+			logger.Errorf("RunQueued.Process", err)
+
+			status, message = model.ImportFailed, importFailure(err)
+		}
+
+		err = w.imports.Finish(job.ID, status, message, time.Now())
+		if err != nil {
+			return finished, err
+		}
+
+		finished++
+	}
+
+	return finished, nil
+}
+
+// Process imports the rows of the claimed import after the processed ones.
+func (w *Worker) Process(ctx context.Context, job *model.Import) error {
+	_, rows, err := ReadCSV(job.Data, 0)
+	if err != nil {
+		return err
+	}
+
+	if fields := job.Mapping.Validate(job.Headers); len(fields) > 0 {
+		return errInvalidMapping
+	}
+
+	for from := job.ProcessedRows; from < len(rows); from += w.conf.Batch {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		to := from + w.conf.Batch
+		if to > len(rows) {
+			to = len(rows)
+		}
+
+		contacts, rowErrors := Check(job, rows[from:to], from)
+
+		err = w.imports.SaveBatch(&model.ImportBatch{
+			ImportID: job.ID,
+			From:     from,
+			To:       to,
+			Contacts: contacts,
+			Errors:   rowErrors,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var errInvalidMapping = errors.New("the mapping is invalid")
+
+// importFailure is the error the owner of a failed import sees, database errors aren't shown.
+func importFailure(err error) string {
+	if errors.Is(err, errInvalidMapping) || errors.Is(err, ErrNoHeader) || errors.Is(err, ErrNoRows) {
+		return err.Error()
+	}
+
+	return "the import failed, the rows counted as processed were saved"
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"context"
+	"crm-system/pkg/config"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const contactsCSV = "\xef\xbb\xbfFirst Name;Last Name;E-mail;Mobile\n" +
+	"Ann;Lee;ann@example.com;+380681234567\n" +
+	";;\n" +
+	";;not an email;\n" +
+	"Bob;;BOB@example.com;\n"
+
+func newImport(data string) *model.Import {
+	headers, _, _ := ReadCSV([]byte(data), 0)
+
+	return &model.Import{
+		ID:      uuid.NewV4(),
+		OwnerID: uuid.NewV4(),
+		Headers: headers,
+		Mapping: model.SuggestImportMapping(headers),
+		Status:  model.ImportRunning,
+		Data:    []byte(data),
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	headers, rows, err := ReadCSV([]byte(contactsCSV), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First Name", "Last Name", "E-mail", "Mobile"}, headers)
+	assert.Len(t, rows, 3)
+
+	_, _, err = ReadCSV([]byte(contactsCSV), 2)
+	assert.EqualError(t, err, "the file has more than 2 rows")
+
+	_, _, err = ReadCSV([]byte("name,name\nAnn,Lee\n"), 0)
+	assert.EqualError(t, err, `the header "name" is repeated`)
+
+	_, _, err = ReadCSV([]byte("name\n\n"), 0)
+	assert.ErrorIs(t, err, ErrNoRows)
+
+	_, _, err = ReadCSV([]byte{}, 0)
+	assert.ErrorIs(t, err, ErrNoHeader)
+}
+
+func TestCheck(t *testing.T) {
+	job := newImport(contactsCSV)
+	_, rows, err := ReadCSV(job.Data, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, model.ImportMapping{
+		"First Name": model.ImportFirstName,
+		"Last Name":  model.ImportLastName,
+		"E-mail":     model.ImportEmail,
+		"Mobile":     model.ImportPhone,
+	}, job.Mapping)
+
+	contacts, rowErrors := Check(job, rows, 10)
+	require.Len(t, contacts, 2)
+	assert.Equal(t, "Lee", contacts[0].LastName)
+	assert.Equal(t, &job.OwnerID, contacts[0].OwnerID)
+	assert.Equal(t, "+380681234567", contacts[0].Phones[0].Phone)
+	assert.Equal(t, "bob@example.com", contacts[1].Emails[0].Email)
+	assert.Equal(t, model.LifecycleLead, contacts[1].LifecycleStage)
+	assert.Equal(t, []model.ImportRowError{{ImportID: job.ID, Row: 12, Errors: model.FieldErrors{
+		{Field: "first_name", Rule: "required", Message: "first or last name is required"},
+		{Field: "emails", Rule: "email", Message: "invalid email address"},
+	}}}, rowErrors)
+}
+
+func TestRunQueued(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	imports := mockpostgresstore.NewMockImportRepository(mockCtrl)
+	worker := NewWorker(imports, config.ImportConfig{Batch: 2})
+
+	// the first row was saved before the import was taken over
+	job := newImport(contactsCSV)
+	job.ProcessedRows = 1
+	failed := newImport("Name\nAnn\n")
+
+	saved := []*model.ImportBatch{}
+	gomock.InOrder(
+		imports.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(job, true, nil),
+		imports.EXPECT().SaveBatch(gomock.Any()).DoAndReturn(func(batch *model.ImportBatch) error {
+			saved = append(saved, batch)
+
+			return nil
+		}),
+		imports.EXPECT().Finish(job.ID, model.ImportCompleted, "", gomock.Any()).Return(nil),
+		imports.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(failed, true, nil),
+		imports.EXPECT().Finish(failed.ID, model.ImportFailed, "the mapping is invalid", gomock.Any()).Return(nil),
+		imports.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(nil, false, nil),
+	)
+
+	finished, err := worker.RunQueued(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, finished)
+	require.Len(t, saved, 1)
+	assert.Equal(t, 1, saved[0].From)
+	assert.Equal(t, 3, saved[0].To)
+	assert.Len(t, saved[0].Contacts, 1)
+	assert.Equal(t, 2, saved[0].Errors[0].Row)
+}
+
+func TestRunQueuedTakenOver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	imports := mockpostgresstore.NewMockImportRepository(mockCtrl)
+	worker := NewWorker(imports, config.ImportConfig{})
+
+	job := newImport(contactsCSV)
+
+	gomock.InOrder(
+		imports.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(job, true, nil),
+		imports.EXPECT().SaveBatch(gomock.Any()).Return(model.ErrImportTakenOver),
+		imports.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(nil, false, errors.New("error")),
+	)
+
+	// the import is left to the worker that took it over
+	finished, err := worker.RunQueued(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 0, finished)
+}
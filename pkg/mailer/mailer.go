@@ -0,0 +1,125 @@
+// Package mailer sends transactional emails. SMTP is used in production, the log
+// and file mailers keep messages local for development and tests.
+package mailer
+
+import (
+	"bytes"
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	SMTPDriver = "smtp"
+	FileDriver = "file"
+	LogDriver  = "log"
+
+	fileMode = 0o600
+	dirMode  = 0o700
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+//nolint:ireturn
+func New(conf config.MailerConfig) (Mailer, error) {
+	switch conf.Driver {
+	case SMTPDriver:
+		return NewSMTPMailer(conf), nil
+	case FileDriver:
+		return NewFileMailer(conf.Dir, conf.From)
+	case LogDriver, "":
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", conf.Driver)
+	}
+}
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(conf config.MailerConfig) *SMTPMailer {
+	var auth smtp.Auth
+	if conf.SMTPUser != "" {
+		auth = smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPassword, conf.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(conf.SMTPHost, conf.SMTPPort),
+		auth: auth,
+		from: conf.From,
+	}
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, Format(m.from, msg))
+}
+
+// FileMailer writes every message as an .eml file into the directory.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, dirMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewV4())
+
+	return os.WriteFile(filepath.Join(m.dir, name), Format(m.from, msg), fileMode)
+}
+
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	logger.Infof("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	return nil
+}
+
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// Format renders a plain text RFC 5322 message.
+func Format(from string, msg Message) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", headerReplacer.Replace(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", headerReplacer.Replace(msg.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return buf.Bytes()
+}
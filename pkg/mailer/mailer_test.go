@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"crm-system/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+
+	mailer, err := New(config.MailerConfig{Driver: FileDriver, Dir: dir, From: "no-reply@example.com"})
+	require.NoError(t, err)
+
+	err = mailer.Send(Message{To: "user@example.com", Subject: "Password reset", Body: "line 1\nline 2"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: user@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Password reset\r\n")
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nline 1\r\nline 2"))
+}
+
+func TestFormatStripsHeaderInjection(t *testing.T) {
+	msg := Format("no-reply@example.com", Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "Hi"})
+
+	assert.NotContains(t, string(msg), "\r\nBcc:")
+}
+
+func TestNewUnknownDriver(t *testing.T) {
+	_, err := New(config.MailerConfig{Driver: "pigeon"})
+	assert.Error(t, err)
+}
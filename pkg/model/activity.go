@@ -0,0 +1,188 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type ActivityType string
+
+const (
+	ActivityCall    ActivityType = "call"
+	ActivityMeeting ActivityType = "meeting"
+	ActivityEmail   ActivityType = "email"
+	ActivityNote    ActivityType = "note"
+)
+
+func (t ActivityType) IsKnown() bool {
+	return t == ActivityCall || t == ActivityMeeting || t == ActivityEmail || t == ActivityNote
+}
+
+const (
+	maxActivityBody         = 10000
+	maxActivityParticipants = 20
+	maxActivityDuration     = 24 * 60
+)
+
+// Activity is a call, meeting, email or note logged against a record. The
+// author and the participants are users, Duration is in minutes.
+type Activity struct {
+	ID             uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	Type           ActivityType `json:"type"`
+	Body           string       `json:"body"`
+	OccurredAt     time.Time    `json:"occurred_at"`
+	Duration       int          `json:"duration"`
+	AuthorID       *uuid.UUID   `gorm:"type:uuid" json:"author_id"`
+	EntityType     RecordType   `json:"entity_type"`
+	EntityID       uuid.UUID    `gorm:"type:uuid" json:"entity_id"`
+	ParticipantIDs []uuid.UUID  `gorm:"-" json:"participant_ids"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (a *Activity) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsAuthoredBy reports whether userID logged the activity.
+func (a *Activity) IsAuthoredBy(userID uuid.UUID) bool {
+	return a.AuthorID != nil && *a.AuthorID == userID
+}
+
+// Validate normalizes the activity and returns every broken rule. Participants
+// are deduplicated keeping the order, OccurredAt defaults to now.
+func (a *Activity) Validate(now time.Time) []FieldError {
+	fields := []FieldError{}
+
+	a.Type = ActivityType(strings.ToLower(strings.TrimSpace(string(a.Type))))
+	a.Body = strings.TrimSpace(a.Body)
+	a.EntityType = RecordType(strings.ToLower(strings.TrimSpace(string(a.EntityType))))
+
+	if !a.Type.IsKnown() {
+		fields = append(fields, FieldError{Field: "type", Rule: "oneof", Message: "type must be call, meeting, email or note"})
+	}
+
+	if a.Type == ActivityNote && a.Body == "" {
+		fields = append(fields, FieldError{Field: "body", Rule: "required", Message: "a note needs a body"})
+	}
+
+	if len([]rune(a.Body)) > maxActivityBody {
+		fields = append(fields, FieldError{Field: "body", Rule: "max_length", Message: "must be at most 10000 characters"})
+	}
+
+	if a.OccurredAt.IsZero() {
+		a.OccurredAt = now
+	}
+
+	a.OccurredAt = a.OccurredAt.UTC().Truncate(time.Second)
+
+	if a.Duration < 0 || a.Duration > maxActivityDuration {
+		fields = append(fields, FieldError{Field: "duration", Rule: "range", Message: "duration must be between 0 and 1440 minutes"})
+	}
+
+	if !a.EntityType.IsKnown() {
+		fields = append(fields, FieldError{Field: "entity_type", Rule: "oneof", Message: "unknown entity type"})
+	}
+
+	if a.EntityID == uuid.Nil {
+		fields = append(fields, FieldError{Field: "entity_id", Rule: "required", Message: "entity_id is required"})
+	}
+
+	a.ParticipantIDs = uniqueIDs(a.ParticipantIDs)
+	if len(a.ParticipantIDs) > maxActivityParticipants {
+		fields = append(fields, FieldError{Field: "participant_ids", Rule: "max_items", Message: "at most 20 participants"})
+	}
+
+	return fields
+}
+
+// ActivityParticipant keeps the participants of an activity in order.
+type ActivityParticipant struct {
+	ActivityID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Position   int       `gorm:"primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid"`
+}
+
+type TimelineEventKind string
+
+const (
+	EventProfileUpdated TimelineEventKind = "profile_updated"
+)
+
+// FieldChange is the old and the new value of a changed field.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// FieldChanges is stored as a JSON array.
+type FieldChanges []FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+
+	value, err := json.Marshal(c)
+
+	return string(value), err
+}
+
+func (c *FieldChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = FieldChanges{}
+
+		return nil
+	default:
+		return errors.New("unsupported field changes value")
+	}
+}
+
+// TimelineEvent is a change of a record the system recorded, it is never edited.
+// ActorID is who made the change: the user or the impersonating admin.
+type TimelineEvent struct {
+	ID         uuid.UUID         `gorm:"type:uuid;primary_key;" json:"id"`
+	EntityType RecordType        `json:"entity_type"`
+	EntityID   uuid.UUID         `gorm:"type:uuid" json:"entity_id"`
+	Kind       TimelineEventKind `json:"kind"`
+	ActorID    *uuid.UUID        `gorm:"type:uuid" json:"actor_id"`
+	Changes    FieldChanges      `gorm:"type:jsonb" json:"changes" swaggertype:"array,object"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+func (e *TimelineEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+const (
+	TimelineEntryActivity = "activity"
+	TimelineEntryEvent    = "event"
+)
+
+// TimelineEntry is an activity or an event of the timeline of a record.
+type TimelineEntry struct {
+	Kind       string         `json:"kind"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Activity   *Activity      `json:"activity,omitempty"`
+	Event      *TimelineEvent `json:"event,omitempty"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential of a service account. Only the hash of the
+// key is stored, Prefix is its first, non-secret part to tell keys apart.
+type APIKey struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	Name        string       `json:"name"`
+	Prefix      string       `json:"prefix"`
+	KeyHash     string       `json:"-"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	RevokedAt   *time.Time   `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Scope limits the permissions of the key owner's role to the ones granted to the key.
+func (k *APIKey) Scope(rolePermissions []Permission) []Permission {
+	scoped := make([]Permission, 0, len(k.Permissions))
+	for _, permission := range k.Permissions {
+		for _, granted := range rolePermissions {
+			if permission == granted {
+				scoped = append(scoped, permission)
+
+				break
+			}
+		}
+	}
+
+	return scoped
+}
+
+type APIKeyPermission struct {
+	APIKeyID   uuid.UUID  `gorm:"type:uuid;primary_key"`
+	Permission Permission `gorm:"primary_key"`
+}
+
+// APIKeyCreate is the request to issue a key, the key needs at least one permission.
+type APIKeyCreate struct {
+	UserID      uuid.UUID    `json:"user_id"`
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+}
+
+func (k *APIKeyCreate) IsValid(now time.Time) bool {
+	k.Name = strings.TrimSpace(k.Name)
+	if k.UserID == uuid.Nil || k.Name == "" || len(k.Permissions) == 0 {
+		return false
+	}
+
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+
+	permissions, ok := normalizePermissions(k.Permissions)
+	k.Permissions = permissions
+
+	return ok
+}
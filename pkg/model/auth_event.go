@@ -0,0 +1,126 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type AuthEventType string
+
+const (
+	AuthEventLogin          AuthEventType = "login"
+	AuthEventRefresh        AuthEventType = "refresh"
+	AuthEventPasswordChange AuthEventType = "password_change"
+	AuthEventRegistration   AuthEventType = "registration"
+	AuthEventRoleChange     AuthEventType = "role_change"
+	AuthEventLockout        AuthEventType = "lockout"
+)
+
+// Reasons of failed events.
+const (
+	ReasonUnknownUser        = "unknown_user"
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonInvalidMFACode     = "invalid_mfa_code"
+	ReasonInvalidToken       = "invalid_token"
+	ReasonAccountDisabled    = "account_disabled"
+	ReasonPasswordExpired    = "password_expired"
+	ReasonTooManyAttempts    = "too_many_attempts"
+)
+
+// AuthEvent is a record of the append-only authentication audit log. ActorID is
+// who acted: the signed-in user, the impersonating admin or, on success without
+// one, the user itself. TargetID and Username are the account acted on, Username
+// is kept for failures of unknown users. Detail is the reason of a failure or
+// what changed.
+type AuthEvent struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
+	Type      AuthEventType `json:"type"`
+	Success   bool          `json:"success"`
+	Detail    string        `json:"detail"`
+	ActorID   *uuid.UUID    `gorm:"type:uuid" json:"actor_id"`
+	TargetID  *uuid.UUID    `gorm:"type:uuid" json:"target_id"`
+	Username  string        `json:"username"`
+	IP        string        `json:"ip"`
+	UserAgent string        `json:"user_agent"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func (e *AuthEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// NewAuthEvent returns an event on the user, user may only carry the username.
+func NewAuthEvent(eventType AuthEventType, user *AuthUser) *AuthEvent {
+	event := &AuthEvent{Type: eventType}
+
+	if user != nil {
+		event.Username = user.Username
+
+		if user.ID != uuid.Nil {
+			targetID := user.ID
+			event.TargetID = &targetID
+		}
+	}
+
+	return event
+}
+
+func (e *AuthEvent) Succeeded(detail string) *AuthEvent {
+	e.Success = true
+	e.Detail = detail
+
+	return e
+}
+
+func (e *AuthEvent) Failed(reason string) *AuthEvent {
+	e.Success = false
+	e.Detail = reason
+
+	return e
+}
+
+// AuthEventQuery filters the audit log. UserID matches the actor or the target,
+// From is inclusive and To exclusive.
+type AuthEventQuery struct {
+	Pagination
+	Type     AuthEventType `form:"type"`
+	UserID   string        `form:"user_id"`
+	Username string        `form:"username"`
+	IP       string        `form:"ip"`
+	Success  *bool         `form:"success"`
+	From     *time.Time    `form:"from"`
+	To       *time.Time    `form:"to"`
+	Format   string        `form:"format"`
+}
+
+// IsValid normalizes the query, it reports false on an invalid user ID or format.
+func (q *AuthEventQuery) IsValid() bool {
+	q.Pagination.Normalize()
+	q.Type = AuthEventType(strings.ToLower(strings.TrimSpace(string(q.Type))))
+	q.Username = strings.TrimSpace(q.Username)
+	q.IP = strings.TrimSpace(q.IP)
+	q.Format = strings.ToLower(strings.TrimSpace(q.Format))
+
+	if q.Format != "" && q.Format != "json" && q.Format != "csv" {
+		return false
+	}
+
+	q.UserID = strings.TrimSpace(q.UserID)
+	if q.UserID != "" {
+		userID, err := uuid.FromString(q.UserID)
+		if err != nil {
+			return false
+		}
+
+		q.UserID = userID.String()
+	}
+
+	return true
+}
@@ -4,7 +4,9 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
 
+	"net/mail"
 	"strings"
+	"time"
 )
 
 type UserRole string
@@ -15,10 +17,18 @@ const (
 )
 
 type AuthUser struct {
-	ID       uuid.UUID `gorm:"type:uuid;primary_key;" json:"-"`
-	Username string    `json:"username"`
-	Password string    `json:"password"`
-	Role     UserRole  `json:"role"`
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"-"`
+	Username    string    `json:"username"`
+	Email       *string   `json:"email,omitempty"`
+	Password    string    `json:"password"`
+	Role        UserRole  `json:"role"`
+	TOTPSecret  string    `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabled bool      `gorm:"column:totp_enabled" json:"-"`
+	Active      bool      `gorm:"default:true" json:"-"`
+	// ServiceAccount users have no password and authenticate with API keys only.
+	ServiceAccount bool `gorm:"column:service_account" json:"-"`
+	// PasswordChangedAt starts the max password age of the policy.
+	PasswordChangedAt time.Time `gorm:"default:now()" json:"-"`
 }
 
 func (a *AuthUser) BeforeCreate(tx *gorm.DB) error {
@@ -38,5 +48,15 @@ func (a *AuthUser) IsValid(forRegister bool) bool {
 	a.Username = username
 	a.Password = pass
 
+	if forRegister && a.Email != nil {
+		address, err := mail.ParseAddress(strings.TrimSpace(*a.Email))
+		if err != nil {
+			return false
+		}
+
+		email := strings.ToLower(address.Address)
+		a.Email = &email
+	}
+
 	return true
 }
@@ -19,3 +19,16 @@ func (c *ChangePassword) IsValid() bool {
 
 	return true
 }
+
+// ExpiredPasswordChange replaces a password past the max age, its owner can't log in to change it.
+type ExpiredPasswordChange struct {
+	Username    string `json:"username"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+func (e *ExpiredPasswordChange) IsValid() bool {
+	e.Username = strings.TrimSpace(e.Username)
+
+	return e.Username != "" && strings.TrimSpace(e.OldPassword) != "" && strings.TrimSpace(e.NewPassword) != ""
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// CompanySize is the employee count range of a company.
+type CompanySize string
+
+const (
+	CompanySize1To10      CompanySize = "1-10"
+	CompanySize11To50     CompanySize = "11-50"
+	CompanySize51To200    CompanySize = "51-200"
+	CompanySize201To1000  CompanySize = "201-1000"
+	CompanySize1001To5000 CompanySize = "1001-5000"
+	CompanySizeOver5000   CompanySize = "5000+"
+)
+
+const (
+	maxCompanyAddresses    = 10
+	maxCompanyAddressField = 200
+)
+
+var companySizes = []CompanySize{
+	CompanySize1To10,
+	CompanySize11To50,
+	CompanySize51To200,
+	CompanySize201To1000,
+	CompanySize1001To5000,
+	CompanySizeOver5000,
+}
+
+func (s CompanySize) IsKnown() bool {
+	for _, known := range companySizes {
+		if s == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// Company is an organization contacts work at. ParentID makes it a subsidiary
+// of another company. Like contacts, it is visible to its owner and to holders
+// of companies:read; OwnerID is nil once the owning user is deleted.
+type Company struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primary_key;" json:"id"`
+	Name      string           `json:"name"`
+	Domain    string           `json:"domain"`
+	Industry  string           `json:"industry"`
+	Size      CompanySize      `json:"size"`
+	ParentID  *uuid.UUID       `gorm:"type:uuid" json:"parent_id"`
+	OwnerID   *uuid.UUID       `gorm:"type:uuid" json:"owner_id"`
+	Addresses []CompanyAddress `gorm:"-" json:"addresses"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+func (c *Company) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsOwnedBy reports whether userID owns the company.
+func (c *Company) IsOwnedBy(userID uuid.UUID) bool {
+	return c.OwnerID != nil && *c.OwnerID == userID
+}
+
+// Validate normalizes the company and returns every broken rule. The domain is
+// stored without scheme, path and "www." prefix.
+func (c *Company) Validate() []FieldError {
+	fields := []FieldError{}
+
+	c.Name = strings.TrimSpace(c.Name)
+	c.Industry = strings.TrimSpace(c.Industry)
+	c.Size = CompanySize(strings.TrimSpace(string(c.Size)))
+	c.Domain = normalizeDomain(c.Domain)
+
+	if c.Name == "" {
+		fields = append(fields, FieldError{Field: "name", Rule: "required", Message: "name is required"})
+	}
+
+	for _, text := range []struct{ field, value string }{
+		{"name", c.Name},
+		{"industry", c.Industry},
+	} {
+		if len([]rune(text.value)) > maxContactText {
+			fields = append(fields, FieldError{Field: text.field, Rule: "max_length", Message: "must be at most 100 characters"})
+		}
+	}
+
+	if c.Domain != "" && !domainPattern.MatchString(c.Domain) {
+		fields = append(fields, FieldError{Field: "domain", Rule: "domain", Message: "invalid domain"})
+	}
+
+	if c.Size != "" && !c.Size.IsKnown() {
+		fields = append(fields, FieldError{Field: "size", Rule: "oneof", Message: "unknown company size"})
+	}
+
+	return append(fields, c.validateAddresses()...)
+}
+
+func (c *Company) validateAddresses() []FieldError {
+	if c.Addresses == nil {
+		c.Addresses = []CompanyAddress{}
+	}
+
+	if len(c.Addresses) > maxCompanyAddresses {
+		return []FieldError{{Field: "addresses", Rule: "max_items", Message: "at most 10 addresses"}}
+	}
+
+	for i := range c.Addresses {
+		address := &c.Addresses[i]
+		values := []*string{&address.Label, &address.Street, &address.City, &address.Region, &address.PostalCode, &address.Country}
+
+		for _, value := range values {
+			*value = strings.TrimSpace(*value)
+			if len([]rune(*value)) > maxCompanyAddressField {
+				return []FieldError{{Field: "addresses", Rule: "max_length", Message: "address fields must be at most 200 characters"}}
+			}
+		}
+
+		if address.Street == "" && address.City == "" && address.Country == "" {
+			return []FieldError{{Field: "addresses", Rule: "required", Message: "an address needs a street, city or country"}}
+		}
+	}
+
+	return nil
+}
+
+// CompanyAddress is an address of a company, Position keeps the order of the list.
+type CompanyAddress struct {
+	CompanyID  uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	Position   int       `gorm:"primary_key" json:"-"`
+	Label      string    `json:"label"`
+	Street     string    `json:"street"`
+	City       string    `json:"city"`
+	Region     string    `json:"region"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+}
+
+// companySorts are the sort fields of the company list.
+var companySorts = []string{"name", "created_at", "updated_at"}
+
+// CompanyQuery filters the company list. Search matches the name and the domain
+// case-insensitively. Sort works like ContactQuery.Sort.
+type CompanyQuery struct {
+	Pagination
+	Sorting
+	TagFilter
+	Search   string      `form:"search"`
+	OwnerID  string      `form:"owner_id"`
+	ParentID string      `form:"parent_id"`
+	Industry string      `form:"industry"`
+	Size     CompanySize `form:"size"`
+}
+
+// IsValid normalizes the query, it reports false on invalid IDs, size, tags or
+// sort.
+func (q *CompanyQuery) IsValid() bool {
+	q.Pagination.Normalize()
+	q.Search = strings.TrimSpace(q.Search)
+	q.Industry = strings.TrimSpace(q.Industry)
+	q.Size = CompanySize(strings.TrimSpace(string(q.Size)))
+
+	if q.Size != "" && !q.Size.IsKnown() {
+		return false
+	}
+
+	var ok bool
+
+	if q.OwnerID, ok = normalizeUUID(q.OwnerID); !ok {
+		return false
+	}
+
+	if q.ParentID, ok = normalizeUUID(q.ParentID); !ok {
+		return false
+	}
+
+	if !q.TagFilter.IsValid() {
+		return false
+	}
+
+	return q.Sorting.Normalize(companySorts)
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+
+	for _, scheme := range []string{"https://", "http://"} {
+		domain = strings.TrimPrefix(domain, scheme)
+	}
+
+	if end := strings.IndexAny(domain, "/?#"); end >= 0 {
+		domain = domain[:end]
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(domain, "www."), ".")
+}
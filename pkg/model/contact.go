@@ -0,0 +1,273 @@
+package model
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type LifecycleStage string
+
+const (
+	LifecycleSubscriber  LifecycleStage = "subscriber"
+	LifecycleLead        LifecycleStage = "lead"
+	LifecycleQualified   LifecycleStage = "qualified"
+	LifecycleOpportunity LifecycleStage = "opportunity"
+	LifecycleCustomer    LifecycleStage = "customer"
+	LifecycleEvangelist  LifecycleStage = "evangelist"
+	LifecycleOther       LifecycleStage = "other"
+)
+
+var lifecycleStages = []LifecycleStage{
+	LifecycleSubscriber,
+	LifecycleLead,
+	LifecycleQualified,
+	LifecycleOpportunity,
+	LifecycleCustomer,
+	LifecycleEvangelist,
+	LifecycleOther,
+}
+
+func (s LifecycleStage) IsKnown() bool {
+	for _, known := range lifecycleStages {
+		if s == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	maxContactText     = 100
+	maxContactChannels = 10
+	maxChannelLabel    = 50
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()./-]{2,31}$`)
+
+// Contact is a customer contact. It is visible to its owner and to holders of
+// contacts:read; OwnerID is nil once the owning user is deleted. CompanyRole is
+// the position at CompanyID. The first email and phone are the primary ones.
+type Contact struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;" json:"id"`
+	FirstName      string         `json:"first_name"`
+	LastName       string         `json:"last_name"`
+	JobTitle       string         `json:"job_title"`
+	CompanyID      *uuid.UUID     `gorm:"type:uuid" json:"company_id"`
+	CompanyRole    string         `json:"company_role"`
+	OwnerID        *uuid.UUID     `gorm:"type:uuid" json:"owner_id"`
+	Source         string         `json:"source"`
+	LifecycleStage LifecycleStage `json:"lifecycle_stage"`
+	Emails         []ContactEmail `gorm:"-" json:"emails"`
+	Phones         []ContactPhone `gorm:"-" json:"phones"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (c *Contact) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsOwnedBy reports whether userID owns the contact.
+func (c *Contact) IsOwnedBy(userID uuid.UUID) bool {
+	return c.OwnerID != nil && *c.OwnerID == userID
+}
+
+// Validate normalizes the contact and returns every broken rule. The lifecycle
+// stage defaults to lead.
+func (c *Contact) Validate() []FieldError {
+	fields := []FieldError{}
+
+	c.FirstName = strings.TrimSpace(c.FirstName)
+	c.LastName = strings.TrimSpace(c.LastName)
+	c.JobTitle = strings.TrimSpace(c.JobTitle)
+	c.CompanyRole = strings.TrimSpace(c.CompanyRole)
+	c.Source = strings.TrimSpace(c.Source)
+	c.LifecycleStage = LifecycleStage(strings.ToLower(strings.TrimSpace(string(c.LifecycleStage))))
+
+	if c.FirstName == "" && c.LastName == "" {
+		fields = append(fields, FieldError{Field: "first_name", Rule: "required", Message: "first or last name is required"})
+	}
+
+	for _, text := range []struct{ field, value string }{
+		{"first_name", c.FirstName},
+		{"last_name", c.LastName},
+		{"job_title", c.JobTitle},
+		{"company_role", c.CompanyRole},
+		{"source", c.Source},
+	} {
+		if len([]rune(text.value)) > maxContactText {
+			fields = append(fields, FieldError{Field: text.field, Rule: "max_length", Message: "must be at most 100 characters"})
+		}
+	}
+
+	if c.CompanyRole != "" && c.CompanyID == nil {
+		fields = append(fields, FieldError{Field: "company_role", Rule: "required_with", Message: "company_id is required with a company role"})
+	}
+
+	if c.LifecycleStage == "" {
+		c.LifecycleStage = LifecycleLead
+	}
+
+	if !c.LifecycleStage.IsKnown() {
+		fields = append(fields, FieldError{Field: "lifecycle_stage", Rule: "oneof", Message: "unknown lifecycle stage"})
+	}
+
+	fields = append(fields, c.validateEmails()...)
+	fields = append(fields, c.validatePhones()...)
+
+	return fields
+}
+
+func (c *Contact) validateEmails() []FieldError {
+	if c.Emails == nil {
+		c.Emails = []ContactEmail{}
+	}
+
+	if len(c.Emails) > maxContactChannels {
+		return []FieldError{{Field: "emails", Rule: "max_items", Message: "at most 10 emails"}}
+	}
+
+	seen := make(map[string]struct{}, len(c.Emails))
+	for i := range c.Emails {
+		email := &c.Emails[i]
+		email.Label = strings.TrimSpace(email.Label)
+
+		address, err := mail.ParseAddress(strings.TrimSpace(email.Email))
+		if err != nil {
+			return []FieldError{{Field: "emails", Rule: "email", Message: "invalid email address"}}
+		}
+
+		email.Email = strings.ToLower(address.Address)
+		if _, ok := seen[email.Email]; ok {
+			return []FieldError{{Field: "emails", Rule: "unique", Message: "duplicate email address"}}
+		}
+
+		seen[email.Email] = struct{}{}
+
+		if len([]rune(email.Label)) > maxChannelLabel {
+			return []FieldError{{Field: "emails", Rule: "max_length", Message: "labels must be at most 50 characters"}}
+		}
+	}
+
+	return nil
+}
+
+func (c *Contact) validatePhones() []FieldError {
+	if c.Phones == nil {
+		c.Phones = []ContactPhone{}
+	}
+
+	if len(c.Phones) > maxContactChannels {
+		return []FieldError{{Field: "phones", Rule: "max_items", Message: "at most 10 phones"}}
+	}
+
+	seen := make(map[string]struct{}, len(c.Phones))
+	for i := range c.Phones {
+		phone := &c.Phones[i]
+		phone.Phone = strings.TrimSpace(phone.Phone)
+		phone.Label = strings.TrimSpace(phone.Label)
+
+		if !phonePattern.MatchString(phone.Phone) {
+			return []FieldError{{Field: "phones", Rule: "phone", Message: "invalid phone number"}}
+		}
+
+		if _, ok := seen[phone.Phone]; ok {
+			return []FieldError{{Field: "phones", Rule: "unique", Message: "duplicate phone number"}}
+		}
+
+		seen[phone.Phone] = struct{}{}
+
+		if len([]rune(phone.Label)) > maxChannelLabel {
+			return []FieldError{{Field: "phones", Rule: "max_length", Message: "labels must be at most 50 characters"}}
+		}
+	}
+
+	return nil
+}
+
+// ContactEmail is an email of a contact, Position keeps the order of the list.
+type ContactEmail struct {
+	ContactID uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	Position  int       `gorm:"primary_key" json:"-"`
+	Email     string    `json:"email"`
+	Label     string    `json:"label"`
+}
+
+// ContactPhone is a phone number of a contact, Position keeps the order of the list.
+type ContactPhone struct {
+	ContactID uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	Position  int       `gorm:"primary_key" json:"-"`
+	Phone     string    `json:"phone"`
+	Label     string    `json:"label"`
+}
+
+// contactSorts are the sort fields of the contact list.
+var contactSorts = []string{"name", "created_at", "updated_at"}
+
+// ContactQuery filters the contact list. Search matches names, job title,
+// emails and phones case-insensitively. Sort is one of name, created_at and
+// updated_at, prefixed with "-" for descending order, newest first by default.
+type ContactQuery struct {
+	Pagination
+	Sorting
+	TagFilter
+	Search         string         `form:"search"`
+	OwnerID        string         `form:"owner_id"`
+	CompanyID      string         `form:"company_id"`
+	LifecycleStage LifecycleStage `form:"lifecycle_stage"`
+	Source         string         `form:"source"`
+}
+
+// IsValid normalizes the query, it reports false on invalid IDs, stage, tags or
+// sort.
+func (q *ContactQuery) IsValid() bool {
+	q.Pagination.Normalize()
+	q.Search = strings.TrimSpace(q.Search)
+	q.Source = strings.TrimSpace(q.Source)
+	q.LifecycleStage = LifecycleStage(strings.ToLower(strings.TrimSpace(string(q.LifecycleStage))))
+
+	if q.LifecycleStage != "" && !q.LifecycleStage.IsKnown() {
+		return false
+	}
+
+	var ok bool
+
+	if q.OwnerID, ok = normalizeUUID(q.OwnerID); !ok {
+		return false
+	}
+
+	if q.CompanyID, ok = normalizeUUID(q.CompanyID); !ok {
+		return false
+	}
+
+	if !q.TagFilter.IsValid() {
+		return false
+	}
+
+	return q.Sorting.Normalize(contactSorts)
+}
+
+// normalizeUUID returns the canonical form of an optional UUID string.
+func normalizeUUID(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", true
+	}
+
+	id, err := uuid.FromString(value)
+	if err != nil {
+		return value, false
+	}
+
+	return id.String(), true
+}
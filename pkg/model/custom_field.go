@@ -0,0 +1,466 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldDate        CustomFieldType = "date"
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+	CustomFieldBoolean     CustomFieldType = "boolean"
+	CustomFieldURL         CustomFieldType = "url"
+)
+
+func (t CustomFieldType) IsKnown() bool {
+	switch t {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSelect,
+		CustomFieldMultiSelect, CustomFieldBoolean, CustomFieldURL:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasOptions reports whether the values are picked from the options.
+func (t CustomFieldType) hasOptions() bool {
+	return t == CustomFieldSelect || t == CustomFieldMultiSelect
+}
+
+const (
+	maxCustomFieldOptions = 100
+	maxCustomFieldText    = 10000
+	defaultCustomFieldMax = 1000
+	maxCustomFieldURL     = 2048
+)
+
+var customFieldKeyRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// CustomFieldDefinition is a field admins add to the records of an entity type
+// at runtime. The values are kept in the custom_fields column of the records
+// under Key. Options list the values of select fields, MaxLength limits text
+// fields and Min and Max number fields.
+type CustomFieldDefinition struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key;" json:"id"`
+	EntityType RecordType      `json:"entity_type"`
+	Key        string          `json:"key"`
+	Label      string          `json:"label"`
+	Type       CustomFieldType `json:"type"`
+	Required   bool            `json:"required"`
+	Unique     bool            `json:"unique"`
+	Options    StringList      `gorm:"type:jsonb" json:"options" swaggertype:"array,string"`
+	MaxLength  *int            `json:"max_length,omitempty"`
+	Min        *float64        `json:"min,omitempty"`
+	Max        *float64        `json:"max,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func (d *CustomFieldDefinition) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// Validate normalizes the definition and returns every broken rule.
+func (d *CustomFieldDefinition) Validate() []FieldError {
+	fields := []FieldError{}
+
+	d.EntityType = RecordType(strings.ToLower(strings.TrimSpace(string(d.EntityType))))
+	d.Key = strings.TrimSpace(d.Key)
+	d.Label = strings.TrimSpace(d.Label)
+	d.Type = CustomFieldType(strings.ToLower(strings.TrimSpace(string(d.Type))))
+
+	// only user profiles have custom fields so far
+	if d.EntityType != RecordUser {
+		fields = append(fields, FieldError{Field: "entity_type", Rule: "oneof", Message: "unknown entity type"})
+	}
+
+	if !customFieldKeyRegexp.MatchString(d.Key) {
+		fields = append(fields, FieldError{Field: "key", Rule: "format",
+			Message: "key must start with a letter and have up to 63 lowercase letters, digits and underscores"})
+	}
+
+	if d.Label == "" || len([]rune(d.Label)) > maxContactText {
+		fields = append(fields, FieldError{Field: "label", Rule: "max_length", Message: "label must have 1 to 100 characters"})
+	}
+
+	if !d.Type.IsKnown() {
+		fields = append(fields, FieldError{Field: "type", Rule: "oneof",
+			Message: "type must be text, number, date, select, multi_select, boolean or url"})
+
+		return fields
+	}
+
+	if d.Unique && (d.Type == CustomFieldMultiSelect || d.Type == CustomFieldBoolean) {
+		fields = append(fields, FieldError{Field: "unique", Rule: "oneof",
+			Message: "multi_select and boolean fields can't be unique"})
+	}
+
+	if d.MaxLength != nil && (d.Type != CustomFieldText || *d.MaxLength < 1 || *d.MaxLength > maxCustomFieldText) {
+		fields = append(fields, FieldError{Field: "max_length", Rule: "range",
+			Message: "max_length is 1 to 10000 for text fields"})
+	}
+
+	if (d.Min != nil || d.Max != nil) && d.Type != CustomFieldNumber {
+		fields = append(fields, FieldError{Field: "min", Rule: "oneof", Message: "min and max are for number fields"})
+	} else if d.Min != nil && d.Max != nil && *d.Min > *d.Max {
+		fields = append(fields, FieldError{Field: "min", Rule: "range", Message: "min can't be greater than max"})
+	}
+
+	return append(fields, d.validateOptions()...)
+}
+
+func (d *CustomFieldDefinition) validateOptions() []FieldError {
+	if !d.Type.hasOptions() {
+		if len(d.Options) > 0 {
+			return []FieldError{{Field: "options", Rule: "oneof", Message: "options are for select fields"}}
+		}
+
+		d.Options = StringList{}
+
+		return nil
+	}
+
+	if len(d.Options) == 0 {
+		return []FieldError{{Field: "options", Rule: "required", Message: "select fields need options"}}
+	}
+
+	if len(d.Options) > maxCustomFieldOptions {
+		return []FieldError{{Field: "options", Rule: "max_items", Message: "at most 100 options"}}
+	}
+
+	seen := make(map[string]struct{}, len(d.Options))
+
+	for i := range d.Options {
+		d.Options[i] = strings.TrimSpace(d.Options[i])
+
+		if d.Options[i] == "" || len([]rune(d.Options[i])) > maxContactText {
+			return []FieldError{{Field: "options", Rule: "max_length", Message: "options must have 1 to 100 characters"}}
+		}
+
+		if _, ok := seen[strings.ToLower(d.Options[i])]; ok {
+			return []FieldError{{Field: "options", Rule: "unique", Message: "duplicate option"}}
+		}
+
+		seen[strings.ToLower(d.Options[i])] = struct{}{}
+	}
+
+	return nil
+}
+
+// option returns the option matching value case-insensitively.
+func (d *CustomFieldDefinition) option(value string) (string, bool) {
+	for _, option := range d.Options {
+		if strings.EqualFold(option, strings.TrimSpace(value)) {
+			return option, true
+		}
+	}
+
+	return "", false
+}
+
+// Normalize checks a value written to the field and returns it the way it is
+// stored. Empty values return nil, they remove the value of the record.
+//
+//nolint:cyclop
+func (d *CustomFieldDefinition) Normalize(value interface{}) (interface{}, *FieldError) {
+	field := "custom_fields." + d.Key
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return d.normalizeString(field, v)
+	case float64:
+		if d.Type != CustomFieldNumber {
+			break
+		}
+
+		if (d.Min != nil && v < *d.Min) || (d.Max != nil && v > *d.Max) {
+			return nil, &FieldError{Field: field, Rule: "range", Message: "number is out of range"}
+		}
+
+		return v, nil
+	case bool:
+		if d.Type == CustomFieldBoolean {
+			return v, nil
+		}
+	case []interface{}:
+		if d.Type == CustomFieldMultiSelect {
+			return d.normalizeOptions(field, v)
+		}
+	}
+
+	return nil, d.typeError(field)
+}
+
+func (d *CustomFieldDefinition) typeError(field string) *FieldError {
+	return &FieldError{Field: field, Rule: "type", Message: "value must be a " + strings.ReplaceAll(string(d.Type), "_", " ")}
+}
+
+func (d *CustomFieldDefinition) normalizeString(field, value string) (interface{}, *FieldError) {
+	value = strings.TrimSpace(value)
+
+	switch d.Type {
+	case CustomFieldText:
+		if value == "" {
+			return nil, nil
+		}
+
+		maxLength := defaultCustomFieldMax
+		if d.MaxLength != nil {
+			maxLength = *d.MaxLength
+		}
+
+		if len([]rune(value)) > maxLength {
+			return nil, &FieldError{Field: field, Rule: "max_length",
+				Message: fmt.Sprintf("must be at most %d characters", maxLength)}
+		}
+
+		return value, nil
+	case CustomFieldDate:
+		if value == "" {
+			return nil, nil
+		}
+
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return nil, &FieldError{Field: field, Rule: "format", Message: "date must be YYYY-MM-DD"}
+		}
+
+		return value, nil
+	case CustomFieldSelect:
+		if value == "" {
+			return nil, nil
+		}
+
+		option, ok := d.option(value)
+		if !ok {
+			return nil, &FieldError{Field: field, Rule: "oneof", Message: "value is not one of the options"}
+		}
+
+		return option, nil
+	case CustomFieldURL:
+		if value == "" {
+			return nil, nil
+		}
+
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			len(value) > maxCustomFieldURL {
+			return nil, &FieldError{Field: field, Rule: "format", Message: "value must be an http or https URL"}
+		}
+
+		return value, nil
+	default:
+		return nil, d.typeError(field)
+	}
+}
+
+func (d *CustomFieldDefinition) normalizeOptions(field string, values []interface{}) (interface{}, *FieldError) {
+	options := []string{}
+	seen := make(map[string]struct{}, len(values))
+
+	for _, value := range values {
+		text, ok := value.(string)
+		if !ok {
+			return nil, &FieldError{Field: field, Rule: "type", Message: "value must be a list of options"}
+		}
+
+		option, ok := d.option(text)
+		if !ok {
+			return nil, &FieldError{Field: field, Rule: "oneof", Message: "value is not one of the options"}
+		}
+
+		if _, ok := seen[option]; !ok {
+			seen[option] = struct{}{}
+			options = append(options, option)
+		}
+	}
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	return options, nil
+}
+
+// Filter parses a query parameter value into the JSON the values of the field
+// must contain to match: the value itself, or one of the options of multi-select fields.
+func (d *CustomFieldDefinition) Filter(raw string) (CustomFieldValues, bool) {
+	var value interface{}
+
+	raw = strings.TrimSpace(raw)
+
+	switch d.Type {
+	case CustomFieldNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		value = number
+	case CustomFieldBoolean:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, false
+		}
+
+		value = boolean
+	case CustomFieldMultiSelect:
+		option, ok := d.option(raw)
+		if !ok {
+			return nil, false
+		}
+
+		value = []string{option}
+	default:
+		normalized, fieldErr := d.normalizeString("", raw)
+		if fieldErr != nil || normalized == nil {
+			return nil, false
+		}
+
+		value = normalized
+	}
+
+	return CustomFieldValues{d.Key: value}, true
+}
+
+// ValidateCustomFields applies the update to the current values of a record
+// and returns the values to store, or every broken rule. A null value removes
+// the field, the fields missing from the update keep their value, and only the
+// written values are checked against the definitions.
+func ValidateCustomFields(
+	definitions []CustomFieldDefinition,
+	current, update CustomFieldValues,
+) (CustomFieldValues, []FieldError) {
+	fields := []FieldError{}
+	byKey := make(map[string]*CustomFieldDefinition, len(definitions))
+	values := CustomFieldValues{}
+
+	for i := range definitions {
+		byKey[definitions[i].Key] = &definitions[i]
+
+		if value, ok := current[definitions[i].Key]; ok && value != nil {
+			values[definitions[i].Key] = value
+		}
+	}
+
+	for _, key := range update.Keys() {
+		definition, ok := byKey[key]
+		if !ok {
+			fields = append(fields, FieldError{Field: "custom_fields." + key, Rule: "unknown", Message: "unknown custom field"})
+
+			continue
+		}
+
+		value, fieldErr := definition.Normalize(update[key])
+		if fieldErr != nil {
+			fields = append(fields, *fieldErr)
+
+			continue
+		}
+
+		if value == nil {
+			delete(values, key)
+		} else {
+			values[key] = value
+		}
+	}
+
+	for i := range definitions {
+		if _, ok := values[definitions[i].Key]; definitions[i].Required && !ok {
+			fields = append(fields, FieldError{Field: "custom_fields." + definitions[i].Key, Rule: "required",
+				Message: definitions[i].Label + " is required"})
+		}
+	}
+
+	return values, fields
+}
+
+// CustomFieldValues are the values of the custom fields of a record by key,
+// stored as a JSON object.
+type CustomFieldValues map[string]interface{}
+
+// Keys returns the keys in order.
+func (v CustomFieldValues) Keys() []string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+
+	value, err := json.Marshal(v)
+
+	return string(value), err
+}
+
+func (v *CustomFieldValues) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	case nil:
+		*v = CustomFieldValues{}
+
+		return nil
+	default:
+		return errors.New("unsupported custom field values")
+	}
+}
+
+// StringList is stored as a JSON array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	value, err := json.Marshal(l)
+
+	return string(value), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil:
+		*l = StringList{}
+
+		return nil
+	default:
+		return errors.New("unsupported string list value")
+	}
+}
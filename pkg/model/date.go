@@ -0,0 +1,68 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Date is a calendar day without time zone, formatted as YYYY-MM-DD in JSON and
+// stored in date columns.
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return err
+	}
+
+	d.Time = parsed
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*d = NewDate(v.Date())
+	case string:
+		parsed, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return err
+		}
+
+		d.Time = parsed
+	default:
+		return fmt.Errorf("can't scan %T into a date", value)
+	}
+
+	return nil
+}
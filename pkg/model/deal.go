@@ -0,0 +1,208 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultCurrency is the currency of deals created without one.
+const DefaultCurrency = "USD"
+
+const (
+	maxDealTitle    = 200
+	maxDealContacts = 50
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Deal is a sales opportunity in a stage of a pipeline. Amount is in minor units
+// of the ISO 4217 Currency, e.g. cents. ClosedAt is set while the stage is won
+// or lost. Like contacts, a deal is visible to its owner and to holders of
+// deals:read.
+type Deal struct {
+	ID                uuid.UUID   `gorm:"type:uuid;primary_key;" json:"id"`
+	Title             string      `json:"title"`
+	Amount            int64       `json:"amount"`
+	Currency          string      `json:"currency"`
+	ExpectedCloseDate *Date       `gorm:"type:date" json:"expected_close_date" swaggertype:"string" format:"date"`
+	PipelineID        uuid.UUID   `gorm:"type:uuid" json:"pipeline_id"`
+	StageID           uuid.UUID   `gorm:"type:uuid" json:"stage_id"`
+	OwnerID           *uuid.UUID  `gorm:"type:uuid" json:"owner_id"`
+	CompanyID         *uuid.UUID  `gorm:"type:uuid" json:"company_id"`
+	ContactIDs        []uuid.UUID `gorm:"-" json:"contact_ids"`
+	ClosedAt          *time.Time  `json:"closed_at"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+func (d *Deal) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsOwnedBy reports whether userID owns the deal.
+func (d *Deal) IsOwnedBy(userID uuid.UUID) bool {
+	return d.OwnerID != nil && *d.OwnerID == userID
+}
+
+// MoveTo puts the deal in the stage. Moving into a won or lost stage closes the
+// deal at now, moving back to an open stage reopens it.
+func (d *Deal) MoveTo(stage *PipelineStage, now time.Time) {
+	switch {
+	case !stage.IsClosed():
+		d.ClosedAt = nil
+	case d.ClosedAt == nil || d.StageID != stage.ID:
+		d.ClosedAt = &now
+	}
+
+	d.StageID = stage.ID
+}
+
+// Validate normalizes the deal and returns every broken rule. The currency
+// defaults to DefaultCurrency, duplicate contacts are dropped.
+func (d *Deal) Validate() []FieldError {
+	fields := []FieldError{}
+
+	d.Title = strings.TrimSpace(d.Title)
+	d.Currency = strings.ToUpper(strings.TrimSpace(d.Currency))
+
+	if d.Title == "" {
+		fields = append(fields, FieldError{Field: "title", Rule: "required", Message: "title is required"})
+	}
+
+	if len([]rune(d.Title)) > maxDealTitle {
+		fields = append(fields, FieldError{Field: "title", Rule: "max_length", Message: "must be at most 200 characters"})
+	}
+
+	if d.Amount < 0 {
+		fields = append(fields, FieldError{Field: "amount", Rule: "min", Message: "amount can't be negative"})
+	}
+
+	if d.Currency == "" {
+		d.Currency = DefaultCurrency
+	}
+
+	if !currencyPattern.MatchString(d.Currency) {
+		fields = append(fields, FieldError{Field: "currency", Rule: "iso4217", Message: "currency must be a three-letter code"})
+	}
+
+	if d.PipelineID == uuid.Nil {
+		fields = append(fields, FieldError{Field: "pipeline_id", Rule: "required", Message: "pipeline_id is required"})
+	}
+
+	d.ContactIDs = uniqueIDs(d.ContactIDs)
+	if len(d.ContactIDs) > maxDealContacts {
+		fields = append(fields, FieldError{Field: "contact_ids", Rule: "max_items", Message: "at most 50 contacts"})
+	}
+
+	return fields
+}
+
+// uniqueIDs drops the nil and repeated IDs keeping the order.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok && id != uuid.Nil {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+// DealContact links a contact to a deal, Position keeps the order of the list.
+type DealContact struct {
+	DealID    uuid.UUID `gorm:"type:uuid;primary_key"`
+	Position  int       `gorm:"primary_key"`
+	ContactID uuid.UUID `gorm:"type:uuid"`
+}
+
+// DealStageChange records a move of a deal between stages. FromStageID is nil
+// for the stage the deal was created in, the stages are nil once they are
+// deleted and ChangedBy once the user is.
+type DealStageChange struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	DealID      uuid.UUID  `gorm:"type:uuid" json:"deal_id"`
+	FromStageID *uuid.UUID `gorm:"type:uuid" json:"from_stage_id"`
+	ToStageID   *uuid.UUID `gorm:"type:uuid" json:"to_stage_id"`
+	ChangedBy   *uuid.UUID `gorm:"type:uuid" json:"changed_by"`
+	ChangedAt   time.Time  `json:"changed_at"`
+}
+
+func (c *DealStageChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// DealStageMove is the body of a stage change.
+type DealStageMove struct {
+	StageID uuid.UUID `json:"stage_id"`
+}
+
+// DealTotal sums the deals of a stage in one currency. WeightedAmount weighs
+// every amount by the win probability of the stage.
+type DealTotal struct {
+	StageID        uuid.UUID `json:"-"`
+	Currency       string    `json:"currency"`
+	Count          int64     `json:"count"`
+	Amount         int64     `json:"amount"`
+	WeightedAmount int64     `json:"weighted_amount"`
+}
+
+// dealSorts are the sort fields of the deal list.
+var dealSorts = []string{"title", "amount", "expected_close_date", "created_at", "updated_at"}
+
+// DealQuery filters the deal list. Search matches the title case-insensitively,
+// Status keeps the deals in open, won or lost stages. Sort works like
+// ContactQuery.Sort.
+type DealQuery struct {
+	Pagination
+	Sorting
+	TagFilter
+	Search     string    `form:"search"`
+	OwnerID    string    `form:"owner_id"`
+	CompanyID  string    `form:"company_id"`
+	ContactID  string    `form:"contact_id"`
+	PipelineID string    `form:"pipeline_id"`
+	StageID    string    `form:"stage_id"`
+	Status     StageKind `form:"status"`
+}
+
+// IsValid normalizes the query, it reports false on invalid IDs, status, tags
+// or sort.
+func (q *DealQuery) IsValid() bool {
+	q.Pagination.Normalize()
+	q.Search = strings.TrimSpace(q.Search)
+	q.Status = StageKind(strings.ToLower(strings.TrimSpace(string(q.Status))))
+
+	if q.Status != "" && !q.Status.IsKnown() {
+		return false
+	}
+
+	for _, id := range []*string{&q.OwnerID, &q.CompanyID, &q.ContactID, &q.PipelineID, &q.StageID} {
+		var ok bool
+
+		if *id, ok = normalizeUUID(*id); !ok {
+			return false
+		}
+	}
+
+	if !q.TagFilter.IsValid() {
+		return false
+	}
+
+	return q.Sorting.Normalize(dealSorts)
+}
@@ -2,4 +2,12 @@ package model
 
 import "errors"
 
-var ErrRecordNotFound = errors.New("record not found")
+var (
+	ErrRecordNotFound = errors.New("record not found")
+	ErrTokenReused    = errors.New("refresh token reused")
+	ErrRoleInUse      = errors.New("role is assigned to users")
+	ErrPipelineInUse  = errors.New("pipeline has deals")
+	ErrStageInUse     = errors.New("stage has deals")
+	// ErrImportTakenOver stops a worker whose import another worker took over.
+	ErrImportTakenOver = errors.New("import was taken over")
+)
@@ -3,12 +3,47 @@ package model
 import "net/http"
 
 var (
-	ErrUnhealthy      = NewError(http.StatusInternalServerError, "something went wrong")
-	ErrRefreshExpired = NewError(http.StatusUnauthorized, "refresh")
-	ErrUnauthorized   = NewError(http.StatusUnauthorized, "user unauthorized")
-	ErrInvalidBody    = NewError(http.StatusBadRequest, "request invalid body")
-	ErrInvalidRole    = NewError(http.StatusBadRequest, "user invalid role")
-	ErrUsenameExist   = NewError(http.StatusBadRequest, "username exist")
+	ErrUnhealthy          = NewError(http.StatusInternalServerError, "something went wrong")
+	ErrRefreshExpired     = NewError(http.StatusUnauthorized, "refresh")
+	ErrUnauthorized       = NewError(http.StatusUnauthorized, "user unauthorized")
+	ErrInvalidBody        = NewError(http.StatusBadRequest, "request invalid body")
+	ErrInvalidRole        = NewError(http.StatusBadRequest, "user invalid role")
+	ErrUsenameExist       = NewError(http.StatusBadRequest, "username exist")
+	ErrInvalidMFACode     = NewError(http.StatusUnauthorized, "invalid two-factor code")
+	ErrMFARequired        = NewError(http.StatusForbidden, "two-factor authentication is required for the role")
+	ErrMFANotEnrolled     = NewError(http.StatusBadRequest, "two-factor authentication is not enrolled")
+	ErrMFAEnrolled        = NewError(http.StatusBadRequest, "two-factor authentication is already enrolled")
+	ErrEmailExist         = NewError(http.StatusBadRequest, "email exist")
+	ErrInvalidResetToken  = NewError(http.StatusBadRequest, "invalid or expired reset token")
+	ErrForbidden          = NewError(http.StatusForbidden, "permission denied")
+	ErrRoleExist          = NewError(http.StatusBadRequest, "role exist")
+	ErrRoleBuiltin        = NewError(http.StatusBadRequest, "built-in role can't be changed or deleted")
+	ErrRoleAssigned       = NewError(http.StatusBadRequest, "role is assigned to users")
+	ErrAccountDisabled    = NewError(http.StatusForbidden, "account is deactivated")
+	ErrSelfAction         = NewError(http.StatusBadRequest, "action is not allowed on your own account")
+	ErrTooManyAttempts    = NewError(http.StatusTooManyRequests, "too many failed attempts, try again later")
+	ErrNotServiceAccount  = NewError(http.StatusBadRequest, "API keys can only be issued to service accounts")
+	ErrOIDCLogin          = NewError(http.StatusUnauthorized, "single sign-on failed")
+	ErrInvalidInvitation  = NewError(http.StatusBadRequest, "invalid or expired invitation")
+	ErrPasswordExpired    = NewError(http.StatusForbidden, "password has expired and must be changed")
+	ErrExternalPassword   = NewError(http.StatusForbidden, "password is managed by the directory or identity provider")
+	ErrImpersonation      = NewError(http.StatusForbidden, "action is not allowed while impersonating")
+	ErrNotImpersonable    = NewError(http.StatusBadRequest, "user can't be impersonated")
+	ErrInvalidOwner       = NewError(http.StatusBadRequest, "owner does not exist")
+	ErrInvalidCompany     = NewError(http.StatusBadRequest, "company does not exist")
+	ErrCompanyHierarchy   = NewError(http.StatusBadRequest, "parent company can't be the company or one of its subsidiaries")
+	ErrInvalidContact     = NewError(http.StatusBadRequest, "contact does not exist")
+	ErrInvalidPipeline    = NewError(http.StatusBadRequest, "pipeline does not exist")
+	ErrInvalidStage       = NewError(http.StatusBadRequest, "stage does not belong to the pipeline")
+	ErrPipelineHasDeals   = NewError(http.StatusBadRequest, "pipeline has deals")
+	ErrStageHasDeals      = NewError(http.StatusBadRequest, "stage has deals, move them first")
+	ErrInvalidAssignee    = NewError(http.StatusBadRequest, "assignee does not exist")
+	ErrInvalidRecord      = NewError(http.StatusBadRequest, "linked record does not exist")
+	ErrInvalidParticipant = NewError(http.StatusBadRequest, "participant does not exist")
+	ErrCustomFieldExist   = NewError(http.StatusBadRequest, "custom field key exist")
+	ErrTagExist           = NewError(http.StatusBadRequest, "tag exist")
+	ErrInvalidTag         = NewError(http.StatusBadRequest, "tag does not exist")
+	ErrImportStarted      = NewError(http.StatusBadRequest, "import already started")
 )
 
 const (
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// ImpersonationLog is the audit record of a request made with an impersonation
+// token. TokenID ties together the requests of one impersonation.
+type ImpersonationLog struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	ImpersonatorID uuid.UUID `json:"impersonator_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	TokenID        string    `json:"token_id"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Status         int       `json:"status"`
+	IP             string    `json:"ip"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (l *ImpersonationLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// ImpersonationLogQuery filters the audit log, empty IDs match every user.
+type ImpersonationLogQuery struct {
+	Pagination
+	UserID         string `form:"user_id"`
+	ImpersonatorID string `form:"impersonator_id"`
+}
+
+// IsValid normalizes the query, it reports false when an ID is not a UUID.
+func (q *ImpersonationLogQuery) IsValid() bool {
+	q.Pagination.Normalize()
+
+	for _, id := range []*string{&q.UserID, &q.ImpersonatorID} {
+		*id = strings.TrimSpace(*id)
+		if *id == "" {
+			continue
+		}
+
+		parsed, err := uuid.FromString(*id)
+		if err != nil {
+			return false
+		}
+
+		*id = parsed.String()
+	}
+
+	return true
+}
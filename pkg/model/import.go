@@ -0,0 +1,297 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type ImportStatus string
+
+const (
+	// ImportUploaded imports wait for their mapping, they can be checked with a dry run.
+	ImportUploaded  ImportStatus = "uploaded"
+	ImportQueued    ImportStatus = "queued"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// The contact fields CSV columns are mapped to, a row may have several emails and phones.
+const (
+	ImportFirstName      = "first_name"
+	ImportLastName       = "last_name"
+	ImportJobTitle       = "job_title"
+	ImportSource         = "source"
+	ImportLifecycleStage = "lifecycle_stage"
+	ImportEmail          = "email"
+	ImportPhone          = "phone"
+)
+
+var importFields = []string{
+	ImportFirstName,
+	ImportLastName,
+	ImportJobTitle,
+	ImportSource,
+	ImportLifecycleStage,
+	ImportEmail,
+	ImportPhone,
+}
+
+// importAliases are the header spellings the suggested mapping recognizes
+// besides the field names, compared without case, spaces, dashes and underscores.
+var importAliases = map[string]string{
+	"firstname":    ImportFirstName,
+	"givenname":    ImportFirstName,
+	"lastname":     ImportLastName,
+	"surname":      ImportLastName,
+	"familyname":   ImportLastName,
+	"jobtitle":     ImportJobTitle,
+	"title":        ImportJobTitle,
+	"position":     ImportJobTitle,
+	"source":       ImportSource,
+	"leadsource":   ImportSource,
+	"stage":        ImportLifecycleStage,
+	"lifecycle":    ImportLifecycleStage,
+	"email":        ImportEmail,
+	"emailaddress": ImportEmail,
+	"mail":         ImportEmail,
+	"phone":        ImportPhone,
+	"phonenumber":  ImportPhone,
+	"mobile":       ImportPhone,
+	"telephone":    ImportPhone,
+}
+
+func isRepeatableImportField(field string) bool {
+	return field == ImportEmail || field == ImportPhone
+}
+
+// Import is a CSV file of contacts the owner imports. Data is the uploaded file,
+// Get and List leave it out. ProcessedRows is how many rows were imported or
+// failed so far, an import taken over by another worker goes on after them.
+type Import struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primary_key;" json:"id"`
+	OwnerID       uuid.UUID     `gorm:"type:uuid" json:"owner_id"`
+	FileName      string        `json:"file_name"`
+	Headers       StringList    `gorm:"type:jsonb" json:"headers" swaggertype:"array,string"`
+	Mapping       ImportMapping `gorm:"type:jsonb" json:"mapping" swaggertype:"object,string"`
+	Status        ImportStatus  `json:"status"`
+	TotalRows     int           `json:"total_rows"`
+	ProcessedRows int           `json:"processed_rows"`
+	CreatedRows   int           `json:"created_rows"`
+	FailedRows    int           `json:"failed_rows"`
+	Error         string        `json:"error"`
+	Data          []byte        `json:"-"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	StartedAt     *time.Time    `json:"started_at"`
+	FinishedAt    *time.Time    `json:"finished_at"`
+}
+
+func (i *Import) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// Contact makes the contact of a row and returns the rules it breaks, the
+// contact is validated like the contacts of the API.
+func (i *Import) Contact(row []string) (*Contact, []FieldError) {
+	contact := &Contact{OwnerID: &i.OwnerID}
+
+	for column, header := range i.Headers {
+		if column >= len(row) {
+			break
+		}
+
+		value := strings.TrimSpace(row[column])
+		if value == "" {
+			continue
+		}
+
+		switch i.Mapping[header] {
+		case ImportFirstName:
+			contact.FirstName = value
+		case ImportLastName:
+			contact.LastName = value
+		case ImportJobTitle:
+			contact.JobTitle = value
+		case ImportSource:
+			contact.Source = value
+		case ImportLifecycleStage:
+			contact.LifecycleStage = LifecycleStage(value)
+		case ImportEmail:
+			contact.Emails = append(contact.Emails, ContactEmail{Email: value})
+		case ImportPhone:
+			contact.Phones = append(contact.Phones, ContactPhone{Phone: value})
+		}
+	}
+
+	return contact, contact.Validate()
+}
+
+// ImportMapping maps CSV column headers to contact fields, columns that aren't
+// mapped or are mapped to "" are skipped.
+type ImportMapping map[string]string
+
+// SuggestImportMapping maps the headers that name a contact field.
+func SuggestImportMapping(headers []string) ImportMapping {
+	mapping := ImportMapping{}
+	used := map[string]bool{}
+
+	for _, header := range headers {
+		key := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(header))
+
+		field, ok := importAliases[key]
+		if !ok || (used[field] && !isRepeatableImportField(field)) {
+			continue
+		}
+
+		used[field] = true
+		mapping[header] = field
+	}
+
+	return mapping
+}
+
+// Validate normalizes the mapping of the headers and returns every broken rule.
+// A contact field other than email and phone can be mapped once, the mapping
+// needs a name column.
+func (m ImportMapping) Validate(headers []string) []FieldError {
+	fields := []FieldError{}
+	known := make(map[string]bool, len(headers))
+	used := map[string]bool{}
+
+	for _, header := range headers {
+		known[header] = true
+	}
+
+	for _, header := range headers {
+		field, ok := m[header]
+		if !ok {
+			continue
+		}
+
+		field = strings.ToLower(strings.TrimSpace(field))
+		m[header] = field
+
+		switch {
+		case field == "":
+			continue
+		case !isImportField(field):
+			fields = append(fields, FieldError{Field: "mapping." + header, Rule: "oneof", Message: "unknown contact field"})
+		case used[field] && !isRepeatableImportField(field):
+			fields = append(fields, FieldError{Field: "mapping." + header, Rule: "unique", Message: field + " is mapped more than once"})
+		}
+
+		used[field] = true
+	}
+
+	unknown := []string{}
+
+	for header := range m {
+		if !known[header] {
+			unknown = append(unknown, header)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, header := range unknown {
+		fields = append(fields, FieldError{Field: "mapping." + header, Rule: "unknown", Message: "the file has no such column"})
+	}
+
+	if !used[ImportFirstName] && !used[ImportLastName] {
+		fields = append(fields, FieldError{Field: "mapping", Rule: "required", Message: "map a column to first_name or last_name"})
+	}
+
+	return fields
+}
+
+func isImportField(field string) bool {
+	for _, known := range importFields {
+		if field == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m ImportMapping) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	value, err := json.Marshal(m)
+
+	return string(value), err
+}
+
+func (m *ImportMapping) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		*m = ImportMapping{}
+
+		return nil
+	default:
+		return errors.New("unsupported import mapping value")
+	}
+}
+
+// ImportRowError is a row that wasn't imported, Row 1 is the first row after the
+// header and blank rows aren't counted.
+type ImportRowError struct {
+	ImportID uuid.UUID   `gorm:"type:uuid;primaryKey" json:"-"`
+	Row      int         `gorm:"column:row_no;primaryKey" json:"row"`
+	Errors   FieldErrors `gorm:"type:jsonb" json:"errors"`
+}
+
+// ImportBatch is the outcome of the rows From to To, not included, saved in one transaction.
+type ImportBatch struct {
+	ImportID uuid.UUID
+	From     int
+	To       int
+	Contacts []Contact
+	Errors   []ImportRowError
+}
+
+// FieldErrors is stored as a JSON array.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+
+	value, err := json.Marshal(e)
+
+	return string(value), err
+}
+
+func (e *FieldErrors) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	case nil:
+		*e = FieldErrors{}
+
+		return nil
+	default:
+		return errors.New("unsupported field errors value")
+	}
+}
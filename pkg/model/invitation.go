@@ -0,0 +1,78 @@
+package model
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Invitation lets the invitee create their own account with the role chosen by
+// the admin. Only the hash of the emailed token is stored.
+type Invitation struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	Email      string     `json:"email"`
+	Role       UserRole   `json:"role"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  uuid.UUID  `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"-"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// InvitationCreate is the admin request, the configured TTL applies without ExpiresAt.
+type InvitationCreate struct {
+	Email     string     `json:"email"`
+	Role      UserRole   `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (i *InvitationCreate) IsValid(now time.Time) bool {
+	address, err := mail.ParseAddress(strings.TrimSpace(i.Email))
+	if err != nil {
+		return false
+	}
+
+	i.Email = strings.ToLower(address.Address)
+	i.Role = UserRole(strings.ToUpper(strings.TrimSpace(string(i.Role))))
+
+	return i.Role != "" && (i.ExpiresAt == nil || now.Before(*i.ExpiresAt))
+}
+
+// InvitationAccept creates the account of the invitee, Username defaults to the invited email.
+type InvitationAccept struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Surname  string `json:"surname"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+}
+
+func (i *InvitationAccept) IsValid() bool {
+	i.Token = strings.TrimSpace(i.Token)
+	i.Username = strings.TrimSpace(i.Username)
+
+	return i.Token != "" && strings.TrimSpace(i.Password) != ""
+}
+
+func (i *InvitationAccept) Profile() *User {
+	return &User{
+		Name:    strings.TrimSpace(i.Name),
+		Surname: strings.TrimSpace(i.Surname),
+		Phone:   strings.TrimSpace(i.Phone),
+		Address: strings.TrimSpace(i.Address),
+	}
+}
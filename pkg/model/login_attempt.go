@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// LoginAttempt counts recent failed logins for a key, see bruteforce.UsernameKey and bruteforce.IPKey.
+type LoginAttempt struct {
+	Key           string `gorm:"primary_key"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// RetryAfter returns how long the key stays locked.
+func (a *LoginAttempt) RetryAfter(now time.Time) time.Duration {
+	if a.LockedUntil == nil || !a.LockedUntil.After(now) {
+		return 0
+	}
+
+	return a.LockedUntil.Sub(now)
+}
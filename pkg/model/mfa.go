@@ -0,0 +1,79 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use 2FA backup code, only its hash is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	uuid := uuid.NewV4().String()
+	tx.Statement.SetColumn("ID", uuid)
+
+	return nil
+}
+
+// MFAChallenge is the id of a used challenge token, kept until the token expires
+// so enrollment challenges can be used once.
+type MFAChallenge struct {
+	TokenID   string `gorm:"primary_key"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// MFAPolicy tells whether users of the role must use two-factor authentication.
+type MFAPolicy struct {
+	Role     UserRole `gorm:"primary_key" json:"role"`
+	Required bool     `json:"required"`
+}
+
+func (MFAPolicy) TableName() string {
+	return "mfa_policies"
+}
+
+func (p *MFAPolicy) IsValid() bool {
+	return strings.TrimSpace(string(p.Role)) != ""
+}
+
+type MFALogin struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (m *MFALogin) IsValid() bool {
+	return m.MFAToken != "" && (strings.TrimSpace(m.Code) != "" || strings.TrimSpace(m.RecoveryCode) != "")
+}
+
+type MFACode struct {
+	MFAToken string `json:"mfa_token,omitempty"`
+	Code     string `json:"code"`
+}
+
+func (m *MFACode) IsValid() bool {
+	m.Code = strings.TrimSpace(m.Code)
+
+	return m.Code != ""
+}
+
+type MFADisable struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (m *MFADisable) IsValid() bool {
+	m.Code = strings.TrimSpace(m.Code)
+
+	return m.Password != "" && m.Code != ""
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuthIdentity links a user to its subject at an OpenID Connect provider.
+type AuthIdentity struct {
+	Issuer    string `gorm:"primary_key"`
+	Subject   string `gorm:"primary_key"`
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// OIDCState is kept between the redirect to the provider and the callback,
+// it is found by the hash of the state and can be used once.
+type OIDCState struct {
+	StateHash    string `gorm:"primary_key"`
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
@@ -0,0 +1,64 @@
+package model
+
+import "strings"
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Pagination is bound from the page and per_page query parameters.
+type Pagination struct {
+	Page    int `form:"page" json:"page"`
+	PerPage int `form:"per_page" json:"per_page"`
+}
+
+// Normalize replaces missing or out of range values with defaults.
+func (p *Pagination) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+
+	if p.PerPage < 1 {
+		p.PerPage = DefaultPerPage
+	}
+
+	if p.PerPage > MaxPerPage {
+		p.PerPage = MaxPerPage
+	}
+}
+
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// Sorting is bound from the sort query parameter: a field prefixed with "-"
+// for descending order, newest first by default.
+type Sorting struct {
+	Sort string `form:"sort" json:"sort"`
+}
+
+// Normalize lowercases Sort and defaults it to -created_at, it reports whether
+// the field is one of fields.
+func (s *Sorting) Normalize(fields []string) bool {
+	s.Sort = strings.ToLower(strings.TrimSpace(s.Sort))
+	if s.Sort == "" {
+		s.Sort = "-created_at"
+	}
+
+	field, _ := s.SortField()
+	for _, known := range fields {
+		if field == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortField splits Sort into the field and the direction.
+func (s *Sorting) SortField() (string, bool) {
+	field, desc := strings.CutPrefix(s.Sort, "-")
+
+	return field, desc
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken is a single-use token emailed to the user, only its hash is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	uuid := uuid.NewV4().String()
+	tx.Statement.SetColumn("ID", uuid)
+
+	return nil
+}
+
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+func (f *ForgotPassword) IsValid() bool {
+	f.Email = strings.TrimSpace(f.Email)
+
+	return f.Email != ""
+}
+
+type ResetPassword struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (r *ResetPassword) IsValid() bool {
+	r.Token = strings.TrimSpace(r.Token)
+
+	return r.Token != "" && strings.TrimSpace(r.NewPassword) != ""
+}
+
+// PasswordHistory keeps a replaced password hash, so the policy can refuse its reuse.
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;"`
+	UserID       uuid.UUID
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	uuid := uuid.NewV4().String()
+	tx.Statement.SetColumn("ID", uuid)
+
+	return nil
+}
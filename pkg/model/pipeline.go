@@ -0,0 +1,151 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// StageKind tells whether deals in a stage are still open, won or lost.
+type StageKind string
+
+const (
+	StageOpen StageKind = "open"
+	StageWon  StageKind = "won"
+	StageLost StageKind = "lost"
+)
+
+const maxPipelineStages = 20
+
+func (k StageKind) IsKnown() bool {
+	return k == StageOpen || k == StageWon || k == StageLost
+}
+
+// Pipeline is an ordered list of stages deals move through. Pipelines are
+// configured by holders of the pipelines permissions and visible to everyone.
+type Pipeline struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key;" json:"id"`
+	Name      string          `json:"name"`
+	Stages    []PipelineStage `gorm:"-" json:"stages"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (p *Pipeline) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// Stage returns the stage of the pipeline with the id.
+func (p *Pipeline) Stage(id uuid.UUID) (*PipelineStage, bool) {
+	for i := range p.Stages {
+		if p.Stages[i].ID == id {
+			return &p.Stages[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Validate normalizes the pipeline and returns every broken rule. Stages are
+// open by default, won stages have a probability of 100 and lost ones of 0.
+func (p *Pipeline) Validate() []FieldError {
+	fields := []FieldError{}
+
+	p.Name = strings.TrimSpace(p.Name)
+
+	if p.Name == "" {
+		fields = append(fields, FieldError{Field: "name", Rule: "required", Message: "name is required"})
+	}
+
+	if len([]rune(p.Name)) > maxContactText {
+		fields = append(fields, FieldError{Field: "name", Rule: "max_length", Message: "must be at most 100 characters"})
+	}
+
+	return append(fields, p.validateStages()...)
+}
+
+func (p *Pipeline) validateStages() []FieldError {
+	if len(p.Stages) == 0 {
+		return []FieldError{{Field: "stages", Rule: "required", Message: "at least one stage is required"}}
+	}
+
+	if len(p.Stages) > maxPipelineStages {
+		return []FieldError{{Field: "stages", Rule: "max_items", Message: "at most 20 stages"}}
+	}
+
+	names := make(map[string]struct{}, len(p.Stages))
+	ids := make(map[uuid.UUID]struct{}, len(p.Stages))
+
+	for i := range p.Stages {
+		stage := &p.Stages[i]
+		stage.Name = strings.TrimSpace(stage.Name)
+		stage.Kind = StageKind(strings.ToLower(strings.TrimSpace(string(stage.Kind))))
+
+		if stage.Name == "" || len([]rune(stage.Name)) > maxContactText {
+			return []FieldError{{Field: "stages", Rule: "max_length", Message: "stage names must have 1 to 100 characters"}}
+		}
+
+		if _, ok := names[strings.ToLower(stage.Name)]; ok {
+			return []FieldError{{Field: "stages", Rule: "unique", Message: "duplicate stage name"}}
+		}
+
+		names[strings.ToLower(stage.Name)] = struct{}{}
+
+		if stage.ID != uuid.Nil {
+			if _, ok := ids[stage.ID]; ok {
+				return []FieldError{{Field: "stages", Rule: "unique", Message: "duplicate stage id"}}
+			}
+
+			ids[stage.ID] = struct{}{}
+		}
+
+		if stage.Kind == "" {
+			stage.Kind = StageOpen
+		}
+
+		switch stage.Kind {
+		case StageWon:
+			stage.Probability = 100
+		case StageLost:
+			stage.Probability = 0
+		case StageOpen:
+			if stage.Probability < 0 || stage.Probability > 100 {
+				return []FieldError{{Field: "stages", Rule: "range", Message: "probability must be between 0 and 100"}}
+			}
+		default:
+			return []FieldError{{Field: "stages", Rule: "oneof", Message: "unknown stage kind"}}
+		}
+	}
+
+	return nil
+}
+
+// PipelineStage is a step of a pipeline, Position keeps the order of the list.
+// Probability is the chance in percent that a deal in the stage is won.
+type PipelineStage struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	PipelineID  uuid.UUID `gorm:"type:uuid" json:"-"`
+	Position    int       `json:"-"`
+	Name        string    `json:"name"`
+	Probability int       `json:"probability"`
+	Kind        StageKind `json:"kind"`
+}
+
+func (s *PipelineStage) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsClosed reports whether deals in the stage are won or lost.
+func (s *PipelineStage) IsClosed() bool {
+	return s.Kind != StageOpen
+}
@@ -0,0 +1,16 @@
+package model
+
+// RecordType names the records other records are attached to. The user record
+// is the profile of an account, identified by the account ID.
+type RecordType string
+
+const (
+	RecordUser    RecordType = "user"
+	RecordContact RecordType = "contact"
+	RecordCompany RecordType = "company"
+	RecordDeal    RecordType = "deal"
+)
+
+func (t RecordType) IsKnown() bool {
+	return t == RecordUser || t == RecordContact || t == RecordCompany || t == RecordDeal
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RefreshToken is a server-side record of an issued refresh JWT.
+// Every token issued by a rotation chain shares the same FamilyID, so
+// presenting an already rotated token lets us revoke the whole chain.
+type RefreshToken struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;"`
+	UserID     uuid.UUID
+	FamilyID   uuid.UUID
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
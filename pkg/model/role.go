@@ -0,0 +1,177 @@
+package model
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Permission is a "<resource>:<action>" grant checked by authmiddleware.RequirePermission.
+type Permission string
+
+const (
+	PermUsersCreate Permission = "users:create"
+	PermUsersRead   Permission = "users:read"
+	PermUsersUpdate Permission = "users:update"
+	PermUsersDelete Permission = "users:delete"
+	// PermUsersImpersonate lets the holder act as users whose permissions it holds itself.
+	PermUsersImpersonate Permission = "users:impersonate"
+
+	PermRolesRead   Permission = "roles:read"
+	PermRolesCreate Permission = "roles:create"
+	PermRolesUpdate Permission = "roles:update"
+	PermRolesDelete Permission = "roles:delete"
+
+	PermMFAPoliciesRead   Permission = "mfa-policies:read"
+	PermMFAPoliciesUpdate Permission = "mfa-policies:update"
+
+	PermAPIKeysRead   Permission = "api-keys:read"
+	PermAPIKeysCreate Permission = "api-keys:create"
+	PermAPIKeysDelete Permission = "api-keys:delete"
+
+	PermAuditRead Permission = "audit:read"
+
+	// Contacts a user owns need no permission, these grant access to every contact.
+	PermContactsRead   Permission = "contacts:read"
+	PermContactsUpdate Permission = "contacts:update"
+	PermContactsDelete Permission = "contacts:delete"
+
+	// Like the contact permissions, companies the user owns need none.
+	PermCompaniesRead   Permission = "companies:read"
+	PermCompaniesUpdate Permission = "companies:update"
+	PermCompaniesDelete Permission = "companies:delete"
+
+	// Everyone reads pipelines, these configure them.
+	PermPipelinesCreate Permission = "pipelines:create"
+	PermPipelinesUpdate Permission = "pipelines:update"
+	PermPipelinesDelete Permission = "pipelines:delete"
+
+	// Deals are owned like contacts and companies.
+	PermDealsRead   Permission = "deals:read"
+	PermDealsUpdate Permission = "deals:update"
+	PermDealsDelete Permission = "deals:delete"
+
+	// Tasks are visible to their assignee and creator.
+	PermTasksRead   Permission = "tasks:read"
+	PermTasksUpdate Permission = "tasks:update"
+	PermTasksDelete Permission = "tasks:delete"
+
+	// Authors change their activities, these grant it on every activity.
+	PermActivitiesUpdate Permission = "activities:update"
+	PermActivitiesDelete Permission = "activities:delete"
+
+	// Everyone reads the custom field definitions, these configure them.
+	PermCustomFieldsCreate Permission = "custom-fields:create"
+	PermCustomFieldsUpdate Permission = "custom-fields:update"
+	PermCustomFieldsDelete Permission = "custom-fields:delete"
+
+	// Everyone reads and assigns tags, these manage them.
+	PermTagsCreate Permission = "tags:create"
+	PermTagsUpdate Permission = "tags:update"
+	PermTagsDelete Permission = "tags:delete"
+
+	// PermImportsCreate lets the holder import contacts from CSV files, they own the imported contacts.
+	PermImportsCreate Permission = "imports:create"
+)
+
+// AllPermissions lists every permission a role may be granted.
+var AllPermissions = []Permission{
+	PermUsersCreate,
+	PermUsersRead,
+	PermUsersUpdate,
+	PermUsersDelete,
+	PermUsersImpersonate,
+	PermRolesRead,
+	PermRolesCreate,
+	PermRolesUpdate,
+	PermRolesDelete,
+	PermMFAPoliciesRead,
+	PermMFAPoliciesUpdate,
+	PermAPIKeysRead,
+	PermAPIKeysCreate,
+	PermAPIKeysDelete,
+	PermAuditRead,
+	PermContactsRead,
+	PermContactsUpdate,
+	PermContactsDelete,
+	PermCompaniesRead,
+	PermCompaniesUpdate,
+	PermCompaniesDelete,
+	PermPipelinesCreate,
+	PermPipelinesUpdate,
+	PermPipelinesDelete,
+	PermDealsRead,
+	PermDealsUpdate,
+	PermDealsDelete,
+	PermTasksRead,
+	PermTasksUpdate,
+	PermTasksDelete,
+	PermActivitiesUpdate,
+	PermActivitiesDelete,
+	PermCustomFieldsCreate,
+	PermCustomFieldsUpdate,
+	PermCustomFieldsDelete,
+	PermTagsCreate,
+	PermTagsUpdate,
+	PermTagsDelete,
+	PermImportsCreate,
+}
+
+func (p Permission) IsKnown() bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// Role is a named set of permissions assigned to users. Built-in roles are seeded
+// by migrations and can't be deleted.
+type Role struct {
+	Name        UserRole     `gorm:"primary_key" json:"name"`
+	Description string       `json:"description"`
+	Builtin     bool         `json:"builtin"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+}
+
+func (r *Role) IsValid() bool {
+	r.Name = UserRole(strings.ToUpper(strings.TrimSpace(string(r.Name))))
+	if !roleNamePattern.MatchString(string(r.Name)) {
+		return false
+	}
+
+	permissions, ok := normalizePermissions(r.Permissions)
+	r.Permissions = permissions
+
+	return ok
+}
+
+// normalizePermissions sorts and dedups the permissions, it reports false on unknown ones.
+func normalizePermissions(permissions []Permission) ([]Permission, bool) {
+	unique := make(map[Permission]struct{}, len(permissions))
+	for _, permission := range permissions {
+		if !permission.IsKnown() {
+			return permissions, false
+		}
+
+		unique[permission] = struct{}{}
+	}
+
+	normalized := make([]Permission, 0, len(unique))
+	for permission := range unique {
+		normalized = append(normalized, permission)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i] < normalized[j] })
+
+	return normalized, true
+}
+
+type RolePermission struct {
+	Role       UserRole   `gorm:"primary_key"`
+	Permission Permission `gorm:"primary_key"`
+}
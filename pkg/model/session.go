@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Session is a login on one device. It spans the rotation chain of its refresh
+// tokens, so the session ID is their FamilyID and the sid claim of access tokens.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;" json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// Current marks the session of the request listing the sessions.
+	Current bool `gorm:"-" json:"current"`
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
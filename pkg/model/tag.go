@@ -0,0 +1,203 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTagName        = 50
+	maxTagDescription = 500
+	maxTagFilter      = 20
+	maxBulkTags       = 50
+	maxBulkRecords    = 500
+)
+
+var tagColorRegexp = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Tag is a free-form label of records, names are unique case-insensitively.
+// Records is how many records have the tag, it is only filled in by lists.
+type Tag struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Description string    `json:"description"`
+	Records     int64     `gorm:"->" json:"records"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// Validate normalizes the tag and returns every broken rule. Colors are
+// #rrggbb, lowercase.
+func (t *Tag) Validate() []FieldError {
+	fields := []FieldError{}
+
+	t.Name = strings.TrimSpace(t.Name)
+	t.Color = strings.ToLower(strings.TrimSpace(t.Color))
+	t.Description = strings.TrimSpace(t.Description)
+
+	if t.Name == "" || len([]rune(t.Name)) > maxTagName {
+		fields = append(fields, FieldError{Field: "name", Rule: "max_length", Message: "name must have 1 to 50 characters"})
+	}
+
+	if strings.Contains(t.Name, ",") {
+		fields = append(fields, FieldError{Field: "name", Rule: "format", Message: "name can't contain commas"})
+	}
+
+	if t.Color != "" && !tagColorRegexp.MatchString(t.Color) {
+		fields = append(fields, FieldError{Field: "color", Rule: "format", Message: "color must be #rrggbb"})
+	}
+
+	if len([]rune(t.Description)) > maxTagDescription {
+		fields = append(fields, FieldError{Field: "description", Rule: "max_length", Message: "must be at most 500 characters"})
+	}
+
+	return fields
+}
+
+// EntityTag links a tag to a record.
+type EntityTag struct {
+	TagID      uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	EntityType RecordType `gorm:"primaryKey"`
+	EntityID   uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CreatedAt  time.Time
+}
+
+// TagMerge names the tags merged into another one.
+type TagMerge struct {
+	SourceIDs []uuid.UUID `json:"source_ids"`
+}
+
+// Validate normalizes the merge into the target and returns every broken rule.
+func (m *TagMerge) Validate(targetID uuid.UUID) []FieldError {
+	m.SourceIDs = uniqueIDs(m.SourceIDs)
+
+	if len(m.SourceIDs) == 0 || len(m.SourceIDs) > maxBulkTags {
+		return []FieldError{{Field: "source_ids", Rule: "max_items", Message: "1 to 50 tags are required"}}
+	}
+
+	for _, id := range m.SourceIDs {
+		if id == targetID {
+			return []FieldError{{Field: "source_ids", Rule: "oneof", Message: "a tag can't be merged into itself"}}
+		}
+	}
+
+	return nil
+}
+
+// TagAttach names the tags added to a record.
+type TagAttach struct {
+	TagIDs []uuid.UUID `json:"tag_ids"`
+}
+
+// Validate normalizes the tags and returns every broken rule.
+func (a *TagAttach) Validate() []FieldError {
+	a.TagIDs = uniqueIDs(a.TagIDs)
+
+	if len(a.TagIDs) == 0 || len(a.TagIDs) > maxBulkTags {
+		return []FieldError{{Field: "tag_ids", Rule: "max_items", Message: "1 to 50 tags are required"}}
+	}
+
+	return nil
+}
+
+// TagBulk adds and removes tags on many records of an entity type at once.
+type TagBulk struct {
+	EntityType RecordType  `json:"entity_type"`
+	EntityIDs  []uuid.UUID `json:"entity_ids"`
+	Add        []uuid.UUID `json:"add"`
+	Remove     []uuid.UUID `json:"remove"`
+}
+
+// Validate normalizes the operation and returns every broken rule.
+func (b *TagBulk) Validate() []FieldError {
+	fields := []FieldError{}
+
+	b.EntityType = RecordType(strings.ToLower(strings.TrimSpace(string(b.EntityType))))
+	b.EntityIDs = uniqueIDs(b.EntityIDs)
+	b.Add = uniqueIDs(b.Add)
+	b.Remove = uniqueIDs(b.Remove)
+
+	if !b.EntityType.IsKnown() {
+		fields = append(fields, FieldError{Field: "entity_type", Rule: "oneof", Message: "unknown entity type"})
+	}
+
+	if len(b.EntityIDs) == 0 || len(b.EntityIDs) > maxBulkRecords {
+		fields = append(fields, FieldError{Field: "entity_ids", Rule: "max_items", Message: "1 to 500 records are required"})
+	}
+
+	if len(b.Add) == 0 && len(b.Remove) == 0 {
+		fields = append(fields, FieldError{Field: "add", Rule: "required", Message: "add or remove tags"})
+	}
+
+	if len(b.Add) > maxBulkTags || len(b.Remove) > maxBulkTags {
+		fields = append(fields, FieldError{Field: "add", Rule: "max_items", Message: "at most 50 tags to add and to remove"})
+	}
+
+	for _, id := range b.Add {
+		for _, removed := range b.Remove {
+			if id == removed {
+				return append(fields, FieldError{Field: "remove", Rule: "unique", Message: "a tag can't be added and removed"})
+			}
+		}
+	}
+
+	return fields
+}
+
+// TagIDs returns the added and the removed tags.
+func (b *TagBulk) TagIDs() []uuid.UUID {
+	return append(append([]uuid.UUID{}, b.Add...), b.Remove...)
+}
+
+const (
+	TagsMatchAll = "all"
+	TagsMatchAny = "any"
+)
+
+// TagFilter selects the records of a list by tag names, comma separated. The
+// records have all the tags, or any of them when Match is any.
+type TagFilter struct {
+	Tags  string `form:"tags"`
+	Match string `form:"tags_match"`
+}
+
+// IsValid normalizes the filter, Match defaults to all.
+func (f *TagFilter) IsValid() bool {
+	f.Match = strings.ToLower(strings.TrimSpace(f.Match))
+	if f.Match == "" {
+		f.Match = TagsMatchAll
+	}
+
+	return (f.Match == TagsMatchAll || f.Match == TagsMatchAny) && len(f.Names()) <= maxTagFilter
+}
+
+// Names returns the lowercase tag names without repeats.
+func (f *TagFilter) Names() []string {
+	names := []string{}
+	seen := map[string]struct{}{}
+
+	for _, name := range strings.Split(f.Tags, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := seen[name]; name == "" || ok {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	return names
+}
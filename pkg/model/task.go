@@ -0,0 +1,211 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type TaskPriority string
+
+const (
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityNormal TaskPriority = "normal"
+	TaskPriorityHigh   TaskPriority = "high"
+	TaskPriorityUrgent TaskPriority = "urgent"
+)
+
+func (p TaskPriority) IsKnown() bool {
+	return p == TaskPriorityLow || p == TaskPriorityNormal || p == TaskPriorityHigh || p == TaskPriorityUrgent
+}
+
+type TaskStatus string
+
+const (
+	TaskOpen       TaskStatus = "open"
+	TaskInProgress TaskStatus = "in_progress"
+	TaskDone       TaskStatus = "done"
+	TaskCanceled   TaskStatus = "canceled"
+)
+
+func (s TaskStatus) IsKnown() bool {
+	return s == TaskOpen || s == TaskInProgress || s == TaskDone || s == TaskCanceled
+}
+
+// OpenTaskStatuses are the statuses of tasks that still need work.
+var OpenTaskStatuses = []TaskStatus{TaskOpen, TaskInProgress}
+
+const (
+	maxTaskTitle       = 200
+	maxTaskDescription = 5000
+)
+
+// Task is a follow-up assigned to a user. It is visible to its assignee and
+// creator and to holders of tasks:read; the IDs are nil once the users are
+// deleted. RecordType and RecordID optionally link it to a user profile,
+// contact, company or deal. RemindedAt is set once the due-soon reminder is sent.
+type Task struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;" json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	DueAt       *time.Time   `json:"due_at"`
+	Priority    TaskPriority `json:"priority"`
+	Status      TaskStatus   `json:"status"`
+	AssigneeID  *uuid.UUID   `gorm:"type:uuid" json:"assignee_id"`
+	CreatorID   *uuid.UUID   `gorm:"type:uuid" json:"creator_id"`
+	RecordType  RecordType   `json:"record_type"`
+	RecordID    *uuid.UUID   `gorm:"type:uuid" json:"record_id"`
+	CompletedAt *time.Time   `json:"completed_at"`
+	RemindedAt  *time.Time   `json:"reminded_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (t *Task) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.NewV4()
+	}
+
+	return nil
+}
+
+// IsAssignedTo reports whether userID is the assignee of the task.
+func (t *Task) IsAssignedTo(userID uuid.UUID) bool {
+	return t.AssigneeID != nil && *t.AssigneeID == userID
+}
+
+// IsCreatedBy reports whether userID created the task.
+func (t *Task) IsCreatedBy(userID uuid.UUID) bool {
+	return t.CreatorID != nil && *t.CreatorID == userID
+}
+
+// Stamp sets the completion and reminder times from the previous version of
+// the task, nil for a new one. Completing the task stamps it with now, a new
+// due time makes the reminder due again.
+func (t *Task) Stamp(previous *Task, now time.Time) {
+	t.CompletedAt = nil
+	t.RemindedAt = nil
+
+	if t.Status == TaskDone {
+		t.CompletedAt = &now
+		if previous != nil && previous.Status == TaskDone {
+			t.CompletedAt = previous.CompletedAt
+		}
+	}
+
+	if previous != nil && sameTime(previous.DueAt, t.DueAt) {
+		t.RemindedAt = previous.RemindedAt
+	}
+}
+
+// Validate normalizes the task and returns every broken rule. Priority defaults
+// to normal and status to open.
+func (t *Task) Validate() []FieldError {
+	fields := []FieldError{}
+
+	t.Title = strings.TrimSpace(t.Title)
+	t.Description = strings.TrimSpace(t.Description)
+	t.Priority = TaskPriority(strings.ToLower(strings.TrimSpace(string(t.Priority))))
+	t.Status = TaskStatus(strings.ToLower(strings.TrimSpace(string(t.Status))))
+	t.RecordType = RecordType(strings.ToLower(strings.TrimSpace(string(t.RecordType))))
+
+	if t.Title == "" {
+		fields = append(fields, FieldError{Field: "title", Rule: "required", Message: "title is required"})
+	}
+
+	if len([]rune(t.Title)) > maxTaskTitle {
+		fields = append(fields, FieldError{Field: "title", Rule: "max_length", Message: "must be at most 200 characters"})
+	}
+
+	if len([]rune(t.Description)) > maxTaskDescription {
+		fields = append(fields, FieldError{Field: "description", Rule: "max_length", Message: "must be at most 5000 characters"})
+	}
+
+	if t.Priority == "" {
+		t.Priority = TaskPriorityNormal
+	}
+
+	if !t.Priority.IsKnown() {
+		fields = append(fields, FieldError{Field: "priority", Rule: "oneof", Message: "unknown priority"})
+	}
+
+	if t.Status == "" {
+		t.Status = TaskOpen
+	}
+
+	if !t.Status.IsKnown() {
+		fields = append(fields, FieldError{Field: "status", Rule: "oneof", Message: "unknown status"})
+	}
+
+	if t.DueAt != nil {
+		dueAt := t.DueAt.UTC().Truncate(time.Second)
+		t.DueAt = &dueAt
+	}
+
+	switch {
+	case t.RecordType == "" && t.RecordID == nil:
+	case t.RecordType == "" || t.RecordID == nil:
+		fields = append(fields, FieldError{Field: "record_id", Rule: "required_with", Message: "record_type and record_id go together"})
+	case !t.RecordType.IsKnown():
+		fields = append(fields, FieldError{Field: "record_type", Rule: "oneof", Message: "unknown record type"})
+	}
+
+	return fields
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+// taskSorts are the sort fields of the task list.
+var taskSorts = []string{"due_at", "priority", "created_at", "updated_at"}
+
+// TaskQuery filters the task list. Search matches the title and the description
+// case-insensitively, Open keeps the open and in progress tasks. Tasks without
+// a due time sort last. ParticipantID limits the list to the tasks assigned to
+// or created by the user, it is set by the handler.
+type TaskQuery struct {
+	Pagination
+	Sorting
+	Search        string       `form:"search"`
+	AssigneeID    string       `form:"assignee_id"`
+	CreatorID     string       `form:"creator_id"`
+	Status        TaskStatus   `form:"status"`
+	Open          bool         `form:"open"`
+	Priority      TaskPriority `form:"priority"`
+	RecordType    RecordType   `form:"record_type"`
+	RecordID      string       `form:"record_id"`
+	DueBefore     *time.Time   `form:"due_before"`
+	DueAfter      *time.Time   `form:"due_after"`
+	ParticipantID string       `form:"-"`
+}
+
+// IsValid normalizes the query, it reports false on invalid IDs, enums or sort.
+func (q *TaskQuery) IsValid() bool {
+	q.Pagination.Normalize()
+	q.Search = strings.TrimSpace(q.Search)
+	q.Status = TaskStatus(strings.ToLower(strings.TrimSpace(string(q.Status))))
+	q.Priority = TaskPriority(strings.ToLower(strings.TrimSpace(string(q.Priority))))
+	q.RecordType = RecordType(strings.ToLower(strings.TrimSpace(string(q.RecordType))))
+
+	if (q.Status != "" && !q.Status.IsKnown()) || (q.Priority != "" && !q.Priority.IsKnown()) ||
+		(q.RecordType != "" && !q.RecordType.IsKnown()) {
+		return false
+	}
+
+	for _, id := range []*string{&q.AssigneeID, &q.CreatorID, &q.RecordID} {
+		var ok bool
+
+		if *id, ok = normalizeUUID(*id); !ok {
+			return false
+		}
+	}
+
+	return q.Sorting.Normalize(taskSorts)
+}
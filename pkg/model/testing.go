@@ -1,5 +1,7 @@
 package model
 
+import uuid "github.com/satori/go.uuid"
+
 type TestStructure struct {
 	Name         string
 	Method       string
@@ -13,4 +15,10 @@ type TestStructure struct {
 	QueryParams  map[string]interface{}
 	SkipFields   []string
 	SkipRoot     string
+	// UserID, SessionID, Permissions and ImpersonatorID of the principal set by
+	// the stubbed Authorize, all permissions when Permissions is nil.
+	UserID         uuid.UUID
+	SessionID      uuid.UUID
+	Permissions    []Permission
+	ImpersonatorID uuid.UUID
 }
@@ -0,0 +1,13 @@
+package admin
+
+import "crm-system/pkg/model"
+
+// APIKeyCreateResponse carries the key itself, it is shown only once.
+type APIKeyCreateResponse struct {
+	Key    string       `json:"key"`
+	APIKey model.APIKey `json:"api_key"`
+}
+
+type APIKeyRevokeResponse struct {
+	Status string `json:"status"`
+}
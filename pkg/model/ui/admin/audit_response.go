@@ -0,0 +1,10 @@
+package admin
+
+import "crm-system/pkg/model"
+
+type AuthEventListResponse struct {
+	Events  []model.AuthEvent `json:"events"`
+	Total   int64             `json:"total"`
+	Page    int               `json:"page"`
+	PerPage int               `json:"per_page"`
+}
@@ -0,0 +1,5 @@
+package admin
+
+type CustomFieldDeleteResponse struct {
+	Status string `json:"status"`
+}
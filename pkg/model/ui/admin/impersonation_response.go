@@ -0,0 +1,17 @@
+package admin
+
+import "crm-system/pkg/model"
+
+// ImpersonationResponse carries an access token of the impersonated user, there
+// is no refresh token. ExpiresIn is in seconds.
+type ImpersonationResponse struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+type ImpersonationLogListResponse struct {
+	Logs    []model.ImpersonationLog `json:"logs"`
+	Total   int64                    `json:"total"`
+	Page    int                      `json:"page"`
+	PerPage int                      `json:"per_page"`
+}
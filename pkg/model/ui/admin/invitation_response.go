@@ -0,0 +1,5 @@
+package admin
+
+type InvitationRevokeResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,5 @@
+package admin
+
+type RoleDeleteResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,5 @@
+package admin
+
+type TagDeleteResponse struct {
+	Status string `json:"status"`
+}
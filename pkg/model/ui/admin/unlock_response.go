@@ -0,0 +1,5 @@
+package admin
+
+type UnlockResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,14 @@
+package admin
+
+import "crm-system/pkg/model"
+
+type UserListResponse struct {
+	Users   []model.UserAccount `json:"users"`
+	Total   int64               `json:"total"`
+	Page    int                 `json:"page"`
+	PerPage int                 `json:"per_page"`
+}
+
+type UserStatusResponse struct {
+	Status string `json:"status"`
+}
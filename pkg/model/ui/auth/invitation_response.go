@@ -0,0 +1,5 @@
+package auth
+
+type InvitationAcceptResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,5 @@
+package auth
+
+type LogoutResponse struct {
+	Status string `json:"status"`
+}
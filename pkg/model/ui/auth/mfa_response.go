@@ -0,0 +1,24 @@
+package auth
+
+import "crm-system/pkg/authmiddleware"
+
+// MFAChallengeResponse is returned by login instead of tokens when a second factor is needed.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+}
+
+type MFAEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFAConfirmResponse struct {
+	RecoveryCodes []string               `json:"recovery_codes"`
+	Tokens        *authmiddleware.Tokens `json:"tokens,omitempty"`
+}
+
+type MFADisableResponse struct {
+	Status string `json:"status"`
+}
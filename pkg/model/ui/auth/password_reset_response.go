@@ -0,0 +1,5 @@
+package auth
+
+type PasswordResetResponse struct {
+	Status string `json:"status"`
+}
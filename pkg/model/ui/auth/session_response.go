@@ -0,0 +1,5 @@
+package auth
+
+type SessionRevokeResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,14 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type TimelineResponse struct {
+	Entries []model.TimelineEntry `json:"entries"`
+	Total   int64                 `json:"total"`
+	Page    int                   `json:"page"`
+	PerPage int                   `json:"per_page"`
+}
+
+type ActivityDeleteResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,24 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type CompanyListResponse struct {
+	Companies []model.Company `json:"companies"`
+	Total     int64           `json:"total"`
+	Page      int             `json:"page"`
+	PerPage   int             `json:"per_page"`
+}
+
+// CompanyDetailResponse is the company with the first page of its contacts and
+// subsidiaries by name, the totals tell whether there are more.
+type CompanyDetailResponse struct {
+	model.Company
+	Contacts      []model.Contact `json:"contacts"`
+	ContactsTotal int64           `json:"contacts_total"`
+	Children      []model.Company `json:"children"`
+	ChildrenTotal int64           `json:"children_total"`
+}
+
+type CompanyDeleteResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,14 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type ContactListResponse struct {
+	Contacts []model.Contact `json:"contacts"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PerPage  int             `json:"per_page"`
+}
+
+type ContactDeleteResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,18 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type DealListResponse struct {
+	Deals   []model.Deal `json:"deals"`
+	Total   int64        `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+}
+
+type DealHistoryResponse struct {
+	Changes []model.DealStageChange `json:"changes"`
+}
+
+type DealDeleteResponse struct {
+	Status string `json:"status"`
+}
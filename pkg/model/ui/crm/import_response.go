@@ -0,0 +1,23 @@
+package crm
+
+import "crm-system/pkg/model"
+
+// ImportPreviewResponse is an uploaded import with its first rows and the suggested mapping.
+type ImportPreviewResponse struct {
+	Import  model.Import `json:"import"`
+	Preview [][]string   `json:"preview"`
+}
+
+type ImportListResponse struct {
+	Imports []model.Import `json:"imports"`
+	Total   int64          `json:"total"`
+	Page    int            `json:"page"`
+	PerPage int            `json:"per_page"`
+}
+
+type ImportDryRunResponse struct {
+	TotalRows  int                    `json:"total_rows"`
+	ValidRows  int                    `json:"valid_rows"`
+	FailedRows int                    `json:"failed_rows"`
+	Errors     []model.ImportRowError `json:"errors"`
+}
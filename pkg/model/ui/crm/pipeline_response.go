@@ -0,0 +1,28 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type PipelineListResponse struct {
+	Pipelines []model.Pipeline `json:"pipelines"`
+}
+
+type PipelineDeleteResponse struct {
+	Status string `json:"status"`
+}
+
+// PipelineBoardResponse groups the deals of a pipeline by stage in stage order.
+// Totals sums the open stages by currency.
+type PipelineBoardResponse struct {
+	Pipeline model.Pipeline    `json:"pipeline"`
+	Stages   []BoardStage      `json:"stages"`
+	Totals   []model.DealTotal `json:"totals"`
+}
+
+// BoardStage is a stage with its first deals and the totals of all its deals
+// by currency, Count tells whether there are more deals.
+type BoardStage struct {
+	model.PipelineStage
+	Deals  []model.Deal      `json:"deals"`
+	Count  int64             `json:"count"`
+	Totals []model.DealTotal `json:"totals"`
+}
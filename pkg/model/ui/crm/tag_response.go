@@ -0,0 +1,6 @@
+package crm
+
+type TagBulkResponse struct {
+	Status  string `json:"status"`
+	Records int    `json:"records"`
+}
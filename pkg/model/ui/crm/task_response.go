@@ -0,0 +1,14 @@
+package crm
+
+import "crm-system/pkg/model"
+
+type TaskListResponse struct {
+	Tasks   []model.Task `json:"tasks"`
+	Total   int64        `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+}
+
+type TaskDeleteResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,9 @@
+package errors
+
+import "crm-system/pkg/model"
+
+type UIResponseErrorValidation struct {
+	Code    int                `example:"400"                       json:"code"`
+	Message string             `example:"request validation failed" json:"message"`
+	Fields  []model.FieldError `json:"fields"`
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
 )
@@ -12,6 +14,8 @@ type User struct {
 	Surname string    `json:"surname"`
 	Phone   string    `json:"phone"`
 	Address string    `json:"address"`
+	// CustomFields are the values of the custom fields defined for the user entity.
+	CustomFields CustomFieldValues `gorm:"type:jsonb" json:"custom_fields" swaggertype:"object"`
 }
 
 func (p *User) BeforeCreate(tx *gorm.DB) error {
@@ -20,3 +24,60 @@ func (p *User) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+// Changes lists the fields the update changes, empty fields of the update are
+// kept like the repository keeps them. Custom fields are compared when the
+// update has them, they are named custom_fields.<key>.
+func (p *User) Changes(update *User) FieldChanges {
+	changes := FieldChanges{}
+
+	for _, field := range []struct {
+		name     string
+		from, to string
+	}{
+		{"name", p.Name, update.Name},
+		{"surname", p.Surname, update.Surname},
+		{"phone", p.Phone, update.Phone},
+		{"address", p.Address, update.Address},
+	} {
+		if field.to != "" && field.to != field.from {
+			changes = append(changes, FieldChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+
+	if update.CustomFields == nil {
+		return changes
+	}
+
+	keys := CustomFieldValues{}
+	for key := range p.CustomFields {
+		keys[key] = nil
+	}
+
+	for key := range update.CustomFields {
+		keys[key] = nil
+	}
+
+	for _, key := range keys.Keys() {
+		from, to := customFieldText(p.CustomFields[key]), customFieldText(update.CustomFields[key])
+		if from != to {
+			changes = append(changes, FieldChange{Field: "custom_fields." + key, From: from, To: to})
+		}
+	}
+
+	return changes
+}
+
+// customFieldText formats a custom field value for the timeline.
+func customFieldText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		text, _ := json.Marshal(v)
+
+		return string(text)
+	}
+}
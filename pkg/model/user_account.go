@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// UserAccount is the admin view of a user: auth_users joined with users.
+type UserAccount struct {
+	ID             uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	Email          *string   `json:"email,omitempty"`
+	Role           UserRole  `json:"role"`
+	Active         bool      `json:"active"`
+	TOTPEnabled    bool      `gorm:"column:totp_enabled" json:"totp_enabled"`
+	ServiceAccount bool      `gorm:"column:service_account" json:"service_account"`
+	Name           string    `json:"name"`
+	Surname        string    `json:"surname"`
+	Phone          string    `json:"phone"`
+	Address        string    `json:"address"`
+	// CustomFields is empty for users without a profile.
+	CustomFields CustomFieldValues `gorm:"column:custom_fields" json:"custom_fields" swaggertype:"object"`
+}
+
+// UserListQuery filters the admin user list. Search matches username, email,
+// name and surname case-insensitively. CustomFields is the JSON the custom
+// fields of the listed users contain, the handler builds it from the cf[key]
+// parameters.
+type UserListQuery struct {
+	Pagination
+	TagFilter
+	Search       string            `form:"search"`
+	Role         UserRole          `form:"role"`
+	Active       *bool             `form:"active"`
+	CustomFields CustomFieldValues `form:"-"`
+}
+
+func (q *UserListQuery) Normalize() {
+	q.Pagination.Normalize()
+	q.Search = strings.TrimSpace(q.Search)
+	q.Role = UserRole(strings.ToUpper(strings.TrimSpace(string(q.Role))))
+}
+
+type UserRoleChange struct {
+	Role UserRole `json:"role"`
+}
+
+func (u *UserRoleChange) IsValid() bool {
+	u.Role = UserRole(strings.ToUpper(strings.TrimSpace(string(u.Role))))
+
+	return u.Role != ""
+}
+
+// ServiceAccountCreate registers an integration user, it has a role but no password.
+type ServiceAccountCreate struct {
+	Username string   `json:"username"`
+	Role     UserRole `json:"role"`
+}
+
+func (s *ServiceAccountCreate) IsValid() bool {
+	s.Username = strings.TrimSpace(s.Username)
+	s.Role = UserRole(strings.ToUpper(strings.TrimSpace(string(s.Role))))
+
+	return s.Username != "" && s.Role != ""
+}
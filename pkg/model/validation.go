@@ -0,0 +1,23 @@
+package model
+
+import "net/http"
+
+// FieldError is a rule broken by the value of a request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every broken rule, unlike ErrInvalidBody.
+type ValidationError struct {
+	StatusError
+	Fields []FieldError `json:"fields"`
+}
+
+func NewValidationError(fields []FieldError) ValidationError {
+	return ValidationError{
+		StatusError: StatusError{Code: http.StatusBadRequest, Message: "request validation failed"},
+		Fields:      fields,
+	}
+}
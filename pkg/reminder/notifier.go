@@ -0,0 +1,81 @@
+// Package reminder notifies the assignees of tasks that are due soon. The
+// scheduler claims the tasks in the database so several API instances can run
+// it side by side, the notifier decides how the assignee is told.
+package reminder
+
+import (
+	"crm-system/pkg/logger"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
+	"fmt"
+)
+
+const (
+	MailDriver = "mail"
+	LogDriver  = "log"
+
+	dueFormat = "2006-01-02 15:04 MST"
+)
+
+// Notifier tells the assignee that the task is due soon.
+type Notifier interface {
+	DueSoon(user *model.AuthUser, task *model.Task) error
+}
+
+//nolint:ireturn
+func NewNotifier(driver string, mail mailer.Mailer) (Notifier, error) {
+	switch driver {
+	case MailDriver, "":
+		return NewMailNotifier(mail), nil
+	case LogDriver:
+		return NewLogNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", driver)
+	}
+}
+
+// MailNotifier emails the reminder, users without an email are skipped.
+type MailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func NewMailNotifier(mail mailer.Mailer) *MailNotifier {
+	return &MailNotifier{mailer: mail}
+}
+
+func (n *MailNotifier) DueSoon(user *model.AuthUser, task *model.Task) error {
+	if user.Email == nil || *user.Email == "" {
+		return nil
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nthe task %q is due at %s.", user.Username, task.Title, formatDue(task))
+	if task.Description != "" {
+		body += "\n\n" + task.Description
+	}
+
+	return n.mailer.Send(mailer.Message{
+		To:      *user.Email,
+		Subject: "Task due soon: " + task.Title,
+		Body:    body,
+	})
+}
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) DueSoon(user *model.AuthUser, task *model.Task) error {
+	logger.Infof("Task %s %q of %s is due at %s", task.ID, task.Title, user.Username, formatDue(task))
+
+	return nil
+}
+
+func formatDue(task *model.Task) string {
+	if task.DueAt == nil {
+		return ""
+	}
+
+	return task.DueAt.UTC().Format(dueFormat)
+}
@@ -0,0 +1,90 @@
+package reminder
+
+import (
+	"context"
+	"crm-system/pkg/config"
+	"crm-system/pkg/logger"
+	"crm-system/pkg/store"
+	"time"
+)
+
+const defaultBatch = 100
+
+// Scheduler sends the due-soon reminders. A task is claimed before its assignee
+// is notified, so it is reminded at most once even when the notifier fails.
+type Scheduler struct {
+	tasks    store.TaskRepository
+	users    store.AuthRepository
+	notifier Notifier
+	conf     config.ReminderConfig
+}
+
+func NewScheduler(tasks store.TaskRepository, users store.AuthRepository, notifier Notifier, conf config.ReminderConfig) *Scheduler {
+	if conf.Batch <= 0 {
+		conf.Batch = defaultBatch
+	}
+
+	return &Scheduler{tasks: tasks, users: users, notifier: notifier, conf: conf}
+}
+
+// Run sends the reminders every interval until the context is done, it returns
+// at once when the interval is zero.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.conf.Interval.Duration <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.conf.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		sent, err := s.RemindDueSoon(time.Now())
+		if err != nil {
+			logger.Errorf("Scheduler.RemindDueSoon", err)
+		} else if sent > 0 {
+			logger.Infof("Sent %d task reminders", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RemindDueSoon notifies the assignees of the open tasks due within the lead
+// time that weren't reminded yet and returns how many were notified. Inactive
+// assignees are skipped.
+func (s *Scheduler) RemindDueSoon(now time.Time) (int, error) {
+	sent := 0
+
+	for {
+		tasks, err := s.tasks.ClaimDueSoon(now.Add(s.conf.Lead.Duration), now, s.conf.Batch)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+
+			user, exists := s.users.Get(*task.AssigneeID)
+			if !exists || !user.Active {
+				continue
+			}
+
+			err = s.notifier.DueSoon(user, task)
+			if err != nil {
+				logger.Errorf("RemindDueSoon.DueSoon", err)
+
+				continue
+			}
+
+			sent++
+		}
+
+		if len(tasks) < s.conf.Batch {
+			return sent, nil
+		}
+	}
+}
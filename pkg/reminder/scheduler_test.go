@@ -0,0 +1,125 @@
+package reminder
+
+import (
+	"crm-system/pkg/config"
+	"crm-system/pkg/mailer"
+	"crm-system/pkg/model"
+	"crm-system/pkg/store/mockpostgresstore"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	notified []uuid.UUID
+	err      error
+}
+
+func (n *recordingNotifier) DueSoon(user *model.AuthUser, task *model.Task) error {
+	if n.err != nil {
+		return n.err
+	}
+
+	n.notified = append(n.notified, task.ID)
+
+	return nil
+}
+
+func newTask(assigneeID uuid.UUID) model.Task {
+	return model.Task{ID: uuid.NewV4(), Title: "Call back", AssigneeID: &assigneeID}
+}
+
+func TestRemindDueSoon(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tasks := mockpostgresstore.NewMockTaskRepository(mockCtrl)
+	users := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	notifier := &recordingNotifier{}
+
+	activeID, inactiveID := uuid.NewV4(), uuid.NewV4()
+	first, second, skipped := newTask(activeID), newTask(activeID), newTask(inactiveID)
+	now := time.Date(2026, time.November, 2, 14, 0, 0, 0, time.UTC)
+
+	conf := config.ReminderConfig{Lead: config.Duration{Duration: time.Hour}, Batch: 2}
+	scheduler := NewScheduler(tasks, users, notifier, conf)
+
+	gomock.InOrder(
+		tasks.EXPECT().ClaimDueSoon(now.Add(time.Hour), now, 2).Return([]model.Task{first, skipped}, nil),
+		tasks.EXPECT().ClaimDueSoon(now.Add(time.Hour), now, 2).Return([]model.Task{second}, nil),
+	)
+	users.EXPECT().Get(activeID).Return(&model.AuthUser{ID: activeID, Active: true}, true).Times(2)
+	users.EXPECT().Get(inactiveID).Return(&model.AuthUser{ID: inactiveID}, true)
+
+	sent, err := scheduler.RemindDueSoon(now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID}, notifier.notified)
+}
+
+func TestRemindDueSoonNotifierError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tasks := mockpostgresstore.NewMockTaskRepository(mockCtrl)
+	users := mockpostgresstore.NewMockAuthRepository(mockCtrl)
+	notifier := &recordingNotifier{err: errors.New("error")}
+
+	userID := uuid.NewV4()
+	scheduler := NewScheduler(tasks, users, notifier, config.ReminderConfig{})
+
+	tasks.EXPECT().ClaimDueSoon(gomock.Any(), gomock.Any(), defaultBatch).Return([]model.Task{newTask(userID)}, nil)
+	users.EXPECT().Get(userID).Return(&model.AuthUser{ID: userID, Active: true}, true)
+
+	// the claimed task isn't retried
+	sent, err := scheduler.RemindDueSoon(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestRemindDueSoonClaimError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tasks := mockpostgresstore.NewMockTaskRepository(mockCtrl)
+	scheduler := NewScheduler(tasks, nil, &recordingNotifier{}, config.ReminderConfig{})
+
+	tasks.EXPECT().ClaimDueSoon(gomock.Any(), gomock.Any(), defaultBatch).Return(nil, errors.New("error"))
+
+	_, err := scheduler.RemindDueSoon(time.Now())
+	assert.Error(t, err)
+}
+
+type sendingMailer struct {
+	to, subject string
+}
+
+func (m *sendingMailer) Send(msg mailer.Message) error {
+	m.to, m.subject = msg.To, msg.Subject
+
+	return nil
+}
+
+func TestMailNotifier(t *testing.T) {
+	mail := &sendingMailer{}
+	email := "user@example.com"
+	task := newTask(uuid.NewV4())
+
+	notifier, err := NewNotifier(MailDriver, mail)
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.DueSoon(&model.AuthUser{Username: "user"}, &task))
+	assert.Empty(t, mail.to, "users without an email are skipped")
+
+	require.NoError(t, notifier.DueSoon(&model.AuthUser{Username: "user", Email: &email}, &task))
+	assert.Equal(t, email, mail.to)
+	assert.Equal(t, "Task due soon: Call back", mail.subject)
+
+	_, err = NewNotifier("sms", mail)
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package memorystore
+
+import (
+	"crm-system/pkg/model"
+	"sort"
+	"strings"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type AuthEventRepository struct {
+	mu     sync.Mutex
+	events []model.AuthEvent
+}
+
+func NewAuthEventRepository() *AuthEventRepository {
+	return &AuthEventRepository{}
+}
+
+func (r *AuthEventRepository) Create(event *model.AuthEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.NewV4()
+	}
+
+	r.events = append(r.events, *event)
+
+	return nil
+}
+
+func (r *AuthEventRepository) List(query model.AuthEventQuery) ([]model.AuthEvent, int64, error) {
+	matched := r.matching(query)
+
+	start := query.Offset()
+	if start > len(matched) {
+		start = len(matched)
+	}
+
+	end := start + query.PerPage
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return matched[start:end], int64(len(matched)), nil
+}
+
+func (r *AuthEventRepository) Export(query model.AuthEventQuery, write func([]model.AuthEvent) error) error {
+	matched := r.matching(query)
+	if len(matched) == 0 {
+		return nil
+	}
+
+	return write(matched)
+}
+
+// Events returns every event in the order they were created.
+func (r *AuthEventRepository) Events() []model.AuthEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]model.AuthEvent(nil), r.events...)
+}
+
+func (r *AuthEventRepository) matching(query model.AuthEventQuery) []model.AuthEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := []model.AuthEvent{}
+
+	for _, event := range r.events {
+		if matches(query, &event) {
+			matched = append(matched, event)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	return matched
+}
+
+func matches(query model.AuthEventQuery, event *model.AuthEvent) bool {
+	switch {
+	case query.Type != "" && event.Type != query.Type,
+		query.UserID != "" && !isUser(event.ActorID, query.UserID) && !isUser(event.TargetID, query.UserID),
+		query.Username != "" && !strings.EqualFold(event.Username, query.Username),
+		query.IP != "" && event.IP != query.IP,
+		query.Success != nil && event.Success != *query.Success,
+		query.From != nil && event.CreatedAt.Before(*query.From),
+		query.To != nil && !event.CreatedAt.Before(*query.To):
+		return false
+	default:
+		return true
+	}
+}
+
+func isUser(id *uuid.UUID, userID string) bool {
+	return id != nil && id.String() == userID
+}
@@ -0,0 +1,74 @@
+// Package memorystore keeps store implementations in process memory,
+// they are meant for tests and single instance development setups.
+package memorystore
+
+import (
+	"crm-system/pkg/model"
+	"sync"
+	"time"
+)
+
+type LoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]model.LoginAttempt
+}
+
+func NewLoginAttemptRepository() *LoginAttemptRepository {
+	return &LoginAttemptRepository{attempts: make(map[string]model.LoginAttempt)}
+}
+
+func (r *LoginAttemptRepository) Get(key string) (*model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt = model.LoginAttempt{Key: key}
+	}
+
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepository) RegisterFailure(key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	attempt := r.attempts[key]
+	attempt.Key = key
+
+	if attempt.LastFailureAt.Before(now.Add(-window)) {
+		attempt.Failures = 0
+	}
+
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	r.attempts[key] = attempt
+
+	return attempt.Failures, nil
+}
+
+func (r *LoginAttemptRepository) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.attempts[key]
+	attempt.Key = key
+
+	if attempt.LockedUntil == nil || attempt.LockedUntil.Before(until) {
+		attempt.LockedUntil = &until
+	}
+
+	r.attempts[key] = attempt
+
+	return nil
+}
+
+func (r *LoginAttemptRepository) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+
+	return nil
+}
@@ -1,4 +1,4 @@
 package mockpostgresstore
 
 //nolint:lll
-//go:generate mockgen -destination=./store.go -package=mockpostgresstore crm-system/pkg/store UserRepository,AuthRepository
+//go:generate mockgen -destination=./store.go -package=mockpostgresstore crm-system/pkg/store UserRepository,AuthRepository,RefreshTokenRepository,RecoveryCodeRepository,MFAPolicyRepository,MFAChallengeRepository,LoginAttemptRepository,PasswordResetRepository,RoleRepository,APIKeyRepository,SessionRepository,IdentityRepository,OIDCStateRepository,InvitationRepository,ImpersonationLogRepository,AuthEventRepository,ContactRepository,CompanyRepository,PipelineRepository,DealRepository,TaskRepository,ActivityRepository,CustomFieldRepository,TagRepository,ImportRepository
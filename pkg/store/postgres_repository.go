@@ -2,6 +2,7 @@ package store
 
 import (
 	"crm-system/pkg/model"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 )
@@ -13,8 +14,289 @@ type UserRepository interface {
 
 type AuthRepository interface {
 	GetByUsername(username string) (*model.AuthUser, error)
+	GetByEmail(email string) (*model.AuthUser, bool)
 	Get(id uuid.UUID) (*model.AuthUser, bool)
 	Create(user *model.AuthUser) error
+	// Delete removes the user with its profile, the timeline and the tags of the profile.
 	Delete(id uuid.UUID) error
-	ChangePassword(id uuid.UUID, pass string) error
+	// ChangePassword sets the new hash and keeps the replaced one, so the last
+	// history hashes of the user stay available to the password policy.
+	ChangePassword(id uuid.UUID, pass string, history int) error
+	// RehashPassword replaces the hash of the same password, the password age is kept.
+	RehashPassword(id uuid.UUID, pass string) error
+	// PasswordHistory returns the latest replaced hashes, newest first.
+	PasswordHistory(id uuid.UUID, limit int) ([]string, error)
+	SetTOTP(id uuid.UUID, secret string, enabled bool) error
+	// UseTOTPStep records the time step of an accepted code and reports whether it's
+	// later than the last one, so every code is accepted once.
+	UseTOTPStep(id uuid.UUID, step int64) (bool, error)
+	List(query model.UserListQuery) ([]model.UserAccount, int64, error)
+	GetAccount(id uuid.UUID) (*model.UserAccount, bool)
+	SetRole(id uuid.UUID, role model.UserRole) error
+	SetActive(id uuid.UUID, active bool) error
+}
+
+type RefreshTokenRepository interface {
+	Create(token *model.RefreshToken) error
+	Get(id uuid.UUID) (*model.RefreshToken, bool)
+	Rotate(oldID uuid.UUID, next *model.RefreshToken) error
+}
+
+type RecoveryCodeRepository interface {
+	Replace(userID uuid.UUID, codeHashes []string) error
+	Use(userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type MFAChallengeRepository interface {
+	// Use reports false when the challenge token was already used.
+	Use(tokenID string, expiresAt time.Time) (bool, error)
+}
+
+type MFAPolicyRepository interface {
+	IsRequired(role model.UserRole) (bool, error)
+	Set(policy *model.MFAPolicy) error
+	List() ([]model.MFAPolicy, error)
+}
+
+type LoginAttemptRepository interface {
+	Get(key string) (*model.LoginAttempt, error)
+	// RegisterFailure increments the counter, restarting it when the last
+	// failure is older than the window, and returns the new value.
+	RegisterFailure(key string, window time.Duration) (int, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type PasswordResetRepository interface {
+	// Create stores the token and drops the unused tokens issued to the user before.
+	Create(token *model.PasswordResetToken) error
+	// Get returns the user of an unused, unexpired token without using it.
+	Get(tokenHash string) (uuid.UUID, bool)
+	// Use marks an unused, unexpired token as used and returns its user.
+	Use(tokenHash string) (uuid.UUID, bool, error)
+}
+
+type RoleRepository interface {
+	List() ([]model.Role, error)
+	Get(name model.UserRole) (*model.Role, bool)
+	Permissions(name model.UserRole) ([]model.Permission, error)
+	Create(role *model.Role) error
+	// Update replaces the description and the permissions of the role.
+	Update(role *model.Role) error
+	// Delete returns model.ErrRoleInUse while users have the role.
+	Delete(name model.UserRole) error
+}
+
+type APIKeyRepository interface {
+	// Create stores the key with its permissions.
+	Create(key *model.APIKey) error
+	Get(id uuid.UUID) (*model.APIKey, bool)
+	GetByHash(hash string) (*model.APIKey, bool)
+	// List returns the keys of the user, of every user when userID is uuid.Nil.
+	List(userID uuid.UUID) ([]model.APIKey, error)
+	Revoke(id uuid.UUID) error
+	Touch(id uuid.UUID, at time.Time) error
+}
+
+type SessionRepository interface {
+	Create(session *model.Session) error
+	Get(id uuid.UUID) (*model.Session, bool)
+	// ListActive returns the sessions that are neither revoked nor expired, last seen first.
+	ListActive(userID uuid.UUID) ([]model.Session, error)
+	Touch(id uuid.UUID, seenAt time.Time) error
+	// Extend is called on refresh, the session lives as long as its last refresh token.
+	Extend(id uuid.UUID, seenAt, expiresAt time.Time) error
+	// Revoke and RevokeAllByUser also revoke the refresh tokens of the sessions.
+	Revoke(id uuid.UUID) error
+	RevokeAllByUser(userID uuid.UUID) error
+}
+
+type IdentityRepository interface {
+	Get(issuer, subject string) (*model.AuthIdentity, bool)
+	Link(identity *model.AuthIdentity) error
+	// Provision creates the user with its profile and links the identity to it.
+	Provision(user *model.AuthUser, profile *model.User, identity *model.AuthIdentity) error
+	// IsLinked reports whether the user signs in through a directory or identity provider.
+	IsLinked(userID uuid.UUID) (bool, error)
+}
+
+type OIDCStateRepository interface {
+	// Create stores the state and drops the expired ones.
+	Create(state *model.OIDCState) error
+	// Use deletes an unexpired state and returns it.
+	Use(stateHash string) (*model.OIDCState, bool, error)
+}
+
+type InvitationRepository interface {
+	// Create stores the invitation and revokes the pending invitations of the same email.
+	Create(invitation *model.Invitation) error
+	// Get and GetByHash return pending invitations only: not accepted, revoked or expired.
+	Get(id uuid.UUID) (*model.Invitation, bool)
+	GetByHash(tokenHash string) (*model.Invitation, bool)
+	ListPending() ([]model.Invitation, error)
+	Revoke(id uuid.UUID) error
+	// Accept marks a pending invitation accepted and creates the user with its profile.
+	Accept(id uuid.UUID, user *model.AuthUser, profile *model.User) (bool, error)
+}
+
+type ImpersonationLogRepository interface {
+	Create(log *model.ImpersonationLog) error
+	// List returns the matching records, newest first, and their total count.
+	List(query model.ImpersonationLogQuery) ([]model.ImpersonationLog, int64, error)
+}
+
+// AuthEventRepository is append-only, the table refuses updates and deletes.
+type AuthEventRepository interface {
+	Create(event *model.AuthEvent) error
+	// List returns the matching events, newest first, and their total count.
+	List(query model.AuthEventQuery) ([]model.AuthEvent, int64, error)
+	// Export passes every matching event to write in batches, newest first.
+	Export(query model.AuthEventQuery, write func([]model.AuthEvent) error) error
+}
+
+type ContactRepository interface {
+	// Create stores the contact with its emails and phones.
+	Create(contact *model.Contact) error
+	Get(id uuid.UUID) (*model.Contact, bool)
+	// List returns a page of the matching contacts and their total count.
+	List(query model.ContactQuery) ([]model.Contact, int64, error)
+	// Update replaces the fields, emails and phones of the contact.
+	Update(contact *model.Contact) error
+	// Delete keeps the tasks linked to the contact, they lose the link.
+	Delete(id uuid.UUID) error
+	// Count returns how many of the ids belong to contacts.
+	Count(ids []uuid.UUID) (int64, error)
+}
+
+type CompanyRepository interface {
+	// Create stores the company with its addresses.
+	Create(company *model.Company) error
+	Get(id uuid.UUID) (*model.Company, bool)
+	// List returns a page of the matching companies and their total count.
+	List(query model.CompanyQuery) ([]model.Company, int64, error)
+	// InSubtree reports whether id is the root company or one of its subsidiaries, at any depth.
+	InSubtree(rootID, id uuid.UUID) (bool, error)
+	// Update replaces the fields and the addresses of the company.
+	Update(company *model.Company) error
+	// Delete keeps the subsidiaries, the contacts and the tasks, they lose the link to the company.
+	Delete(id uuid.UUID) error
+}
+
+type PipelineRepository interface {
+	// Create stores the pipeline with its stages.
+	Create(pipeline *model.Pipeline) error
+	Get(id uuid.UUID) (*model.Pipeline, bool)
+	// List returns every pipeline with its stages, by name.
+	List() ([]model.Pipeline, error)
+	// Update replaces the name and the stages, stages with a known ID are kept.
+	// It returns model.ErrStageInUse when a removed stage has deals.
+	Update(pipeline *model.Pipeline) error
+	// Delete returns model.ErrPipelineInUse when the pipeline has deals.
+	Delete(id uuid.UUID) error
+}
+
+type DealRepository interface {
+	// Create stores the deal with its contacts and records the stage it starts in.
+	Create(deal *model.Deal, changedBy uuid.UUID) error
+	Get(id uuid.UUID) (*model.Deal, bool)
+	// List returns a page of the matching deals and their total count.
+	List(query model.DealQuery) ([]model.Deal, int64, error)
+	// Totals sums the matching deals by stage and currency, pagination and sort are ignored.
+	Totals(query model.DealQuery) ([]model.DealTotal, error)
+	// Update replaces the fields and the contacts of the deal and records a stage change.
+	Update(deal *model.Deal, changedBy uuid.UUID) error
+	// Delete keeps the tasks linked to the deal, they lose the link.
+	Delete(id uuid.UUID) error
+	// History returns the stage changes of the deal, oldest first.
+	History(id uuid.UUID) ([]model.DealStageChange, error)
+}
+
+type TaskRepository interface {
+	Create(task *model.Task) error
+	Get(id uuid.UUID) (*model.Task, bool)
+	// List returns a page of the matching tasks and their total count.
+	List(query model.TaskQuery) ([]model.Task, int64, error)
+	Update(task *model.Task) error
+	Delete(id uuid.UUID) error
+	// ClaimDueSoon marks up to limit open, assigned tasks due until then as
+	// reminded at now and returns them, concurrent callers claim distinct tasks.
+	ClaimDueSoon(until, now time.Time, limit int) ([]model.Task, error)
+}
+
+type ActivityRepository interface {
+	// Create stores the activity with its participants.
+	Create(activity *model.Activity) error
+	Get(id uuid.UUID) (*model.Activity, bool)
+	// Update replaces the fields and the participants of the activity, the record is kept.
+	Update(activity *model.Activity) error
+	Delete(id uuid.UUID) error
+	// CreateEvent appends a system event to the timeline of its record.
+	CreateEvent(event *model.TimelineEvent) error
+	// Timeline returns a page of the activities and the events of the record,
+	// newest first, and their total count.
+	Timeline(entityType model.RecordType, entityID uuid.UUID, page model.Pagination) ([]model.TimelineEntry, int64, error)
+}
+
+type CustomFieldRepository interface {
+	// List returns the definitions of the entity type by key, of every type when it's empty.
+	List(entityType model.RecordType) ([]model.CustomFieldDefinition, error)
+	Get(id uuid.UUID) (*model.CustomFieldDefinition, bool)
+	GetByKey(entityType model.RecordType, key string) (*model.CustomFieldDefinition, bool)
+	Create(definition *model.CustomFieldDefinition) error
+	// Update replaces the label, the flags, the options and the limits, the key and the type are kept.
+	Update(definition *model.CustomFieldDefinition) error
+	// Delete removes the definition and the values the records have for it.
+	Delete(id uuid.UUID) error
+	// IsTaken reports whether a record other than exceptID has the value in the field.
+	IsTaken(entityType model.RecordType, key string, value interface{}, exceptID uuid.UUID) (bool, error)
+}
+
+type TagRepository interface {
+	// List returns every tag by name with the number of records having it.
+	List() ([]model.Tag, error)
+	Get(id uuid.UUID) (*model.Tag, bool)
+	// GetByName matches the name case-insensitively.
+	GetByName(name string) (*model.Tag, bool)
+	Create(tag *model.Tag) error
+	// Update replaces the name, the color and the description of the tag.
+	Update(tag *model.Tag) error
+	// Delete removes the tag from every record.
+	Delete(id uuid.UUID) error
+	// Merge gives the records of the source tags the target tag and deletes the sources.
+	Merge(targetID uuid.UUID, sourceIDs []uuid.UUID) error
+	// Count returns how many of the ids belong to tags.
+	Count(ids []uuid.UUID) (int64, error)
+	// ForEntity returns the tags of the record by name.
+	ForEntity(entityType model.RecordType, entityID uuid.UUID) ([]model.Tag, error)
+	// Bulk gives the records the added tags and takes the removed ones away in
+	// one transaction, the tags the records have already are kept.
+	Bulk(bulk *model.TagBulk) error
+	// ExistingEntities returns the ids that are records of the entity type.
+	ExistingEntities(entityType model.RecordType, ids []uuid.UUID) ([]uuid.UUID, error)
+}
+
+type ImportRepository interface {
+	Create(job *model.Import) error
+	// Get returns the import without its file.
+	Get(id uuid.UUID) (*model.Import, bool)
+	// List returns the imports of the owner without their files, newest first.
+	List(ownerID uuid.UUID, page model.Pagination) ([]model.Import, int64, error)
+	// Data returns the uploaded file of the import.
+	Data(id uuid.UUID) ([]byte, error)
+	// UpdateMapping replaces the mapping of an uploaded import, it reports false
+	// once the import was queued.
+	UpdateMapping(id uuid.UUID, mapping model.ImportMapping) (bool, error)
+	// Queue queues an uploaded import, it reports false when it was queued already.
+	Queue(id uuid.UUID) (bool, error)
+	// Claim marks the oldest queued import running and returns it with its file.
+	// Running imports not updated since staleBefore are taken over.
+	Claim(staleBefore, now time.Time) (*model.Import, bool, error)
+	// SaveBatch creates the contacts and the row errors of the batch and moves
+	// the progress on in one transaction. It returns model.ErrImportTakenOver when the
+	// import isn't running or another worker saved the rows.
+	SaveBatch(batch *model.ImportBatch) error
+	// Finish marks a running import completed or failed.
+	Finish(id uuid.UUID, status model.ImportStatus, message string, now time.Time) error
+	// Errors returns the row errors of the import by row.
+	Errors(id uuid.UUID) ([]model.ImportRowError, error)
 }
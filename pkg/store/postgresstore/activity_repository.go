@@ -0,0 +1,224 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// timelineUnion selects the entries of the timeline of a record, the activities
+// and the events share the occurred_at ordering.
+const timelineUnion = `SELECT 'activity' AS kind, id, occurred_at FROM activities
+	WHERE entity_type = @type AND entity_id = @id
+	UNION ALL
+	SELECT 'event' AS kind, id, occurred_at FROM timeline_events
+	WHERE entity_type = @type AND entity_id = @id`
+
+type ActivityRepository struct {
+	store *PostgresStore
+}
+
+func NewActivityRepository(store *PostgresStore) *ActivityRepository {
+	return &ActivityRepository{store: store}
+}
+
+func (r *ActivityRepository) Create(activity *model.Activity) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(activity).Error
+		if err != nil {
+			return err
+		}
+
+		return createActivityParticipants(tx, activity)
+	})
+}
+
+func (r *ActivityRepository) Get(id uuid.UUID) (*model.Activity, bool) {
+	var activity *model.Activity
+
+	result := r.store.DB.Where("id=?", id).Find(&activity)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	activities := []model.Activity{*activity}
+
+	err := r.loadParticipants(activities)
+	if err != nil {
+		return nil, false
+	}
+
+	return &activities[0], true
+}
+
+func (r *ActivityRepository) Update(activity *model.Activity) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(activity).
+			Select("type", "body", "occurred_at", "duration", "updated_at").
+			Updates(activity).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.ActivityParticipant{}, "activity_id=?", activity.ID).Error
+		if err != nil {
+			return err
+		}
+
+		return createActivityParticipants(tx, activity)
+	})
+}
+
+func (r *ActivityRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Delete(&model.Activity{}, "id=?", id).Error
+}
+
+func (r *ActivityRepository) CreateEvent(event *model.TimelineEvent) error {
+	return r.store.DB.Create(event).Error
+}
+
+func (r *ActivityRepository) Timeline(
+	entityType model.RecordType,
+	entityID uuid.UUID,
+	page model.Pagination,
+) ([]model.TimelineEntry, int64, error) {
+	var total int64
+
+	params := []interface{}{sql.Named("type", entityType), sql.Named("id", entityID)}
+
+	err := r.store.DB.Raw("SELECT count(*) FROM ("+timelineUnion+") AS timeline", params...).Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		Kind       string
+		ID         uuid.UUID
+		OccurredAt time.Time
+	}
+
+	err = r.store.DB.Raw("SELECT kind, id, occurred_at FROM ("+timelineUnion+") AS timeline "+
+		"ORDER BY occurred_at DESC, id DESC LIMIT @limit OFFSET @offset",
+		append(params, sql.Named("limit", page.PerPage), sql.Named("offset", page.Offset()))...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var activityIDs, eventIDs []uuid.UUID
+
+	for _, row := range rows {
+		if row.Kind == model.TimelineEntryActivity {
+			activityIDs = append(activityIDs, row.ID)
+		} else {
+			eventIDs = append(eventIDs, row.ID)
+		}
+	}
+
+	activities := make(map[uuid.UUID]*model.Activity, len(activityIDs))
+	events := make(map[uuid.UUID]*model.TimelineEvent, len(eventIDs))
+
+	if len(activityIDs) > 0 {
+		var found []model.Activity
+
+		err = r.store.DB.Where("id IN ?", activityIDs).Find(&found).Error
+		if err != nil {
+			return nil, 0, err
+		}
+
+		err = r.loadParticipants(found)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i := range found {
+			activities[found[i].ID] = &found[i]
+		}
+	}
+
+	if len(eventIDs) > 0 {
+		var found []model.TimelineEvent
+
+		err = r.store.DB.Where("id IN ?", eventIDs).Find(&found).Error
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i := range found {
+			events[found[i].ID] = &found[i]
+		}
+	}
+
+	entries := make([]model.TimelineEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := model.TimelineEntry{Kind: row.Kind, OccurredAt: row.OccurredAt}
+		if row.Kind == model.TimelineEntryActivity {
+			entry.Activity = activities[row.ID]
+		} else {
+			entry.Event = events[row.ID]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
+
+// loadParticipants sets the participant IDs of the activities in list order.
+func (r *ActivityRepository) loadParticipants(activities []model.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(activities))
+	for i := range activities {
+		ids = append(ids, activities[i].ID)
+	}
+
+	var participants []model.ActivityParticipant
+
+	err := r.store.DB.Where("activity_id IN ?", ids).Order("position").Find(&participants).Error
+	if err != nil {
+		return err
+	}
+
+	byActivity := make(map[uuid.UUID][]uuid.UUID, len(activities))
+	for _, participant := range participants {
+		byActivity[participant.ActivityID] = append(byActivity[participant.ActivityID], participant.UserID)
+	}
+
+	for i := range activities {
+		activities[i].ParticipantIDs = byActivity[activities[i].ID]
+		if activities[i].ParticipantIDs == nil {
+			activities[i].ParticipantIDs = []uuid.UUID{}
+		}
+	}
+
+	return nil
+}
+
+func createActivityParticipants(tx *gorm.DB, activity *model.Activity) error {
+	if len(activity.ParticipantIDs) == 0 {
+		return nil
+	}
+
+	participants := make([]model.ActivityParticipant, 0, len(activity.ParticipantIDs))
+	for i, userID := range activity.ParticipantIDs {
+		participants = append(participants, model.ActivityParticipant{ActivityID: activity.ID, Position: i, UserID: userID})
+	}
+
+	return tx.Create(&participants).Error
+}
+
+// deleteTimeline deletes the activities and the events of a deleted record.
+func deleteTimeline(tx *gorm.DB, entityType model.RecordType, id uuid.UUID) error {
+	err := tx.Delete(&model.Activity{}, "entity_type=? AND entity_id=?", entityType, id).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(&model.TimelineEvent{}, "entity_type=? AND entity_id=?", entityType, id).Error
+}
@@ -0,0 +1,84 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestActivityRepository_Timeline() {
+	users := s.AuthUserFixture.List()
+	err := s.store.DB.Create(&users).Error
+	s.Nil(err)
+
+	user, participant := users[0], users[1]
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	call := &model.Activity{Type: model.ActivityCall, OccurredAt: now.Add(-2 * time.Hour), Duration: 15, AuthorID: &user.ID,
+		EntityType: model.RecordUser, EntityID: user.ID, ParticipantIDs: []uuid.UUID{participant.ID, user.ID}}
+	note := &model.Activity{Type: model.ActivityNote, Body: "Prefers email", OccurredAt: now, AuthorID: &user.ID,
+		EntityType: model.RecordUser, EntityID: user.ID}
+
+	for _, activity := range []*model.Activity{call, note} {
+		err = s.store.Activity().Create(activity)
+		s.Nil(err)
+	}
+
+	event := &model.TimelineEvent{EntityType: model.RecordUser, EntityID: user.ID, Kind: model.EventProfileUpdated,
+		ActorID: &user.ID, Changes: model.FieldChanges{{Field: "phone", From: "", To: "+380681234567"}}, OccurredAt: now.Add(-time.Hour)}
+	err = s.store.Activity().CreateEvent(event)
+	s.Nil(err)
+
+	entries, total, err := s.store.Activity().Timeline(model.RecordUser, user.ID, model.Pagination{Page: 1, PerPage: 2})
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Len(entries, 2)
+	s.Equal(note.ID, entries[0].Activity.ID)
+	s.Equal(event.ID, entries[1].Event.ID)
+	s.Equal(event.Changes, entries[1].Event.Changes)
+
+	entries, _, err = s.store.Activity().Timeline(model.RecordUser, user.ID, model.Pagination{Page: 2, PerPage: 2})
+	s.Nil(err)
+	s.Len(entries, 1)
+	s.Equal(call.ID, entries[0].Activity.ID)
+	s.Equal([]uuid.UUID{participant.ID, user.ID}, entries[0].Activity.ParticipantIDs)
+
+	call.ParticipantIDs = []uuid.UUID{user.ID}
+	call.Duration = 30
+	err = s.store.Activity().Update(call)
+	s.Nil(err)
+
+	actual, exists := s.store.Activity().Get(call.ID)
+	s.True(exists)
+	s.Equal(30, actual.Duration)
+	s.Equal([]uuid.UUID{user.ID}, actual.ParticipantIDs)
+
+	err = s.store.Activity().Delete(note.ID)
+	s.Nil(err)
+
+	_, exists = s.store.Activity().Get(note.ID)
+	s.False(exists)
+}
+
+func (s *StoreSuite) TestActivityRepository_DeletedRecord() {
+	contact := &model.Contact{LastName: "Doe"}
+	err := s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	meeting := &model.Activity{Type: model.ActivityMeeting, OccurredAt: time.Now().UTC().Truncate(time.Second),
+		EntityType: model.RecordContact, EntityID: contact.ID}
+	err = s.store.Activity().Create(meeting)
+	s.Nil(err)
+
+	_, total, err := s.store.Activity().Timeline(model.RecordContact, contact.ID, model.Pagination{Page: 1, PerPage: 20})
+	s.Nil(err)
+	s.Equal(int64(1), total)
+
+	err = s.store.Contact().Delete(contact.ID)
+	s.Nil(err)
+
+	_, exists := s.store.Activity().Get(meeting.ID)
+	s.False(exists)
+}
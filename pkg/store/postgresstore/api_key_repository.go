@@ -0,0 +1,124 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	store *PostgresStore
+}
+
+func NewAPIKeyRepository(store *PostgresStore) *APIKeyRepository {
+	return &APIKeyRepository{store: store}
+}
+
+func (r *APIKeyRepository) Create(key *model.APIKey) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(key).Error
+		if err != nil {
+			return err
+		}
+
+		grants := make([]model.APIKeyPermission, 0, len(key.Permissions))
+		for _, permission := range key.Permissions {
+			grants = append(grants, model.APIKeyPermission{APIKeyID: key.ID, Permission: permission})
+		}
+
+		if len(grants) == 0 {
+			return nil
+		}
+
+		return tx.Create(&grants).Error
+	})
+}
+
+func (r *APIKeyRepository) Get(id uuid.UUID) (*model.APIKey, bool) {
+	return r.getWhere("id=?", id)
+}
+
+func (r *APIKeyRepository) GetByHash(hash string) (*model.APIKey, bool) {
+	return r.getWhere("key_hash=?", hash)
+}
+
+func (r *APIKeyRepository) List(userID uuid.UUID) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+
+	query := r.store.DB.Order("created_at DESC")
+	if userID != uuid.Nil {
+		query = query.Where("user_id=?", userID)
+	}
+
+	err := query.Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(keys))
+	for i := range keys {
+		ids = append(ids, keys[i].ID)
+	}
+
+	var grants []model.APIKeyPermission
+
+	err = r.store.DB.Where("api_key_id IN ?", ids).Order("permission").Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[uuid.UUID][]model.Permission, len(keys))
+	for _, grant := range grants {
+		byKey[grant.APIKeyID] = append(byKey[grant.APIKeyID], grant.Permission)
+	}
+
+	for i := range keys {
+		keys[i].Permissions = byKey[keys[i].ID]
+		if keys[i].Permissions == nil {
+			keys[i].Permissions = []model.Permission{}
+		}
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(id uuid.UUID) error {
+	return r.store.DB.Model(&model.APIKey{}).
+		Where("id=? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *APIKeyRepository) Touch(id uuid.UUID, at time.Time) error {
+	return r.store.DB.Model(&model.APIKey{}).
+		Where("id=?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *APIKeyRepository) getWhere(query string, args ...interface{}) (*model.APIKey, bool) {
+	var key *model.APIKey
+
+	result := r.store.DB.Where(query, args...).Find(&key)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	permissions := []model.Permission{}
+
+	err := r.store.DB.Model(&model.APIKeyPermission{}).
+		Where("api_key_id=?", key.ID).
+		Order("permission").
+		Pluck("permission", &permissions).Error
+	if err != nil {
+		return nil, false
+	}
+
+	key.Permissions = permissions
+
+	return key, true
+}
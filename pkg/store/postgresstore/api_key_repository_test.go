@@ -0,0 +1,66 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestAPIKeyRepository_CreateGetByHash() {
+	user := s.AuthUserFixture.One()
+	user.ServiceAccount = true
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	key := &model.APIKey{
+		UserID:      user.ID,
+		Name:        "billing",
+		Prefix:      "crm_0123456789ab",
+		KeyHash:     "hash",
+		Permissions: []model.Permission{model.PermUsersRead, model.PermRolesRead},
+	}
+
+	err = s.store.APIKey().Create(key)
+	s.Nil(err)
+
+	actual, exists := s.store.APIKey().GetByHash("hash")
+	s.Equal(true, exists)
+	s.Equal(key.ID, actual.ID)
+	s.Equal([]model.Permission{model.PermRolesRead, model.PermUsersRead}, actual.Permissions)
+
+	_, exists = s.store.APIKey().GetByHash("other")
+	s.Equal(false, exists)
+
+	keys, err := s.store.APIKey().List(user.ID)
+	s.Nil(err)
+	s.Len(keys, 1)
+	s.Equal(actual.Permissions, keys[0].Permissions)
+
+	keys, err = s.store.APIKey().List(uuid.NewV4())
+	s.Nil(err)
+	s.Len(keys, 0)
+}
+
+func (s *StoreSuite) TestAPIKeyRepository_RevokeTouch() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	key := &model.APIKey{UserID: user.ID, Name: "support", Prefix: "crm_ba9876543210", KeyHash: "hash"}
+	err = s.store.APIKey().Create(key)
+	s.Nil(err)
+
+	now := time.Now()
+	err = s.store.APIKey().Touch(key.ID, now)
+	s.Nil(err)
+
+	err = s.store.APIKey().Revoke(key.ID)
+	s.Nil(err)
+
+	actual, exists := s.store.APIKey().Get(key.ID)
+	s.Equal(true, exists)
+	s.NotNil(actual.LastUsedAt)
+	s.NotNil(actual.RevokedAt)
+	s.Equal(false, actual.IsUsable(now))
+}
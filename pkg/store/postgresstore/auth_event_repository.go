@@ -0,0 +1,88 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many events Export loads at once.
+const exportBatchSize = 500
+
+type AuthEventRepository struct {
+	store *PostgresStore
+}
+
+func NewAuthEventRepository(store *PostgresStore) *AuthEventRepository {
+	return &AuthEventRepository{store: store}
+}
+
+func (r *AuthEventRepository) Create(event *model.AuthEvent) error {
+	return r.store.DB.Create(event).Error
+}
+
+func (r *AuthEventRepository) List(query model.AuthEventQuery) ([]model.AuthEvent, int64, error) {
+	var total int64
+
+	events := []model.AuthEvent{}
+	db := r.filter(query)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("created_at DESC, id").
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *AuthEventRepository) Export(query model.AuthEventQuery, write func([]model.AuthEvent) error) error {
+	events := []model.AuthEvent{}
+
+	return r.filter(query).
+		Order("created_at DESC, id").
+		FindInBatches(&events, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			return write(events)
+		}).Error
+}
+
+func (r *AuthEventRepository) filter(query model.AuthEventQuery) *gorm.DB {
+	db := r.store.DB.Model(&model.AuthEvent{})
+
+	if query.Type != "" {
+		db = db.Where("type=?", query.Type)
+	}
+
+	if query.UserID != "" {
+		db = db.Where("actor_id=? OR target_id=?", query.UserID, query.UserID)
+	}
+
+	if query.Username != "" {
+		db = db.Where("lower(username)=lower(?)", query.Username)
+	}
+
+	if query.IP != "" {
+		db = db.Where("ip=?", query.IP)
+	}
+
+	if query.Success != nil {
+		db = db.Where("success=?", *query.Success)
+	}
+
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+
+	return db
+}
@@ -0,0 +1,99 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func newAuthEvent(eventType model.AuthEventType, userID uuid.UUID, success bool, createdAt time.Time) *model.AuthEvent {
+	return &model.AuthEvent{
+		Type:      eventType,
+		Success:   success,
+		ActorID:   &userID,
+		TargetID:  &userID,
+		Username:  "jane",
+		IP:        "10.0.0.1",
+		UserAgent: "curl/8.0",
+		CreatedAt: createdAt,
+	}
+}
+
+func (s *StoreSuite) TestAuthEventRepository_List() {
+	userID, otherID := uuid.NewV4(), uuid.NewV4()
+	now := time.Now()
+
+	failed := newAuthEvent(model.AuthEventLogin, userID, false, now.Add(-2*time.Minute))
+	succeeded := newAuthEvent(model.AuthEventLogin, userID, true, now.Add(-time.Minute))
+	other := newAuthEvent(model.AuthEventRefresh, otherID, true, now)
+
+	for _, event := range []*model.AuthEvent{failed, succeeded, other} {
+		err := s.store.AuthEvent().Create(event)
+		s.Nil(err)
+	}
+
+	query := model.AuthEventQuery{UserID: userID.String()}
+	s.True(query.IsValid())
+
+	events, total, err := s.store.AuthEvent().List(query)
+	s.Nil(err)
+	s.Equal(int64(2), total)
+	s.Equal(succeeded.ID, events[0].ID)
+	s.Equal(failed.ID, events[1].ID)
+
+	success := false
+	query = model.AuthEventQuery{Type: model.AuthEventLogin, Success: &success}
+	s.True(query.IsValid())
+
+	events, total, err = s.store.AuthEvent().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(failed.ID, events[0].ID)
+
+	from := now.Add(-90 * time.Second)
+	query = model.AuthEventQuery{From: &from, Pagination: model.Pagination{Page: 2, PerPage: 1}}
+	s.True(query.IsValid())
+
+	events, total, err = s.store.AuthEvent().List(query)
+	s.Nil(err)
+	s.Equal(int64(2), total)
+	s.Len(events, 1)
+	s.Equal(succeeded.ID, events[0].ID)
+}
+
+func (s *StoreSuite) TestAuthEventRepository_Export() {
+	userID := uuid.NewV4()
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		err := s.store.AuthEvent().Create(newAuthEvent(model.AuthEventLogin, userID, true, now.Add(time.Duration(i)*time.Second)))
+		s.Nil(err)
+	}
+
+	query := model.AuthEventQuery{UserID: userID.String()}
+	s.True(query.IsValid())
+
+	exported := []model.AuthEvent{}
+	err := s.store.AuthEvent().Export(query, func(events []model.AuthEvent) error {
+		exported = append(exported, events...)
+
+		return nil
+	})
+	s.Nil(err)
+	s.Len(exported, 3)
+	s.True(exported[0].CreatedAt.After(exported[1].CreatedAt))
+}
+
+func (s *StoreSuite) TestAuthEventRepository_AppendOnly() {
+	event := newAuthEvent(model.AuthEventLogin, uuid.NewV4(), true, time.Now())
+
+	err := s.store.AuthEvent().Create(event)
+	s.Nil(err)
+
+	err = s.store.DB.Model(event).Update("success", false).Error
+	s.NotNil(err)
+
+	err = s.store.DB.Delete(event).Error
+	s.NotNil(err)
+}
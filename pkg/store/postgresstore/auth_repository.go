@@ -1,7 +1,12 @@
 package postgresstore
 
 import (
+	"database/sql"
+	"strings"
+	"time"
+
 	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
 
 	"crm-system/pkg/model"
 )
@@ -25,6 +30,17 @@ func (r *AuthRepository) GetByUsername(username string) (*model.AuthUser, error)
 	return authUser, nil
 }
 
+func (r *AuthRepository) GetByEmail(email string) (*model.AuthUser, bool) {
+	var authUser *model.AuthUser
+
+	result := r.store.DB.Where("lower(email)=lower(?)", email).Find(&authUser)
+	if result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return authUser, true
+}
+
 func (r *AuthRepository) Create(user *model.AuthUser) error {
 	tx := r.store.DB.Begin()
 
@@ -44,20 +60,29 @@ func (r *AuthRepository) Create(user *model.AuthUser) error {
 }
 
 func (r *AuthRepository) Delete(userID uuid.UUID) error {
-	tx := r.store.DB.Begin()
-	err := tx.Delete(&model.User{}, "user_id=?", userID).Error
-	if err != nil {
-		return err
-	}
-
-	err = tx.Delete(&model.AuthUser{}, "id=?", userID).Error
-	if err != nil {
-		return err
-	}
-
-	tx.Commit()
-
-	return nil
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.User{}, "user_id=?", userID).Error
+		if err != nil {
+			return err
+		}
+
+		err = deleteTimeline(tx, model.RecordUser, userID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTags(tx, model.RecordUser, userID)
+		if err != nil {
+			return err
+		}
+
+		err = unlinkTasks(tx, model.RecordUser, userID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.AuthUser{}, "id=?", userID).Error
+	})
 }
 
 func (r *AuthRepository) Get(id uuid.UUID) (*model.AuthUser, bool) {
@@ -71,23 +96,160 @@ func (r *AuthRepository) Get(id uuid.UUID) (*model.AuthUser, bool) {
 	return user, true
 }
 
-func (r *AuthRepository) ChangePassword(id uuid.UUID, pass string) error {
-	var user *model.AuthUser
-	tx := r.store.DB.Begin()
+func (r *AuthRepository) ChangePassword(id uuid.UUID, pass string, history int) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var user *model.AuthUser
+
+		result := tx.Where("id=?", id).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ErrRecordNotFound
+		}
+
+		if user.Password != "" && history > 1 {
+			err := tx.Create(&model.PasswordHistory{UserID: id, PasswordHash: user.Password}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// the current hash is checked from auth_users, the table keeps the ones before it
+		prune := tx.Where("user_id=?", id)
+		if history > 1 {
+			prune = prune.Where("id NOT IN (?)", tx.Model(&model.PasswordHistory{}).
+				Select("id").
+				Where("user_id=?", id).
+				Order("created_at DESC").
+				Limit(history-1))
+		}
+
+		err := prune.Delete(&model.PasswordHistory{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.AuthUser{}).
+			Where("id=?", id).
+			Updates(map[string]interface{}{
+				"password":            pass,
+				"password_changed_at": time.Now(),
+			}).Error
+	})
+}
+
+func (r *AuthRepository) RehashPassword(id uuid.UUID, pass string) error {
+	return r.store.DB.Model(&model.AuthUser{}).Where("id=?", id).Update("password", pass).Error
+}
+
+func (r *AuthRepository) PasswordHistory(id uuid.UUID, limit int) ([]string, error) {
+	hashes := []string{}
 
-	err := tx.Where("id=?", id).Find(&user).Error
+	err := r.store.DB.Model(&model.PasswordHistory{}).
+		Where("user_id=?", id).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (r *AuthRepository) SetTOTP(id uuid.UUID, secret string, enabled bool) error {
+	return r.store.DB.Model(&model.AuthUser{}).
+		Where("id=?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":  secret,
+			"totp_enabled": enabled,
+		}).Error
+}
+
+// UseTOTPStep moves the step forward in a single statement, so a code can't be
+// accepted twice concurrently.
+func (r *AuthRepository) UseTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.store.DB.Model(&model.AuthUser{}).
+		Where("id=? AND totp_step<?", id, step).
+		Update("totp_step", step)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) List(query model.UserListQuery) ([]model.UserAccount, int64, error) {
+	var total int64
+
+	accounts := []model.UserAccount{}
+	db := r.accounts()
+
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		db = db.Where("auth_users.username ILIKE @p OR auth_users.email ILIKE @p OR users.name ILIKE @p OR users.surname ILIKE @p",
+			sql.Named("p", pattern))
 	}
 
-	user.Password = pass
+	if query.Role != "" {
+		db = db.Where("auth_users.role=?", query.Role)
+	}
 
-	err = tx.Updates(user).Error
+	if query.Active != nil {
+		db = db.Where("auth_users.active=?", *query.Active)
+	}
+
+	if len(query.CustomFields) > 0 {
+		db = db.Where("users.custom_fields @> ?::jsonb", query.CustomFields)
+	}
+
+	db = tagFilter(db, model.RecordUser, "auth_users.id", query.TagFilter)
+
+	err := db.Count(&total).Error
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	tx.Commit()
+	err = db.Order("auth_users.username").
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Scan(&accounts).Error
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return nil
+	return accounts, total, nil
+}
+
+func (r *AuthRepository) GetAccount(id uuid.UUID) (*model.UserAccount, bool) {
+	var account model.UserAccount
+
+	result := r.accounts().Where("auth_users.id=?", id).Scan(&account)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &account, true
+}
+
+func (r *AuthRepository) SetRole(id uuid.UUID, role model.UserRole) error {
+	return r.store.DB.Model(&model.AuthUser{}).Where("id=?", id).Update("role", role).Error
+}
+
+func (r *AuthRepository) SetActive(id uuid.UUID, active bool) error {
+	return r.store.DB.Model(&model.AuthUser{}).Where("id=?", id).Update("active", active).Error
+}
+
+func (r *AuthRepository) accounts() *gorm.DB {
+	return r.store.DB.Table("auth_users").
+		Select("auth_users.id, auth_users.username, auth_users.email, auth_users.role, auth_users.active, " +
+			"auth_users.totp_enabled, auth_users.service_account, users.name, users.surname, users.phone, users.address, " +
+			"users.custom_fields").
+		Joins("LEFT JOIN users ON users.user_id = auth_users.id")
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes user input match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
 }
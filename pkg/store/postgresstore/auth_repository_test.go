@@ -78,7 +78,7 @@ func (s *StoreSuite) TestAuthRepository_ChangePassword() {
 
 	}
 	newPass := "newPass"
-	err := s.store.Auth().ChangePassword(users[0].ID, newPass)
+	err := s.store.Auth().ChangePassword(users[0].ID, newPass, 3)
 	s.Nil(err)
 
 	var actualUser *model.AuthUser
@@ -87,5 +87,157 @@ func (s *StoreSuite) TestAuthRepository_ChangePassword() {
 	s.Nil(err)
 
 	s.Equal(newPass, actualUser.Password)
+	s.True(actualUser.PasswordChangedAt.After(users[0].PasswordChangedAt))
 
 }
+
+func (s *StoreSuite) TestAuthRepository_PasswordHistory() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	for _, pass := range []string{"second", "third", "fourth"} {
+		err = s.store.Auth().ChangePassword(user.ID, pass, 3)
+		s.Nil(err)
+	}
+
+	// the current hash is not part of the history, two replaced ones are kept
+	hashes, err := s.store.Auth().PasswordHistory(user.ID, 5)
+	s.Nil(err)
+	s.Equal([]string{"third", "second"}, hashes)
+
+	err = s.store.Auth().RehashPassword(user.ID, "rehashed")
+	s.Nil(err)
+
+	hashes, err = s.store.Auth().PasswordHistory(user.ID, 1)
+	s.Nil(err)
+	s.Equal([]string{"third"}, hashes)
+
+	err = s.store.Auth().ChangePassword(user.ID, "fifth", 0)
+	s.Nil(err)
+
+	hashes, err = s.store.Auth().PasswordHistory(user.ID, 5)
+	s.Nil(err)
+	s.Empty(hashes)
+}
+
+func (s *StoreSuite) TestAuthRepository_SetTOTP() {
+	users := s.AuthUserFixture.List()
+
+	for i := range users {
+		err := s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+	}
+
+	err := s.store.Auth().SetTOTP(users[0].ID, "SECRET", true)
+	s.Nil(err)
+
+	user, _ := s.store.Auth().Get(users[0].ID)
+	s.Equal("SECRET", user.TOTPSecret)
+	s.Equal(true, user.TOTPEnabled)
+	s.Equal(users[0].Password, user.Password)
+
+	other, _ := s.store.Auth().Get(users[1].ID)
+	s.Equal(false, other.TOTPEnabled)
+}
+
+func (s *StoreSuite) TestAuthRepository_UseTOTPStep() {
+	user := s.AuthUserFixture.One()
+
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	used, err := s.store.Auth().UseTOTPStep(user.ID, 100)
+	s.Nil(err)
+	s.True(used)
+
+	used, err = s.store.Auth().UseTOTPStep(user.ID, 100)
+	s.Nil(err)
+	s.False(used)
+
+	used, err = s.store.Auth().UseTOTPStep(user.ID, 99)
+	s.Nil(err)
+	s.False(used)
+
+	used, err = s.store.Auth().UseTOTPStep(user.ID, 101)
+	s.Nil(err)
+	s.True(used)
+}
+
+func (s *StoreSuite) TestAuthRepository_GetByEmail() {
+	email := "user@example.com"
+	user := s.AuthUserFixture.One()
+	user.Email = &email
+
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	actual, exists := s.store.Auth().GetByEmail("User@Example.com")
+	s.Equal(true, exists)
+	s.Equal(user.ID, actual.ID)
+
+	_, exists = s.store.Auth().GetByEmail("unknown@example.com")
+	s.Equal(false, exists)
+}
+
+func (s *StoreSuite) TestAuthRepository_List() {
+	users := s.AuthUserFixture.List()
+
+	for i := range users {
+		users[i].Username = "list_" + users[i].Username
+		err := s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+	}
+
+	err := s.store.Auth().SetActive(users[0].ID, false)
+	s.Nil(err)
+
+	query := model.UserListQuery{Search: "LIST_"}
+	query.Normalize()
+
+	accounts, total, err := s.store.Auth().List(query)
+	s.Nil(err)
+	s.Equal(int64(len(users)), total)
+	s.Len(accounts, len(users))
+
+	active := false
+	query.Active = &active
+
+	accounts, total, err = s.store.Auth().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(users[0].ID, accounts[0].ID)
+}
+
+func (s *StoreSuite) TestAuthRepository_GetAccount() {
+	user := s.AuthUserFixture.One()
+
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.DB.Create(&model.User{UserID: user.ID, Name: "Name"}).Error
+	s.Nil(err)
+
+	account, exists := s.store.Auth().GetAccount(user.ID)
+	s.Equal(true, exists)
+	s.Equal(user.Username, account.Username)
+	s.Equal("Name", account.Name)
+	s.Equal(true, account.Active)
+
+	_, exists = s.store.Auth().GetAccount(uuid.NewV4())
+	s.Equal(false, exists)
+}
+
+func (s *StoreSuite) TestAuthRepository_SetRole() {
+	user := s.AuthUserFixture.One()
+	user.Role = model.BaseUserRole
+
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.Auth().SetRole(user.ID, model.AdminUserRole)
+	s.Nil(err)
+
+	actual, _ := s.store.Auth().Get(user.ID)
+	s.Equal(model.AdminUserRole, actual.Role)
+}
@@ -0,0 +1,215 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// companyOrders are the columns of the company sort fields, id keeps pages stable.
+var companyOrders = map[string][2]string{
+	"name":       {"lower(name), id", "lower(name) DESC, id"},
+	"created_at": {"created_at, id", "created_at DESC, id"},
+	"updated_at": {"updated_at, id", "updated_at DESC, id"},
+}
+
+type CompanyRepository struct {
+	store *PostgresStore
+}
+
+func NewCompanyRepository(store *PostgresStore) *CompanyRepository {
+	return &CompanyRepository{store: store}
+}
+
+func (r *CompanyRepository) Create(company *model.Company) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(company).Error
+		if err != nil {
+			return err
+		}
+
+		return createAddresses(tx, company)
+	})
+}
+
+func (r *CompanyRepository) Get(id uuid.UUID) (*model.Company, bool) {
+	var company *model.Company
+
+	result := r.store.DB.Where("id=?", id).Find(&company)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	companies := []model.Company{*company}
+
+	err := r.loadAddresses(companies)
+	if err != nil {
+		return nil, false
+	}
+
+	return &companies[0], true
+}
+
+func (r *CompanyRepository) List(query model.CompanyQuery) ([]model.Company, int64, error) {
+	var total int64
+
+	companies := []model.Company{}
+	db := r.filter(query)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	field, desc := query.SortField()
+
+	order := companyOrders[field][0]
+	if desc {
+		order = companyOrders[field][1]
+	}
+
+	err = db.Order(order).
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&companies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.loadAddresses(companies)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return companies, total, nil
+}
+
+func (r *CompanyRepository) InSubtree(rootID, id uuid.UUID) (bool, error) {
+	var count int64
+
+	err := r.store.DB.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM companies WHERE id = @root
+		UNION
+		SELECT companies.id FROM companies JOIN subtree ON companies.parent_id = subtree.id
+	) SELECT count(*) FROM subtree WHERE id = @id`, sql.Named("root", rootID), sql.Named("id", id)).
+		Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *CompanyRepository) Update(company *model.Company) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(company).
+			Select("name", "domain", "industry", "size", "parent_id", "owner_id", "updated_at").
+			Updates(company).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.CompanyAddress{}, "company_id=?", company.ID).Error
+		if err != nil {
+			return err
+		}
+
+		return createAddresses(tx, company)
+	})
+}
+
+func (r *CompanyRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := unlinkTasks(tx, model.RecordCompany, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTimeline(tx, model.RecordCompany, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTags(tx, model.RecordCompany, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Company{}, "id=?", id).Error
+	})
+}
+
+func (r *CompanyRepository) filter(query model.CompanyQuery) *gorm.DB {
+	db := r.store.DB.Model(&model.Company{})
+
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		db = db.Where("name ILIKE @p OR domain ILIKE @p", sql.Named("p", pattern))
+	}
+
+	if query.OwnerID != "" {
+		db = db.Where("owner_id=?", query.OwnerID)
+	}
+
+	if query.ParentID != "" {
+		db = db.Where("parent_id=?", query.ParentID)
+	}
+
+	if query.Industry != "" {
+		db = db.Where("lower(industry)=lower(?)", query.Industry)
+	}
+
+	if query.Size != "" {
+		db = db.Where("size=?", query.Size)
+	}
+
+	return tagFilter(db, model.RecordCompany, "companies.id", query.TagFilter)
+}
+
+// loadAddresses sets the addresses of the companies in list order.
+func (r *CompanyRepository) loadAddresses(companies []model.Company) error {
+	if len(companies) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(companies))
+	for i := range companies {
+		ids = append(ids, companies[i].ID)
+	}
+
+	var addresses []model.CompanyAddress
+
+	err := r.store.DB.Where("company_id IN ?", ids).Order("position").Find(&addresses).Error
+	if err != nil {
+		return err
+	}
+
+	byCompany := make(map[uuid.UUID][]model.CompanyAddress, len(companies))
+	for _, address := range addresses {
+		byCompany[address.CompanyID] = append(byCompany[address.CompanyID], address)
+	}
+
+	for i := range companies {
+		companies[i].Addresses = byCompany[companies[i].ID]
+		if companies[i].Addresses == nil {
+			companies[i].Addresses = []model.CompanyAddress{}
+		}
+	}
+
+	return nil
+}
+
+func createAddresses(tx *gorm.DB, company *model.Company) error {
+	if len(company.Addresses) == 0 {
+		return nil
+	}
+
+	for i := range company.Addresses {
+		company.Addresses[i].CompanyID = company.ID
+		company.Addresses[i].Position = i
+	}
+
+	return tx.Create(&company.Addresses).Error
+}
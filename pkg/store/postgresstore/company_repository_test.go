@@ -0,0 +1,155 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+)
+
+func (s *StoreSuite) TestCompanyRepository_CreateGetUpdate() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	company := &model.Company{
+		Name:    "Acme",
+		Domain:  "acme.example.com",
+		Size:    model.CompanySize51To200,
+		OwnerID: &user.ID,
+		Addresses: []model.CompanyAddress{
+			{Label: "hq", City: "Kyiv", Country: "Ukraine"},
+			{Label: "office", City: "Lviv", Country: "Ukraine"},
+		},
+	}
+
+	err = s.store.Company().Create(company)
+	s.Nil(err)
+
+	actual, exists := s.store.Company().Get(company.ID)
+	s.True(exists)
+	s.Equal("Acme", actual.Name)
+	s.Equal(user.ID, *actual.OwnerID)
+	s.Equal("Kyiv", actual.Addresses[0].City)
+	s.Equal("Lviv", actual.Addresses[1].City)
+
+	actual.Industry = "Manufacturing"
+	actual.Addresses = []model.CompanyAddress{{Street: "1 Main St"}}
+
+	err = s.store.Company().Update(actual)
+	s.Nil(err)
+
+	actual, exists = s.store.Company().Get(company.ID)
+	s.True(exists)
+	s.Equal("Manufacturing", actual.Industry)
+	s.Equal([]model.CompanyAddress{{CompanyID: company.ID, Street: "1 Main St"}}, actual.Addresses)
+
+	// companies outlive their owner
+	err = s.store.Auth().Delete(user.ID)
+	s.Nil(err)
+
+	actual, exists = s.store.Company().Get(company.ID)
+	s.True(exists)
+	s.Nil(actual.OwnerID)
+}
+
+func (s *StoreSuite) TestCompanyRepository_Hierarchy() {
+	parent := &model.Company{Name: "Acme"}
+	err := s.store.Company().Create(parent)
+	s.Nil(err)
+
+	child := &model.Company{Name: "Acme Labs", ParentID: &parent.ID}
+	err = s.store.Company().Create(child)
+	s.Nil(err)
+
+	grandchild := &model.Company{Name: "Acme Labs Research", ParentID: &child.ID}
+	err = s.store.Company().Create(grandchild)
+	s.Nil(err)
+
+	other := &model.Company{Name: "Globex"}
+	err = s.store.Company().Create(other)
+	s.Nil(err)
+
+	inSubtree, err := s.store.Company().InSubtree(parent.ID, grandchild.ID)
+	s.Nil(err)
+	s.True(inSubtree)
+
+	inSubtree, err = s.store.Company().InSubtree(parent.ID, parent.ID)
+	s.Nil(err)
+	s.True(inSubtree)
+
+	inSubtree, err = s.store.Company().InSubtree(child.ID, parent.ID)
+	s.Nil(err)
+	s.False(inSubtree)
+
+	inSubtree, err = s.store.Company().InSubtree(parent.ID, other.ID)
+	s.Nil(err)
+	s.False(inSubtree)
+
+	contact := &model.Contact{LastName: "Doe", CompanyID: &child.ID, CompanyRole: "CTO"}
+	err = s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	// deleting a company keeps its contacts and subsidiaries
+	err = s.store.Company().Delete(child.ID)
+	s.Nil(err)
+
+	_, exists := s.store.Company().Get(child.ID)
+	s.False(exists)
+
+	actual, exists := s.store.Company().Get(grandchild.ID)
+	s.True(exists)
+	s.Nil(actual.ParentID)
+
+	actualContact, exists := s.store.Contact().Get(contact.ID)
+	s.True(exists)
+	s.Nil(actualContact.CompanyID)
+}
+
+func (s *StoreSuite) TestCompanyRepository_List() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	ownerID := user.ID
+
+	companies := []*model.Company{
+		{Name: "Globex", Domain: "globex.example.com", OwnerID: &ownerID, Industry: "Energy"},
+		{Name: "acme", Domain: "acme.example.com", Size: model.CompanySize1To10},
+		{Name: "Initech", OwnerID: &ownerID, Industry: "Software", Size: model.CompanySize1To10},
+	}
+
+	for _, company := range companies {
+		err = s.store.Company().Create(company)
+		s.Nil(err)
+	}
+
+	query := model.CompanyQuery{Sorting: model.Sorting{Sort: "name"}}
+	s.True(query.IsValid())
+
+	actual, total, err := s.store.Company().List(query)
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Equal([]string{"acme", "Globex", "Initech"}, []string{actual[0].Name, actual[1].Name, actual[2].Name})
+
+	query = model.CompanyQuery{OwnerID: ownerID.String(), Size: model.CompanySize1To10}
+	s.True(query.IsValid())
+
+	actual, total, err = s.store.Company().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(companies[2].ID, actual[0].ID)
+
+	query = model.CompanyQuery{Industry: "energy"}
+	s.True(query.IsValid())
+
+	actual, total, err = s.store.Company().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(companies[0].ID, actual[0].ID)
+
+	query = model.CompanyQuery{Search: "acme.example"}
+	s.True(query.IsValid())
+
+	actual, total, err = s.store.Company().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(companies[1].ID, actual[0].ID)
+}
@@ -0,0 +1,249 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// contactOrders are the columns of the contact sort fields, id keeps pages stable.
+var contactOrders = map[string][2]string{
+	"name":       {"lower(last_name), lower(first_name), id", "lower(last_name) DESC, lower(first_name) DESC, id"},
+	"created_at": {"created_at, id", "created_at DESC, id"},
+	"updated_at": {"updated_at, id", "updated_at DESC, id"},
+}
+
+type ContactRepository struct {
+	store *PostgresStore
+}
+
+func NewContactRepository(store *PostgresStore) *ContactRepository {
+	return &ContactRepository{store: store}
+}
+
+func (r *ContactRepository) Create(contact *model.Contact) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(contact).Error
+		if err != nil {
+			return err
+		}
+
+		return createChannels(tx, contact)
+	})
+}
+
+func (r *ContactRepository) Get(id uuid.UUID) (*model.Contact, bool) {
+	var contact *model.Contact
+
+	result := r.store.DB.Where("id=?", id).Find(&contact)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	contacts := []model.Contact{*contact}
+
+	err := r.loadChannels(contacts)
+	if err != nil {
+		return nil, false
+	}
+
+	return &contacts[0], true
+}
+
+func (r *ContactRepository) List(query model.ContactQuery) ([]model.Contact, int64, error) {
+	var total int64
+
+	contacts := []model.Contact{}
+	db := r.filter(query)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	field, desc := query.SortField()
+
+	order := contactOrders[field][0]
+	if desc {
+		order = contactOrders[field][1]
+	}
+
+	err = db.Order(order).
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&contacts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.loadChannels(contacts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return contacts, total, nil
+}
+
+func (r *ContactRepository) Update(contact *model.Contact) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(contact).
+			Select("first_name", "last_name", "job_title", "company_id", "company_role", "owner_id", "source",
+				"lifecycle_stage", "updated_at").
+			Updates(contact).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.ContactEmail{}, "contact_id=?", contact.ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.ContactPhone{}, "contact_id=?", contact.ID).Error
+		if err != nil {
+			return err
+		}
+
+		return createChannels(tx, contact)
+	})
+}
+
+func (r *ContactRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := unlinkTasks(tx, model.RecordContact, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTimeline(tx, model.RecordContact, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTags(tx, model.RecordContact, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Contact{}, "id=?", id).Error
+	})
+}
+
+func (r *ContactRepository) Count(ids []uuid.UUID) (int64, error) {
+	var count int64
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := r.store.DB.Model(&model.Contact{}).Where("id IN ?", ids).Count(&count).Error
+
+	return count, err
+}
+
+func (r *ContactRepository) filter(query model.ContactQuery) *gorm.DB {
+	db := r.store.DB.Model(&model.Contact{})
+
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		db = db.Where("first_name ILIKE @p OR last_name ILIKE @p OR (first_name || ' ' || last_name) ILIKE @p OR job_title ILIKE @p "+
+			"OR EXISTS (SELECT 1 FROM contact_emails WHERE contact_emails.contact_id = contacts.id AND contact_emails.email ILIKE @p) "+
+			"OR EXISTS (SELECT 1 FROM contact_phones WHERE contact_phones.contact_id = contacts.id AND contact_phones.phone ILIKE @p)",
+			sql.Named("p", pattern))
+	}
+
+	if query.OwnerID != "" {
+		db = db.Where("owner_id=?", query.OwnerID)
+	}
+
+	if query.CompanyID != "" {
+		db = db.Where("company_id=?", query.CompanyID)
+	}
+
+	if query.LifecycleStage != "" {
+		db = db.Where("lifecycle_stage=?", query.LifecycleStage)
+	}
+
+	if query.Source != "" {
+		db = db.Where("lower(source)=lower(?)", query.Source)
+	}
+
+	return tagFilter(db, model.RecordContact, "contacts.id", query.TagFilter)
+}
+
+// loadChannels sets the emails and phones of the contacts in list order.
+func (r *ContactRepository) loadChannels(contacts []model.Contact) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(contacts))
+	for i := range contacts {
+		ids = append(ids, contacts[i].ID)
+	}
+
+	var emails []model.ContactEmail
+
+	err := r.store.DB.Where("contact_id IN ?", ids).Order("position").Find(&emails).Error
+	if err != nil {
+		return err
+	}
+
+	var phones []model.ContactPhone
+
+	err = r.store.DB.Where("contact_id IN ?", ids).Order("position").Find(&phones).Error
+	if err != nil {
+		return err
+	}
+
+	emailsByContact := make(map[uuid.UUID][]model.ContactEmail, len(contacts))
+	for _, email := range emails {
+		emailsByContact[email.ContactID] = append(emailsByContact[email.ContactID], email)
+	}
+
+	phonesByContact := make(map[uuid.UUID][]model.ContactPhone, len(contacts))
+	for _, phone := range phones {
+		phonesByContact[phone.ContactID] = append(phonesByContact[phone.ContactID], phone)
+	}
+
+	for i := range contacts {
+		contacts[i].Emails = emailsByContact[contacts[i].ID]
+		if contacts[i].Emails == nil {
+			contacts[i].Emails = []model.ContactEmail{}
+		}
+
+		contacts[i].Phones = phonesByContact[contacts[i].ID]
+		if contacts[i].Phones == nil {
+			contacts[i].Phones = []model.ContactPhone{}
+		}
+	}
+
+	return nil
+}
+
+func createChannels(tx *gorm.DB, contact *model.Contact) error {
+	for i := range contact.Emails {
+		contact.Emails[i].ContactID = contact.ID
+		contact.Emails[i].Position = i
+	}
+
+	for i := range contact.Phones {
+		contact.Phones[i].ContactID = contact.ID
+		contact.Phones[i].Position = i
+	}
+
+	if len(contact.Emails) > 0 {
+		err := tx.Create(&contact.Emails).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(contact.Phones) > 0 {
+		return tx.Create(&contact.Phones).Error
+	}
+
+	return nil
+}
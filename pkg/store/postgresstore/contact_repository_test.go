@@ -0,0 +1,124 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestContactRepository_CreateGetUpdate() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	contact := &model.Contact{
+		FirstName:      "Jane",
+		LastName:       "Doe",
+		OwnerID:        &user.ID,
+		LifecycleStage: model.LifecycleLead,
+		Emails: []model.ContactEmail{
+			{Email: "jane@example.com", Label: "work"},
+			{Email: "jane@home.example.com", Label: "home"},
+		},
+		Phones: []model.ContactPhone{{Phone: "+380441234567"}},
+	}
+
+	err = s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	actual, exists := s.store.Contact().Get(contact.ID)
+	s.True(exists)
+	s.Equal("Doe", actual.LastName)
+	s.Equal(user.ID, *actual.OwnerID)
+	s.Equal("jane@example.com", actual.Emails[0].Email)
+	s.Equal("jane@home.example.com", actual.Emails[1].Email)
+	s.Len(actual.Phones, 1)
+
+	actual.LifecycleStage = model.LifecycleCustomer
+	actual.Emails = []model.ContactEmail{{Email: "jane@doe.example.com"}}
+	actual.Phones = []model.ContactPhone{}
+
+	err = s.store.Contact().Update(actual)
+	s.Nil(err)
+
+	actual, exists = s.store.Contact().Get(contact.ID)
+	s.True(exists)
+	s.Equal(model.LifecycleCustomer, actual.LifecycleStage)
+	s.Equal([]model.ContactEmail{{ContactID: contact.ID, Email: "jane@doe.example.com"}}, actual.Emails)
+	s.Empty(actual.Phones)
+
+	// contacts outlive their owner
+	err = s.store.Auth().Delete(user.ID)
+	s.Nil(err)
+
+	actual, exists = s.store.Contact().Get(contact.ID)
+	s.True(exists)
+	s.Nil(actual.OwnerID)
+
+	err = s.store.Contact().Delete(contact.ID)
+	s.Nil(err)
+
+	_, exists = s.store.Contact().Get(contact.ID)
+	s.False(exists)
+}
+
+func (s *StoreSuite) TestContactRepository_List() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	ownerID := user.ID
+
+	contacts := []*model.Contact{
+		{FirstName: "Jane", LastName: "Doe", OwnerID: &ownerID, LifecycleStage: model.LifecycleCustomer},
+		{FirstName: "John", LastName: "Adams", LifecycleStage: model.LifecycleLead,
+			Emails: []model.ContactEmail{{Email: "john@acme.example.com"}}},
+		{FirstName: "Ann", LastName: "Brown", OwnerID: &ownerID, LifecycleStage: model.LifecycleLead,
+			Phones: []model.ContactPhone{{Phone: "+1 555 0100"}}},
+	}
+
+	for _, contact := range contacts {
+		err = s.store.Contact().Create(contact)
+		s.Nil(err)
+	}
+
+	query := model.ContactQuery{Sorting: model.Sorting{Sort: "name"}}
+	s.True(query.IsValid())
+
+	actual, total, err := s.store.Contact().List(query)
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Equal([]string{"Adams", "Brown", "Doe"}, []string{actual[0].LastName, actual[1].LastName, actual[2].LastName})
+
+	query = model.ContactQuery{OwnerID: ownerID.String(), LifecycleStage: model.LifecycleLead}
+	s.True(query.IsValid())
+
+	actual, total, err = s.store.Contact().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(contacts[2].ID, actual[0].ID)
+	s.Equal("+1 555 0100", actual[0].Phones[0].Phone)
+
+	for search, expected := range map[string]uuid.UUID{
+		"acme":     contacts[1].ID,
+		"jane doe": contacts[0].ID,
+		"555":      contacts[2].ID,
+	} {
+		query = model.ContactQuery{Search: search}
+		s.True(query.IsValid())
+
+		actual, total, err = s.store.Contact().List(query)
+		s.Nil(err)
+		s.Equal(int64(1), total, search)
+		s.Equal(expected, actual[0].ID, search)
+	}
+
+	query = model.ContactQuery{Sorting: model.Sorting{Sort: "-name"}, Pagination: model.Pagination{Page: 2, PerPage: 2}}
+	s.True(query.IsValid())
+
+	actual, total, err = s.store.Contact().List(query)
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Len(actual, 1)
+	s.Equal("Adams", actual[0].LastName)
+}
@@ -0,0 +1,117 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// customFieldTables are the tables whose records have a custom_fields column,
+// only user profiles have custom fields so far.
+var customFieldTables = map[model.RecordType]entityTable{
+	model.RecordUser: {table: "users", idColumn: "user_id"},
+}
+
+type CustomFieldRepository struct {
+	store *PostgresStore
+}
+
+func NewCustomFieldRepository(store *PostgresStore) *CustomFieldRepository {
+	return &CustomFieldRepository{store: store}
+}
+
+func (r *CustomFieldRepository) List(entityType model.RecordType) ([]model.CustomFieldDefinition, error) {
+	definitions := []model.CustomFieldDefinition{}
+	db := r.store.DB
+
+	if entityType != "" {
+		db = db.Where("entity_type=?", entityType)
+	}
+
+	err := db.Order("entity_type, key").Find(&definitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+func (r *CustomFieldRepository) Get(id uuid.UUID) (*model.CustomFieldDefinition, bool) {
+	var definition model.CustomFieldDefinition
+
+	result := r.store.DB.Where("id=?", id).Find(&definition)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &definition, true
+}
+
+func (r *CustomFieldRepository) GetByKey(entityType model.RecordType, key string) (*model.CustomFieldDefinition, bool) {
+	var definition model.CustomFieldDefinition
+
+	result := r.store.DB.Where("entity_type=? AND key=?", entityType, key).Find(&definition)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &definition, true
+}
+
+func (r *CustomFieldRepository) Create(definition *model.CustomFieldDefinition) error {
+	return r.store.DB.Create(definition).Error
+}
+
+func (r *CustomFieldRepository) Update(definition *model.CustomFieldDefinition) error {
+	return r.store.DB.Model(definition).
+		Select("label", "required", "unique", "options", "max_length", "min", "max", "updated_at").
+		Updates(definition).Error
+}
+
+func (r *CustomFieldRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var definition model.CustomFieldDefinition
+
+		result := tx.Where("id=?", id).Find(&definition)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		err := tx.Delete(&definition).Error
+		if err != nil {
+			return err
+		}
+
+		records, ok := customFieldTables[definition.EntityType]
+		if !ok {
+			return nil
+		}
+
+		return tx.Exec(fmt.Sprintf("UPDATE %s SET custom_fields = custom_fields - @key::text "+
+			"WHERE custom_fields -> @key::text IS NOT NULL", records.table), sql.Named("key", definition.Key)).Error
+	})
+}
+
+func (r *CustomFieldRepository) IsTaken(
+	entityType model.RecordType,
+	key string,
+	value interface{},
+	exceptID uuid.UUID,
+) (bool, error) {
+	var count int64
+
+	records, ok := customFieldTables[entityType]
+	if !ok {
+		return false, fmt.Errorf("no custom fields for %s", entityType)
+	}
+
+	err := r.store.DB.Table(records.table).
+		Where("custom_fields @> ?::jsonb", model.CustomFieldValues{key: value}).
+		Where(records.idColumn+"<>?", exceptID).
+		Count(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,75 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestCustomFieldRepository_FilterAndDelete() {
+	department := &model.CustomFieldDefinition{EntityType: model.RecordUser, Key: "department", Label: "Department",
+		Type: model.CustomFieldSelect, Options: model.StringList{"Sales", "Support"}}
+	languages := &model.CustomFieldDefinition{EntityType: model.RecordUser, Key: "languages", Label: "Languages",
+		Type: model.CustomFieldMultiSelect, Options: model.StringList{"en", "uk"}}
+
+	for _, definition := range []*model.CustomFieldDefinition{department, languages} {
+		err := s.store.CustomField().Create(definition)
+		s.Nil(err)
+	}
+
+	users := s.AuthUserFixture.List()
+	profiles := s.UserFixture.List()
+	values := []model.CustomFieldValues{
+		{"department": "Sales", "languages": []string{"en", "uk"}},
+		{"department": "Sales", "languages": []string{"uk"}},
+		{"department": "Support"},
+	}
+
+	for i := range users {
+		err := s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+
+		profiles[i].UserID = users[i].ID
+		profiles[i].CustomFields = values[i]
+		err = s.store.DB.Create(&profiles[i]).Error
+		s.Nil(err)
+	}
+
+	actual, exists := s.store.CustomField().GetByKey(model.RecordUser, "languages")
+	s.True(exists)
+	s.Equal(model.StringList{"en", "uk"}, actual.Options)
+
+	accounts, total, err := s.store.Auth().List(model.UserListQuery{
+		Pagination:   model.Pagination{Page: 1, PerPage: 10},
+		CustomFields: model.CustomFieldValues{"department": "Sales", "languages": []string{"en"}},
+	})
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(users[0].ID, accounts[0].ID)
+	s.Equal("Sales", accounts[0].CustomFields["department"])
+
+	taken, err := s.store.CustomField().IsTaken(model.RecordUser, "department", "Support", users[0].ID)
+	s.Nil(err)
+	s.True(taken)
+
+	taken, err = s.store.CustomField().IsTaken(model.RecordUser, "department", "Support", users[2].ID)
+	s.Nil(err)
+	s.False(taken)
+
+	err = s.store.CustomField().Delete(department.ID)
+	s.Nil(err)
+
+	_, exists = s.store.CustomField().Get(department.ID)
+	s.False(exists)
+
+	profile, err := s.store.User().Get(users[1].ID)
+	s.Nil(err)
+	s.Equal(model.CustomFieldValues{"languages": []interface{}{"uk"}}, profile.CustomFields)
+
+	definitions, err := s.store.CustomField().List(model.RecordUser)
+	s.Nil(err)
+	s.Len(definitions, 1)
+
+	_, exists = s.store.CustomField().Get(uuid.NewV4())
+	s.False(exists)
+}
@@ -0,0 +1,274 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dealOrders are the columns of the deal sort fields, id keeps pages stable.
+var dealOrders = map[string][2]string{
+	"title":               {"lower(deals.title), deals.id", "lower(deals.title) DESC, deals.id"},
+	"amount":              {"deals.amount, deals.id", "deals.amount DESC, deals.id"},
+	"expected_close_date": {"deals.expected_close_date NULLS LAST, deals.id", "deals.expected_close_date DESC NULLS LAST, deals.id"},
+	"created_at":          {"deals.created_at, deals.id", "deals.created_at DESC, deals.id"},
+	"updated_at":          {"deals.updated_at, deals.id", "deals.updated_at DESC, deals.id"},
+}
+
+type DealRepository struct {
+	store *PostgresStore
+}
+
+func NewDealRepository(store *PostgresStore) *DealRepository {
+	return &DealRepository{store: store}
+}
+
+func (r *DealRepository) Create(deal *model.Deal, changedBy uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(deal).Error
+		if err != nil {
+			return err
+		}
+
+		err = createDealContacts(tx, deal)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&model.DealStageChange{
+			DealID:    deal.ID,
+			ToStageID: &deal.StageID,
+			ChangedBy: &changedBy,
+			ChangedAt: deal.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *DealRepository) Get(id uuid.UUID) (*model.Deal, bool) {
+	var deal *model.Deal
+
+	result := r.store.DB.Where("id=?", id).Find(&deal)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	deals := []model.Deal{*deal}
+
+	err := r.loadContacts(deals)
+	if err != nil {
+		return nil, false
+	}
+
+	return &deals[0], true
+}
+
+func (r *DealRepository) List(query model.DealQuery) ([]model.Deal, int64, error) {
+	var total int64
+
+	deals := []model.Deal{}
+	db := r.filter(query)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	field, desc := query.SortField()
+
+	order := dealOrders[field][0]
+	if desc {
+		order = dealOrders[field][1]
+	}
+
+	err = db.Order(order).
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&deals).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.loadContacts(deals)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deals, total, nil
+}
+
+func (r *DealRepository) Totals(query model.DealQuery) ([]model.DealTotal, error) {
+	totals := []model.DealTotal{}
+
+	err := r.filter(query).
+		Select("deals.stage_id, deals.currency, count(*) AS count, sum(deals.amount)::bigint AS amount, " +
+			"(sum(deals.amount * pipeline_stages.probability) / 100)::bigint AS weighted_amount").
+		Joins("JOIN pipeline_stages ON pipeline_stages.id = deals.stage_id").
+		Group("deals.stage_id, deals.currency").
+		Order("deals.currency").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func (r *DealRepository) Update(deal *model.Deal, changedBy uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var current model.Deal
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("stage_id").
+			Where("id=?", deal.ID).
+			Take(&current).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(deal).
+			Select("title", "amount", "currency", "expected_close_date", "pipeline_id", "stage_id", "owner_id",
+				"company_id", "closed_at", "updated_at").
+			Updates(deal).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.DealContact{}, "deal_id=?", deal.ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = createDealContacts(tx, deal)
+		if err != nil {
+			return err
+		}
+
+		if current.StageID == deal.StageID {
+			return nil
+		}
+
+		return tx.Create(&model.DealStageChange{
+			DealID:      deal.ID,
+			FromStageID: &current.StageID,
+			ToStageID:   &deal.StageID,
+			ChangedBy:   &changedBy,
+			ChangedAt:   deal.UpdatedAt,
+		}).Error
+	})
+}
+
+func (r *DealRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := unlinkTasks(tx, model.RecordDeal, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTimeline(tx, model.RecordDeal, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTags(tx, model.RecordDeal, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Deal{}, "id=?", id).Error
+	})
+}
+
+func (r *DealRepository) History(id uuid.UUID) ([]model.DealStageChange, error) {
+	changes := []model.DealStageChange{}
+
+	err := r.store.DB.Where("deal_id=?", id).Order("changed_at, id").Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// filter qualifies the columns, Totals joins the stages.
+func (r *DealRepository) filter(query model.DealQuery) *gorm.DB {
+	db := r.store.DB.Model(&model.Deal{})
+
+	if query.Search != "" {
+		db = db.Where("deals.title ILIKE ?", "%"+escapeLike(query.Search)+"%")
+	}
+
+	if query.OwnerID != "" {
+		db = db.Where("deals.owner_id=?", query.OwnerID)
+	}
+
+	if query.CompanyID != "" {
+		db = db.Where("deals.company_id=?", query.CompanyID)
+	}
+
+	if query.ContactID != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM deal_contacts WHERE deal_contacts.deal_id = deals.id AND deal_contacts.contact_id = ?)",
+			query.ContactID)
+	}
+
+	if query.PipelineID != "" {
+		db = db.Where("deals.pipeline_id=?", query.PipelineID)
+	}
+
+	if query.StageID != "" {
+		db = db.Where("deals.stage_id=?", query.StageID)
+	}
+
+	if query.Status != "" {
+		db = db.Where("deals.stage_id IN (SELECT id FROM pipeline_stages WHERE kind=?)", query.Status)
+	}
+
+	return tagFilter(db, model.RecordDeal, "deals.id", query.TagFilter)
+}
+
+// loadContacts sets the contact IDs of the deals in list order.
+func (r *DealRepository) loadContacts(deals []model.Deal) error {
+	if len(deals) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(deals))
+	for i := range deals {
+		ids = append(ids, deals[i].ID)
+	}
+
+	var links []model.DealContact
+
+	err := r.store.DB.Where("deal_id IN ?", ids).Order("position").Find(&links).Error
+	if err != nil {
+		return err
+	}
+
+	byDeal := make(map[uuid.UUID][]uuid.UUID, len(deals))
+	for _, link := range links {
+		byDeal[link.DealID] = append(byDeal[link.DealID], link.ContactID)
+	}
+
+	for i := range deals {
+		deals[i].ContactIDs = byDeal[deals[i].ID]
+		if deals[i].ContactIDs == nil {
+			deals[i].ContactIDs = []uuid.UUID{}
+		}
+	}
+
+	return nil
+}
+
+func createDealContacts(tx *gorm.DB, deal *model.Deal) error {
+	if len(deal.ContactIDs) == 0 {
+		return nil
+	}
+
+	links := make([]model.DealContact, 0, len(deal.ContactIDs))
+	for i, contactID := range deal.ContactIDs {
+		links = append(links, model.DealContact{DealID: deal.ID, Position: i, ContactID: contactID})
+	}
+
+	return tx.Create(&links).Error
+}
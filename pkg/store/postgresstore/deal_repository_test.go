@@ -0,0 +1,149 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) createPipeline() *model.Pipeline {
+	pipeline := &model.Pipeline{
+		Name: "Sales",
+		Stages: []model.PipelineStage{
+			{Name: "Lead", Probability: 20, Kind: model.StageOpen},
+			{Name: "Won", Probability: 100, Kind: model.StageWon},
+			{Name: "Lost", Kind: model.StageLost},
+		},
+	}
+
+	err := s.store.Pipeline().Create(pipeline)
+	s.Nil(err)
+
+	return pipeline
+}
+
+func (s *StoreSuite) TestDealRepository_CreateUpdateHistory() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	pipeline := s.createPipeline()
+	lead, won := &pipeline.Stages[0], &pipeline.Stages[1]
+
+	contact := &model.Contact{LastName: "Doe"}
+	err = s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	closeDate := model.NewDate(2026, time.December, 31)
+	deal := &model.Deal{
+		Title:             "Renewal",
+		Amount:            150000,
+		Currency:          "EUR",
+		ExpectedCloseDate: &closeDate,
+		PipelineID:        pipeline.ID,
+		StageID:           lead.ID,
+		OwnerID:           &user.ID,
+		ContactIDs:        []uuid.UUID{contact.ID},
+	}
+
+	err = s.store.Deal().Create(deal, user.ID)
+	s.Nil(err)
+
+	actual, exists := s.store.Deal().Get(deal.ID)
+	s.True(exists)
+	s.Equal("2026-12-31", actual.ExpectedCloseDate.String())
+	s.Equal([]uuid.UUID{contact.ID}, actual.ContactIDs)
+	s.Nil(actual.ClosedAt)
+
+	// changing fields only isn't a move
+	actual.Amount = 200000
+	err = s.store.Deal().Update(actual, user.ID)
+	s.Nil(err)
+
+	actual.MoveTo(won, time.Now())
+	err = s.store.Deal().Update(actual, user.ID)
+	s.Nil(err)
+
+	actual, exists = s.store.Deal().Get(deal.ID)
+	s.True(exists)
+	s.Equal(int64(200000), actual.Amount)
+	s.Equal(won.ID, actual.StageID)
+	s.NotNil(actual.ClosedAt)
+
+	changes, err := s.store.Deal().History(deal.ID)
+	s.Nil(err)
+	s.Len(changes, 2)
+	s.Nil(changes[0].FromStageID)
+	s.Equal(lead.ID, *changes[0].ToStageID)
+	s.Equal(lead.ID, *changes[1].FromStageID)
+	s.Equal(won.ID, *changes[1].ToStageID)
+	s.Equal(user.ID, *changes[1].ChangedBy)
+
+	// deleting the contact unlinks it
+	err = s.store.Contact().Delete(contact.ID)
+	s.Nil(err)
+
+	actual, exists = s.store.Deal().Get(deal.ID)
+	s.True(exists)
+	s.Empty(actual.ContactIDs)
+}
+
+func (s *StoreSuite) TestDealRepository_ListTotals() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	pipeline := s.createPipeline()
+	lead, won := pipeline.Stages[0].ID, pipeline.Stages[1].ID
+
+	contact := &model.Contact{LastName: "Doe"}
+	err = s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	deals := []*model.Deal{
+		{Title: "Renewal", Amount: 1000, Currency: "USD", PipelineID: pipeline.ID, StageID: lead},
+		{Title: "Expansion", Amount: 3000, Currency: "USD", PipelineID: pipeline.ID, StageID: lead,
+			ContactIDs: []uuid.UUID{contact.ID}},
+		{Title: "Support renewal", Amount: 500, Currency: "EUR", PipelineID: pipeline.ID, StageID: lead},
+		{Title: "Migration", Amount: 2000, Currency: "USD", PipelineID: pipeline.ID, StageID: won},
+	}
+
+	for _, deal := range deals {
+		err = s.store.Deal().Create(deal, user.ID)
+		s.Nil(err)
+	}
+
+	query := model.DealQuery{Sorting: model.Sorting{Sort: "-amount"}}
+	s.True(query.IsValid())
+
+	actual, total, err := s.store.Deal().List(query)
+	s.Nil(err)
+	s.Equal(int64(4), total)
+	s.Equal([]int64{3000, 2000, 1000, 500}, []int64{actual[0].Amount, actual[1].Amount, actual[2].Amount, actual[3].Amount})
+
+	for query, expected := range map[*model.DealQuery]int64{
+		{Status: model.StageWon}:            1,
+		{Search: "renewal"}:                 2,
+		{ContactID: contact.ID.String()}:    1,
+		{StageID: lead.String()}:            3,
+		{PipelineID: uuid.NewV4().String()}: 0,
+	} {
+		s.True(query.IsValid())
+
+		_, total, err = s.store.Deal().List(*query)
+		s.Nil(err)
+		s.Equal(expected, total, query)
+	}
+
+	query = model.DealQuery{PipelineID: pipeline.ID.String()}
+	s.True(query.IsValid())
+
+	totals, err := s.store.Deal().Totals(query)
+	s.Nil(err)
+	s.ElementsMatch([]model.DealTotal{
+		{StageID: lead, Currency: "EUR", Count: 1, Amount: 500, WeightedAmount: 100},
+		{StageID: lead, Currency: "USD", Count: 2, Amount: 4000, WeightedAmount: 800},
+		{StageID: won, Currency: "USD", Count: 1, Amount: 2000, WeightedAmount: 2000},
+	}, totals)
+}
@@ -0,0 +1,16 @@
+package postgresstore
+
+import "crm-system/pkg/model"
+
+// entityTable is where the records of an entity type are kept, idColumn
+// identifies the record like the entity ID does.
+type entityTable struct {
+	table, idColumn string
+}
+
+var entityTables = map[model.RecordType]entityTable{
+	model.RecordUser:    {table: "users", idColumn: "user_id"},
+	model.RecordContact: {table: "contacts", idColumn: "id"},
+	model.RecordCompany: {table: "companies", idColumn: "id"},
+	model.RecordDeal:    {table: "deals", idColumn: "id"},
+}
@@ -0,0 +1,60 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository struct {
+	store *PostgresStore
+}
+
+func NewIdentityRepository(store *PostgresStore) *IdentityRepository {
+	return &IdentityRepository{store: store}
+}
+
+func (r *IdentityRepository) Get(issuer, subject string) (*model.AuthIdentity, bool) {
+	var identity *model.AuthIdentity
+
+	result := r.store.DB.Where("issuer=? AND subject=?", issuer, subject).Find(&identity)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return identity, true
+}
+
+func (r *IdentityRepository) Link(identity *model.AuthIdentity) error {
+	return r.store.DB.Create(identity).Error
+}
+
+func (r *IdentityRepository) IsLinked(userID uuid.UUID) (bool, error) {
+	var count int64
+
+	err := r.store.DB.Model(&model.AuthIdentity{}).Where("user_id=?", userID).Count(&count).Error
+
+	return count > 0, err
+}
+
+// Provision creates the user with its profile and links the identity in one transaction.
+func (r *IdentityRepository) Provision(user *model.AuthUser, profile *model.User, identity *model.AuthIdentity) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(user).Error
+		if err != nil {
+			return err
+		}
+
+		profile.UserID = user.ID
+
+		err = tx.Create(profile).Error
+		if err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+
+		return tx.Create(identity).Error
+	})
+}
@@ -0,0 +1,66 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestIdentityRepository_Link() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	_, ok := s.store.Identity().Get("https://idp.example.com", "subject")
+	s.Equal(false, ok)
+
+	linked, err := s.store.Identity().IsLinked(user.ID)
+	s.Nil(err)
+	s.Equal(false, linked)
+
+	err = s.store.Identity().Link(&model.AuthIdentity{
+		Issuer:  "https://idp.example.com",
+		Subject: "subject",
+		UserID:  user.ID,
+	})
+	s.Nil(err)
+
+	identity, ok := s.store.Identity().Get("https://idp.example.com", "subject")
+	s.Equal(true, ok)
+	s.Equal(user.ID, identity.UserID)
+
+	linked, err = s.store.Identity().IsLinked(user.ID)
+	s.Nil(err)
+	s.Equal(true, linked)
+
+	_, ok = s.store.Identity().Get("https://other.example.com", "subject")
+	s.Equal(false, ok)
+}
+
+func (s *StoreSuite) TestIdentityRepository_Provision() {
+	user := model.AuthUser{Username: "oidc-user", Role: model.BaseUserRole}
+	profile := model.User{Name: "Jane", Surname: "Doe"}
+
+	err := s.store.Identity().Provision(&user, &profile, &model.AuthIdentity{
+		Issuer:  "https://idp.example.com",
+		Subject: "subject",
+	})
+	s.Nil(err)
+
+	identity, ok := s.store.Identity().Get("https://idp.example.com", "subject")
+	s.Equal(true, ok)
+	s.Equal(user.ID, identity.UserID)
+
+	userDB, err := s.store.User().Get(user.ID)
+	s.Nil(err)
+	s.Equal("Jane", userDB.Name)
+
+	// the same subject can't be provisioned twice, the user is rolled back
+	err = s.store.Identity().Provision(&model.AuthUser{Username: "second", Role: model.BaseUserRole}, &model.User{},
+		&model.AuthIdentity{Issuer: "https://idp.example.com", Subject: "subject"})
+	s.NotNil(err)
+
+	second, err := s.store.Auth().GetByUsername("second")
+	s.Nil(err)
+	s.Equal(uuid.Nil, second.ID)
+}
@@ -0,0 +1,47 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+)
+
+type ImpersonationLogRepository struct {
+	store *PostgresStore
+}
+
+func NewImpersonationLogRepository(store *PostgresStore) *ImpersonationLogRepository {
+	return &ImpersonationLogRepository{store: store}
+}
+
+func (r *ImpersonationLogRepository) Create(log *model.ImpersonationLog) error {
+	return r.store.DB.Create(log).Error
+}
+
+func (r *ImpersonationLogRepository) List(query model.ImpersonationLogQuery) ([]model.ImpersonationLog, int64, error) {
+	var total int64
+
+	logs := []model.ImpersonationLog{}
+	db := r.store.DB.Model(&model.ImpersonationLog{})
+
+	if query.UserID != "" {
+		db = db.Where("user_id=?", query.UserID)
+	}
+
+	if query.ImpersonatorID != "" {
+		db = db.Where("impersonator_id=?", query.ImpersonatorID)
+	}
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Order("created_at DESC").
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,62 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"net/http"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func newImpersonationLog(impersonatorID, userID uuid.UUID, createdAt time.Time) *model.ImpersonationLog {
+	return &model.ImpersonationLog{
+		ImpersonatorID: impersonatorID,
+		UserID:         userID,
+		TokenID:        uuid.NewV4().String(),
+		Method:         http.MethodGet,
+		Path:           "/api/v1/user/",
+		Status:         http.StatusOK,
+		IP:             "10.0.0.1",
+		CreatedAt:      createdAt,
+	}
+}
+
+func (s *StoreSuite) TestImpersonationLogRepository_List() {
+	adminID, userID, otherID := uuid.NewV4(), uuid.NewV4(), uuid.NewV4()
+	now := time.Now()
+
+	older := newImpersonationLog(adminID, userID, now.Add(-time.Minute))
+	newer := newImpersonationLog(adminID, userID, now)
+	other := newImpersonationLog(uuid.NewV4(), otherID, now)
+
+	for _, log := range []*model.ImpersonationLog{older, newer, other} {
+		err := s.store.ImpersonationLog().Create(log)
+		s.Nil(err)
+	}
+
+	query := model.ImpersonationLogQuery{ImpersonatorID: adminID.String()}
+	s.True(query.IsValid())
+
+	logs, total, err := s.store.ImpersonationLog().List(query)
+	s.Nil(err)
+	s.Equal(int64(2), total)
+	s.Len(logs, 2)
+	s.Equal(newer.ID, logs[0].ID)
+	s.Equal(older.ID, logs[1].ID)
+
+	query = model.ImpersonationLogQuery{UserID: otherID.String()}
+	s.True(query.IsValid())
+
+	logs, total, err = s.store.ImpersonationLog().List(query)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(other.ID, logs[0].ID)
+
+	query = model.ImpersonationLogQuery{Pagination: model.Pagination{Page: 2, PerPage: 2}}
+	s.True(query.IsValid())
+
+	logs, total, err = s.store.ImpersonationLog().List(query)
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Len(logs, 1)
+}
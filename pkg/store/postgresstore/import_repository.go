@@ -0,0 +1,162 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImportRepository struct {
+	store *PostgresStore
+}
+
+func NewImportRepository(store *PostgresStore) *ImportRepository {
+	return &ImportRepository{store: store}
+}
+
+func (r *ImportRepository) Create(job *model.Import) error {
+	return r.store.DB.Create(job).Error
+}
+
+func (r *ImportRepository) Get(id uuid.UUID) (*model.Import, bool) {
+	var job model.Import
+
+	result := r.store.DB.Omit("data").Where("id=?", id).Find(&job)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &job, true
+}
+
+func (r *ImportRepository) List(ownerID uuid.UUID, page model.Pagination) ([]model.Import, int64, error) {
+	var total int64
+
+	jobs := []model.Import{}
+	db := r.store.DB.Model(&model.Import{}).Where("owner_id=?", ownerID)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = db.Omit("data").
+		Order("created_at DESC, id").
+		Offset(page.Offset()).
+		Limit(page.PerPage).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+func (r *ImportRepository) Data(id uuid.UUID) ([]byte, error) {
+	var data []byte
+
+	err := r.store.DB.Model(&model.Import{}).Where("id=?", id).Select("data").Row().Scan(&data)
+
+	return data, err
+}
+
+func (r *ImportRepository) UpdateMapping(id uuid.UUID, mapping model.ImportMapping) (bool, error) {
+	result := r.store.DB.Model(&model.Import{}).
+		Where("id=? AND status=?", id, model.ImportUploaded).
+		Updates(map[string]interface{}{"mapping": mapping, "updated_at": time.Now()})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ImportRepository) Queue(id uuid.UUID) (bool, error) {
+	result := r.store.DB.Model(&model.Import{}).
+		Where("id=? AND status=?", id, model.ImportUploaded).
+		Updates(map[string]interface{}{"status": model.ImportQueued, "updated_at": time.Now()})
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ImportRepository) Claim(staleBefore, now time.Time) (*model.Import, bool, error) {
+	jobs := []model.Import{}
+
+	err := r.store.DB.Raw(`UPDATE imports SET status = @running, started_at = coalesce(started_at, @now), updated_at = @now
+	WHERE id IN (
+		SELECT id FROM imports
+		WHERE status = @queued OR (status = @running AND updated_at < @stale)
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	) RETURNING *`,
+		sql.Named("running", model.ImportRunning),
+		sql.Named("queued", model.ImportQueued),
+		sql.Named("stale", staleBefore),
+		sql.Named("now", now),
+	).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, false, err
+	}
+
+	return &jobs[0], true, nil
+}
+
+func (r *ImportRepository) SaveBatch(batch *model.ImportBatch) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		// the row lock makes a worker that took the import over wait and find the rows saved
+		result := tx.Model(&model.Import{}).
+			Where("id=? AND status=? AND processed_rows=?", batch.ImportID, model.ImportRunning, batch.From).
+			Updates(map[string]interface{}{
+				"processed_rows": batch.To,
+				"created_rows":   gorm.Expr("created_rows + ?", len(batch.Contacts)),
+				"failed_rows":    gorm.Expr("failed_rows + ?", len(batch.Errors)),
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ErrImportTakenOver
+		}
+
+		if len(batch.Contacts) > 0 {
+			err := tx.Create(&batch.Contacts).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range batch.Contacts {
+			err := createChannels(tx, &batch.Contacts[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(batch.Errors) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&batch.Errors).Error
+	})
+}
+
+func (r *ImportRepository) Finish(id uuid.UUID, status model.ImportStatus, message string, now time.Time) error {
+	return r.store.DB.Model(&model.Import{}).
+		Where("id=? AND status=?", id, model.ImportRunning).
+		Updates(map[string]interface{}{"status": status, "error": message, "finished_at": now, "updated_at": now}).Error
+}
+
+func (r *ImportRepository) Errors(id uuid.UUID) ([]model.ImportRowError, error) {
+	rowErrors := []model.ImportRowError{}
+
+	err := r.store.DB.Where("import_id=?", id).Order("row_no").Find(&rowErrors).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rowErrors, nil
+}
@@ -0,0 +1,118 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestImportRepository_RunBatches() {
+	user := s.AuthUserFixture.List()[0]
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	data := []byte("First Name,Email\nAnn,ann@example.com\nBob,bad\n")
+	job := &model.Import{
+		OwnerID:   user.ID,
+		FileName:  "contacts.csv",
+		Headers:   model.StringList{"First Name", "Email"},
+		Mapping:   model.ImportMapping{"First Name": model.ImportFirstName},
+		Status:    model.ImportUploaded,
+		TotalRows: 2,
+		Data:      data,
+	}
+
+	err = s.store.Import().Create(job)
+	s.Nil(err)
+
+	actual, exists := s.store.Import().Get(job.ID)
+	s.True(exists)
+	s.Nil(actual.Data)
+	s.Equal(job.Headers, actual.Headers)
+
+	stored, err := s.store.Import().Data(job.ID)
+	s.Nil(err)
+	s.Equal(data, stored)
+
+	mapping := model.ImportMapping{"First Name": model.ImportFirstName, "Email": model.ImportEmail}
+	updated, err := s.store.Import().UpdateMapping(job.ID, mapping)
+	s.Nil(err)
+	s.True(updated)
+
+	now := time.Now()
+
+	_, claimed, err := s.store.Import().Claim(now.Add(-time.Minute), now)
+	s.Nil(err)
+	s.False(claimed)
+
+	queued, err := s.store.Import().Queue(job.ID)
+	s.Nil(err)
+	s.True(queued)
+
+	queued, err = s.store.Import().Queue(job.ID)
+	s.Nil(err)
+	s.False(queued)
+
+	updated, err = s.store.Import().UpdateMapping(job.ID, model.ImportMapping{})
+	s.Nil(err)
+	s.False(updated)
+
+	running, claimed, err := s.store.Import().Claim(now.Add(-time.Minute), now)
+	s.Nil(err)
+	s.True(claimed)
+	s.Equal(model.ImportRunning, running.Status)
+	s.Equal(mapping, running.Mapping)
+	s.Equal(data, running.Data)
+
+	_, claimed, err = s.store.Import().Claim(now.Add(-time.Minute), now)
+	s.Nil(err)
+	s.False(claimed)
+
+	batch := &model.ImportBatch{
+		ImportID: job.ID,
+		From:     0,
+		To:       2,
+		Contacts: []model.Contact{{FirstName: "Ann", OwnerID: &user.ID, LifecycleStage: model.LifecycleLead,
+			Emails: []model.ContactEmail{{Email: "ann@example.com"}}}},
+		Errors: []model.ImportRowError{{ImportID: job.ID, Row: 2, Errors: model.FieldErrors{
+			{Field: "emails", Rule: "email", Message: "invalid email address"},
+		}}},
+	}
+
+	err = s.store.Import().SaveBatch(batch)
+	s.Nil(err)
+
+	// the rows were saved already
+	err = s.store.Import().SaveBatch(batch)
+	s.ErrorIs(err, model.ErrImportTakenOver)
+
+	contact, exists := s.store.Contact().Get(batch.Contacts[0].ID)
+	s.True(exists)
+	s.Equal("ann@example.com", contact.Emails[0].Email)
+
+	err = s.store.Import().Finish(job.ID, model.ImportCompleted, "", now)
+	s.Nil(err)
+
+	actual, exists = s.store.Import().Get(job.ID)
+	s.True(exists)
+	s.Equal(model.ImportCompleted, actual.Status)
+	s.Equal(2, actual.ProcessedRows)
+	s.Equal(1, actual.CreatedRows)
+	s.Equal(1, actual.FailedRows)
+	s.NotNil(actual.StartedAt)
+	s.NotNil(actual.FinishedAt)
+
+	rowErrors, err := s.store.Import().Errors(job.ID)
+	s.Nil(err)
+	s.Equal(batch.Errors[0].Errors, rowErrors[0].Errors)
+	s.Equal(2, rowErrors[0].Row)
+
+	jobs, total, err := s.store.Import().List(user.ID, model.Pagination{Page: 1, PerPage: 10})
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Nil(jobs[0].Data)
+
+	_, exists = s.store.Import().Get(uuid.NewV4())
+	s.False(exists)
+}
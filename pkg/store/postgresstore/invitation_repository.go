@@ -0,0 +1,107 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+const pendingInvitation = "accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?"
+
+type InvitationRepository struct {
+	store *PostgresStore
+}
+
+func NewInvitationRepository(store *PostgresStore) *InvitationRepository {
+	return &InvitationRepository{store: store}
+}
+
+func (r *InvitationRepository) Create(invitation *model.Invitation) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Invitation{}).
+			Where("lower(email)=lower(?) AND "+pendingInvitation, invitation.Email, time.Now()).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(invitation).Error
+	})
+}
+
+func (r *InvitationRepository) Get(id uuid.UUID) (*model.Invitation, bool) {
+	return r.getWhere("id=? AND "+pendingInvitation, id, time.Now())
+}
+
+func (r *InvitationRepository) GetByHash(tokenHash string) (*model.Invitation, bool) {
+	return r.getWhere("token_hash=? AND "+pendingInvitation, tokenHash, time.Now())
+}
+
+func (r *InvitationRepository) ListPending() ([]model.Invitation, error) {
+	invitations := []model.Invitation{}
+
+	err := r.store.DB.Where(pendingInvitation, time.Now()).Order("created_at DESC").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r *InvitationRepository) Revoke(id uuid.UUID) error {
+	return r.store.DB.Model(&model.Invitation{}).
+		Where("id=? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Accept marks the invitation accepted with a conditional update, so it can't be
+// redeemed twice concurrently, and creates the user with its profile in the same
+// transaction.
+func (r *InvitationRepository) Accept(id uuid.UUID, user *model.AuthUser, profile *model.User) (bool, error) {
+	accepted := false
+
+	err := r.store.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&model.Invitation{}).
+			Where("id=? AND "+pendingInvitation, id, now).
+			Update("accepted_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		err := tx.Create(user).Error
+		if err != nil {
+			return err
+		}
+
+		profile.UserID = user.ID
+
+		err = tx.Create(profile).Error
+		if err != nil {
+			return err
+		}
+
+		accepted = true
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return accepted, nil
+}
+
+func (r *InvitationRepository) getWhere(query string, args ...interface{}) (*model.Invitation, bool) {
+	var invitation *model.Invitation
+
+	result := r.store.DB.Where(query, args...).Find(&invitation)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return invitation, true
+}
@@ -0,0 +1,86 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func newInvitation(email, tokenHash string, expiresAt time.Time) *model.Invitation {
+	return &model.Invitation{
+		Email:     email,
+		Role:      model.BaseUserRole,
+		TokenHash: tokenHash,
+		InvitedBy: uuid.NewV4(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+func (s *StoreSuite) TestInvitationRepository_Create() {
+	first := newInvitation("jane@example.com", "first", time.Now().Add(time.Hour))
+	err := s.store.Invitation().Create(first)
+	s.Nil(err)
+
+	// a new invitation for the same email replaces the pending one
+	second := newInvitation("Jane@Example.com", "second", time.Now().Add(time.Hour))
+	err = s.store.Invitation().Create(second)
+	s.Nil(err)
+
+	_, ok := s.store.Invitation().GetByHash("first")
+	s.Equal(false, ok)
+
+	invitation, ok := s.store.Invitation().GetByHash("second")
+	s.Equal(true, ok)
+	s.Equal(second.ID, invitation.ID)
+
+	err = s.store.Invitation().Create(newInvitation("expired@example.com", "expired", time.Now().Add(-time.Minute)))
+	s.Nil(err)
+
+	pending, err := s.store.Invitation().ListPending()
+	s.Nil(err)
+	s.Equal(1, len(pending))
+	s.Equal(second.ID, pending[0].ID)
+}
+
+func (s *StoreSuite) TestInvitationRepository_Revoke() {
+	invitation := newInvitation("jane@example.com", "hash", time.Now().Add(time.Hour))
+	err := s.store.Invitation().Create(invitation)
+	s.Nil(err)
+
+	err = s.store.Invitation().Revoke(invitation.ID)
+	s.Nil(err)
+
+	_, ok := s.store.Invitation().Get(invitation.ID)
+	s.Equal(false, ok)
+
+	accepted, err := s.store.Invitation().Accept(invitation.ID, &model.AuthUser{Username: "jane", Role: model.BaseUserRole}, &model.User{})
+	s.Nil(err)
+	s.Equal(false, accepted)
+}
+
+func (s *StoreSuite) TestInvitationRepository_Accept() {
+	invitation := newInvitation("jane@example.com", "hash", time.Now().Add(time.Hour))
+	err := s.store.Invitation().Create(invitation)
+	s.Nil(err)
+
+	email := invitation.Email
+	user := model.AuthUser{Username: "jane", Email: &email, Role: invitation.Role, Active: true}
+
+	accepted, err := s.store.Invitation().Accept(invitation.ID, &user, &model.User{Name: "Jane"})
+	s.Nil(err)
+	s.Equal(true, accepted)
+
+	userDB, err := s.store.User().Get(user.ID)
+	s.Nil(err)
+	s.Equal("Jane", userDB.Name)
+
+	// invitations are single-use
+	accepted, err = s.store.Invitation().Accept(invitation.ID, &model.AuthUser{Username: "second", Role: model.BaseUserRole}, &model.User{})
+	s.Nil(err)
+	s.Equal(false, accepted)
+
+	second, err := s.store.Auth().GetByUsername("second")
+	s.Nil(err)
+	s.Equal(uuid.Nil, second.ID)
+}
@@ -0,0 +1,56 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+)
+
+type LoginAttemptRepository struct {
+	store *PostgresStore
+}
+
+func NewLoginAttemptRepository(store *PostgresStore) *LoginAttemptRepository {
+	return &LoginAttemptRepository{store: store}
+}
+
+func (r *LoginAttemptRepository) Get(key string) (*model.LoginAttempt, error) {
+	attempt := &model.LoginAttempt{Key: key}
+
+	err := r.store.DB.Where("key=?", key).Find(attempt).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+// RegisterFailure is a single upsert, so concurrent failures on several replicas are all counted.
+func (r *LoginAttemptRepository) RegisterFailure(key string, window time.Duration) (int, error) {
+	var failures int
+
+	now := time.Now()
+	err := r.store.DB.Raw(`insert into login_attempts (key, failures, last_failure_at)
+values (?, 1, ?)
+on conflict (key) do update set
+    failures        = case
+                          when login_attempts.last_failure_at < ? then 1
+                          else login_attempts.failures + 1
+        end,
+    last_failure_at = excluded.last_failure_at
+returning failures`, key, now, now.Add(-window)).Row().Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (r *LoginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.store.DB.Model(&model.LoginAttempt{}).
+		Where("key=? AND (locked_until IS NULL OR locked_until < ?)", key, until).
+		Update("locked_until", until).Error
+}
+
+func (r *LoginAttemptRepository) Reset(key string) error {
+	return r.store.DB.Delete(&model.LoginAttempt{}, "key=?", key).Error
+}
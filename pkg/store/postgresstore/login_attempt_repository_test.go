@@ -0,0 +1,52 @@
+package postgresstore_test
+
+import (
+	"time"
+)
+
+func (s *StoreSuite) TestLoginAttemptRepository_RegisterFailure() {
+	for i := 1; i <= 3; i++ {
+		failures, err := s.store.LoginAttempt().RegisterFailure("user:test", time.Hour)
+		s.Nil(err)
+		s.Equal(i, failures)
+	}
+
+	// the last failure is outside of a zero window, so the counter restarts
+	failures, err := s.store.LoginAttempt().RegisterFailure("user:test", 0)
+	s.Nil(err)
+	s.Equal(1, failures)
+}
+
+func (s *StoreSuite) TestLoginAttemptRepository_Lock() {
+	now := time.Now()
+
+	attempt, err := s.store.LoginAttempt().Get("ip:127.0.0.1")
+	s.Nil(err)
+	s.Equal(time.Duration(0), attempt.RetryAfter(now))
+
+	_, err = s.store.LoginAttempt().RegisterFailure("ip:127.0.0.1", time.Hour)
+	s.Nil(err)
+
+	err = s.store.LoginAttempt().Lock("ip:127.0.0.1", now.Add(time.Hour))
+	s.Nil(err)
+
+	// a shorter lock does not shorten the current one
+	err = s.store.LoginAttempt().Lock("ip:127.0.0.1", now.Add(time.Minute))
+	s.Nil(err)
+
+	attempt, err = s.store.LoginAttempt().Get("ip:127.0.0.1")
+	s.Nil(err)
+	s.Greater(attempt.RetryAfter(now), 30*time.Minute)
+}
+
+func (s *StoreSuite) TestLoginAttemptRepository_Reset() {
+	_, err := s.store.LoginAttempt().RegisterFailure("user:test", time.Hour)
+	s.Nil(err)
+
+	err = s.store.LoginAttempt().Reset("user:test")
+	s.Nil(err)
+
+	attempt, err := s.store.LoginAttempt().Get("user:test")
+	s.Nil(err)
+	s.Equal(0, attempt.Failures)
+}
@@ -0,0 +1,114 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecoveryCodeRepository struct {
+	store *PostgresStore
+}
+
+func NewRecoveryCodeRepository(store *PostgresStore) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{store: store}
+}
+
+// Replace drops every previous code of the user and stores the new set.
+func (r *RecoveryCodeRepository) Replace(userID uuid.UUID, codeHashes []string) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.RecoveryCode{}, "user_id=?", userID).Error
+		if err != nil {
+			return err
+		}
+
+		if len(codeHashes) == 0 {
+			return nil
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use marks an unused code as used and reports whether there was one.
+func (r *RecoveryCodeRepository) Use(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.store.DB.Model(&model.RecoveryCode{}).
+		Where("user_id=? AND code_hash=? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+type MFAChallengeRepository struct {
+	store *PostgresStore
+}
+
+func NewMFAChallengeRepository(store *PostgresStore) *MFAChallengeRepository {
+	return &MFAChallengeRepository{store: store}
+}
+
+// Use records the challenge token and reports whether it wasn't used before,
+// the insert is a single statement so a token can't be used twice concurrently.
+func (r *MFAChallengeRepository) Use(tokenID string, expiresAt time.Time) (bool, error) {
+	var used bool
+
+	err := r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.MFAChallenge{}, "expires_at <= ?", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.MFAChallenge{TokenID: tokenID, ExpiresAt: expiresAt})
+		used = result.RowsAffected > 0
+
+		return result.Error
+	})
+
+	return used, err
+}
+
+type MFAPolicyRepository struct {
+	store *PostgresStore
+}
+
+func NewMFAPolicyRepository(store *PostgresStore) *MFAPolicyRepository {
+	return &MFAPolicyRepository{store: store}
+}
+
+func (r *MFAPolicyRepository) IsRequired(role model.UserRole) (bool, error) {
+	var policy model.MFAPolicy
+
+	err := r.store.DB.Where("role=?", role).Find(&policy).Error
+	if err != nil {
+		return false, err
+	}
+
+	return policy.Required, nil
+}
+
+func (r *MFAPolicyRepository) Set(policy *model.MFAPolicy) error {
+	return r.store.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(policy).Error
+}
+
+func (r *MFAPolicyRepository) List() ([]model.MFAPolicy, error) {
+	var policies []model.MFAPolicy
+
+	err := r.store.DB.Order("role").Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,79 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+)
+
+func (s *StoreSuite) TestRecoveryCodeRepository_Replace() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.RecoveryCode().Replace(user.ID, []string{"old"})
+	s.Nil(err)
+
+	err = s.store.RecoveryCode().Replace(user.ID, []string{"first", "second"})
+	s.Nil(err)
+
+	var codes []model.RecoveryCode
+	err = s.store.DB.Where("user_id=?", user.ID).Order("code_hash").Find(&codes).Error
+	s.Nil(err)
+	s.Len(codes, 2)
+	s.Equal("first", codes[0].CodeHash)
+}
+
+func (s *StoreSuite) TestRecoveryCodeRepository_Use() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.RecoveryCode().Replace(user.ID, []string{"code"})
+	s.Nil(err)
+
+	used, err := s.store.RecoveryCode().Use(user.ID, "code")
+	s.Nil(err)
+	s.Equal(true, used)
+
+	used, err = s.store.RecoveryCode().Use(user.ID, "code")
+	s.Nil(err)
+	s.Equal(false, used)
+
+	used, err = s.store.RecoveryCode().Use(user.ID, "unknown")
+	s.Nil(err)
+	s.Equal(false, used)
+}
+
+func (s *StoreSuite) TestMFAPolicyRepository_Set() {
+	required, err := s.store.MFAPolicy().IsRequired(model.AdminUserRole)
+	s.Nil(err)
+	s.Equal(false, required)
+
+	err = s.store.MFAPolicy().Set(&model.MFAPolicy{Role: model.AdminUserRole, Required: true})
+	s.Nil(err)
+
+	required, err = s.store.MFAPolicy().IsRequired(model.AdminUserRole)
+	s.Nil(err)
+	s.Equal(true, required)
+
+	err = s.store.MFAPolicy().Set(&model.MFAPolicy{Role: model.AdminUserRole, Required: false})
+	s.Nil(err)
+
+	policies, err := s.store.MFAPolicy().List()
+	s.Nil(err)
+	s.Equal([]model.MFAPolicy{{Role: model.AdminUserRole, Required: false}}, policies)
+}
+
+func (s *StoreSuite) TestMFAChallengeRepository_Use() {
+	used, err := s.store.MFAChallenge().Use("token", time.Now().Add(time.Minute))
+	s.Nil(err)
+	s.Equal(true, used)
+
+	used, err = s.store.MFAChallenge().Use("token", time.Now().Add(time.Minute))
+	s.Nil(err)
+	s.Equal(false, used)
+
+	used, err = s.store.MFAChallenge().Use("other", time.Now().Add(time.Minute))
+	s.Nil(err)
+	s.Equal(true, used)
+}
@@ -0,0 +1,47 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCStateRepository struct {
+	store *PostgresStore
+}
+
+func NewOIDCStateRepository(store *PostgresStore) *OIDCStateRepository {
+	return &OIDCStateRepository{store: store}
+}
+
+func (r *OIDCStateRepository) Create(state *model.OIDCState) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.OIDCState{}, "expires_at <= ?", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(state).Error
+	})
+}
+
+// Use is a single delete, so a state can't be redeemed twice concurrently.
+func (r *OIDCStateRepository) Use(stateHash string) (*model.OIDCState, bool, error) {
+	var states []model.OIDCState
+
+	result := r.store.DB.
+		Clauses(clause.Returning{}).
+		Where("state_hash=? AND expires_at > ?", stateHash, time.Now()).
+		Delete(&states)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	if result.RowsAffected == 0 || len(states) == 0 {
+		return nil, false, nil
+	}
+
+	return &states[0], true, nil
+}
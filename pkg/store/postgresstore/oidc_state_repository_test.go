@@ -0,0 +1,41 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+)
+
+func (s *StoreSuite) TestOIDCStateRepository_Use() {
+	err := s.store.OIDCState().Create(&model.OIDCState{
+		StateHash:    "hash",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	})
+	s.Nil(err)
+
+	state, ok, err := s.store.OIDCState().Use("hash")
+	s.Nil(err)
+	s.Equal(true, ok)
+	s.Equal("nonce", state.Nonce)
+	s.Equal("verifier", state.CodeVerifier)
+
+	// states are single-use
+	_, ok, err = s.store.OIDCState().Use("hash")
+	s.Nil(err)
+	s.Equal(false, ok)
+}
+
+func (s *StoreSuite) TestOIDCStateRepository_Expired() {
+	err := s.store.OIDCState().Create(&model.OIDCState{
+		StateHash:    "hash",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	})
+	s.Nil(err)
+
+	_, ok, err := s.store.OIDCState().Use("hash")
+	s.Nil(err)
+	s.Equal(false, ok)
+}
@@ -0,0 +1,60 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetRepository struct {
+	store *PostgresStore
+}
+
+func NewPasswordResetRepository(store *PostgresStore) *PasswordResetRepository {
+	return &PasswordResetRepository{store: store}
+}
+
+func (r *PasswordResetRepository) Create(token *model.PasswordResetToken) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Delete(&model.PasswordResetToken{}, "user_id=? AND used_at IS NULL", token.UserID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+func (r *PasswordResetRepository) Get(tokenHash string) (uuid.UUID, bool) {
+	var token *model.PasswordResetToken
+
+	result := r.store.DB.Where("token_hash=? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).Find(&token)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return uuid.Nil, false
+	}
+
+	return token.UserID, true
+}
+
+// Use is a single conditional update, so a token can't be redeemed twice concurrently.
+func (r *PasswordResetRepository) Use(tokenHash string) (uuid.UUID, bool, error) {
+	var tokens []model.PasswordResetToken
+
+	now := time.Now()
+	result := r.store.DB.Model(&tokens).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+		Where("token_hash=? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return uuid.Nil, false, result.Error
+	}
+
+	if result.RowsAffected == 0 || len(tokens) == 0 {
+		return uuid.Nil, false, nil
+	}
+
+	return tokens[0].UserID, true, nil
+}
@@ -0,0 +1,77 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+)
+
+func (s *StoreSuite) TestPasswordResetRepository_Use() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.PasswordReset().Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	s.Nil(err)
+
+	// a token is not used by looking it up
+	userID, ok := s.store.PasswordReset().Get("hash")
+	s.Equal(true, ok)
+	s.Equal(user.ID, userID)
+
+	userID, ok, err = s.store.PasswordReset().Use("hash")
+	s.Nil(err)
+	s.Equal(true, ok)
+	s.Equal(user.ID, userID)
+
+	// tokens are single-use
+	_, ok, err = s.store.PasswordReset().Use("hash")
+	s.Nil(err)
+	s.Equal(false, ok)
+
+	_, ok = s.store.PasswordReset().Get("hash")
+	s.Equal(false, ok)
+}
+
+func (s *StoreSuite) TestPasswordResetRepository_Expired() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.PasswordReset().Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	s.Nil(err)
+
+	_, ok, err := s.store.PasswordReset().Use("hash")
+	s.Nil(err)
+	s.Equal(false, ok)
+}
+
+func (s *StoreSuite) TestPasswordResetRepository_CreateDropsPrevious() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	for _, hash := range []string{"first", "second"} {
+		err = s.store.PasswordReset().Create(&model.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		s.Nil(err)
+	}
+
+	_, ok, err := s.store.PasswordReset().Use("first")
+	s.Nil(err)
+	s.Equal(false, ok)
+
+	_, ok, err = s.store.PasswordReset().Use("second")
+	s.Nil(err)
+	s.Equal(true, ok)
+}
@@ -0,0 +1,182 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type PipelineRepository struct {
+	store *PostgresStore
+}
+
+func NewPipelineRepository(store *PostgresStore) *PipelineRepository {
+	return &PipelineRepository{store: store}
+}
+
+func (r *PipelineRepository) Create(pipeline *model.Pipeline) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(pipeline).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range pipeline.Stages {
+			pipeline.Stages[i].ID = uuid.Nil
+		}
+
+		return saveStages(tx, pipeline)
+	})
+}
+
+func (r *PipelineRepository) Get(id uuid.UUID) (*model.Pipeline, bool) {
+	var pipeline *model.Pipeline
+
+	result := r.store.DB.Where("id=?", id).Find(&pipeline)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	pipelines := []model.Pipeline{*pipeline}
+
+	err := r.loadStages(pipelines)
+	if err != nil {
+		return nil, false
+	}
+
+	return &pipelines[0], true
+}
+
+func (r *PipelineRepository) List() ([]model.Pipeline, error) {
+	pipelines := []model.Pipeline{}
+
+	err := r.store.DB.Order("lower(name), id").Find(&pipelines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.loadStages(pipelines)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipelines, nil
+}
+
+func (r *PipelineRepository) Update(pipeline *model.Pipeline) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(pipeline).Select("name", "updated_at").Updates(pipeline).Error
+		if err != nil {
+			return err
+		}
+
+		kept := make([]uuid.UUID, 0, len(pipeline.Stages))
+		for _, stage := range pipeline.Stages {
+			if stage.ID != uuid.Nil {
+				kept = append(kept, stage.ID)
+			}
+		}
+
+		removed := tx.Model(&model.PipelineStage{}).Select("id").Where("pipeline_id=?", pipeline.ID)
+		if len(kept) > 0 {
+			removed = removed.Where("id NOT IN ?", kept)
+		}
+
+		var deals int64
+
+		err = tx.Model(&model.Deal{}).Where("stage_id IN (?)", removed).Count(&deals).Error
+		if err != nil {
+			return err
+		}
+
+		if deals > 0 {
+			return model.ErrStageInUse
+		}
+
+		err = tx.Where("id IN (?)", removed).Delete(&model.PipelineStage{}).Error
+		if err != nil {
+			return err
+		}
+
+		return saveStages(tx, pipeline)
+	})
+}
+
+func (r *PipelineRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var deals int64
+
+		err := tx.Model(&model.Deal{}).Where("pipeline_id=?", id).Count(&deals).Error
+		if err != nil {
+			return err
+		}
+
+		if deals > 0 {
+			return model.ErrPipelineInUse
+		}
+
+		return tx.Delete(&model.Pipeline{}, "id=?", id).Error
+	})
+}
+
+// loadStages sets the stages of the pipelines in list order.
+func (r *PipelineRepository) loadStages(pipelines []model.Pipeline) error {
+	if len(pipelines) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(pipelines))
+	for i := range pipelines {
+		ids = append(ids, pipelines[i].ID)
+	}
+
+	var stages []model.PipelineStage
+
+	err := r.store.DB.Where("pipeline_id IN ?", ids).Order("position").Find(&stages).Error
+	if err != nil {
+		return err
+	}
+
+	byPipeline := make(map[uuid.UUID][]model.PipelineStage, len(pipelines))
+	for _, stage := range stages {
+		byPipeline[stage.PipelineID] = append(byPipeline[stage.PipelineID], stage)
+	}
+
+	for i := range pipelines {
+		pipelines[i].Stages = byPipeline[pipelines[i].ID]
+		if pipelines[i].Stages == nil {
+			pipelines[i].Stages = []model.PipelineStage{}
+		}
+	}
+
+	return nil
+}
+
+// saveStages updates the stages with an ID and creates the others in list order.
+func saveStages(tx *gorm.DB, pipeline *model.Pipeline) error {
+	for i := range pipeline.Stages {
+		stage := &pipeline.Stages[i]
+		stage.PipelineID = pipeline.ID
+		stage.Position = i
+
+		if stage.ID == uuid.Nil {
+			err := tx.Create(stage).Error
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := tx.Model(stage).
+			Select("position", "name", "probability", "kind").
+			Where("pipeline_id=?", pipeline.ID).
+			Updates(stage).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+)
+
+func (s *StoreSuite) TestPipelineRepository_CreateUpdateDelete() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	pipeline := &model.Pipeline{
+		Name: "Sales",
+		Stages: []model.PipelineStage{
+			{Name: "Lead", Probability: 20, Kind: model.StageOpen},
+			{Name: "Won", Probability: 100, Kind: model.StageWon},
+			{Name: "Lost", Kind: model.StageLost},
+		},
+	}
+
+	err = s.store.Pipeline().Create(pipeline)
+	s.Nil(err)
+
+	actual, exists := s.store.Pipeline().Get(pipeline.ID)
+	s.True(exists)
+	s.Equal("Sales", actual.Name)
+	s.Equal([]string{"Lead", "Won", "Lost"}, []string{actual.Stages[0].Name, actual.Stages[1].Name, actual.Stages[2].Name})
+
+	lead, won := actual.Stages[0], actual.Stages[1]
+
+	deal := &model.Deal{Title: "Renewal", Currency: "USD", PipelineID: pipeline.ID, StageID: won.ID}
+	err = s.store.Deal().Create(deal, user.ID)
+	s.Nil(err)
+
+	// the won stage has a deal
+	actual.Stages = []model.PipelineStage{{ID: lead.ID, Name: "Qualified", Probability: 30, Kind: model.StageOpen}}
+	err = s.store.Pipeline().Update(actual)
+	s.ErrorIs(err, model.ErrStageInUse)
+
+	actual.Stages = []model.PipelineStage{
+		{Name: "Proposal", Probability: 60, Kind: model.StageOpen},
+		won,
+		{ID: lead.ID, Name: "Qualified", Probability: 30, Kind: model.StageOpen},
+	}
+	err = s.store.Pipeline().Update(actual)
+	s.Nil(err)
+
+	actual, exists = s.store.Pipeline().Get(pipeline.ID)
+	s.True(exists)
+	s.Equal([]string{"Proposal", "Won", "Qualified"}, []string{actual.Stages[0].Name, actual.Stages[1].Name, actual.Stages[2].Name})
+	s.Equal(lead.ID, actual.Stages[2].ID)
+	s.Equal(30, actual.Stages[2].Probability)
+
+	err = s.store.Pipeline().Delete(pipeline.ID)
+	s.ErrorIs(err, model.ErrPipelineInUse)
+
+	err = s.store.Deal().Delete(deal.ID)
+	s.Nil(err)
+
+	err = s.store.Pipeline().Delete(pipeline.ID)
+	s.Nil(err)
+
+	pipelines, err := s.store.Pipeline().List()
+	s.Nil(err)
+	s.Empty(pipelines)
+}
@@ -15,8 +15,31 @@ import (
 type PostgresStore struct {
 	DB *gorm.DB
 
-	UserRepository *UserRepository
-	AuthRepository *AuthRepository
+	UserRepository             *UserRepository
+	AuthRepository             *AuthRepository
+	RefreshTokenRepository     *RefreshTokenRepository
+	RecoveryCodeRepository     *RecoveryCodeRepository
+	MFAPolicyRepository        *MFAPolicyRepository
+	MFAChallengeRepository     *MFAChallengeRepository
+	LoginAttemptRepository     *LoginAttemptRepository
+	PasswordResetRepository    *PasswordResetRepository
+	RoleRepository             *RoleRepository
+	APIKeyRepository           *APIKeyRepository
+	SessionRepository          *SessionRepository
+	IdentityRepository         *IdentityRepository
+	OIDCStateRepository        *OIDCStateRepository
+	InvitationRepository       *InvitationRepository
+	ImpersonationLogRepository *ImpersonationLogRepository
+	AuthEventRepository        *AuthEventRepository
+	ContactRepository          *ContactRepository
+	CompanyRepository          *CompanyRepository
+	PipelineRepository         *PipelineRepository
+	DealRepository             *DealRepository
+	TaskRepository             *TaskRepository
+	ActivityRepository         *ActivityRepository
+	CustomFieldRepository      *CustomFieldRepository
+	TagRepository              *TagRepository
+	ImportRepository           *ImportRepository
 }
 
 //nolint:nosprintfhostport
@@ -61,3 +84,187 @@ func (s *PostgresStore) Auth() *AuthRepository {
 
 	return s.AuthRepository
 }
+
+func (s *PostgresStore) RefreshToken() *RefreshTokenRepository {
+	if s.RefreshTokenRepository == nil {
+		s.RefreshTokenRepository = NewRefreshTokenRepository(s)
+	}
+
+	return s.RefreshTokenRepository
+}
+
+func (s *PostgresStore) RecoveryCode() *RecoveryCodeRepository {
+	if s.RecoveryCodeRepository == nil {
+		s.RecoveryCodeRepository = NewRecoveryCodeRepository(s)
+	}
+
+	return s.RecoveryCodeRepository
+}
+
+func (s *PostgresStore) MFAPolicy() *MFAPolicyRepository {
+	if s.MFAPolicyRepository == nil {
+		s.MFAPolicyRepository = NewMFAPolicyRepository(s)
+	}
+
+	return s.MFAPolicyRepository
+}
+
+func (s *PostgresStore) MFAChallenge() *MFAChallengeRepository {
+	if s.MFAChallengeRepository == nil {
+		s.MFAChallengeRepository = NewMFAChallengeRepository(s)
+	}
+
+	return s.MFAChallengeRepository
+}
+
+func (s *PostgresStore) LoginAttempt() *LoginAttemptRepository {
+	if s.LoginAttemptRepository == nil {
+		s.LoginAttemptRepository = NewLoginAttemptRepository(s)
+	}
+
+	return s.LoginAttemptRepository
+}
+
+func (s *PostgresStore) PasswordReset() *PasswordResetRepository {
+	if s.PasswordResetRepository == nil {
+		s.PasswordResetRepository = NewPasswordResetRepository(s)
+	}
+
+	return s.PasswordResetRepository
+}
+
+func (s *PostgresStore) Role() *RoleRepository {
+	if s.RoleRepository == nil {
+		s.RoleRepository = NewRoleRepository(s)
+	}
+
+	return s.RoleRepository
+}
+
+func (s *PostgresStore) APIKey() *APIKeyRepository {
+	if s.APIKeyRepository == nil {
+		s.APIKeyRepository = NewAPIKeyRepository(s)
+	}
+
+	return s.APIKeyRepository
+}
+
+func (s *PostgresStore) Session() *SessionRepository {
+	if s.SessionRepository == nil {
+		s.SessionRepository = NewSessionRepository(s)
+	}
+
+	return s.SessionRepository
+}
+
+func (s *PostgresStore) Identity() *IdentityRepository {
+	if s.IdentityRepository == nil {
+		s.IdentityRepository = NewIdentityRepository(s)
+	}
+
+	return s.IdentityRepository
+}
+
+func (s *PostgresStore) OIDCState() *OIDCStateRepository {
+	if s.OIDCStateRepository == nil {
+		s.OIDCStateRepository = NewOIDCStateRepository(s)
+	}
+
+	return s.OIDCStateRepository
+}
+
+func (s *PostgresStore) Invitation() *InvitationRepository {
+	if s.InvitationRepository == nil {
+		s.InvitationRepository = NewInvitationRepository(s)
+	}
+
+	return s.InvitationRepository
+}
+
+func (s *PostgresStore) ImpersonationLog() *ImpersonationLogRepository {
+	if s.ImpersonationLogRepository == nil {
+		s.ImpersonationLogRepository = NewImpersonationLogRepository(s)
+	}
+
+	return s.ImpersonationLogRepository
+}
+
+func (s *PostgresStore) AuthEvent() *AuthEventRepository {
+	if s.AuthEventRepository == nil {
+		s.AuthEventRepository = NewAuthEventRepository(s)
+	}
+
+	return s.AuthEventRepository
+}
+
+func (s *PostgresStore) Contact() *ContactRepository {
+	if s.ContactRepository == nil {
+		s.ContactRepository = NewContactRepository(s)
+	}
+
+	return s.ContactRepository
+}
+
+func (s *PostgresStore) Company() *CompanyRepository {
+	if s.CompanyRepository == nil {
+		s.CompanyRepository = NewCompanyRepository(s)
+	}
+
+	return s.CompanyRepository
+}
+
+func (s *PostgresStore) Pipeline() *PipelineRepository {
+	if s.PipelineRepository == nil {
+		s.PipelineRepository = NewPipelineRepository(s)
+	}
+
+	return s.PipelineRepository
+}
+
+func (s *PostgresStore) Deal() *DealRepository {
+	if s.DealRepository == nil {
+		s.DealRepository = NewDealRepository(s)
+	}
+
+	return s.DealRepository
+}
+
+func (s *PostgresStore) Task() *TaskRepository {
+	if s.TaskRepository == nil {
+		s.TaskRepository = NewTaskRepository(s)
+	}
+
+	return s.TaskRepository
+}
+
+func (s *PostgresStore) Activity() *ActivityRepository {
+	if s.ActivityRepository == nil {
+		s.ActivityRepository = NewActivityRepository(s)
+	}
+
+	return s.ActivityRepository
+}
+
+func (s *PostgresStore) CustomField() *CustomFieldRepository {
+	if s.CustomFieldRepository == nil {
+		s.CustomFieldRepository = NewCustomFieldRepository(s)
+	}
+
+	return s.CustomFieldRepository
+}
+
+func (s *PostgresStore) Tag() *TagRepository {
+	if s.TagRepository == nil {
+		s.TagRepository = NewTagRepository(s)
+	}
+
+	return s.TagRepository
+}
+
+func (s *PostgresStore) Import() *ImportRepository {
+	if s.ImportRepository == nil {
+		s.ImportRepository = NewImportRepository(s)
+	}
+
+	return s.ImportRepository
+}
@@ -17,8 +17,9 @@ type StoreSuite struct {
 	suite.Suite
 	store *postgresstore.PostgresStore
 
-	AuthUserFixture *postgresstore.FixtureAuthUser
-	UserFixture     *postgresstore.FixtureUser
+	AuthUserFixture     *postgresstore.FixtureAuthUser
+	UserFixture         *postgresstore.FixtureUser
+	RefreshTokenFixture *postgresstore.FixtureRefreshToken
 }
 
 func TestSuite(t *testing.T) {
@@ -39,13 +40,43 @@ func (s *StoreSuite) SetupSuite() {
 
 	s.AuthUserFixture = postgresstore.NewFixtureAuthUser()
 	s.UserFixture = postgresstore.NewFixtureUser()
+	s.RefreshTokenFixture = postgresstore.NewFixtureRefreshToken()
 
 	s.cleanDB()
 }
 
 func (s *StoreSuite) cleanDB() {
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ImportRowError{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Import{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.EntityTag{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Tag{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.CustomFieldDefinition{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.TimelineEvent{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ActivityParticipant{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Activity{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Task{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Deal{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Pipeline{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Contact{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Company{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ImpersonationLog{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.LoginAttempt{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Invitation{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.OIDCState{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.AuthIdentity{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.APIKey{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PasswordResetToken{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.PasswordHistory{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.RecoveryCode{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.MFAPolicy{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.MFAChallenge{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.RefreshToken{})
+	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Session{})
 	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.User{})
 	s.store.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.AuthUser{})
+	s.store.DB.Delete(&model.Role{}, "builtin=?", false)
+	// the append-only trigger refuses deletes
+	s.store.DB.Exec("truncate auth_events")
 
 }
 
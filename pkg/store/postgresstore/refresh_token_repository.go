@@ -0,0 +1,55 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	store *PostgresStore
+}
+
+func NewRefreshTokenRepository(store *PostgresStore) *RefreshTokenRepository {
+	return &RefreshTokenRepository{store: store}
+}
+
+func (r *RefreshTokenRepository) Create(token *model.RefreshToken) error {
+	return r.store.DB.Create(token).Error
+}
+
+func (r *RefreshTokenRepository) Get(id uuid.UUID) (*model.RefreshToken, bool) {
+	var token *model.RefreshToken
+
+	result := r.store.DB.Where("id=?", id).Find(&token)
+	if result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return token, true
+}
+
+// Rotate revokes the old token and stores its replacement in one transaction.
+// It returns model.ErrTokenReused when the old token was already revoked,
+// which also covers two concurrent refreshes with the same token.
+func (r *RefreshTokenRepository) Rotate(oldID uuid.UUID, next *model.RefreshToken) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id=? AND revoked_at IS NULL", oldID).
+			Updates(map[string]interface{}{
+				"revoked_at":  time.Now(),
+				"replaced_by": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ErrTokenReused
+		}
+
+		return tx.Create(next).Error
+	})
+}
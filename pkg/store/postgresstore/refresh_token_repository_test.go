@@ -0,0 +1,69 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) createRefreshTokens() (*model.AuthUser, []model.RefreshToken) {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	tokens := s.RefreshTokenFixture.List(user.ID, uuid.NewV4())
+	for i := range tokens {
+		err = s.store.DB.Create(&tokens[i]).Error
+		s.Nil(err)
+	}
+
+	return &user, tokens
+}
+
+func (s *StoreSuite) TestRefreshTokenRepository_Create() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	token := s.RefreshTokenFixture.One(user.ID, uuid.NewV4())
+	err = s.store.RefreshToken().Create(&token)
+	s.Nil(err)
+
+	actual, exists := s.store.RefreshToken().Get(token.ID)
+	s.Equal(true, exists)
+	s.Equal(token.FamilyID, actual.FamilyID)
+	s.Equal(false, actual.IsRevoked())
+}
+
+func (s *StoreSuite) TestRefreshTokenRepository_Get() {
+	_, tokens := s.createRefreshTokens()
+
+	token, exists := s.store.RefreshToken().Get(tokens[0].ID)
+	s.Equal(true, exists)
+	s.Equal(tokens[0].ID, token.ID)
+
+	_, exists = s.store.RefreshToken().Get(uuid.NewV4())
+	s.Equal(false, exists)
+}
+
+func (s *StoreSuite) TestRefreshTokenRepository_Rotate() {
+	user, tokens := s.createRefreshTokens()
+
+	next := s.RefreshTokenFixture.One(user.ID, tokens[0].FamilyID)
+	err := s.store.RefreshToken().Rotate(tokens[0].ID, &next)
+	s.Nil(err)
+
+	old, _ := s.store.RefreshToken().Get(tokens[0].ID)
+	s.Equal(true, old.IsRevoked())
+	s.Equal(next.ID, *old.ReplacedBy)
+
+	_, exists := s.store.RefreshToken().Get(next.ID)
+	s.Equal(true, exists)
+
+	again := s.RefreshTokenFixture.One(user.ID, tokens[0].FamilyID)
+	err = s.store.RefreshToken().Rotate(tokens[0].ID, &again)
+	s.ErrorIs(err, model.ErrTokenReused)
+
+	_, exists = s.store.RefreshToken().Get(again.ID)
+	s.Equal(false, exists)
+}
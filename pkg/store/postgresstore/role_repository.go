@@ -0,0 +1,136 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	store *PostgresStore
+}
+
+func NewRoleRepository(store *PostgresStore) *RoleRepository {
+	return &RoleRepository{store: store}
+}
+
+func (r *RoleRepository) List() ([]model.Role, error) {
+	var roles []model.Role
+
+	err := r.store.DB.Order("name").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var grants []model.RolePermission
+
+	err = r.store.DB.Order("permission").Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byRole := make(map[model.UserRole][]model.Permission, len(roles))
+	for _, grant := range grants {
+		byRole[grant.Role] = append(byRole[grant.Role], grant.Permission)
+	}
+
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []model.Permission{}
+		}
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) Get(name model.UserRole) (*model.Role, bool) {
+	var role *model.Role
+
+	result := r.store.DB.Where("name=?", name).Find(&role)
+	if result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	permissions, err := r.Permissions(name)
+	if err != nil {
+		return nil, false
+	}
+
+	role.Permissions = permissions
+
+	return role, true
+}
+
+func (r *RoleRepository) Permissions(name model.UserRole) ([]model.Permission, error) {
+	permissions := []model.Permission{}
+
+	err := r.store.DB.Model(&model.RolePermission{}).
+		Where("role=?", name).
+		Order("permission").
+		Pluck("permission", &permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepository) Create(role *model.Role) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(role).Error
+		if err != nil {
+			return err
+		}
+
+		return createPermissions(tx, role)
+	})
+}
+
+func (r *RoleRepository) Update(role *model.Role) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Role{}).
+			Where("name=?", role.Name).
+			Update("description", role.Description).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(&model.RolePermission{}, "role=?", role.Name).Error
+		if err != nil {
+			return err
+		}
+
+		return createPermissions(tx, role)
+	})
+}
+
+func (r *RoleRepository) Delete(name model.UserRole) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		var users int64
+
+		err := tx.Model(&model.AuthUser{}).Where("role=?", name).Count(&users).Error
+		if err != nil {
+			return err
+		}
+
+		if users > 0 {
+			return model.ErrRoleInUse
+		}
+
+		return tx.Delete(&model.Role{}, "name=?", name).Error
+	})
+}
+
+func createPermissions(tx *gorm.DB, role *model.Role) error {
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+
+	grants := make([]model.RolePermission, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		grants = append(grants, model.RolePermission{Role: role.Name, Permission: permission})
+	}
+
+	return tx.Create(&grants).Error
+}
@@ -0,0 +1,63 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+)
+
+func (s *StoreSuite) TestRoleRepository_Seeded() {
+	admin, exists := s.store.Role().Get(model.AdminUserRole)
+	s.Equal(true, exists)
+	s.Equal(true, admin.Builtin)
+	s.ElementsMatch(model.AllPermissions, admin.Permissions)
+
+	permissions, err := s.store.Role().Permissions(model.BaseUserRole)
+	s.Nil(err)
+	s.Empty(permissions)
+}
+
+func (s *StoreSuite) TestRoleRepository_CreateUpdate() {
+	role := model.Role{
+		Name:        "MANAGER",
+		Description: "Sales manager",
+		Permissions: []model.Permission{model.PermUsersRead},
+	}
+
+	err := s.store.Role().Create(&role)
+	s.Nil(err)
+
+	role.Description = "Team lead"
+	role.Permissions = []model.Permission{model.PermUsersCreate, model.PermUsersUpdate}
+	err = s.store.Role().Update(&role)
+	s.Nil(err)
+
+	actual, exists := s.store.Role().Get("MANAGER")
+	s.Equal(true, exists)
+	s.Equal(&role, actual)
+
+	roles, err := s.store.Role().List()
+	s.Nil(err)
+	s.Len(roles, 3)
+}
+
+func (s *StoreSuite) TestRoleRepository_Delete() {
+	role := model.Role{Name: "MANAGER", Permissions: []model.Permission{model.PermUsersRead}}
+	err := s.store.Role().Create(&role)
+	s.Nil(err)
+
+	user := s.AuthUserFixture.One()
+	user.Role = role.Name
+	err = s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	err = s.store.Role().Delete(role.Name)
+	s.ErrorIs(err, model.ErrRoleInUse)
+
+	err = s.store.DB.Delete(&user).Error
+	s.Nil(err)
+
+	err = s.store.Role().Delete(role.Name)
+	s.Nil(err)
+
+	_, exists := s.store.Role().Get(role.Name)
+	s.Equal(false, exists)
+}
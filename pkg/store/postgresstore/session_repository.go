@@ -0,0 +1,96 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	store *PostgresStore
+}
+
+func NewSessionRepository(store *PostgresStore) *SessionRepository {
+	return &SessionRepository{store: store}
+}
+
+func (r *SessionRepository) Create(session *model.Session) error {
+	return r.store.DB.Create(session).Error
+}
+
+func (r *SessionRepository) Get(id uuid.UUID) (*model.Session, bool) {
+	var session *model.Session
+
+	result := r.store.DB.Where("id=?", id).Find(&session)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return session, true
+}
+
+func (r *SessionRepository) ListActive(userID uuid.UUID) ([]model.Session, error) {
+	sessions := []model.Session{}
+
+	err := r.store.DB.
+		Where("user_id=? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(id uuid.UUID, seenAt time.Time) error {
+	return r.store.DB.Model(&model.Session{}).
+		Where("id=?", id).
+		Update("last_seen_at", seenAt).Error
+}
+
+func (r *SessionRepository) Extend(id uuid.UUID, seenAt, expiresAt time.Time) error {
+	return r.store.DB.Model(&model.Session{}).
+		Where("id=?", id).
+		Updates(map[string]interface{}{
+			"last_seen_at": seenAt,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// Revoke ends the session and revokes its refresh tokens in one transaction.
+func (r *SessionRepository) Revoke(id uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Model(&model.Session{}).
+			Where("id=? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RefreshToken{}).
+			Where("family_id=? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+	})
+}
+
+func (r *SessionRepository) RevokeAllByUser(userID uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Model(&model.Session{}).
+			Where("user_id=? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RefreshToken{}).
+			Where("user_id=? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
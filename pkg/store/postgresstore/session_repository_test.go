@@ -0,0 +1,98 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) createSession(userID uuid.UUID) *model.Session {
+	now := time.Now()
+	session := &model.Session{
+		ID:         uuid.NewV4(),
+		UserID:     userID,
+		UserAgent:  "curl/8.0",
+		IP:         "10.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+
+	err := s.store.Session().Create(session)
+	s.Nil(err)
+
+	err = s.store.RefreshToken().Create(&model.RefreshToken{
+		ID:        uuid.NewV4(),
+		UserID:    userID,
+		FamilyID:  session.ID,
+		ExpiresAt: session.ExpiresAt,
+	})
+	s.Nil(err)
+
+	return session
+}
+
+func (s *StoreSuite) TestSessionRepository_ListActive() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	first := s.createSession(user.ID)
+	second := s.createSession(user.ID)
+
+	err = s.store.Session().Touch(first.ID, time.Now().Add(time.Minute))
+	s.Nil(err)
+
+	sessions, err := s.store.Session().ListActive(user.ID)
+	s.Nil(err)
+	s.Len(sessions, 2)
+	s.Equal(first.ID, sessions[0].ID)
+
+	err = s.store.Session().Revoke(second.ID)
+	s.Nil(err)
+
+	sessions, err = s.store.Session().ListActive(user.ID)
+	s.Nil(err)
+	s.Len(sessions, 1)
+}
+
+func (s *StoreSuite) TestSessionRepository_RevokeRevokesRefreshTokens() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	session := s.createSession(user.ID)
+	other := s.createSession(user.ID)
+
+	err = s.store.Session().Revoke(session.ID)
+	s.Nil(err)
+
+	var active int64
+	err = s.store.DB.Model(&model.RefreshToken{}).
+		Where("family_id=? AND revoked_at IS NULL", session.ID).Count(&active).Error
+	s.Nil(err)
+	s.Equal(int64(0), active)
+
+	err = s.store.Session().RevokeAllByUser(user.ID)
+	s.Nil(err)
+
+	actual, exists := s.store.Session().Get(other.ID)
+	s.Equal(true, exists)
+	s.Equal(false, actual.IsActive(time.Now()))
+}
+
+func (s *StoreSuite) TestSessionRepository_Extend() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	session := s.createSession(user.ID)
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	err = s.store.Session().Extend(session.ID, time.Now(), expiresAt)
+	s.Nil(err)
+
+	actual, _ := s.store.Session().Get(session.ID)
+	s.True(expiresAt.Equal(actual.ExpiresAt))
+}
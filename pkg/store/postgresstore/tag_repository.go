@@ -0,0 +1,180 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository struct {
+	store *PostgresStore
+}
+
+func NewTagRepository(store *PostgresStore) *TagRepository {
+	return &TagRepository{store: store}
+}
+
+func (r *TagRepository) List() ([]model.Tag, error) {
+	tags := []model.Tag{}
+
+	err := r.store.DB.Model(&model.Tag{}).
+		Select("tags.*, (SELECT count(*) FROM entity_tags WHERE entity_tags.tag_id = tags.id) AS records").
+		Order("lower(name)").
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepository) Get(id uuid.UUID) (*model.Tag, bool) {
+	var tag model.Tag
+
+	result := r.store.DB.Where("id=?", id).Find(&tag)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &tag, true
+}
+
+func (r *TagRepository) GetByName(name string) (*model.Tag, bool) {
+	var tag model.Tag
+
+	result := r.store.DB.Where("lower(name)=lower(?)", name).Find(&tag)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return &tag, true
+}
+
+func (r *TagRepository) Create(tag *model.Tag) error {
+	return r.store.DB.Create(tag).Error
+}
+
+func (r *TagRepository) Update(tag *model.Tag) error {
+	return r.store.DB.Model(tag).
+		Select("name", "color", "description", "updated_at").
+		Updates(tag).Error
+}
+
+func (r *TagRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Delete(&model.Tag{}, "id=?", id).Error
+}
+
+func (r *TagRepository) Merge(targetID uuid.UUID, sourceIDs []uuid.UUID) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("INSERT INTO entity_tags (tag_id, entity_type, entity_id, created_at) "+
+			"SELECT ?, entity_type, entity_id, min(created_at) FROM entity_tags WHERE tag_id IN ? "+
+			"GROUP BY entity_type, entity_id ON CONFLICT DO NOTHING", targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Tag{}, "id IN ?", sourceIDs).Error
+	})
+}
+
+func (r *TagRepository) Count(ids []uuid.UUID) (int64, error) {
+	var count int64
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := r.store.DB.Model(&model.Tag{}).Where("id IN ?", ids).Count(&count).Error
+
+	return count, err
+}
+
+func (r *TagRepository) ForEntity(entityType model.RecordType, entityID uuid.UUID) ([]model.Tag, error) {
+	tags := []model.Tag{}
+
+	err := r.store.DB.Model(&model.Tag{}).
+		Joins("JOIN entity_tags ON entity_tags.tag_id = tags.id").
+		Where("entity_tags.entity_type=? AND entity_tags.entity_id=?", entityType, entityID).
+		Order("lower(tags.name)").
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepository) Bulk(bulk *model.TagBulk) error {
+	return r.store.DB.Transaction(func(tx *gorm.DB) error {
+		if len(bulk.Remove) > 0 {
+			err := tx.Delete(&model.EntityTag{}, "entity_type=? AND entity_id IN ? AND tag_id IN ?",
+				bulk.EntityType, bulk.EntityIDs, bulk.Remove).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		links := make([]model.EntityTag, 0, len(bulk.EntityIDs)*len(bulk.Add))
+
+		for _, entityID := range bulk.EntityIDs {
+			for _, tagID := range bulk.Add {
+				links = append(links, model.EntityTag{TagID: tagID, EntityType: bulk.EntityType, EntityID: entityID})
+			}
+		}
+
+		if len(links) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+func (r *TagRepository) ExistingEntities(entityType model.RecordType, ids []uuid.UUID) ([]uuid.UUID, error) {
+	existing := []uuid.UUID{}
+
+	records, ok := entityTables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("no records for %s", entityType)
+	}
+
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	err := r.store.DB.Table(records.table).
+		Where(records.idColumn+" IN ?", ids).
+		Pluck(records.idColumn, &existing).Error
+
+	return existing, err
+}
+
+// tagFilter limits the query to the records of the entity type matching the
+// filter, idColumn is the column of the query with the record IDs.
+func tagFilter(db *gorm.DB, entityType model.RecordType, idColumn string, filter model.TagFilter) *gorm.DB {
+	names := filter.Names()
+	if len(names) == 0 {
+		return db
+	}
+
+	tagged := db.Session(&gorm.Session{NewDB: true}).
+		Table("entity_tags").
+		Select("entity_tags.entity_id").
+		Joins("JOIN tags ON tags.id = entity_tags.tag_id").
+		Where("entity_tags.entity_type=? AND lower(tags.name) IN ?", entityType, names).
+		Group("entity_tags.entity_id")
+
+	if filter.Match != model.TagsMatchAny {
+		tagged = tagged.Having("count(DISTINCT tags.id)=?", len(names))
+	}
+
+	return db.Where(idColumn+" IN (?)", tagged)
+}
+
+// deleteTags takes the tags away from a deleted record.
+func deleteTags(tx *gorm.DB, entityType model.RecordType, id uuid.UUID) error {
+	return tx.Delete(&model.EntityTag{}, "entity_type=? AND entity_id=?", entityType, id).Error
+}
@@ -0,0 +1,140 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func (s *StoreSuite) TestTagRepository_BulkFilterAndMerge() {
+	vip := &model.Tag{Name: "VIP", Color: "#ffaa00"}
+	partner := &model.Tag{Name: "partner"}
+	lead := &model.Tag{Name: "lead"}
+
+	for _, tag := range []*model.Tag{vip, partner, lead} {
+		err := s.store.Tag().Create(tag)
+		s.Nil(err)
+	}
+
+	users := s.AuthUserFixture.List()
+	ids := make([]uuid.UUID, 0, len(users))
+
+	for i := range users {
+		err := s.store.DB.Create(&users[i]).Error
+		s.Nil(err)
+
+		ids = append(ids, users[i].ID)
+	}
+
+	existing, err := s.store.Tag().ExistingEntities(model.RecordUser, append(ids, uuid.NewV4()))
+	s.Nil(err)
+	s.ElementsMatch(ids, existing)
+
+	err = s.store.Tag().Bulk(&model.TagBulk{EntityType: model.RecordUser, EntityIDs: ids, Add: []uuid.UUID{vip.ID}})
+	s.Nil(err)
+
+	err = s.store.Tag().Bulk(&model.TagBulk{EntityType: model.RecordUser, EntityIDs: ids[:2],
+		Add: []uuid.UUID{partner.ID, vip.ID}, Remove: []uuid.UUID{}})
+	s.Nil(err)
+
+	err = s.store.Tag().Bulk(&model.TagBulk{EntityType: model.RecordUser, EntityIDs: ids[1:2], Remove: []uuid.UUID{vip.ID}})
+	s.Nil(err)
+
+	tags, err := s.store.Tag().ForEntity(model.RecordUser, ids[0])
+	s.Nil(err)
+	s.Equal([]string{"partner", "VIP"}, tagNames(tags))
+
+	all, total, err := s.store.Auth().List(model.UserListQuery{
+		Pagination: model.Pagination{Page: 1, PerPage: 10},
+		TagFilter:  model.TagFilter{Tags: "vip, Partner", Match: model.TagsMatchAll},
+	})
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(ids[0], all[0].ID)
+
+	_, total, err = s.store.Auth().List(model.UserListQuery{
+		Pagination: model.Pagination{Page: 1, PerPage: 10},
+		TagFilter:  model.TagFilter{Tags: "vip,partner", Match: model.TagsMatchAny},
+	})
+	s.Nil(err)
+	s.Equal(int64(3), total)
+
+	found, exists := s.store.Tag().GetByName("vip")
+	s.True(exists)
+	s.Equal(vip.ID, found.ID)
+
+	err = s.store.Tag().Merge(lead.ID, []uuid.UUID{vip.ID, partner.ID})
+	s.Nil(err)
+
+	count, err := s.store.Tag().Count([]uuid.UUID{vip.ID, partner.ID, lead.ID})
+	s.Nil(err)
+	s.Equal(int64(1), count)
+
+	list, err := s.store.Tag().List()
+	s.Nil(err)
+	s.Len(list, 1)
+	s.Equal(int64(3), list[0].Records)
+
+	err = s.store.Tag().Delete(lead.ID)
+	s.Nil(err)
+
+	tags, err = s.store.Tag().ForEntity(model.RecordUser, ids[0])
+	s.Nil(err)
+	s.Empty(tags)
+}
+
+func tagNames(tags []model.Tag) []string {
+	names := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+
+	return names
+}
+
+func (s *StoreSuite) TestTagRepository_CRMRecords() {
+	vip := &model.Tag{Name: "VIP"}
+	err := s.store.Tag().Create(vip)
+	s.Nil(err)
+
+	jane, john := &model.Contact{LastName: "Doe"}, &model.Contact{LastName: "Smith"}
+	for _, contact := range []*model.Contact{jane, john} {
+		err = s.store.Contact().Create(contact)
+		s.Nil(err)
+	}
+
+	company := &model.Company{Name: "Acme"}
+	err = s.store.Company().Create(company)
+	s.Nil(err)
+
+	existing, err := s.store.Tag().ExistingEntities(model.RecordContact, []uuid.UUID{jane.ID, john.ID, company.ID})
+	s.Nil(err)
+	s.ElementsMatch([]uuid.UUID{jane.ID, john.ID}, existing)
+
+	err = s.store.Tag().Bulk(&model.TagBulk{EntityType: model.RecordContact, EntityIDs: []uuid.UUID{jane.ID}, Add: []uuid.UUID{vip.ID}})
+	s.Nil(err)
+
+	contactQuery := model.ContactQuery{Pagination: model.Pagination{Page: 1, PerPage: 10}, TagFilter: model.TagFilter{Tags: "vip"}}
+	s.True(contactQuery.IsValid())
+
+	contacts, total, err := s.store.Contact().List(contactQuery)
+	s.Nil(err)
+	s.Equal(int64(1), total)
+	s.Equal(jane.ID, contacts[0].ID)
+
+	// the contact's tag doesn't tag a company
+	companyQuery := model.CompanyQuery{Pagination: model.Pagination{Page: 1, PerPage: 10}, TagFilter: model.TagFilter{Tags: "vip"}}
+	s.True(companyQuery.IsValid())
+
+	_, total, err = s.store.Company().List(companyQuery)
+	s.Nil(err)
+	s.Equal(int64(0), total)
+
+	err = s.store.Contact().Delete(jane.ID)
+	s.Nil(err)
+
+	list, err := s.store.Tag().List()
+	s.Nil(err)
+	s.Equal(int64(0), list[0].Records)
+}
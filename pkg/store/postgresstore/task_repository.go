@@ -0,0 +1,164 @@
+package postgresstore
+
+import (
+	"crm-system/pkg/model"
+	"database/sql"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// taskPriorityRank orders the priorities from low to urgent.
+const taskPriorityRank = "CASE priority WHEN 'urgent' THEN 3 WHEN 'high' THEN 2 WHEN 'normal' THEN 1 ELSE 0 END"
+
+// taskOrders are the columns of the task sort fields, id keeps pages stable.
+var taskOrders = map[string][2]string{
+	"due_at":     {"due_at NULLS LAST, id", "due_at DESC NULLS LAST, id"},
+	"priority":   {taskPriorityRank + ", due_at NULLS LAST, id", taskPriorityRank + " DESC, due_at NULLS LAST, id"},
+	"created_at": {"created_at, id", "created_at DESC, id"},
+	"updated_at": {"updated_at, id", "updated_at DESC, id"},
+}
+
+type TaskRepository struct {
+	store *PostgresStore
+}
+
+func NewTaskRepository(store *PostgresStore) *TaskRepository {
+	return &TaskRepository{store: store}
+}
+
+func (r *TaskRepository) Create(task *model.Task) error {
+	return r.store.DB.Create(task).Error
+}
+
+func (r *TaskRepository) Get(id uuid.UUID) (*model.Task, bool) {
+	var task *model.Task
+
+	result := r.store.DB.Where("id=?", id).Find(&task)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+
+	return task, true
+}
+
+func (r *TaskRepository) List(query model.TaskQuery) ([]model.Task, int64, error) {
+	var total int64
+
+	tasks := []model.Task{}
+	db := r.filter(query)
+
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	field, desc := query.SortField()
+
+	order := taskOrders[field][0]
+	if desc {
+		order = taskOrders[field][1]
+	}
+
+	err = db.Order(order).
+		Offset(query.Offset()).
+		Limit(query.PerPage).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+func (r *TaskRepository) Update(task *model.Task) error {
+	return r.store.DB.Model(task).
+		Select("title", "description", "due_at", "priority", "status", "assignee_id", "record_type", "record_id",
+			"completed_at", "reminded_at", "updated_at").
+		Updates(task).Error
+}
+
+func (r *TaskRepository) Delete(id uuid.UUID) error {
+	return r.store.DB.Delete(&model.Task{}, "id=?", id).Error
+}
+
+func (r *TaskRepository) ClaimDueSoon(until, now time.Time, limit int) ([]model.Task, error) {
+	tasks := []model.Task{}
+
+	err := r.store.DB.Raw(`UPDATE tasks SET reminded_at = @now WHERE id IN (
+		SELECT id FROM tasks
+		WHERE reminded_at IS NULL AND assignee_id IS NOT NULL AND status IN @statuses AND due_at <= @until
+		ORDER BY due_at
+		LIMIT @limit
+		FOR UPDATE SKIP LOCKED
+	) RETURNING *`,
+		sql.Named("now", now),
+		sql.Named("statuses", model.OpenTaskStatuses),
+		sql.Named("until", until),
+		sql.Named("limit", limit),
+	).Scan(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) filter(query model.TaskQuery) *gorm.DB {
+	db := r.store.DB.Model(&model.Task{})
+
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		db = db.Where("title ILIKE @p OR description ILIKE @p", sql.Named("p", pattern))
+	}
+
+	if query.ParticipantID != "" {
+		db = db.Where("assignee_id = @id OR creator_id = @id", sql.Named("id", query.ParticipantID))
+	}
+
+	if query.AssigneeID != "" {
+		db = db.Where("assignee_id=?", query.AssigneeID)
+	}
+
+	if query.CreatorID != "" {
+		db = db.Where("creator_id=?", query.CreatorID)
+	}
+
+	if query.Status != "" {
+		db = db.Where("status=?", query.Status)
+	}
+
+	if query.Open {
+		db = db.Where("status IN ?", model.OpenTaskStatuses)
+	}
+
+	if query.Priority != "" {
+		db = db.Where("priority=?", query.Priority)
+	}
+
+	if query.RecordType != "" {
+		db = db.Where("record_type=?", query.RecordType)
+	}
+
+	if query.RecordID != "" {
+		db = db.Where("record_id=?", query.RecordID)
+	}
+
+	if query.DueBefore != nil {
+		db = db.Where("due_at < ?", *query.DueBefore)
+	}
+
+	if query.DueAfter != nil {
+		db = db.Where("due_at >= ?", *query.DueAfter)
+	}
+
+	return db
+}
+
+// unlinkTasks clears the links of the tasks to a deleted record, the tasks stay.
+func unlinkTasks(tx *gorm.DB, recordType model.RecordType, id uuid.UUID) error {
+	return tx.Model(&model.Task{}).
+		Where("record_type=? AND record_id=?", recordType, id).
+		Updates(map[string]interface{}{"record_type": "", "record_id": nil}).Error
+}
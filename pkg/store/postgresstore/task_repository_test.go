@@ -0,0 +1,128 @@
+package postgresstore_test
+
+import (
+	"crm-system/pkg/model"
+	"time"
+)
+
+func (s *StoreSuite) TestTaskRepository_CreateListUpdate() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	soon, later := now.Add(time.Hour), now.Add(48*time.Hour)
+
+	urgent := &model.Task{Title: "Call back", DueAt: &later, Priority: model.TaskPriorityUrgent, Status: model.TaskOpen,
+		AssigneeID: &user.ID, CreatorID: &user.ID}
+	normal := &model.Task{Title: "Send the quote", DueAt: &soon, Priority: model.TaskPriorityNormal, Status: model.TaskOpen,
+		AssigneeID: &user.ID, CreatorID: &user.ID}
+	done := &model.Task{Title: "Send the contract", Priority: model.TaskPriorityHigh, Status: model.TaskDone,
+		CreatorID: &user.ID, CompletedAt: &now}
+
+	for _, task := range []*model.Task{urgent, normal, done} {
+		err = s.store.Task().Create(task)
+		s.Nil(err)
+	}
+
+	query := model.TaskQuery{AssigneeID: user.ID.String(), Open: true, Sorting: model.Sorting{Sort: "due_at"}}
+	s.True(query.IsValid())
+
+	tasks, total, err := s.store.Task().List(query)
+	s.Nil(err)
+	s.Equal(int64(2), total)
+	s.Equal(normal.ID, tasks[0].ID)
+	s.Equal(urgent.ID, tasks[1].ID)
+
+	query = model.TaskQuery{ParticipantID: user.ID.String(), Sorting: model.Sorting{Sort: "-priority"}}
+	s.True(query.IsValid())
+
+	tasks, total, err = s.store.Task().List(query)
+	s.Nil(err)
+	s.Equal(int64(3), total)
+	s.Equal(urgent.ID, tasks[0].ID)
+	s.Equal(done.ID, tasks[1].ID)
+
+	query = model.TaskQuery{Search: "QUOTE", DueBefore: &later}
+	s.True(query.IsValid())
+
+	tasks, _, err = s.store.Task().List(query)
+	s.Nil(err)
+	s.Len(tasks, 1)
+	s.Equal(normal.ID, tasks[0].ID)
+
+	normal.Status = model.TaskCanceled
+	err = s.store.Task().Update(normal)
+	s.Nil(err)
+
+	actual, exists := s.store.Task().Get(normal.ID)
+	s.True(exists)
+	s.Equal(model.TaskCanceled, actual.Status)
+}
+
+func (s *StoreSuite) TestTaskRepository_ClaimDueSoon() {
+	user := s.AuthUserFixture.One()
+	err := s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	soon, later := now.Add(30*time.Minute), now.Add(48*time.Hour)
+
+	due := &model.Task{Title: "Call back", DueAt: &soon, Priority: model.TaskPriorityNormal, Status: model.TaskOpen, AssigneeID: &user.ID}
+	notDue := &model.Task{Title: "Send the quote", DueAt: &later, Priority: model.TaskPriorityNormal, Status: model.TaskOpen, AssigneeID: &user.ID}
+	closed := &model.Task{Title: "Send the contract", DueAt: &soon, Priority: model.TaskPriorityNormal, Status: model.TaskDone, AssigneeID: &user.ID}
+	unassigned := &model.Task{Title: "Follow up", DueAt: &soon, Priority: model.TaskPriorityNormal, Status: model.TaskOpen}
+
+	for _, task := range []*model.Task{due, notDue, closed, unassigned} {
+		err = s.store.Task().Create(task)
+		s.Nil(err)
+	}
+
+	tasks, err := s.store.Task().ClaimDueSoon(now.Add(time.Hour), now, 10)
+	s.Nil(err)
+	s.Len(tasks, 1)
+	s.Equal(due.ID, tasks[0].ID)
+	s.NotNil(tasks[0].RemindedAt)
+
+	// a claimed task isn't claimed again
+	tasks, err = s.store.Task().ClaimDueSoon(now.Add(time.Hour), now, 10)
+	s.Nil(err)
+	s.Empty(tasks)
+}
+
+func (s *StoreSuite) TestTaskRepository_UnlinkDeletedRecord() {
+	contact := &model.Contact{LastName: "Doe"}
+	err := s.store.Contact().Create(contact)
+	s.Nil(err)
+
+	task := &model.Task{Title: "Call back", Priority: model.TaskPriorityNormal, Status: model.TaskOpen,
+		RecordType: model.RecordContact, RecordID: &contact.ID}
+	err = s.store.Task().Create(task)
+	s.Nil(err)
+
+	err = s.store.Contact().Delete(contact.ID)
+	s.Nil(err)
+
+	actual, exists := s.store.Task().Get(task.ID)
+	s.True(exists)
+	s.Equal(model.RecordType(""), actual.RecordType)
+	s.Nil(actual.RecordID)
+
+	// deleting an account unlinks the tasks about its profile
+	user := s.AuthUserFixture.One()
+	err = s.store.DB.Create(&user).Error
+	s.Nil(err)
+
+	task = &model.Task{Title: "Review", Priority: model.TaskPriorityNormal, Status: model.TaskOpen,
+		RecordType: model.RecordUser, RecordID: &user.ID}
+	err = s.store.Task().Create(task)
+	s.Nil(err)
+
+	err = s.store.Auth().Delete(user.ID)
+	s.Nil(err)
+
+	actual, exists = s.store.Task().Get(task.ID)
+	s.True(exists)
+	s.Equal(model.RecordType(""), actual.RecordType)
+	s.Nil(actual.RecordID)
+}
@@ -3,6 +3,9 @@ package postgresstore
 import (
 	"crm-system/pkg/model"
 	"crm-system/pkg/utils"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
 )
 
 type FixtureAuthUser struct{}
@@ -43,6 +46,8 @@ func (f *FixtureUser) One() model.User {
 		Surname: "Surname",
 		Phone:   "+3801231231",
 		Address: "Kiev",
+		// the column defaults to an empty object
+		CustomFields: model.CustomFieldValues{},
 	}
 }
 
@@ -57,3 +62,27 @@ func (f *FixtureUser) List() []model.User {
 		}),
 	}
 }
+
+type FixtureRefreshToken struct{}
+
+func NewFixtureRefreshToken() *FixtureRefreshToken {
+	return &FixtureRefreshToken{}
+}
+
+func (f *FixtureRefreshToken) One(userID, familyID uuid.UUID) model.RefreshToken {
+	return model.RefreshToken{
+		ID:        uuid.NewV4(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func (f *FixtureRefreshToken) List(userID, familyID uuid.UUID) []model.RefreshToken {
+	return []model.RefreshToken{
+		f.One(userID, familyID),
+		f.One(userID, familyID),
+		f.One(userID, uuid.NewV4()),
+	}
+}
@@ -6,8 +6,31 @@ import (
 )
 
 type Store struct {
-	User UserRepository
-	Auth AuthRepository
+	User             UserRepository
+	Auth             AuthRepository
+	RefreshToken     RefreshTokenRepository
+	RecoveryCode     RecoveryCodeRepository
+	MFAPolicy        MFAPolicyRepository
+	MFAChallenge     MFAChallengeRepository
+	LoginAttempt     LoginAttemptRepository
+	PasswordReset    PasswordResetRepository
+	Role             RoleRepository
+	APIKey           APIKeyRepository
+	Session          SessionRepository
+	Identity         IdentityRepository
+	OIDCState        OIDCStateRepository
+	Invitation       InvitationRepository
+	ImpersonationLog ImpersonationLogRepository
+	AuthEvent        AuthEventRepository
+	Contact          ContactRepository
+	Company          CompanyRepository
+	Pipeline         PipelineRepository
+	Deal             DealRepository
+	Task             TaskRepository
+	Activity         ActivityRepository
+	CustomField      CustomFieldRepository
+	Tag              TagRepository
+	Import           ImportRepository
 }
 
 func NewStore(conf *config.Configs) (*Store, error) {
@@ -17,7 +40,30 @@ func NewStore(conf *config.Configs) (*Store, error) {
 	}
 
 	return &Store{
-		User: postgres.User(),
-		Auth: postgres.Auth(),
+		User:             postgres.User(),
+		Auth:             postgres.Auth(),
+		RefreshToken:     postgres.RefreshToken(),
+		RecoveryCode:     postgres.RecoveryCode(),
+		MFAPolicy:        postgres.MFAPolicy(),
+		MFAChallenge:     postgres.MFAChallenge(),
+		LoginAttempt:     postgres.LoginAttempt(),
+		PasswordReset:    postgres.PasswordReset(),
+		Role:             postgres.Role(),
+		APIKey:           postgres.APIKey(),
+		Session:          postgres.Session(),
+		Identity:         postgres.Identity(),
+		OIDCState:        postgres.OIDCState(),
+		Invitation:       postgres.Invitation(),
+		ImpersonationLog: postgres.ImpersonationLog(),
+		AuthEvent:        postgres.AuthEvent(),
+		Contact:          postgres.Contact(),
+		Company:          postgres.Company(),
+		Pipeline:         postgres.Pipeline(),
+		Deal:             postgres.Deal(),
+		Task:             postgres.Task(),
+		Activity:         postgres.Activity(),
+		CustomField:      postgres.CustomField(),
+		Tag:              postgres.Tag(),
+		Import:           postgres.Import(),
 	}, nil
 }